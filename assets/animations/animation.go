@@ -33,6 +33,12 @@ func (a *Animation) Frame() int {
 	return a.frame
 }
 
+// SetFrame jumps to frame, as when following an animation played elsewhere.
+func (a *Animation) SetFrame(frame int) {
+	a.frame = frame
+	a.frameCounter = a.SpeedInTps
+}
+
 func (a *Animation) Restart() {
 	a.frame = a.First
 	a.frameCounter = a.SpeedInTps
@@ -41,6 +41,7 @@ type Level struct {
 	EnemySpawns  []EnemySpawn
 	PlayerSpawns []PlayerSpawn
 	DeadZones    []DeadZone
+	Platforms    []Platform
 	Checkpoints  []CheckpointSpawn
 	Fires        []FireSpawn
 	Messages     []MessageSpawn
@@ -67,6 +68,12 @@ type DeadZone struct {
 	X, Y, Width, Height float64
 }
 
+// Platform is a one-way platform: landed on from above, dropped through
+// with crouch+jump
+type Platform struct {
+	X, Y, Width, Height float64
+}
+
 type CheckpointSpawn struct {
 	X, Y, Width, Height float64
 	CheckpointID        float64
@@ -289,6 +296,15 @@ func (l *LevelLoader) MustLoadLevel(levelPath string) Level {
 					Height: o.Height,
 				})
 			}
+		case "Platforms":
+			for _, o := range og.Objects {
+				level.Platforms = append(level.Platforms, Platform{
+					X:      o.X,
+					Y:      o.Y,
+					Width:  o.Width,
+					Height: o.Height,
+				})
+			}
 		case "Checkpoint":
 			for _, o := range og.Objects {
 				checkpointID := o.Properties.GetFloat("checkpointID")
@@ -1,15 +1,44 @@
 package components
 
-import "github.com/yohamta/donburi"
+import (
+	"time"
 
-// NetInterpData stores interpolation state for smooth rendering of remote
-// networked entities between server snapshots.
+	"github.com/yohamta/donburi"
+)
+
+// NetInterpSamples is how many server samples NetInterpData keeps, about
+// half a second at 30 snapshots/second.
+const NetInterpSamples = 16
+
+// NetInterpSample is a remote entity's position at one snapshot.
+type NetInterpSample struct {
+	T          time.Duration // Server time the snapshot was taken
+	X, Y       float64
+	VelX, VelY float64 // Velocity at snapshot (for extrapolation)
+}
+
+// NetInterpData buffers recent server samples of a remote networked entity
+// so it can be rendered at the playout clock's delayed server time.
 type NetInterpData struct {
-	PrevX, PrevY     float64
-	TargetX, TargetY float64
-	T                float64
-	Initialized      bool
-	VelX, VelY       float64 // Velocity at snapshot (for extrapolation)
+	Samples [NetInterpSamples]NetInterpSample // ring, oldest overwritten
+	Next    int
+	Count   int
+}
+
+// Push adds the newest sample. A sample that is not newer than the last
+// one means the server's clock restarted, so the buffer starts over.
+func (d *NetInterpData) Push(s NetInterpSample) {
+	if d.Count > 0 && s.T <= d.At(0).T {
+		d.Count = 0
+	}
+	d.Samples[d.Next] = s
+	d.Next = (d.Next + 1) % NetInterpSamples
+	d.Count = min(d.Count+1, NetInterpSamples)
+}
+
+// At returns the i-th newest sample; At(0) is the newest.
+func (d *NetInterpData) At(i int) NetInterpSample {
+	return d.Samples[(d.Next-1-i+2*NetInterpSamples)%NetInterpSamples]
 }
 
 var NetInterp = donburi.NewComponentType[NetInterpData]()
@@ -1,7 +1,7 @@
 package components
 
 import (
-	"github.com/solarlune/resolv"
+	"github.com/automoto/doomerang-mp/shared/playersim"
 	"github.com/yohamta/donburi"
 )
 
@@ -11,16 +11,12 @@ type Vector struct {
 }
 
 type PhysicsData struct {
-	SpeedX         float64
-	SpeedY         float64
+	playersim.Body
 	AccelX         float64
 	Gravity        float64
 	Friction       float64
 	AttackFriction float64
 	MaxSpeed       float64
-	OnGround       *resolv.Object
-	WallSliding    *resolv.Object
-	IgnorePlatform *resolv.Object
 }
 
 var Physics = donburi.NewComponentType[PhysicsData]()
@@ -1,6 +1,7 @@
 package components
 
 import (
+	"github.com/automoto/doomerang-mp/shared/playersim"
 	"github.com/yohamta/donburi"
 )
 
@@ -16,6 +17,7 @@ type PlayerData struct {
 	LastSafeY           float64
 	OriginalSpawnX      float64 // Spawn point assigned at match start
 	OriginalSpawnY      float64
+	Movement            playersim.Movement // Jumping, stance and wall slides, shared with online play
 }
 
 var Player = donburi.NewComponentType[PlayerData]()
@@ -406,6 +406,17 @@ type NetcodeConfig struct {
 	MaxCorrPerTick    float64 // Cap correction per snapshot (pixels)
 	VelocityBlendRate float64 // Lerp rate for velocity sync (0-1)
 	MaxExtrapFrames   float64 // Max frames to extrapolate remote players beyond last snapshot
+	MaxInterpDelay    float64 // Cap on the adaptive remote render delay (frames)
+	JitterMultiplier  float64 // Remote render delay = one snapshot interval + this × measured jitter
+}
+
+// KillFeedConfig contains the online kill feed and damage number settings.
+type KillFeedConfig struct {
+	MaxEntries         int     // KOs listed at once, newest first
+	EntryFrames        int     // Frames each KO stays listed
+	ShowDamageNumbers  bool    // Float the damage of each hit over the target
+	DamageNumberFrames int     // Frames a damage number floats for
+	DamageNumberRise   float64 // Pixels a damage number rises per frame
 }
 
 // Config holds general game configuration
@@ -440,11 +451,13 @@ var Network NetworkConfig
 var Pathfinding PathfindingConfig
 var BotCombat BotCombatConfig
 var Netcode NetcodeConfig
+var KillFeed KillFeedConfig
 
 // DebugConfig contains debug/testing command-line options
 type DebugConfig struct {
 	SkipMenu         bool // Skip menu and go directly to game
 	ShowNetworkDebug bool // Show direction dot + network ID labels
+	ShowNetworkGraph bool // Graph RTT, snapshot timing, prediction error and bandwidth online (F3 toggles)
 }
 
 // MessageConfig contains message popup configuration
@@ -992,5 +1005,16 @@ func init() {
 		MaxCorrPerTick:    2.0,  // Max 2px correction per snapshot
 		VelocityBlendRate: 0.15, // Gentle velocity blend
 		MaxExtrapFrames:   3.0,  // Remote player extrapolation limit
+		MaxInterpDelay:    15.0, // Never render remotes more than 250ms behind
+		JitterMultiplier:  3.0,  // Ride out arrival variation of ~3x the mean
+	}
+
+	// Kill Feed Config (online matches)
+	KillFeed = KillFeedConfig{
+		MaxEntries:         5,
+		EntryFrames:        60 * 5, // 5 seconds
+		ShowDamageNumbers:  true,
+		DamageNumberFrames: 45,
+		DamageNumberRise:   0.6,
 	}
 }
@@ -15,6 +15,7 @@ require (
 	github.com/leap-fish/necs v0.0.5-0.20250625124528-82c5928cb7a1
 	github.com/quasilyte/gdata v0.8.1
 	github.com/solarlune/resolv v0.6.0
+	github.com/stretchr/testify v1.11.1
 	github.com/tanema/gween v0.0.0-20221212145351-621cc8a459d1
 	github.com/yohamta/donburi v1.15.7
 	golang.org/x/image v0.31.0
@@ -26,6 +27,7 @@ require (
 replace github.com/automoto/ggscale-go => ../../../ggscale/sdk-go
 
 require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/disintegration/imaging v1.6.2 // indirect
 	github.com/ebitengine/gomobile v0.0.0-20250923094054-ea854a63cce1 // indirect
 	github.com/ebitengine/hideconsole v1.0.0 // indirect
@@ -38,9 +40,11 @@ require (
 	github.com/jfreymuth/oggvorbis v1.0.5 // indirect
 	github.com/jfreymuth/vorbis v1.0.2 // indirect
 	github.com/kvartborg/vector v0.1.2 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
 	golang.org/x/exp v0.0.0-20250305212735-054e65f0b394 // indirect
 	golang.org/x/sync v0.17.0 // indirect
 	golang.org/x/sys v0.36.0 // indirect
 	golang.org/x/text v0.29.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
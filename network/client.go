@@ -5,13 +5,15 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/automoto/doomerang-mp/shared/messages"
 	ggscale "github.com/automoto/ggscale-go"
 	"github.com/coder/websocket"
 	"github.com/leap-fish/necs/esync"
 	"github.com/leap-fish/necs/router"
-	"github.com/leap-fish/necs/transports"
+	"github.com/leap-fish/necs/wrapws"
 )
 
 type ClientState int
@@ -21,9 +23,35 @@ const (
 	StateConnecting
 	StateConnected
 	StateJoinedGame
+	StateReconnecting
+	StateRedirecting
 	StateError
 )
 
+// Reconnect backoff. The attempts span roughly 20s, inside the server's
+// default 30s hold on a dropped player's slot.
+const (
+	reconnectAttempts  = 8
+	reconnectBaseDelay = 250 * time.Millisecond
+	reconnectMaxDelay  = 4 * time.Second
+)
+
+// redirectMatchTimeout bounds the ggscale matchmaking a ServerRedirect
+// with no address starts.
+const redirectMatchTimeout = 30 * time.Second
+
+// maxPendingSnapshots caps snapshots held for a scene that is not taking
+// them, about two seconds at 30 snapshots/second.
+const maxPendingSnapshots = 64
+
+// TimedSnapshot is a rebuilt world snapshot with its sequence number and
+// the local time it arrived, for the playout clock.
+type TimedSnapshot struct {
+	Seq      uint32
+	Received time.Time
+	World    esync.WorldSnapshot
+}
+
 // Client manages a WebSocket connection to the game server.
 // All shared fields are protected by mu (router callbacks run on necs goroutines).
 type Client struct {
@@ -35,25 +63,71 @@ type Client struct {
 	reconnectToken string
 	serverName     string
 	tickRate       int
+	snapshotRate   int
 	level          string
 	levelNames     []string
+	roomCode       string
+	address        string
 	conn           *websocket.Conn
 
-	snapshotCh chan esync.WorldSnapshot // size-1 buffered; latest wins
-
-	chargeCh chan messages.BoomerangChargeEvent
-	throwCh  chan messages.BoomerangThrowEvent
-	catchCh  chan messages.BoomerangCatchEvent
-	hitCh    chan messages.BoomerangHitEvent
-
-	meleeAttackCh chan messages.MeleeAttackEvent
-	meleeHitCh    chan messages.MeleeHitEvent
-	deathCh       chan messages.DeathEvent
-	respawnCh     chan messages.RespawnEvent
-
-	matchCh       chan messages.MatchEvent
-	scoreCh       chan messages.ScoreEvent
-	lobbyUpdateCh chan messages.LobbyUpdate
+	// reconnectGen identifies the current reconnectLoop; a newer drop
+	// bumps it so an older loop stands down.
+	reconnectGen int
+
+	// roomRequest is a CreateRoomRequest or JoinRoomRequest sent ahead of
+	// the JoinRequest; nil joins any public room.
+	roomRequest any
+
+	// spectate asks to join watch-only. spectator is true while the server
+	// has this client watching without a body, until it is promoted.
+	spectate  bool
+	spectator bool
+
+	// rtt and jitter are the server's latest estimates of this client's
+	// link, from its Pings.
+	rtt    time.Duration
+	jitter time.Duration
+	// playoutDelay is how far behind the newest snapshot remote players
+	// are drawn, reported to the server with each Pong.
+	playoutDelay time.Duration
+
+	// bytesIn and bytesOut count WebSocket payload bytes for the network
+	// graph.
+	bytesIn  atomic.Uint64
+	bytesOut atomic.Uint64
+
+	// clock follows the server's clock for the deadlines it sends.
+	clock ServerClock
+
+	// respawnAt is when the local player respawns, in server UnixNano,
+	// from the DeathEvent that knocked them out; 0 when not waiting to.
+	respawnAt int64
+
+	// shutdownAt is the latest the server will shut down, in server
+	// UnixNano, from its ServerShutdownNotice; 0 when it is not draining.
+	shutdownAt int64
+
+	// redirectAddress is where a ServerRedirect sent this client, followed
+	// once the connection closes; empty re-queues through ggscale.
+	redirectAddress string
+
+	// matchStats is the last match's stats, from the MatchStats sent at
+	// match end; nil until then and again once the next match starts.
+	matchStats *messages.MatchStats
+
+	// snapshots holds rebuilt states for the room joined, as baselines for
+	// the server's deltas. Only touched from router callbacks.
+	snapshots messages.SnapshotHistory
+
+	// pendingSnapshots are rebuilt snapshots not yet taken by
+	// TakeSnapshots, oldest first, guarded by snapshotMu.
+	snapshotMu       sync.Mutex
+	pendingSnapshots []TimedSnapshot
+
+	// events holds server events in the order the server sent them, for
+	// game systems to drain once a frame; see events.go.
+	events eventQueue[any]
+	stream eventStream
 
 	// ggscale integration. nil when GGSCALE_PUBLISHABLE_KEY is unset.
 	// The session token is forwarded to the dedicated game server via
@@ -67,35 +141,62 @@ func NewClient() *Client {
 	gg, lb := SharedGgscale()
 	return &Client{
 		state:                StateDisconnected,
-		snapshotCh:           make(chan esync.WorldSnapshot, 1),
-		chargeCh:             make(chan messages.BoomerangChargeEvent, 4),
-		throwCh:              make(chan messages.BoomerangThrowEvent, 4),
-		catchCh:              make(chan messages.BoomerangCatchEvent, 4),
-		hitCh:                make(chan messages.BoomerangHitEvent, 4),
-		meleeAttackCh:        make(chan messages.MeleeAttackEvent, 4),
-		meleeHitCh:           make(chan messages.MeleeHitEvent, 4),
-		deathCh:              make(chan messages.DeathEvent, 4),
-		respawnCh:            make(chan messages.RespawnEvent, 4),
-		matchCh:              make(chan messages.MatchEvent, 4),
-		scoreCh:              make(chan messages.ScoreEvent, 4),
-		lobbyUpdateCh:        make(chan messages.LobbyUpdate, 4),
 		ggscale:              gg,
 		ggscaleLeaderboardID: lb,
 	}
 }
 
+// CreateRoom makes the next Connect create a new room instead of joining
+// a public one. An empty password makes the room public.
+func (c *Client) CreateRoom(gameMode, password string, maxPlayers int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.roomRequest = messages.CreateRoomRequest{
+		GameMode:   gameMode,
+		Password:   password,
+		MaxPlayers: maxPlayers,
+	}
+}
+
+// JoinRoom makes the next Connect join the room with the given code.
+func (c *Client) JoinRoom(code, password string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.roomRequest = messages.JoinRoomRequest{RoomCode: code, Password: password}
+}
+
+// Spectate makes the next Connect join as a watch-only spectator.
+func (c *Client) Spectate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.spectate = true
+}
+
 // Connect dials the server in a background goroutine and initiates the join handshake.
 func (c *Client) Connect(address, version, playerName, level string) {
 	c.mu.Lock()
 	c.state = StateConnecting
 	c.lastError = nil
+	c.address = address
+	roomRequest := c.roomRequest
+	spectate := c.spectate
 	c.mu.Unlock()
+	c.stopEvents()
 
 	router.OnConnect(func(_ *router.NetworkClient) {
 		log.Println("[client] connected to server")
 		c.mu.Lock()
-		c.state = StateConnected
+		reconnecting := c.state == StateReconnecting
+		redirected := c.state == StateRedirecting
+		if !reconnecting {
+			c.state = StateConnected
+		}
+		reconnectToken := c.reconnectToken
 		c.mu.Unlock()
+		// A reconnect reclaims the held slot by token; the room is implied.
+		if !reconnecting {
+			reconnectToken = ""
+		}
 
 		var ggscaleToken string
 		if gg, _ := SharedGgscale(); gg != nil {
@@ -107,6 +208,8 @@ func (c *Client) Connect(address, version, playerName, level string) {
 			Version:             version,
 			PlayerName:          playerName,
 			Level:               level,
+			ReconnectToken:      reconnectToken,
+			Spectate:            spectate,
 			GgscaleSessionToken: ggscaleToken,
 		})
 		if err != nil {
@@ -114,6 +217,15 @@ func (c *Client) Connect(address, version, playerName, level string) {
 			return
 		}
 
+		// The room choice must reach the server before the JoinRequest.
+		// A redirect joins any public room on the new server.
+		if roomRequest != nil && !reconnecting && !redirected {
+			if err := c.SendMessage(roomRequest); err != nil {
+				c.setError(fmt.Errorf("failed to send room request: %w", err))
+				return
+			}
+		}
+
 		c.mu.RLock()
 		conn := c.conn
 		c.mu.RUnlock()
@@ -122,121 +234,155 @@ func (c *Client) Connect(address, version, playerName, level string) {
 			if err := conn.Write(context.Background(), websocket.MessageBinary, payload); err != nil {
 				c.setError(fmt.Errorf("failed to send join request: %w", err))
 			}
+			c.bytesOut.Add(uint64(len(payload)))
 		}
 	})
 
+	router.On(func(_ *router.NetworkClient, msg messages.RoomCreated) {
+		log.Printf("[client] room created: code=%s id=%s", msg.RoomCode, msg.RoomID)
+		c.mu.Lock()
+		c.roomCode = msg.RoomCode
+		c.mu.Unlock()
+	})
+
 	router.On(func(_ *router.NetworkClient, msg messages.JoinAccepted) {
-		log.Printf("[client] join accepted: networkID=%d server=%s tickRate=%d",
-			msg.NetworkID, msg.ServerName, msg.TickRate)
+		log.Printf("[client] join accepted: networkID=%d server=%s room=%s tickRate=%d snapshotRate=%d",
+			msg.NetworkID, msg.ServerName, msg.RoomCode, msg.TickRate, msg.SnapshotRate)
+		// Baselines from an earlier room or connection are meaningless now.
+		c.snapshots.Reset()
 		c.mu.Lock()
+		// A reconnect whose slot expired joined without its token, afresh.
+		reconnected := c.state == StateReconnecting && c.reconnectToken != ""
 		c.networkID = msg.NetworkID
 		c.reconnectToken = msg.ReconnectToken
 		c.serverName = msg.ServerName
 		c.tickRate = msg.TickRate
+		c.snapshotRate = msg.SnapshotRate
 		c.level = msg.Level
 		c.levelNames = msg.Levels
+		c.roomCode = msg.RoomCode
+		c.spectator = msg.Spectator
 		c.state = StateJoinedGame
+		c.respawnAt = 0
+		if !reconnected {
+			c.shutdownAt = 0
+		}
 		c.mu.Unlock()
+		if !reconnected {
+			c.clock.reset()
+		}
+		c.syncClock()
+		c.startEvents(msg.EventSeq, reconnected)
 	})
 
 	router.On(func(_ *router.NetworkClient, msg messages.JoinRejected) {
 		log.Printf("[client] join rejected: %s", msg.Reason)
-		c.setError(fmt.Errorf("join rejected: %s", msg.Reason))
-	})
-
-	router.On(func(_ *router.NetworkClient, snapshot esync.WorldSnapshot) {
-		select { // drain stale, push latest
-		case <-c.snapshotCh:
-		default:
-		}
-		c.snapshotCh <- snapshot
-	})
-
-	router.On(func(_ *router.NetworkClient, evt messages.BoomerangChargeEvent) {
-		select {
-		case c.chargeCh <- evt:
-		default:
-		}
-	})
-
-	router.On(func(_ *router.NetworkClient, evt messages.BoomerangThrowEvent) {
-		select {
-		case c.throwCh <- evt:
-		default:
+		c.mu.Lock()
+		// Once the held slot is gone, reconnectLoop's next attempt joins
+		// afresh, without the token.
+		rejoin := c.state == StateReconnecting && msg.Reason == messages.RejectReconnectExpired
+		if rejoin {
+			c.reconnectToken = ""
 		}
-	})
-
-	router.On(func(_ *router.NetworkClient, evt messages.BoomerangCatchEvent) {
-		select {
-		case c.catchCh <- evt:
-		default:
+		conn := c.conn
+		c.mu.Unlock()
+		if rejoin {
+			c.events.drain() // Nothing from the lost slot applies to a new one
+		} else {
+			c.setError(fmt.Errorf("join rejected: %s", msg.Reason))
 		}
-	})
-
-	router.On(func(_ *router.NetworkClient, evt messages.BoomerangHitEvent) {
-		select {
-		case c.hitCh <- evt:
-		default:
+		// The server closes the connection too, but dial (and a
+		// reconnectLoop waiting on it) need not wait for it.
+		if conn != nil {
+			_ = conn.CloseNow()
 		}
 	})
 
-	router.On(func(_ *router.NetworkClient, evt messages.MeleeAttackEvent) {
-		select {
-		case c.meleeAttackCh <- evt:
-		default:
+	router.On(func(_ *router.NetworkClient, msg messages.ServerRedirect) {
+		log.Printf("[client] server shutting down; redirected to %q", msg.Address)
+		c.mu.Lock()
+		c.state = StateRedirecting
+		c.redirectAddress = msg.Address
+		conn := c.conn
+		c.mu.Unlock()
+		// The redirect is followed from OnDisconnect, once this
+		// connection is gone.
+		if conn != nil {
+			_ = conn.CloseNow()
 		}
 	})
 
-	router.On(func(_ *router.NetworkClient, evt messages.MeleeHitEvent) {
-		select {
-		case c.meleeHitCh <- evt:
-		default:
+	router.On(func(_ *router.NetworkClient, snap messages.Snapshot) {
+		var base messages.SnapshotState
+		if snap.Baseline != 0 {
+			if base = c.snapshots.Get(snap.Baseline); base == nil {
+				return // baseline aged out; the server falls back to a full snapshot
+			}
 		}
-	})
-
-	router.On(func(_ *router.NetworkClient, evt messages.DeathEvent) {
-		select {
-		case c.deathCh <- evt:
-		default:
+		state := snap.Apply(base)
+		c.snapshots.Put(snap.Seq, state)
+		if err := c.SendMessage(messages.SnapshotAck{Seq: snap.Seq}); err != nil {
+			log.Printf("[client] snapshot ack failed: %v", err)
 		}
-	})
 
-	router.On(func(_ *router.NetworkClient, evt messages.RespawnEvent) {
-		select {
-		case c.respawnCh <- evt:
-		default:
+		c.snapshotMu.Lock()
+		if len(c.pendingSnapshots) == maxPendingSnapshots {
+			c.pendingSnapshots = c.pendingSnapshots[1:] // nobody is taking them
 		}
+		c.pendingSnapshots = append(c.pendingSnapshots, TimedSnapshot{
+			Seq:      snap.Seq,
+			Received: time.Now(),
+			World:    state.World(),
+		})
+		c.snapshotMu.Unlock()
 	})
 
-	router.On(func(_ *router.NetworkClient, evt messages.MatchEvent) {
-		select {
-		case c.matchCh <- evt:
-		default:
+	router.On(func(_ *router.NetworkClient, ping messages.Ping) {
+		c.mu.Lock()
+		c.rtt = ping.RTT
+		c.jitter = ping.Jitter
+		delay := c.playoutDelay
+		c.mu.Unlock()
+		if err := c.SendMessage(messages.Pong{SentAt: ping.SentAt, PlayoutDelay: delay}); err != nil {
+			log.Printf("[client] pong failed: %v", err)
 		}
+		c.syncClock()
 	})
 
-	router.On(func(_ *router.NetworkClient, evt messages.ScoreEvent) {
-		select {
-		case c.scoreCh <- evt:
-		default:
-		}
+	router.On(func(_ *router.NetworkClient, resp messages.ClockSyncResponse) {
+		c.clock.Observe(time.Unix(0, resp.ClientTime), time.Unix(0, resp.ServerTime), time.Now())
 	})
 
-	router.On(func(_ *router.NetworkClient, evt messages.LobbyUpdate) {
-		select {
-		case c.lobbyUpdateCh <- evt:
-		default:
-		}
-	})
+	c.handleEvents()
 
 	router.OnDisconnect(func(_ *router.NetworkClient, err error) {
 		log.Printf("[client] disconnected: %v", err)
 		c.mu.Lock()
-		if c.state != StateError {
+		c.conn = nil
+		// Spectators have no held slot to reclaim.
+		retry := c.state == StateJoinedGame && c.reconnectToken != "" && !c.spectator
+		redirect := c.state == StateRedirecting
+		switch {
+		case retry:
+			c.state = StateReconnecting
+			c.reconnectGen++
+		case c.state == StateReconnecting, redirect:
+			// reconnectLoop or followRedirect owns the state.
+		case c.state != StateError:
 			c.state = StateDisconnected
 		}
-		c.conn = nil
+
+		gen := c.reconnectGen
+		redirectAddress := c.redirectAddress
 		c.mu.Unlock()
+		c.stopEvents()
+
+		switch {
+		case retry:
+			go c.reconnectLoop(gen)
+		case redirect:
+			go c.followRedirect(redirectAddress)
+		}
 	})
 
 	router.OnError(func(_ *router.NetworkClient, err error) {
@@ -244,18 +390,124 @@ func (c *Client) Connect(address, version, playerName, level string) {
 	})
 
 	go func() {
-		transport := transports.NewWsClientTransport("ws://" + address)
-		err := transport.Start(func(conn *websocket.Conn) {
-			c.mu.Lock()
-			c.conn = conn
-			c.mu.Unlock()
-		})
-		if err != nil {
+		if err := c.dial(address); err != nil {
 			c.setError(fmt.Errorf("connection failed: %w", err))
 		}
 	}()
 }
 
+// dial opens the WebSocket and blocks until it closes.
+func (c *Client) dial(address string) error {
+	ws := wrapws.NewWebSocketClient(wsEvents{c: c})
+	return ws.Dial("ws://"+address, nil, func(conn *websocket.Conn) {
+		c.mu.Lock()
+		c.conn = conn
+		c.mu.Unlock()
+	})
+}
+
+// wsEvents passes WebSocket events on to the router, as necs's client
+// transport does, counting the bytes received.
+type wsEvents struct {
+	c *Client
+}
+
+func (w wsEvents) OnConnect(_ context.Context, conn *websocket.Conn) {
+	router.CallConnect(conn)
+}
+
+func (w wsEvents) OnDisconnect(_ context.Context, conn *websocket.Conn, err error) {
+	router.CallDisconnect(conn, err)
+}
+
+func (w wsEvents) OnError(_ context.Context, conn *websocket.Conn, err error) {
+	router.CallError(conn, err)
+}
+
+func (w wsEvents) OnMessage(_ context.Context, conn *websocket.Conn, payload []byte) {
+	w.c.bytesIn.Add(uint64(len(payload)))
+	if err := router.CallProcessMessage(conn, payload); err != nil {
+		router.CallError(conn, err)
+	}
+}
+
+// reconnectLoop redials after an unexpected drop mid-game, presenting the
+// reconnect token so the server hands back the held slot, or joining as a
+// new player once the server says the slot has expired. It stands down
+// when Disconnect, any other join rejection or a newer drop takes over,
+// and gives up with StateError once the attempts run out.
+func (c *Client) reconnectLoop(gen int) {
+	c.mu.RLock()
+	address := c.address
+	c.mu.RUnlock()
+
+	delay := reconnectBaseDelay
+	for attempt := 1; attempt <= reconnectAttempts; attempt++ {
+		time.Sleep(delay)
+		delay = min(delay*2, reconnectMaxDelay)
+
+		if !c.reconnecting(gen) {
+			return
+		}
+		log.Printf("[client] reconnecting to %s (attempt %d/%d)", address, attempt, reconnectAttempts)
+		// dial blocks for the life of the connection, so a nil error means
+		// it opened and has since closed; keep going only if it closed
+		// before the server handed the slot back.
+		if err := c.dial(address); err != nil {
+			log.Printf("[client] reconnect failed: %v", err)
+		}
+	}
+
+	c.mu.Lock()
+	if c.state == StateReconnecting && c.reconnectGen == gen {
+		c.state = StateError
+		c.lastError = fmt.Errorf("reconnect failed")
+	}
+	c.mu.Unlock()
+}
+
+// followRedirect joins the server a ServerRedirect named, or one found
+// through ggscale matchmaking if it named none, as a new player: the slot
+// and room on the old server do not carry over. Disconnect stands it down.
+func (c *Client) followRedirect(address string) {
+	if address == "" {
+		ctx, cancel := context.WithTimeout(context.Background(), redirectMatchTimeout)
+		found, err := FindMatch(ctx, DefaultFleet, DefaultGameMode)
+		cancel()
+		if err != nil {
+			c.mu.Lock()
+			if c.state == StateRedirecting {
+				c.state = StateError
+				c.lastError = fmt.Errorf("re-queue failed: %w", err)
+			}
+			c.mu.Unlock()
+			return
+		}
+		address = found
+	}
+
+	c.mu.Lock()
+	if c.state != StateRedirecting {
+		c.mu.Unlock()
+		return
+	}
+	c.address = address
+	c.reconnectToken = ""
+	c.mu.Unlock()
+	c.events.drain() // Nothing from the old server applies on the new one
+
+	log.Printf("[client] joining %s", address)
+	if err := c.dial(address); err != nil {
+		c.setError(fmt.Errorf("redirect failed: %w", err))
+	}
+}
+
+func (c *Client) reconnecting(gen int) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.state == StateReconnecting && c.reconnectGen == gen
+}
+
 func (c *Client) Disconnect() {
 	c.mu.Lock()
 	conn := c.conn
@@ -300,20 +552,100 @@ func (c *Client) LevelNames() []string {
 	return c.levelNames
 }
 
+// RoomCode returns the code of the room this client joined, or "" before joining.
+func (c *Client) RoomCode() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.roomCode
+}
+
+// IsSpectator reports whether the client is watching without a body,
+// either by choice or while queued for a slot.
+func (c *Client) IsSpectator() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.spectator
+}
+
+// MatchStats returns the stats of the match that just ended, or nil while
+// none has ended since the current match started.
+func (c *Client) MatchStats() *messages.MatchStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.matchStats
+}
+
+// RTT returns the server's estimate of the round-trip time to it and its
+// jitter, both 0 until the first Ping.
+func (c *Client) RTT() (rtt, jitter time.Duration) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.rtt, c.jitter
+}
+
+// SetPlayoutDelay records how far behind the newest snapshot remote
+// players are drawn, for the server's lag compensation.
+func (c *Client) SetPlayoutDelay(delay time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.playoutDelay = delay
+}
+
+// Clock returns the client's estimate of the server's clock.
+func (c *Client) Clock() *ServerClock {
+	return &c.clock
+}
+
+// RespawnAt returns when the local player respawns, in server UnixNano, or
+// 0 if they are not waiting to.
+func (c *Client) RespawnAt() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.respawnAt
+}
+
+// ShutdownAt returns the latest the server will shut down, in server
+// UnixNano, or 0 if it has not said it is shutting down.
+func (c *Client) ShutdownAt() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.shutdownAt
+}
+
+// syncClock starts a clock sync exchange with the server.
+func (c *Client) syncClock() {
+	if err := c.SendMessage(messages.ClockSyncRequest{ClientTime: time.Now().UnixNano()}); err != nil {
+		log.Printf("[client] clock sync failed: %v", err)
+	}
+}
+
+// BytesTransferred returns the WebSocket payload bytes received and sent
+// since the client was created.
+func (c *Client) BytesTransferred() (in, out uint64) {
+	return c.bytesIn.Load(), c.bytesOut.Load()
+}
+
 func (c *Client) TickRate() int {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	return c.tickRate
 }
 
-// LatestSnapshot returns the most recent WorldSnapshot, or nil. Non-blocking.
-func (c *Client) LatestSnapshot() *esync.WorldSnapshot {
-	select {
-	case snap := <-c.snapshotCh:
-		return &snap
-	default:
-		return nil
-	}
+// SnapshotRate returns how many snapshots per second the server sends.
+func (c *Client) SnapshotRate() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.snapshotRate
+}
+
+// TakeSnapshots returns every snapshot received since the last call,
+// oldest first, with their arrival times. Non-blocking.
+func (c *Client) TakeSnapshots() []TimedSnapshot {
+	c.snapshotMu.Lock()
+	defer c.snapshotMu.Unlock()
+	snaps := c.pendingSnapshots
+	c.pendingSnapshots = nil
+	return snaps
 }
 
 func (c *Client) SendMessage(msg any) error {
@@ -330,6 +662,7 @@ func (c *Client) SendMessage(msg any) error {
 		return fmt.Errorf("serialize: %w", err)
 	}
 
+	c.bytesOut.Add(uint64(len(payload)))
 	return conn.Write(context.Background(), websocket.MessageBinary, payload)
 }
 
@@ -340,59 +673,18 @@ func (c *Client) setError(err error) {
 	c.mu.Unlock()
 }
 
-// DrainChargeEvents returns all pending charge events, non-blocking.
-func (c *Client) DrainChargeEvents() []messages.BoomerangChargeEvent {
-	return drainChan(c.chargeCh)
+// DrainEvents returns every server event received since the last call,
+// oldest first, non-blocking. Each is a message value such as a
+// messages.DeathEvent or messages.MatchEvent; a messages.SyncGameState
+// stands in for events missed beyond what the server could replay.
+func (c *Client) DrainEvents() []any {
+	return c.events.drain()
 }
 
-// DrainThrowEvents returns all pending throw events, non-blocking.
-func (c *Client) DrainThrowEvents() []messages.BoomerangThrowEvent {
-	return drainChan(c.throwCh)
-}
-
-// DrainCatchEvents returns all pending catch events, non-blocking.
-func (c *Client) DrainCatchEvents() []messages.BoomerangCatchEvent {
-	return drainChan(c.catchCh)
-}
-
-// DrainHitEvents returns all pending hit events, non-blocking.
-func (c *Client) DrainHitEvents() []messages.BoomerangHitEvent {
-	return drainChan(c.hitCh)
-}
-
-// DrainMeleeAttackEvents returns all pending melee attack initiation events, non-blocking.
-func (c *Client) DrainMeleeAttackEvents() []messages.MeleeAttackEvent {
-	return drainChan(c.meleeAttackCh)
-}
-
-// DrainMeleeHitEvents returns all pending melee hit events, non-blocking.
-func (c *Client) DrainMeleeHitEvents() []messages.MeleeHitEvent {
-	return drainChan(c.meleeHitCh)
-}
-
-// DrainDeathEvents returns all pending death events, non-blocking.
-func (c *Client) DrainDeathEvents() []messages.DeathEvent {
-	return drainChan(c.deathCh)
-}
-
-// DrainRespawnEvents returns all pending respawn events, non-blocking.
-func (c *Client) DrainRespawnEvents() []messages.RespawnEvent {
-	return drainChan(c.respawnCh)
-}
-
-// DrainMatchEvents returns all pending match events, non-blocking.
-func (c *Client) DrainMatchEvents() []messages.MatchEvent {
-	return drainChan(c.matchCh)
-}
-
-// DrainScoreEvents returns all pending score events, non-blocking.
-func (c *Client) DrainScoreEvents() []messages.ScoreEvent {
-	return drainChan(c.scoreCh)
-}
-
-// DrainLobbyUpdates returns all pending lobby updates, non-blocking.
-func (c *Client) DrainLobbyUpdates() []messages.LobbyUpdate {
-	return drainChan(c.lobbyUpdateCh)
+// UnreadEvents puts events back at the front of the queue, for a scene
+// that hands over to another part way through a frame's events.
+func (c *Client) UnreadEvents(events []any) {
+	c.events.unread(events)
 }
 
 // SubmitMyScore is a no-op retained for compatibility. Score submission
@@ -404,15 +696,3 @@ func (c *Client) DrainLobbyUpdates() []messages.LobbyUpdate {
 func (c *Client) SubmitMyScore(_ context.Context, _ int64) error {
 	return nil
 }
-
-func drainChan[T any](ch chan T) []T {
-	var out []T
-	for {
-		select {
-		case v := <-ch:
-			out = append(out, v)
-		default:
-			return out
-		}
-	}
-}
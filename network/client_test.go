@@ -0,0 +1,128 @@
+package network
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/coder/websocket"
+	"github.com/leap-fish/necs/router"
+	"github.com/leap-fish/necs/typeid"
+	"github.com/leap-fish/necs/typemapper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_closes_the_connection_when_the_join_is_rejected(t *testing.T) {
+	t.Cleanup(router.ResetRouter)
+	rejected, err := router.Serialize(messages.JoinRejected{Reason: "reconnect window expired"})
+	require.NoError(t, err)
+
+	// The server answers the JoinRequest with a JoinRejected and leaves
+	// the connection open, as an old server would.
+	closed := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		conn, err := websocket.Accept(w, req, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.CloseNow() }()
+		if _, _, err := conn.Read(context.Background()); err != nil {
+			return
+		}
+		_ = conn.Write(context.Background(), websocket.MessageBinary, rejected)
+		for {
+			if _, _, err := conn.Read(context.Background()); err != nil {
+				close(closed)
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewClient()
+	c.Connect(strings.TrimPrefix(srv.URL, "http://"), "", "Player", "")
+	t.Cleanup(c.Disconnect)
+
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("client kept the rejected connection open")
+	}
+	assert.Equal(t, StateError, c.State())
+	assert.EqualError(t, c.LastError(), "join rejected: reconnect window expired")
+}
+
+func TestClient_rejoins_afresh_when_the_reconnect_window_expired(t *testing.T) {
+	t.Cleanup(router.ResetRouter)
+	mapper := typemapper.NewMapper(map[uint]any{})
+	joinType := reflect.TypeOf(messages.JoinRequest{})
+	require.NoError(t, mapper.RegisterType(typeid.GetTypeId(joinType), joinType))
+
+	// The first connection joins and then drops; the server has forgotten
+	// the slot by the time the client reconnects with its token.
+	var mu sync.Mutex
+	var joins []messages.JoinRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		conn, err := websocket.Accept(w, req, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.CloseNow() }()
+		ctx := context.Background()
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return
+		}
+		msg, err := mapper.Deserialize(data)
+		if err != nil {
+			return
+		}
+		join := msg.(messages.JoinRequest)
+		mu.Lock()
+		joins = append(joins, join)
+		first := len(joins) == 1
+		mu.Unlock()
+
+		var reply any = messages.JoinAccepted{NetworkID: 2, ReconnectToken: "fresh"}
+		switch {
+		case first:
+			reply = messages.JoinAccepted{NetworkID: 1, ReconnectToken: "held"}
+		case join.ReconnectToken != "":
+			reply = messages.JoinRejected{Reason: messages.RejectReconnectExpired}
+		}
+		payload, err := router.Serialize(reply)
+		if err != nil {
+			return
+		}
+		_ = conn.Write(ctx, websocket.MessageBinary, payload)
+		if first {
+			return // Drop the connection mid-game
+		}
+		for {
+			if _, _, err := conn.Read(ctx); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewClient()
+	c.Connect(strings.TrimPrefix(srv.URL, "http://"), "", "Player", "")
+	t.Cleanup(c.Disconnect)
+
+	require.Eventually(t, func() bool {
+		return c.State() == StateJoinedGame && c.NetworkID() == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, joins, 3)
+	assert.Equal(t, "held", joins[1].ReconnectToken)
+	assert.Empty(t, joins[2].ReconnectToken)
+}
@@ -0,0 +1,85 @@
+package network
+
+import (
+	"sync"
+	"time"
+)
+
+// clockSamples is how many recent clock sync exchanges the server clock
+// keeps, about eight seconds of them at one a second.
+const clockSamples = 8
+
+type clockSample struct {
+	offset time.Duration // Server clock minus client clock
+	rtt    time.Duration
+}
+
+// ServerClock estimates the server's clock from clock sync exchanges, so
+// deadlines the server sends in its own time can be counted down locally.
+// Each exchange gives an offset assuming the request and response took
+// equally long; the one with the shortest round trip of the last
+// clockSamples is the least skewed by queueing, and is the one used. Safe
+// for concurrent use.
+type ServerClock struct {
+	mu      sync.RWMutex
+	samples [clockSamples]clockSample
+	next    int
+	count   int
+	offset  time.Duration
+}
+
+// Observe records an exchange whose request left at sent and whose
+// response, stamped serverTime by the server, arrived at received.
+func (c *ServerClock) Observe(sent, serverTime, received time.Time) {
+	rtt := received.Sub(sent)
+	if rtt < 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.samples[c.next] = clockSample{
+		offset: serverTime.Sub(sent.Add(rtt / 2)),
+		rtt:    rtt,
+	}
+	c.next = (c.next + 1) % clockSamples
+	c.count = min(c.count+1, clockSamples)
+
+	best := c.samples[0]
+	for _, s := range c.samples[1:c.count] {
+		if s.rtt < best.rtt {
+			best = s
+		}
+	}
+	c.offset = best.offset
+}
+
+// reset forgets every exchange, for a connection to a different server.
+func (c *ServerClock) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.count, c.next, c.offset = 0, 0, 0
+}
+
+// Synced reports whether any exchange has been observed yet.
+func (c *ServerClock) Synced() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.count > 0
+}
+
+// Offset returns how far the server's clock is ahead of this one.
+func (c *ServerClock) Offset() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.offset
+}
+
+// Now returns the server's current time.
+func (c *ServerClock) Now() time.Time {
+	return time.Now().Add(c.Offset())
+}
+
+// Until returns the time left until deadline, in server UnixNano.
+func (c *ServerClock) Until(deadline int64) time.Duration {
+	return time.Unix(0, deadline).Sub(c.Now())
+}
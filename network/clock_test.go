@@ -0,0 +1,51 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// exchange observes a clock sync exchange with a server ahead by offset,
+// taking up to reach the server and back to return.
+func exchange(c *ServerClock, sent time.Time, offset, up, back time.Duration) {
+	c.Observe(sent, sent.Add(up+offset), sent.Add(up+back))
+}
+
+func TestServerClock_symmetric_exchange_finds_offset(t *testing.T) {
+	var c ServerClock
+	assert.False(t, c.Synced())
+
+	exchange(&c, time.Now(), 5*time.Second, 40*time.Millisecond, 40*time.Millisecond)
+
+	assert.True(t, c.Synced())
+	assert.Equal(t, 5*time.Second, c.Offset())
+	assert.InDelta(t, float64(time.Second), float64(c.Until(c.Now().Add(time.Second).UnixNano())), float64(10*time.Millisecond))
+}
+
+func TestServerClock_prefers_fastest_recent_exchange(t *testing.T) {
+	var c ServerClock
+	start := time.Now()
+	exchange(&c, start, time.Second, 20*time.Millisecond, 20*time.Millisecond)
+	// A response held up in a queue skews its sample by half the delay
+	exchange(&c, start.Add(time.Second), time.Second, 20*time.Millisecond, 220*time.Millisecond)
+
+	assert.Equal(t, time.Second, c.Offset())
+
+	// Once the fast exchange ages out, the best of the rest is used
+	for i := range clockSamples {
+		exchange(&c, start.Add(time.Duration(i+2)*time.Second), time.Second, 30*time.Millisecond, 50*time.Millisecond)
+	}
+	assert.Equal(t, time.Second-10*time.Millisecond, c.Offset())
+}
+
+func TestServerClock_reset_forgets_exchanges(t *testing.T) {
+	var c ServerClock
+	exchange(&c, time.Now(), time.Minute, 0, 0)
+
+	c.reset()
+
+	assert.False(t, c.Synced())
+	assert.Zero(t, c.Offset())
+}
@@ -0,0 +1,49 @@
+package network
+
+import (
+	"slices"
+	"sync"
+)
+
+// maxQueuedEvents bounds an eventQueue that nothing drains, such as while
+// scenes change over. Bursts far below it are kept whole.
+const maxQueuedEvents = 256
+
+// eventQueue buffers server events between the router callbacks that push
+// them and the game systems that drain them once a frame. Unlike a buffered
+// channel it never refuses an event; only past maxQueuedEvents does it drop,
+// and then the oldest.
+type eventQueue[T any] struct {
+	mu     sync.Mutex
+	events []T
+}
+
+// push appends v.
+func (q *eventQueue[T]) push(v T) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.events) == maxQueuedEvents {
+		q.events = append(q.events[:0], q.events[1:]...)
+	}
+	q.events = append(q.events, v)
+}
+
+// unread puts vs back in front of the queued events, keeping the newest
+// maxQueuedEvents.
+func (q *eventQueue[T]) unread(vs []T) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.events = append(slices.Clone(vs), q.events...)
+	if over := len(q.events) - maxQueuedEvents; over > 0 {
+		q.events = q.events[over:]
+	}
+}
+
+// drain returns every queued event, oldest first, and empties the queue.
+func (q *eventQueue[T]) drain() []T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := q.events
+	q.events = nil
+	return out
+}
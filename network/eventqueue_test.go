@@ -0,0 +1,39 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventQueue_keeps_bursts_in_order(t *testing.T) {
+	var q eventQueue[int]
+	for i := range 100 {
+		q.push(i)
+	}
+
+	events := q.drain()
+	assert.Len(t, events, 100)
+	assert.Equal(t, 0, events[0])
+	assert.Equal(t, 99, events[99])
+	assert.Empty(t, q.drain())
+}
+
+func TestEventQueue_drops_oldest_when_undrained(t *testing.T) {
+	var q eventQueue[int]
+	for i := range maxQueuedEvents + 10 {
+		q.push(i)
+	}
+
+	events := q.drain()
+	assert.Len(t, events, maxQueuedEvents)
+	assert.Equal(t, 10, events[0])
+}
+
+func TestEventQueue_unread_puts_events_back_first(t *testing.T) {
+	var q eventQueue[int]
+	q.push(3)
+	q.unread([]int{1, 2})
+
+	assert.Equal(t, []int{1, 2, 3}, q.drain())
+}
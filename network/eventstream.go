@@ -0,0 +1,200 @@
+package network
+
+import (
+	"log"
+	"slices"
+	"sync"
+
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/leap-fish/necs/router"
+)
+
+// eventStream tracks the room's ordered event stream: each GameEvent is
+// applied only if its Seq follows the last one applied. On a gap the
+// client asks the server to replay what it missed and ignores events
+// until the replay catches up.
+type eventStream struct {
+	mu        sync.Mutex
+	started   bool   // JoinAccepted received on this connection
+	seq       uint32 // Last Seq applied
+	resyncing bool   // EventResyncRequest sent, replay not yet applied
+	// early are GameEvents that beat this connection's JoinAccepted.
+	early []messages.GameEvent
+}
+
+// queueEvents queues every event of type T received.
+func queueEvents[T any](c *Client) {
+	router.On(func(_ *router.NetworkClient, evt T) {
+		c.events.push(evt)
+	})
+}
+
+// handleEvents registers the router callbacks for the event stream and
+// the events it carries.
+func (c *Client) handleEvents() {
+	router.On(func(sender *router.NetworkClient, evt messages.GameEvent) {
+		c.receiveEvent(sender, evt)
+	})
+
+	router.On(func(_ *router.NetworkClient, sync messages.SyncGameState) {
+		c.applyResync(sync)
+	})
+
+	queueEvents[messages.BoomerangChargeEvent](c)
+	queueEvents[messages.BoomerangThrowEvent](c)
+	queueEvents[messages.BoomerangCatchEvent](c)
+	queueEvents[messages.BoomerangHitEvent](c)
+	queueEvents[messages.MeleeAttackEvent](c)
+	queueEvents[messages.MeleeHitEvent](c)
+	queueEvents[messages.HazardHitEvent](c)
+	queueEvents[messages.ScoreEvent](c)
+	queueEvents[messages.LobbyUpdate](c)
+
+	router.On(func(_ *router.NetworkClient, evt messages.DeathEvent) {
+		c.mu.Lock()
+		if evt.VictimID == uint(c.networkID) {
+			c.respawnAt = evt.RespawnAt
+		}
+		c.mu.Unlock()
+		c.events.push(evt)
+	})
+
+	router.On(func(_ *router.NetworkClient, evt messages.RespawnEvent) {
+		c.mu.Lock()
+		if evt.PlayerNetworkID == uint(c.networkID) {
+			c.respawnAt = 0
+		}
+		c.mu.Unlock()
+		c.events.push(evt)
+	})
+
+	router.On(func(_ *router.NetworkClient, evt messages.MatchEvent) {
+		switch evt.Type {
+		case "spectator_promoted":
+			c.mu.Lock()
+			if evt.PlayerID == uint32(c.networkID) { //nolint:gosec // NetworkId fits in uint32 for the foreseeable player counts
+				c.spectator = false
+			}
+			c.mu.Unlock()
+		case "match_start":
+			c.mu.Lock()
+			c.matchStats = nil
+			c.respawnAt = 0
+			c.mu.Unlock()
+		case "countdown_start":
+			c.mu.Lock()
+			c.respawnAt = 0 // Everyone spawns for the next round
+			c.mu.Unlock()
+		}
+		c.events.push(evt)
+	})
+
+	router.On(func(_ *router.NetworkClient, notice messages.ServerShutdownNotice) {
+		c.mu.Lock()
+		c.shutdownAt = notice.ShutdownAt
+		c.mu.Unlock()
+		c.events.push(notice)
+	})
+
+	router.On(func(_ *router.NetworkClient, stats messages.MatchStats) {
+		c.mu.Lock()
+		c.matchStats = &stats
+		c.mu.Unlock()
+		c.events.push(stats)
+	})
+}
+
+// receiveEvent applies evt in stream order.
+func (c *Client) receiveEvent(sender *router.NetworkClient, evt messages.GameEvent) {
+	c.stream.mu.Lock()
+	defer c.stream.mu.Unlock()
+	if !c.stream.started {
+		c.stream.early = append(c.stream.early, evt)
+		return
+	}
+	c.acceptEvent(sender, evt)
+}
+
+// acceptEvent dispatches evt's payload if it is next in the stream, drops
+// it if already applied, and starts a resync if events before it are
+// missing. Caller holds stream.mu.
+func (c *Client) acceptEvent(sender *router.NetworkClient, evt messages.GameEvent) {
+	switch {
+	case evt.Seq <= c.stream.seq:
+		return // Already applied; replays overlap what got through
+	case evt.Seq > c.stream.seq+1:
+		c.requestResync()
+		return
+	}
+
+	c.stream.seq = evt.Seq
+	c.stream.resyncing = false
+	if err := router.ProcessMessage(sender, evt.Payload); err != nil {
+		log.Printf("[client] dropping event %d: %v", evt.Seq, err)
+	}
+}
+
+// requestResync asks the server for every event after the last one
+// applied, once per gap. Caller holds stream.mu.
+func (c *Client) requestResync() {
+	if c.stream.resyncing {
+		return
+	}
+	c.stream.resyncing = true
+	log.Printf("[client] missed events after %d; requesting resync", c.stream.seq)
+	if err := c.SendMessage(messages.EventResyncRequest{After: c.stream.seq}); err != nil {
+		log.Printf("[client] resync request failed: %v", err)
+	}
+}
+
+// applyResync takes a SyncGameState in place of the events up to its
+// EventSeq and queues it for the systems those events would have updated.
+func (c *Client) applyResync(sync messages.SyncGameState) {
+	c.stream.mu.Lock()
+	defer c.stream.mu.Unlock()
+	if !c.stream.started || sync.EventSeq < c.stream.seq {
+		return
+	}
+	c.stream.seq = sync.EventSeq
+	c.stream.resyncing = false
+
+	c.mu.Lock()
+	c.matchStats = sync.Stats
+	if c.spectator && slices.Contains(sync.State.SlotNetIDs[:], uint32(c.networkID)) { //nolint:gosec // NetworkId fits in uint32 for the foreseeable player counts
+		c.spectator = false
+	}
+	c.mu.Unlock()
+	c.events.push(sync)
+}
+
+// startEvents starts the stream once a JoinAccepted arrives. A fresh join
+// starts after joinSeq, the room's latest event at the time; a reconnect
+// carries on from the last event applied and resyncs what it missed.
+func (c *Client) startEvents(joinSeq uint32, reconnected bool) {
+	c.stream.mu.Lock()
+	defer c.stream.mu.Unlock()
+	c.stream.started = true
+	c.stream.resyncing = false
+	if !reconnected {
+		c.stream.seq = joinSeq
+	}
+
+	early := c.stream.early
+	c.stream.early = nil
+	for _, evt := range early {
+		c.acceptEvent(nil, evt)
+	}
+	if c.stream.seq < joinSeq {
+		c.requestResync()
+	}
+}
+
+// stopEvents holds the stream until the next JoinAccepted, when the
+// connection drops or a new one starts.
+func (c *Client) stopEvents() {
+	c.stream.mu.Lock()
+	defer c.stream.mu.Unlock()
+	c.stream.started = false
+	c.stream.resyncing = false
+	c.stream.early = nil
+}
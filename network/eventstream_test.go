@@ -0,0 +1,111 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/leap-fish/necs/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEventTestClient(t *testing.T) *Client {
+	t.Helper()
+	c := NewClient()
+	c.handleEvents()
+	t.Cleanup(router.ResetRouter)
+	return c
+}
+
+func scoreGameEvent(t *testing.T, seq uint32) messages.GameEvent {
+	t.Helper()
+	payload, err := router.Serialize(messages.ScoreEvent{PlayerID: 1, KOs: int(seq)})
+	require.NoError(t, err)
+	return messages.GameEvent{Seq: seq, Payload: payload}
+}
+
+func drainedKOs(c *Client) []int {
+	var kos []int
+	for _, evt := range c.DrainEvents() {
+		if score, ok := evt.(messages.ScoreEvent); ok {
+			kos = append(kos, score.KOs)
+		}
+	}
+	return kos
+}
+
+func TestClient_receiveEvent_applies_in_order_once(t *testing.T) {
+	c := newEventTestClient(t)
+	c.startEvents(0, false)
+
+	c.receiveEvent(nil, scoreGameEvent(t, 1))
+	c.receiveEvent(nil, scoreGameEvent(t, 2))
+	c.receiveEvent(nil, scoreGameEvent(t, 2))
+
+	assert.Equal(t, []int{1, 2}, drainedKOs(c))
+}
+
+func TestClient_receiveEvent_waits_for_replay_after_gap(t *testing.T) {
+	c := newEventTestClient(t)
+	c.startEvents(5, false)
+
+	c.receiveEvent(nil, scoreGameEvent(t, 7))
+	c.receiveEvent(nil, scoreGameEvent(t, 8))
+	assert.Empty(t, c.DrainEvents(), "nothing past the gap applies")
+	assert.True(t, c.stream.resyncing)
+
+	for seq := uint32(6); seq <= 8; seq++ {
+		c.receiveEvent(nil, scoreGameEvent(t, seq))
+	}
+	assert.Equal(t, []int{6, 7, 8}, drainedKOs(c))
+	assert.False(t, c.stream.resyncing)
+}
+
+func TestClient_startEvents_applies_events_that_beat_the_join(t *testing.T) {
+	c := newEventTestClient(t)
+	c.receiveEvent(nil, scoreGameEvent(t, 3))
+	c.receiveEvent(nil, scoreGameEvent(t, 4))
+
+	c.startEvents(2, false)
+
+	assert.Equal(t, []int{3, 4}, drainedKOs(c))
+}
+
+func TestClient_startEvents_resyncs_after_reconnect(t *testing.T) {
+	c := newEventTestClient(t)
+	c.startEvents(4, false)
+	c.stopEvents()
+
+	c.startEvents(9, true)
+
+	assert.Equal(t, uint32(4), c.stream.seq, "carries on from the last event applied")
+	assert.True(t, c.stream.resyncing)
+}
+
+func TestClient_applyResync_skips_to_state(t *testing.T) {
+	c := newEventTestClient(t)
+	c.startEvents(1, false)
+	c.receiveEvent(nil, scoreGameEvent(t, 5))
+
+	c.applyResync(messages.SyncGameState{EventSeq: 9, Stats: &messages.MatchStats{WinnerID: 3}})
+	c.receiveEvent(nil, scoreGameEvent(t, 10))
+
+	events := c.DrainEvents()
+	require.Len(t, events, 2)
+	assert.IsType(t, messages.SyncGameState{}, events[0])
+	assert.Equal(t, messages.ScoreEvent{PlayerID: 1, KOs: 10}, events[1])
+	require.NotNil(t, c.MatchStats())
+	assert.Equal(t, uint32(3), c.MatchStats().WinnerID)
+}
+
+func TestClient_shutdown_notice_sets_ShutdownAt(t *testing.T) {
+	c := newEventTestClient(t)
+	c.startEvents(0, false)
+	payload, err := router.Serialize(messages.ServerShutdownNotice{ShutdownAt: 42})
+	require.NoError(t, err)
+
+	c.receiveEvent(nil, messages.GameEvent{Seq: 1, Payload: payload})
+
+	assert.Equal(t, int64(42), c.ShutdownAt())
+	assert.Equal(t, []any{messages.ServerShutdownNotice{ShutdownAt: 42}}, c.DrainEvents())
+}
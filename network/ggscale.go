@@ -43,6 +43,12 @@ func SharedGgscale() (*ggscale.Client, int64) {
 	return sharedGgscaleClient, sharedLeaderboardID
 }
 
+// The fleet and game mode the client matchmakes into.
+const (
+	DefaultFleet    = "docker-default"
+	DefaultGameMode = "deathmatch"
+)
+
 // FindMatch is a convenience wrapper around ggscale matchmaking. It
 // creates a matchmaking ticket and blocks until the server assigns a
 // game server address. Returns the server address (e.g. "192.168.1.5:7777")
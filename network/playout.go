@@ -0,0 +1,130 @@
+package network
+
+import "time"
+
+// transitWindow is how many recent snapshots the playout clock takes its
+// fastest delivery time from. About two seconds at 30 snapshots/second,
+// so it follows route changes without chasing every spike.
+const transitWindow = 64
+
+// PlayoutClock is the jitter buffer clock for remote entities. Snapshot k
+// was taken at server time k × the snapshot interval; the clock measures
+// how unevenly snapshots arrive and picks the server time to render at,
+// behind the newest snapshot by one interval plus a multiple of the
+// measured jitter, so the next snapshot has usually arrived by the time it
+// is needed. Not safe for concurrent use.
+type PlayoutClock struct {
+	maxDelay         time.Duration
+	jitterMultiplier float64
+
+	interval time.Duration
+	epoch    time.Time // local time of the first snapshot observed
+
+	// transits are recent arrival-time minus server-time samples; their
+	// minimum is the clock offset plus the fastest delivery seen.
+	transits    [transitWindow]time.Duration
+	count       int
+	prevTransit time.Duration
+	jitter      time.Duration // RFC 3550 interarrival jitter
+	delay       time.Duration // eased towards the jitter-based target
+	newest      time.Duration // server time of the newest snapshot
+
+	underrun  bool
+	underruns int
+}
+
+// NewPlayoutClock returns a clock that renders remote entities at most
+// maxDelay behind the newest snapshot, aiming for one snapshot interval
+// plus jitterMultiplier times the measured jitter.
+func NewPlayoutClock(maxDelay time.Duration, jitterMultiplier float64) *PlayoutClock {
+	return &PlayoutClock{maxDelay: maxDelay, jitterMultiplier: jitterMultiplier}
+}
+
+// SetInterval sets the server's snapshot interval, forgetting every
+// measurement if it changed.
+func (c *PlayoutClock) SetInterval(interval time.Duration) {
+	if interval == c.interval {
+		return
+	}
+	c.interval = interval
+	c.reset()
+}
+
+func (c *PlayoutClock) reset() {
+	*c = PlayoutClock{maxDelay: c.maxDelay, jitterMultiplier: c.jitterMultiplier, interval: c.interval}
+}
+
+// ServerTime returns when the server took snapshot seq.
+func (c *PlayoutClock) ServerTime(seq uint32) time.Duration {
+	return time.Duration(seq) * c.interval
+}
+
+// Observe records that snapshot seq arrived at received.
+func (c *PlayoutClock) Observe(seq uint32, received time.Time) {
+	if c.interval <= 0 {
+		return
+	}
+	serverTime := c.ServerTime(seq)
+	if c.count > 0 && serverTime+time.Second < c.newest {
+		c.reset() // sequence restarted: a new room or server
+	}
+	if c.count == 0 {
+		c.epoch = received
+	}
+	transit := received.Sub(c.epoch) - serverTime
+
+	if c.count > 0 {
+		d := transit - c.prevTransit
+		if d < 0 {
+			d = -d
+		}
+		c.jitter += (d - c.jitter) / 16
+	}
+	c.prevTransit = transit
+	c.transits[c.count%transitWindow] = transit
+	c.count++
+	c.newest = max(c.newest, serverTime)
+
+	target := c.interval + time.Duration(c.jitterMultiplier*float64(c.jitter))
+	target = min(max(target, c.interval), max(c.maxDelay, c.interval))
+	switch {
+	case c.count == 1:
+		c.delay = target
+	case target > c.delay:
+		c.delay += (target - c.delay) / 4 // back off quickly when delivery gets worse
+	default:
+		c.delay += (target - c.delay) / 32 // and creep back slowly
+	}
+}
+
+// RenderTime returns the server time remote entities should be drawn at,
+// or false before any snapshot has arrived. Running past the newest
+// snapshot is an underrun: entities extrapolate until the next arrives.
+func (c *PlayoutClock) RenderTime(now time.Time) (time.Duration, bool) {
+	if c.count == 0 {
+		return 0, false
+	}
+	base := c.transits[0]
+	for _, transit := range c.transits[1:min(c.count, transitWindow)] {
+		base = min(base, transit)
+	}
+
+	t := now.Sub(c.epoch) - base - c.delay
+	behind := t > c.newest
+	if behind && !c.underrun {
+		c.underruns++
+	}
+	c.underrun = behind
+	return t, true
+}
+
+// Delay returns how far behind the newest snapshot remote entities are
+// rendered.
+func (c *PlayoutClock) Delay() time.Duration { return c.delay }
+
+// Jitter returns the smoothed variation in snapshot arrival times.
+func (c *PlayoutClock) Jitter() time.Duration { return c.jitter }
+
+// Underruns returns how many times rendering has run past the newest
+// snapshot.
+func (c *PlayoutClock) Underruns() int { return c.underruns }
@@ -0,0 +1,81 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testInterval = time.Second / 30
+
+// feed observes snapshots 1..n, each arriving at its server time plus
+// latency plus the jitter returned for it.
+func feed(c *PlayoutClock, start time.Time, n int, jitter func(seq int) time.Duration) time.Time {
+	var last time.Time
+	for seq := 1; seq <= n; seq++ {
+		last = start.Add(time.Duration(seq)*testInterval + 50*time.Millisecond + jitter(seq))
+		c.Observe(uint32(seq), last)
+	}
+	return last
+}
+
+func TestPlayoutClock_steady_delivery_renders_one_interval_behind(t *testing.T) {
+	c := NewPlayoutClock(250*time.Millisecond, 3)
+	c.SetInterval(testInterval)
+
+	_, ok := c.RenderTime(time.Now())
+	assert.False(t, ok, "no snapshots yet")
+
+	last := feed(c, time.Now(), 60, func(int) time.Duration { return 0 })
+
+	assert.Equal(t, testInterval, c.Delay())
+	renderTime, ok := c.RenderTime(last)
+	require.True(t, ok)
+	assert.Equal(t, c.ServerTime(60)-testInterval, renderTime)
+	assert.Zero(t, c.Underruns())
+}
+
+func TestPlayoutClock_delay_adapts_to_jitter(t *testing.T) {
+	clumped := func(seq int) time.Duration {
+		// Every third snapshot is held back and delivered with the next.
+		if seq%3 == 1 {
+			return testInterval
+		}
+		return 0
+	}
+
+	c := NewPlayoutClock(250*time.Millisecond, 3)
+	c.SetInterval(testInterval)
+	feed(c, time.Now(), 120, clumped)
+	assert.Greater(t, c.Delay(), testInterval)
+	assert.LessOrEqual(t, c.Delay(), 250*time.Millisecond)
+
+	capped := NewPlayoutClock(50*time.Millisecond, 3)
+	capped.SetInterval(testInterval)
+	feed(capped, time.Now(), 120, clumped)
+	assert.InDelta(t, 50*time.Millisecond, capped.Delay(), float64(time.Millisecond))
+}
+
+func TestPlayoutClock_counts_each_underrun_once(t *testing.T) {
+	c := NewPlayoutClock(250*time.Millisecond, 3)
+	c.SetInterval(testInterval)
+	last := feed(c, time.Now(), 30, func(int) time.Duration { return 0 })
+
+	// No snapshot for three intervals: rendering runs past the newest.
+	for i := 1; i <= 3; i++ {
+		c.RenderTime(last.Add(time.Duration(i) * testInterval))
+	}
+	assert.Equal(t, 1, c.Underruns())
+
+	// The late snapshots arrive together and rendering catches up, then
+	// runs dry again.
+	for seq := uint32(31); seq <= 34; seq++ {
+		c.Observe(seq, last.Add(3*testInterval))
+	}
+	c.RenderTime(last.Add(3 * testInterval))
+	assert.Equal(t, 1, c.Underruns())
+	c.RenderTime(last.Add(8 * testInterval))
+	assert.Equal(t, 2, c.Underruns())
+}
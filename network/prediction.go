@@ -16,6 +16,7 @@ type InputRecord struct {
 type PredictionBuffer struct {
 	history [predictionBufferSize]InputRecord
 	nextSeq uint32
+	acked   uint32 // newest sequence the server has processed
 }
 
 // Store saves an input and the resulting predicted position.
@@ -28,3 +29,48 @@ func (pb *PredictionBuffer) Store(input messages.PlayerInput, predX, predY float
 	}
 	pb.nextSeq = input.Sequence + 1
 }
+
+// Lookup returns the record for seq if it is still in the buffer.
+func (pb *PredictionBuffer) Lookup(seq uint32) (InputRecord, bool) {
+	rec := pb.history[seq%predictionBufferSize]
+	if seq == 0 || rec.Input.Sequence != seq {
+		return InputRecord{}, false
+	}
+	return rec, true
+}
+
+// Ack records that the server has processed every input up to seq.
+func (pb *PredictionBuffer) Ack(seq uint32) {
+	pb.acked = max(pb.acked, seq)
+}
+
+// Acked returns the newest sequence the server has processed.
+func (pb *PredictionBuffer) Acked() uint32 {
+	return pb.acked
+}
+
+// Unacked returns up to limit of the newest stored inputs the server has
+// not acknowledged, oldest first, stopping short of sequence before.
+func (pb *PredictionBuffer) Unacked(before uint32, limit int) []messages.PlayerInput {
+	first := pb.acked + 1
+	if before > uint32(limit) {
+		first = max(first, before-uint32(limit))
+	}
+	var inputs []messages.PlayerInput
+	for seq := first; seq < before; seq++ {
+		if rec, ok := pb.Lookup(seq); ok {
+			inputs = append(inputs, rec.Input)
+		}
+	}
+	return inputs
+}
+
+// Shift moves every stored prediction by (dx, dy), after the local player
+// has been corrected towards the server so the same error is not applied
+// again on the next snapshot.
+func (pb *PredictionBuffer) Shift(dx, dy float64) {
+	for i := range pb.history {
+		pb.history[i].PredictedX += dx
+		pb.history[i].PredictedY += dy
+	}
+}
@@ -7,6 +7,7 @@ import (
 	cfg "github.com/automoto/doomerang-mp/config"
 	"github.com/automoto/doomerang-mp/network"
 	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
 	"github.com/automoto/doomerang-mp/systems"
 	"github.com/automoto/doomerang-mp/ui"
 	"github.com/hajimehoshi/ebiten/v2"
@@ -38,14 +39,33 @@ func (ns *NetLobbyScene) Update() {
 		ns.sceneChanger.ChangeScene(NewServerBrowserScene(ns.sceneChanger))
 		return
 	}
+	if state == network.StateRedirecting {
+		ns.sceneChanger.ChangeScene(newServerBrowserSceneFollowing(ns.sceneChanger, ns.netClient))
+		return
+	}
 
-	// Drain lobby updates
-	for _, update := range ns.netClient.DrainLobbyUpdates() {
-		ns.lobbyUI.UpdateState(update)
+	// Spectators have no lobby slot; they watch from the arena.
+	if ns.netClient.IsSpectator() {
+		ns.sceneChanger.ChangeScene(NewNetworkedScene(ns.sceneChanger, ns.netClient))
+		return
 	}
 
-	for _, evt := range ns.netClient.DrainMatchEvents() {
-		if evt.Type == "match_start" || evt.Type == "countdown_start" {
+	events := ns.netClient.DrainEvents()
+	for i, evt := range events {
+		starting := false
+		switch evt := evt.(type) {
+		case messages.LobbyUpdate:
+			ns.lobbyUI.UpdateState(evt)
+		case messages.MatchEvent:
+			starting = evt.Type == "match_start" || evt.Type == "countdown_start"
+		case messages.SyncGameState:
+			ns.lobbyUI.UpdateState(evt.Lobby)
+			starting = evt.State.MatchState != netcomponents.MatchStateWaiting &&
+				evt.State.MatchState != netcomponents.MatchStateFinished
+		}
+		if starting {
+			// The arena picks up from the event that started the match.
+			ns.netClient.UnreadEvents(events[i:])
 			ns.sceneChanger.ChangeScene(NewNetworkedScene(ns.sceneChanger, ns.netClient))
 			return
 		}
@@ -79,6 +99,7 @@ func (ns *NetLobbyScene) configure() {
 	ns.lobbyUI = ui.NewNetLobbyUI(
 		localNetID,
 		levelNames,
+		ns.netClient.RoomCode(),
 		func(action messages.LobbyAction) {
 			_ = ns.netClient.SendMessage(action)
 		},
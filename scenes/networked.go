@@ -6,6 +6,7 @@ import (
 	"math"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/automoto/doomerang-mp/assets"
 	"github.com/automoto/doomerang-mp/components"
@@ -28,6 +29,9 @@ type NetworkedScene struct {
 	sceneChanger SceneChanger
 	netClient    *network.Client
 	prediction   *systems.NetPrediction
+	playout      *network.PlayoutClock
+	spectatorCam *systems.SpectatorCamera
+	netGraph     *systems.NetGraph
 	once         sync.Once
 	presentIDs   map[esync.NetworkId]bool
 }
@@ -37,6 +41,9 @@ func NewNetworkedScene(sc SceneChanger, client *network.Client) *NetworkedScene
 		sceneChanger: sc,
 		netClient:    client,
 		prediction:   systems.NewNetPrediction(),
+		playout:      network.NewPlayoutClock(time.Duration(cfg.Netcode.MaxInterpDelay*float64(time.Second)/60), cfg.Netcode.JitterMultiplier),
+		spectatorCam: &systems.SpectatorCamera{},
+		netGraph:     systems.NewNetGraph(),
 		presentIDs:   make(map[esync.NetworkId]bool),
 	}
 }
@@ -51,10 +58,20 @@ func (ns *NetworkedScene) Update() {
 		ns.sceneChanger.ChangeScene(NewServerBrowserScene(ns.sceneChanger))
 		return
 	}
+	if state == network.StateRedirecting {
+		ns.sceneChanger.ChangeScene(newServerBrowserSceneFollowing(ns.sceneChanger, ns.netClient))
+		return
+	}
 
-	if snap := ns.netClient.LatestSnapshot(); snap != nil {
-		ns.applySnapshot(*snap)
+	if rate := ns.netClient.SnapshotRate(); rate > 0 {
+		ns.playout.SetInterval(time.Second / time.Duration(rate))
+	}
+	for _, snap := range ns.netClient.TakeSnapshots() {
+		ns.playout.Observe(snap.Seq, snap.Received)
+		ns.netGraph.AddSnapshot(snap.Received)
+		ns.applySnapshot(snap)
 	}
+	ns.netClient.SetPlayoutDelay(ns.playout.Delay())
 
 	ns.ecsWorld.Update()
 }
@@ -67,6 +84,10 @@ func (ns *NetworkedScene) Draw(screen *ebiten.Image) {
 	}
 
 	ns.ecsWorld.Draw(screen)
+
+	if ns.netClient.State() == network.StateReconnecting {
+		systems.DrawReconnectingOverlay(screen)
+	}
 }
 
 func (ns *NetworkedScene) configure() {
@@ -98,13 +119,18 @@ func (ns *NetworkedScene) configure() {
 				spawnX = lvl.PlayerSpawns[0].X
 				spawnY = lvl.PlayerSpawns[0].Y
 			}
-			ns.prediction.InitCollision(lvl.SolidTiles, lvl.Width, lvl.Height, spawnX, spawnY)
+			ns.prediction.InitCollision(lvl.SolidTiles, lvl.Platforms, lvl.Width, lvl.Height, spawnX, spawnY)
 			factory.CreateSpace(ns.ecsWorld, lvl.Width, lvl.Height, 16, 16)
+
+			// Fires are drawn locally; NetFireSystem keeps them on the server's frame
+			for _, fire := range lvl.Fires {
+				factory.CreateFire(ns.ecsWorld, fire.X, fire.Y, fire.FireType, fire.Direction)
+			}
 		}
 	}
 
 	sendFn := func(msg any) error {
-		if ns.netClient.State() != network.StateJoinedGame {
+		if ns.netClient.State() != network.StateJoinedGame || ns.netClient.IsSpectator() {
 			return nil
 		}
 		return ns.netClient.SendMessage(msg)
@@ -113,20 +139,34 @@ func (ns *NetworkedScene) configure() {
 		return ns.netClient.NetworkID()
 	}
 	ns.ecsWorld.AddSystem(systems.NewNetworkInputSystem(sendFn, ns.prediction, localNetID))
-	ns.ecsWorld.AddSystem(systems.NewNetInterpSystem(ns.netClient.TickRate))
+	ns.ecsWorld.AddSystem(systems.NewNetInterpSystem(ns.playout))
 	ns.ecsWorld.AddSystem(systems.UpdateNetAnimations)
 	ns.ecsWorld.AddSystem(systems.NewNetPlayerEffectsSystem(ns.prediction, localNetID))
 	ns.ecsWorld.AddSystem(systems.NewNetCameraSystem(localNetID))
-	ns.ecsWorld.AddSystem(systems.NewNetBoomerangEventSystem(ns.netClient))
-	ns.ecsWorld.AddSystem(systems.NewNetCombatEventSystem(ns.netClient))
-	ns.ecsWorld.AddSystem(systems.NewNetMatchEventSystem(ns.netClient))
+	ns.ecsWorld.AddSystem(systems.NewNetSpectatorCameraSystem(ns.netClient.IsSpectator, ns.spectatorCam))
+	netEvents := &systems.NetEvents{}
+	ns.ecsWorld.AddSystem(systems.NewNetEventSystem(ns.netClient, netEvents))
+	killFeed := systems.NewKillFeed()
+	ns.ecsWorld.AddSystem(systems.NewNetBoomerangEventSystem(ns.netClient, netEvents, ns.prediction, killFeed))
+	ns.ecsWorld.AddSystem(systems.NewNetCombatEventSystem(ns.netClient, netEvents, ns.prediction, killFeed))
+	ns.ecsWorld.AddSystem(systems.NewNetCombatPredictionSystem(ns.prediction, localNetID))
+	ns.ecsWorld.AddSystem(systems.NewNetMatchEventSystem(netEvents))
+	ns.ecsWorld.AddSystem(systems.UpdateNetFires)
+	ns.ecsWorld.AddSystem(systems.UpdateNetEnemies)
 	ns.ecsWorld.AddSystem(systems.UpdateEffects)
 	ns.ecsWorld.AddSystem(systems.UpdateAudio)
+	ns.ecsWorld.AddSystem(systems.NewNetGraphSystem(ns.netClient, ns.netGraph))
 	ns.ecsWorld.AddRenderer(cfg.Default, systems.DrawLevel)
+	ns.ecsWorld.AddRenderer(cfg.Default, systems.DrawNetworkedEnemies)
 	ns.ecsWorld.AddRenderer(cfg.Default, systems.DrawNetworkedPlayers)
+	ns.ecsWorld.AddRenderer(cfg.Default, systems.DrawNetworkedKnives)
 	ns.ecsWorld.AddRenderer(cfg.Default, systems.DrawNetworkedBoomerangs)
 	ns.ecsWorld.AddRenderer(cfg.Default, systems.DrawAnimated)
-	ns.ecsWorld.AddRenderer(cfg.Default, systems.DrawNetworkHUD)
+	ns.ecsWorld.AddRenderer(cfg.Default, systems.NewKillFeedRenderer(killFeed))
+	ns.ecsWorld.AddRenderer(cfg.Default, systems.NewNetworkHUDRenderer(ns.netClient))
+	ns.ecsWorld.AddRenderer(cfg.Default, systems.NewNetInterpDebugRenderer(ns.playout))
+	ns.ecsWorld.AddRenderer(cfg.Default, systems.NewNetGraphRenderer(ns.netGraph))
+	ns.ecsWorld.AddRenderer(cfg.Default, systems.NewSpectatorHUDRenderer(ns.netClient.IsSpectator, ns.spectatorCam))
 }
 
 // findLevelIndex returns the index of the level matching name, or 0 if not found.
@@ -140,13 +180,14 @@ func findLevelIndex(name string) int {
 	return 0
 }
 
-func (ns *NetworkedScene) applySnapshot(snapshot esync.WorldSnapshot) {
+func (ns *NetworkedScene) applySnapshot(snap network.TimedSnapshot) {
 	world := ns.ecsWorld.World
 	myNetID := ns.netClient.NetworkID()
+	serverTime := ns.playout.ServerTime(snap.Seq)
 
 	clear(ns.presentIDs)
 
-	for _, ent := range snapshot {
+	for _, ent := range snap.World {
 		ns.presentIDs[ent.Id] = true
 
 		var compData []any
@@ -169,6 +210,8 @@ func (ns *NetworkedScene) applySnapshot(snapshot esync.WorldSnapshot) {
 
 			initNetPlayerAnimation(entry)
 			initNetBoomerangSprite(entry)
+			initNetEnemyAnimation(entry, compData)
+			initNetKnifeSprite(entry)
 		}
 
 		entry := world.Entry(entity)
@@ -194,34 +237,42 @@ func (ns *NetworkedScene) applySnapshot(snapshot esync.WorldSnapshot) {
 					applyComponentToEntry(entry, data)
 					break
 				}
-				interp := components.NetInterp.Get(entry)
-				updateInterp(interp, v.X, v.Y,
-					func() { applyComponentToEntry(entry, data) },
-					func() (float64, float64) {
-						pos := netcomponents.NetPosition.Get(entry)
-						return pos.X, pos.Y
-					},
-				)
+				sample := components.NetInterpSample{T: serverTime, X: v.X, Y: v.Y}
 				if remoteVel != nil {
-					interp.VelX = remoteVel.SpeedX
-					interp.VelY = remoteVel.SpeedY
+					sample.VelX = remoteVel.SpeedX
+					sample.VelY = remoteVel.SpeedY
 				}
+				pushInterp(components.NetInterp.Get(entry), sample, func() { applyComponentToEntry(entry, data) })
 
 			case netcomponents.NetBoomerangData:
 				if !entry.HasComponent(components.NetInterp) {
 					applyComponentToEntry(entry, data)
 					break
 				}
-				interp := components.NetInterp.Get(entry)
-				updateInterp(interp, v.X, v.Y,
-					func() { applyComponentToEntry(entry, data) },
-					func() (float64, float64) {
-						nb := netcomponents.NetBoomerang.Get(entry)
-						return nb.X, nb.Y
-					},
-				)
-				interp.VelX = v.VelX
-				interp.VelY = v.VelY
+				sample := components.NetInterpSample{T: serverTime, X: v.X, Y: v.Y, VelX: v.VelX, VelY: v.VelY}
+				pushInterp(components.NetInterp.Get(entry), sample, func() { applyComponentToEntry(entry, data) })
+
+			case netcomponents.NetEnemyData:
+				if !entry.HasComponent(components.NetInterp) {
+					applyComponentToEntry(entry, data)
+					break
+				}
+				sample := components.NetInterpSample{T: serverTime, X: v.X, Y: v.Y, VelX: v.VelX, VelY: v.VelY}
+				pushInterp(components.NetInterp.Get(entry), sample, func() { applyComponentToEntry(entry, data) })
+
+				// Position follows the buffer; the rest applies now
+				ne := netcomponents.NetEnemy.Get(entry)
+				x, y := ne.X, ne.Y
+				*ne = v
+				ne.X, ne.Y = x, y
+
+			case netcomponents.NetKnifeData:
+				if !entry.HasComponent(components.NetInterp) {
+					applyComponentToEntry(entry, data)
+					break
+				}
+				sample := components.NetInterpSample{T: serverTime, X: v.X, Y: v.Y, VelX: v.VelX, VelY: v.VelY}
+				pushInterp(components.NetInterp.Get(entry), sample, func() { applyComponentToEntry(entry, data) })
 
 			default:
 				applyComponentToEntry(entry, data)
@@ -248,9 +299,11 @@ func (ns *NetworkedScene) applySnapshot(snapshot esync.WorldSnapshot) {
 }
 
 // reconcileLocal handles server state for the local player using position
-// smoothing. Instead of seq-based reconciliation + replay, it compares the
-// current server position with the current local position and applies a small,
-// capped correction per snapshot.
+// smoothing. The server plays inputs back one per physics step, so its
+// position is exactly where this client predicted it would be after the
+// snapshot's LastSequence; the error is measured against that prediction
+// (or the current local position when it is no longer buffered) and a
+// small, capped correction is applied per snapshot.
 func (ns *NetworkedScene) reconcileLocal(entry *donburi.Entry, components []any) {
 	var serverPos *netcomponents.NetPositionData
 	var serverVel *netcomponents.NetVelocityData
@@ -314,44 +367,47 @@ func (ns *NetworkedScene) reconcileLocal(entry *donburi.Entry, components []any)
 		// First snapshot: snap to server position
 		localPos.X = serverPos.X
 		localPos.Y = serverPos.Y
-		ns.prediction.VelX = serverVel.SpeedX
-		ns.prediction.VelY = serverVel.SpeedY
-		ns.prediction.OnGround = math.Abs(serverVel.SpeedY) < 0.1
+		ns.prediction.Body.SpeedX = serverVel.SpeedX
+		ns.prediction.Body.SpeedY = serverVel.SpeedY
+		ns.prediction.Correct(serverPos.X, serverPos.Y, serverVel.SpeedY)
 		ns.prediction.Initialized = true
-		if ns.prediction.PlayerObj != nil {
-			ns.prediction.PlayerObj.X = serverPos.X
-			ns.prediction.PlayerObj.Y = serverPos.Y
-			ns.prediction.PlayerObj.Update()
-		}
 	} else {
-		// Compare current positions — no seq lookup, no replay
-		errX := serverPos.X - localPos.X
-		errY := serverPos.Y - localPos.Y
+		// Compare against what we predicted for the last input the server
+		// processed — no replay
+		predX, predY := localPos.X, localPos.Y
+		if serverState != nil {
+			ns.prediction.Buffer.Ack(serverState.LastSequence)
+			if rec, ok := ns.prediction.Buffer.Lookup(serverState.LastSequence); ok {
+				predX, predY = rec.PredictedX, rec.PredictedY
+			}
+		}
+		beforeX, beforeY := localPos.X, localPos.Y
+		errX := serverPos.X - predX
+		errY := serverPos.Y - predY
 		dist := math.Sqrt(errX*errX + errY*errY)
+		ns.netGraph.AddPredictionError(dist)
 
 		if dist > cfg.Netcode.SnapThreshold {
 			// Teleport/respawn: hard snap
 			localPos.X = serverPos.X
 			localPos.Y = serverPos.Y
-			ns.prediction.VelX = serverVel.SpeedX
-			ns.prediction.VelY = serverVel.SpeedY
+			ns.prediction.Body.SpeedX = serverVel.SpeedX
+			ns.prediction.Body.SpeedY = serverVel.SpeedY
 		} else if dist > cfg.Netcode.SmoothThreshold {
 			// Gentle nudge, capped per tick
 			corrX := mathutil.ClampFloat(errX*cfg.Netcode.CorrectionRate, -cfg.Netcode.MaxCorrPerTick, cfg.Netcode.MaxCorrPerTick)
 			corrY := mathutil.ClampFloat(errY*cfg.Netcode.CorrectionRate, -cfg.Netcode.MaxCorrPerTick, cfg.Netcode.MaxCorrPerTick)
 			localPos.X += corrX
 			localPos.Y += corrY
-			ns.prediction.VelX += (serverVel.SpeedX - ns.prediction.VelX) * cfg.Netcode.VelocityBlendRate
-			ns.prediction.VelY += (serverVel.SpeedY - ns.prediction.VelY) * cfg.Netcode.VelocityBlendRate
+			ns.prediction.Body.SpeedX += (serverVel.SpeedX - ns.prediction.Body.SpeedX) * cfg.Netcode.VelocityBlendRate
+			ns.prediction.Body.SpeedY += (serverVel.SpeedY - ns.prediction.Body.SpeedY) * cfg.Netcode.VelocityBlendRate
 		}
 
+		// Later snapshots must not see this correction's error again
+		ns.prediction.Buffer.Shift(localPos.X-beforeX, localPos.Y-beforeY)
+
 		// Sync collision object + ground state
-		if ns.prediction.PlayerObj != nil {
-			ns.prediction.PlayerObj.X = localPos.X
-			ns.prediction.PlayerObj.Y = localPos.Y
-			ns.prediction.PlayerObj.Update()
-		}
-		ns.prediction.OnGround = math.Abs(serverVel.SpeedY) < 0.1
+		ns.prediction.Correct(localPos.X, localPos.Y, serverVel.SpeedY)
 	}
 
 	localVel := netcomponents.NetVelocity.Get(entry)
@@ -372,6 +428,42 @@ func initNetBoomerangSprite(entry *donburi.Entry) {
 	entry.AddComponent(components.NetInterp)
 }
 
+// initNetEnemyAnimation attaches Animation, Flash and NetInterp components
+// to a co-op enemy entity, using the sprite sheet of the type in its first
+// snapshot.
+func initNetEnemyAnimation(entry *donburi.Entry, compData []any) {
+	if !entry.HasComponent(netcomponents.NetEnemy) {
+		return
+	}
+	typeCfg := cfg.Enemy.Types["Guard"]
+	for _, data := range compData {
+		if ne, ok := data.(netcomponents.NetEnemyData); ok {
+			if t, ok := cfg.Enemy.Types[ne.TypeName]; ok {
+				typeCfg = t
+			}
+		}
+	}
+	animData := factory.GenerateAnimations(typeCfg.SpriteSheetKey, typeCfg.FrameWidth, typeCfg.FrameHeight)
+	animData.SetAnimation(cfg.Idle)
+	entry.AddComponent(components.Animation)
+	components.Animation.SetValue(entry, *animData)
+
+	entry.AddComponent(components.Flash)
+	entry.AddComponent(components.NetInterp)
+}
+
+// initNetKnifeSprite attaches Sprite and NetInterp components to a knife entity.
+func initNetKnifeSprite(entry *donburi.Entry) {
+	if !entry.HasComponent(netcomponents.NetKnife) {
+		return
+	}
+	entry.AddComponent(components.Sprite)
+	components.Sprite.SetValue(entry, components.SpriteData{
+		Image: assets.GetObjectImage("knife-green.png"),
+	})
+	entry.AddComponent(components.NetInterp)
+}
+
 // initNetPlayerAnimation attaches Animation and NetInterp components to a networked player entity.
 // Skips non-player entities (e.g. boomerangs).
 func initNetPlayerAnimation(entry *donburi.Entry) {
@@ -387,26 +479,14 @@ func initNetPlayerAnimation(entry *donburi.Entry) {
 	entry.AddComponent(components.NetInterp)
 }
 
-// updateInterp sets interpolation targets. On first snapshot it snaps directly
-// and calls initFn to set the underlying component data; on subsequent snapshots
-// it reads current position via prevFn and starts interpolation.
-func updateInterp(interp *components.NetInterpData, targetX, targetY float64, initFn func(), prevFn func() (float64, float64)) {
-	if !interp.Initialized {
+// pushInterp buffers a server sample for NetInterpSystem. The first
+// sample also calls initFn to set the underlying component data, so the
+// entity has a position before the playout clock reaches it.
+func pushInterp(interp *components.NetInterpData, sample components.NetInterpSample, initFn func()) {
+	if interp.Count == 0 {
 		initFn()
-		interp.PrevX = targetX
-		interp.PrevY = targetY
-		interp.TargetX = targetX
-		interp.TargetY = targetY
-		interp.T = 1.0
-		interp.Initialized = true
-		return
 	}
-	prevX, prevY := prevFn()
-	interp.PrevX = prevX
-	interp.PrevY = prevY
-	interp.TargetX = targetX
-	interp.TargetY = targetY
-	interp.T = 0
+	interp.Push(sample)
 }
 
 func animStillPlaying(entry *donburi.Entry) bool {
@@ -431,6 +511,12 @@ func componentTypesFromInstances(components []any) []donburi.IComponentType {
 			ctypes = append(ctypes, netcomponents.NetBoomerang)
 		case netcomponents.NetGameStateData:
 			ctypes = append(ctypes, netcomponents.NetGameState)
+		case netcomponents.NetFireData:
+			ctypes = append(ctypes, netcomponents.NetFire)
+		case netcomponents.NetEnemyData:
+			ctypes = append(ctypes, netcomponents.NetEnemy)
+		case netcomponents.NetKnifeData:
+			ctypes = append(ctypes, netcomponents.NetKnife)
 		}
 	}
 	return ctypes
@@ -463,5 +549,20 @@ func applyComponentToEntry(entry *donburi.Entry, data any) {
 			entry.AddComponent(netcomponents.NetGameState)
 		}
 		netcomponents.NetGameState.SetValue(entry, v)
+	case netcomponents.NetFireData:
+		if !entry.HasComponent(netcomponents.NetFire) {
+			entry.AddComponent(netcomponents.NetFire)
+		}
+		netcomponents.NetFire.SetValue(entry, v)
+	case netcomponents.NetEnemyData:
+		if !entry.HasComponent(netcomponents.NetEnemy) {
+			entry.AddComponent(netcomponents.NetEnemy)
+		}
+		netcomponents.NetEnemy.SetValue(entry, v)
+	case netcomponents.NetKnifeData:
+		if !entry.HasComponent(netcomponents.NetKnife) {
+			entry.AddComponent(netcomponents.NetKnife)
+		}
+		netcomponents.NetKnife.SetValue(entry, v)
 	}
 }
@@ -17,6 +17,15 @@ import (
 	"github.com/yohamta/donburi/ecs"
 )
 
+// connectMode picks what onConnect asks the server for.
+type connectMode int
+
+const (
+	connectJoin       connectMode = iota // the typed room code, or any public room
+	connectCreateRoom                    // a new room
+	connectSpectate                      // watch the typed room code, or the default room
+)
+
 type ServerBrowserScene struct {
 	ecsWorld     *ecs.ECS
 	sceneChanger SceneChanger
@@ -37,6 +46,16 @@ func NewServerBrowserScene(sc SceneChanger) *ServerBrowserScene {
 	}
 }
 
+// newServerBrowserSceneFollowing returns the server browser waiting on
+// client while it follows a ServerRedirect, going on to the new server's
+// lobby once joined.
+func newServerBrowserSceneFollowing(sc SceneChanger, client *network.Client) *ServerBrowserScene {
+	return &ServerBrowserScene{
+		sceneChanger: sc,
+		netClient:    client,
+	}
+}
+
 func (s *ServerBrowserScene) Update() {
 	s.once.Do(s.configure)
 
@@ -96,6 +115,9 @@ func (s *ServerBrowserScene) Update() {
 		case network.StateConnecting:
 			s.browserUI.SetStatus("Connecting...")
 
+		case network.StateRedirecting:
+			s.browserUI.SetStatus("Server shutting down, moving to another...")
+
 		case network.StateConnected:
 			s.browserUI.SetStatus("Connected, joining game...")
 
@@ -126,7 +148,9 @@ func (s *ServerBrowserScene) configure() {
 	levelNames := discoverLevelNames()
 
 	s.browserUI = ui.NewServerBrowserUI(
-		func(address, level string) { s.onConnect(address, level) },
+		func(address, level string) { s.onConnect(address, level, connectJoin) },
+		func(address, level string) { s.onConnect(address, level, connectCreateRoom) },
+		func(address, level string) { s.onConnect(address, level, connectSpectate) },
 		func() { s.shouldGoBack = true },
 		func() { s.fetchServers() },
 		levelNames,
@@ -134,11 +158,19 @@ func (s *ServerBrowserScene) configure() {
 
 	systems.PlayMusic(s.ecsWorld, cfg.Sound.MenuMusic)
 
+	// A client following a redirect already knows where it is going.
+	if s.netClient != nil {
+		s.browserUI.SetConnecting(true)
+		return
+	}
+
 	// Auto-fetch server list on scene entry
 	s.fetchServers()
 }
 
-func (s *ServerBrowserScene) onConnect(address, level string) {
+// onConnect dials address. connectCreateRoom asks the server for a new
+// room; otherwise the typed room code (if any) selects the room to join.
+func (s *ServerBrowserScene) onConnect(address, level string, mode connectMode) {
 	if s.netClient != nil {
 		s.netClient.Disconnect()
 	}
@@ -147,6 +179,14 @@ func (s *ServerBrowserScene) onConnect(address, level string) {
 	s.browserUI.SetConnecting(true)
 
 	s.netClient = network.NewClient()
+	if mode == connectCreateRoom {
+		s.netClient.CreateRoom("ffa", s.browserUI.RoomPassword(), 4)
+	} else if code := s.browserUI.RoomCode(); code != "" {
+		s.netClient.JoinRoom(code, s.browserUI.RoomPassword())
+	}
+	if mode == connectSpectate {
+		s.netClient.Spectate()
+	}
 	s.netClient.Connect(address, cfg.Network.GameVersion, "Player", level)
 }
 
@@ -160,7 +200,7 @@ func (s *ServerBrowserScene) fetchServers() {
 func (s *ServerBrowserScene) queryGgscaleFleet() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	address, err := network.FindMatch(ctx, "docker-default", "deathmatch")
+	address, err := network.FindMatch(ctx, network.DefaultFleet, network.DefaultGameMode)
 	if err != nil {
 		log.Printf("[browser] matchmaking failed: %v", err)
 		s.recordFetchResult(nil, err)
@@ -162,6 +162,11 @@ func (ps *PlatformerScene) configure() {
 		}
 	}
 
+	// Create one-way platforms from the level
+	for _, p := range levelData.CurrentLevel.Platforms {
+		factory2.CreatePlatform(ps.ecs, p.X, p.Y, p.Width, p.Height)
+	}
+
 	// Create dead zones from the level
 	for _, dz := range levelData.CurrentLevel.DeadZones {
 		factory2.CreateDeadZone(ps.ecs, dz.X, dz.Y, dz.Width, dz.Height)
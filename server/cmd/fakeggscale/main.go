@@ -0,0 +1,31 @@
+// Command fakeggscale serves the in-memory ggscale stand-in from package
+// fakeggscale, for running doomerang's ggscale integration offline.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/automoto/doomerang-mp/server/fakeggscale"
+)
+
+func main() {
+	port := flag.Uint("port", 8080, "HTTP port")
+	apiKey := flag.String("apikey", "", "The only API key accepted (empty = accept any)")
+	matchAddress := flag.String("matchaddress", "", "Game server address every matchmaker ticket gets (empty = the last registered server)")
+	flag.Parse()
+
+	srv := &http.Server{
+		Addr: fmt.Sprintf(":%d", *port),
+		Handler: fakeggscale.New(fakeggscale.Options{
+			APIKey:       *apiKey,
+			MatchAddress: *matchAddress,
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("[fakeggscale] listening on port %d", *port)
+	log.Fatal(srv.ListenAndServe())
+}
@@ -11,6 +11,7 @@ import (
 
 	pkgsdk "agones.dev/agones/pkg/sdk"
 	sdk "agones.dev/agones/sdks/go"
+	"github.com/automoto/doomerang-mp/server/core"
 )
 
 // agonesHealthInterval is well under the Fleet's health.periodSeconds (5 s)
@@ -26,6 +27,15 @@ const agonesHealthInterval = 2 * time.Second
 // 5 s × 3 = 15 s grace.
 const healthEscalateAfter = 3
 
+// Label and annotation keys the lifecycle sets on the GameServer; the
+// SDK prefixes them with "agones.dev/sdk-". Levels are free-form names,
+// so the level goes in an annotation rather than a label.
+const (
+	agonesModeLabel       = "doomerang-mode"
+	agonesMatchStateLabel = "doomerang-match-state"
+	agonesLevelAnnotation = "doomerang-level"
+)
+
 // agonesSDK is the narrow surface of *sdk.SDK that agonesLifecycle uses;
 // defining it as an interface lets tests swap in a fake without standing
 // up the real gRPC sidecar.
@@ -34,11 +44,31 @@ type agonesSDK interface {
 	Health() error
 	Shutdown() error
 	WatchGameServer(sdk.GameServerCallback) error
+	SetLabel(key, value string) error
+	SetAnnotation(key, value string) error
+	PlayerConnect(id string) (bool, error)
+	PlayerDisconnect(id string) (bool, error)
+}
+
+// liveAgonesSDK lifts the SDK's player tracking, still an alpha feature,
+// onto agonesSDK.
+type liveAgonesSDK struct {
+	*sdk.SDK
+}
+
+func (s liveAgonesSDK) PlayerConnect(id string) (bool, error) {
+	return s.Alpha().PlayerConnect(id)
+}
+
+func (s liveAgonesSDK) PlayerDisconnect(id string) (bool, error) {
+	return s.Alpha().PlayerDisconnect(id)
 }
 
 // agonesLifecycle runs the Agones game-server-side protocol: register the
 // state watcher, mark Ready, run a periodic Health heartbeat, and fire
 // drain() exactly once when the GameServer state transitions to Shutdown.
+// In between it tracks players, labels the GameServer with the default
+// room's status, and marks it Ready again after each match.
 type agonesLifecycle struct {
 	sdk      agonesSDK
 	interval time.Duration
@@ -49,12 +79,30 @@ type agonesLifecycle struct {
 	started   atomic.Bool
 
 	drainOnce sync.Once
+	// stopping is set once Shutdown is seen or Stop is called; after it
+	// the GameServer is never marked Ready again.
+	stopping atomic.Bool
+
+	// Room statuses waiting for statusLoop, latest per room code. The
+	// SDK calls they lead to block, so RoomChanged only records them and
+	// the game loop that reports them never waits on the sidecar.
+	statusMu      sync.Mutex
+	statusPending map[string]roomUpdate
+	statusCh      chan struct{} // Buffered 1; signals statusPending changed
+	statusDone    chan struct{}
 
 	stopOnce   sync.Once
 	stopCh     chan struct{}
 	healthDone chan struct{}
 }
 
+// roomUpdate is a room status and whether any room had a match in
+// progress when it was reported.
+type roomUpdate struct {
+	status          core.RoomStatus
+	matchInProgress bool
+}
+
 // newAgonesLifecycle returns a lifecycle wired to the real Agones SDK
 // when AGONES_SDK_GRPC_PORT is set (the sidecar always sets it). When
 // the env var is missing — server running outside Agones, e.g. local
@@ -67,16 +115,19 @@ func newAgonesLifecycle(drain func()) (*agonesLifecycle, error) {
 	if err != nil {
 		return nil, fmt.Errorf("agones sdk init: %w", err)
 	}
-	return newAgonesLifecycleWith(s, agonesHealthInterval, drain), nil
+	return newAgonesLifecycleWith(liveAgonesSDK{s}, agonesHealthInterval, drain), nil
 }
 
 func newAgonesLifecycleWith(s agonesSDK, interval time.Duration, drain func()) *agonesLifecycle {
 	return &agonesLifecycle{
-		sdk:        s,
-		interval:   interval,
-		drain:      drain,
-		stopCh:     make(chan struct{}),
-		healthDone: make(chan struct{}),
+		sdk:           s,
+		interval:      interval,
+		drain:         drain,
+		statusPending: make(map[string]roomUpdate),
+		statusCh:      make(chan struct{}, 1),
+		statusDone:    make(chan struct{}),
+		stopCh:        make(chan struct{}),
+		healthDone:    make(chan struct{}),
 	}
 }
 
@@ -97,6 +148,7 @@ func (a *agonesLifecycle) Start(_ context.Context) error {
 		}
 		a.started.Store(true)
 		go a.healthLoop()
+		go a.statusLoop()
 	})
 	return a.startErr
 }
@@ -108,6 +160,7 @@ func (a *agonesLifecycle) handleStateChange(gs *pkgsdk.GameServer) {
 	if gs.GetStatus().GetState() != "Shutdown" {
 		return
 	}
+	a.stopping.Store(true)
 	a.drainOnce.Do(func() {
 		// Run drain in a goroutine so we don't block the Agones SDK's
 		// watch callback for the full drainTimeout — that would queue
@@ -116,6 +169,78 @@ func (a *agonesLifecycle) handleStateChange(gs *pkgsdk.GameServer) {
 	})
 }
 
+// PlayerConnected records playerID with Agones player tracking.
+func (a *agonesLifecycle) PlayerConnected(playerID string) {
+	if _, err := a.sdk.PlayerConnect(playerID); err != nil {
+		log.Printf("[agones] player connect %s: %v", playerID, err)
+	}
+}
+
+// PlayerDisconnected removes playerID from Agones player tracking.
+func (a *agonesLifecycle) PlayerDisconnected(playerID string) {
+	if _, err := a.sdk.PlayerDisconnect(playerID); err != nil {
+		log.Printf("[agones] player disconnect %s: %v", playerID, err)
+	}
+}
+
+// RoomChanged queues status for the status loop without blocking, so it
+// is safe to call from a room's game loop. Only the latest status of each
+// room is kept.
+func (a *agonesLifecycle) RoomChanged(status core.RoomStatus, matchInProgress bool) {
+	a.statusMu.Lock()
+	a.statusPending[status.Code] = roomUpdate{status: status, matchInProgress: matchInProgress}
+	a.statusMu.Unlock()
+	select {
+	case a.statusCh <- struct{}{}:
+	default:
+	}
+}
+
+func (a *agonesLifecycle) statusLoop() {
+	defer close(a.statusDone)
+	for {
+		select {
+		case <-a.stopCh:
+			return
+		case <-a.statusCh:
+			a.statusMu.Lock()
+			pending := a.statusPending
+			a.statusPending = make(map[string]roomUpdate)
+			a.statusMu.Unlock()
+			for _, u := range pending {
+				a.applyRoomStatus(u.status, u.matchInProgress)
+			}
+		}
+	}
+}
+
+// applyRoomStatus labels the GameServer with the default room's mode and
+// match state and annotates its level. When any room's match finishes
+// and no other is still in progress, it marks the GameServer Ready again
+// so the warm pod can be allocated to new players instead of torn down.
+func (a *agonesLifecycle) applyRoomStatus(status core.RoomStatus, matchInProgress bool) {
+	if status.Default {
+		if err := a.sdk.SetLabel(agonesModeLabel, status.Mode); err != nil {
+			log.Printf("[agones] label %s: %v", agonesModeLabel, err)
+		}
+		if err := a.sdk.SetLabel(agonesMatchStateLabel, status.MatchState); err != nil {
+			log.Printf("[agones] label %s: %v", agonesMatchStateLabel, err)
+		}
+		if err := a.sdk.SetAnnotation(agonesLevelAnnotation, status.Level); err != nil {
+			log.Printf("[agones] annotation %s: %v", agonesLevelAnnotation, err)
+		}
+	}
+
+	if status.MatchState != "finished" || matchInProgress || !a.started.Load() || a.stopping.Load() {
+		return
+	}
+	if err := a.sdk.Ready(); err != nil {
+		log.Printf("[agones] ready after match: %v", err)
+		return
+	}
+	log.Printf("[agones] match over in room %s; marked Ready for reallocation", status.Code)
+}
+
 func (a *agonesLifecycle) healthLoop() {
 	defer close(a.healthDone)
 	t := time.NewTicker(a.interval)
@@ -148,16 +273,18 @@ func (a *agonesLifecycle) healthLoop() {
 }
 
 // Stop tells the Agones controller this game server is exiting
-// (sdk.Shutdown), then signals the health loop to exit and waits for it
-// to drain. Safe to call multiple times and safe to call without a
+// (sdk.Shutdown), then signals the health and status loops to exit and
+// waits for them. Safe to call multiple times and safe to call without a
 // preceding Start — the started flag prevents the wait from deadlocking
-// when no health goroutine was ever launched.
+// when neither goroutine was ever launched.
 func (a *agonesLifecycle) Stop() {
+	a.stopping.Store(true)
 	if err := a.sdk.Shutdown(); err != nil {
 		log.Printf("[agones] shutdown: %v", err)
 	}
 	a.stopOnce.Do(func() { close(a.stopCh) })
 	if a.started.Load() {
 		<-a.healthDone
+		<-a.statusDone
 	}
 }
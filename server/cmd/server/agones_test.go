@@ -12,6 +12,7 @@ import (
 
 	pkgsdk "agones.dev/agones/pkg/sdk"
 	sdk "agones.dev/agones/sdks/go"
+	"github.com/automoto/doomerang-mp/server/core"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
@@ -79,6 +80,10 @@ type fakeAgonesSDK struct {
 	shutdownCalls int
 	watchErr      error
 	watchCb       sdk.GameServerCallback
+
+	labels      map[string]string
+	annotations map[string]string
+	players     map[string]bool // Connected player IDs
 }
 
 func (f *fakeAgonesSDK) Ready() error {
@@ -109,6 +114,45 @@ func (f *fakeAgonesSDK) WatchGameServer(cb sdk.GameServerCallback) error {
 	return f.watchErr
 }
 
+func (f *fakeAgonesSDK) SetLabel(key, value string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.labels == nil {
+		f.labels = make(map[string]string)
+	}
+	f.labels[key] = value
+	return nil
+}
+
+func (f *fakeAgonesSDK) SetAnnotation(key, value string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.annotations == nil {
+		f.annotations = make(map[string]string)
+	}
+	f.annotations[key] = value
+	return nil
+}
+
+func (f *fakeAgonesSDK) PlayerConnect(id string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.players == nil {
+		f.players = make(map[string]bool)
+	}
+	added := !f.players[id]
+	f.players[id] = true
+	return added, nil
+}
+
+func (f *fakeAgonesSDK) PlayerDisconnect(id string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	removed := f.players[id]
+	delete(f.players, id)
+	return removed, nil
+}
+
 func (f *fakeAgonesSDK) emit(state string) {
 	f.mu.Lock()
 	cb := f.watchCb
@@ -424,3 +468,95 @@ var assertReadyError = readyFailure("ready failed")
 type readyFailure string
 
 func (e readyFailure) Error() string { return string(e) }
+
+func TestAgonesLifecycle_tracks_players(t *testing.T) {
+	f := &fakeAgonesSDK{}
+	lc := newAgonesLifecycleWith(f, time.Hour, func() {})
+
+	lc.PlayerConnected("a")
+	lc.PlayerConnected("b")
+	lc.PlayerDisconnected("a")
+
+	assert.Equal(t, map[string]bool{"b": true}, f.players)
+}
+
+func TestAgonesLifecycle_RoomChanged_labels_default_room(t *testing.T) {
+	f := &fakeAgonesSDK{}
+	lc := newAgonesLifecycleWith(f, time.Hour, func() {})
+
+	lc.applyRoomStatus(core.RoomStatus{Code: "ABCD", Default: true, Mode: "2v2", Level: "Level 1", MatchState: "playing"}, true)
+	lc.applyRoomStatus(core.RoomStatus{Code: "WXYZ", Mode: "coop", Level: "level2", MatchState: "waiting"}, true)
+
+	assert.Equal(t, map[string]string{agonesModeLabel: "2v2", agonesMatchStateLabel: "playing"}, f.labels)
+	assert.Equal(t, map[string]string{agonesLevelAnnotation: "Level 1"}, f.annotations)
+}
+
+func TestAgonesLifecycle_RoomChanged_marks_Ready_after_last_match_ends(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	f := &fakeAgonesSDK{}
+	lc := newAgonesLifecycleWith(f, time.Hour, func() {})
+	require.NoError(t, lc.Start(context.Background()))
+	defer lc.Stop()
+	f.emit("Allocated")
+
+	lc.applyRoomStatus(core.RoomStatus{Code: "ABCD", MatchState: "playing"}, true)
+	lc.applyRoomStatus(core.RoomStatus{Code: "ABCD", MatchState: "finished"}, true)
+	assert.Equal(t, 1, f.readyCount(), "another room's match is still in progress")
+
+	lc.applyRoomStatus(core.RoomStatus{Code: "WXYZ", MatchState: "finished"}, false)
+	assert.Equal(t, 2, f.readyCount())
+}
+
+func TestAgonesLifecycle_RoomChanged_stays_down_once_shutting_down(t *testing.T) {
+	f := &fakeAgonesSDK{}
+	lc := newAgonesLifecycleWith(f, time.Hour, func() {})
+	require.NoError(t, lc.Start(context.Background()))
+	defer lc.Stop()
+
+	f.emit("Shutdown")
+	lc.applyRoomStatus(core.RoomStatus{Code: "ABCD", MatchState: "finished"}, false)
+
+	assert.Equal(t, 1, f.readyCount(), "only Start's Ready")
+}
+
+// blockingLabelSDK holds every SetLabel until release is closed, like a
+// sidecar that has stopped answering.
+type blockingLabelSDK struct {
+	*fakeAgonesSDK
+	release chan struct{}
+}
+
+func (b *blockingLabelSDK) SetLabel(key, value string) error {
+	<-b.release
+	return b.fakeAgonesSDK.SetLabel(key, value)
+}
+
+func TestAgonesLifecycle_RoomChanged_does_not_wait_for_the_SDK(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	f := &blockingLabelSDK{fakeAgonesSDK: &fakeAgonesSDK{}, release: make(chan struct{})}
+	lc := newAgonesLifecycleWith(f, time.Hour, func() {})
+	require.NoError(t, lc.Start(context.Background()))
+
+	done := make(chan struct{})
+	go func() {
+		for _, state := range []string{"waiting", "countdown", "playing"} {
+			lc.RoomChanged(core.RoomStatus{Code: "ABCD", Default: true, MatchState: state}, true)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("RoomChanged blocked on a stuck SDK")
+	}
+
+	close(f.release)
+	waitFor(t, time.Second, func() bool {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return f.labels[agonesMatchStateLabel] == "playing"
+	}, "latest match state label")
+	lc.Stop()
+}
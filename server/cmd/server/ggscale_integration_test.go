@@ -0,0 +1,175 @@
+//go:build integration
+
+package main
+
+// These tests run the server's ggscale integration through the real
+// ggscale-go SDK against the fake ggscale service, on one machine:
+//
+//	go test -tags "nogui integration" ./server/cmd/server/ -run Integration
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/automoto/doomerang-mp/server/core"
+	"github.com/automoto/doomerang-mp/server/fakeggscale"
+	"github.com/automoto/doomerang-mp/shared/leveldata"
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/automoto/ggscale-go"
+	"github.com/coder/websocket"
+	"github.com/leap-fish/necs/router"
+	"github.com/leap-fish/necs/typeid"
+	"github.com/leap-fish/necs/typemapper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	integrationSecretKey      = "secret-key"
+	integrationLeaderboardID  = 1
+	integrationLeaderboardEnv = "1"
+)
+
+// startIntegrationStack starts the fake ggscale service and a game
+// server on a free port, with the server's ggscale environment pointing
+// at the fake. The server is not registered yet.
+func startIntegrationStack(t *testing.T) (*fakeggscale.Server, string, *core.Server, string) {
+	t.Helper()
+	fake := fakeggscale.New(fakeggscale.Options{})
+	gg := httptest.NewServer(fake)
+	t.Cleanup(gg.Close)
+	t.Setenv("GGSCALE_URL", gg.URL)
+	t.Setenv("GGSCALE_SECRET_KEY", integrationSecretKey)
+	t.Setenv("GGSCALE_LEADERBOARD_ID", integrationLeaderboardEnv)
+
+	ln, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	require.NoError(t, ln.Close())
+
+	levels := map[string]*core.ServerLevel{
+		"test": core.NewServerLevel(&leveldata.CollisionData{MapWidth: 320, MapHeight: 240}),
+	}
+	srv := core.NewServer(60, "Test Server", "", levels, []string{"test"})
+	go func() { _ = srv.Start(uint(port)) }() //nolint:gosec // A TCP port fits in uint
+	t.Cleanup(srv.Stop)
+
+	address := fmt.Sprintf("localhost:%d", port)
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", address)
+		if err == nil {
+			_ = conn.Close()
+		}
+		return err == nil
+	}, 5*time.Second, 20*time.Millisecond)
+	return fake, gg.URL, srv, address
+}
+
+// loginPlayer signs a player in anonymously, as the client's
+// initGgscale does.
+func loginPlayer(t *testing.T, baseURL string) *ggscale.Client {
+	t.Helper()
+	transport := &ggscale.StdNetTransport{BaseURL: baseURL}
+	auth := ggscale.NewAnonymousAuth(transport, "publishable-key", t.TempDir()+"/session.json")
+	player, err := ggscale.NewClient(ggscale.Options{
+		BaseURL:         baseURL,
+		APIKey:          "publishable-key",
+		Transport:       transport,
+		OnSessionUpdate: auth.SaveSession,
+	})
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, player.Login(ctx, auth))
+	require.NotNil(t, player.Session())
+	return player
+}
+
+// joinGame dials address and joins its default room with the player's
+// ggscale session token, returning the JoinAccepted.
+func joinGame(t *testing.T, address, sessionToken string) messages.JoinAccepted {
+	t.Helper()
+	mapper := typemapper.NewMapper(map[uint]any{})
+	accepted := reflect.TypeOf(messages.JoinAccepted{})
+	require.NoError(t, mapper.RegisterType(typeid.GetTypeId(accepted), accepted))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := websocket.Dial(ctx, "ws://"+address, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.CloseNow() })
+
+	req, err := router.Serialize(messages.JoinRequest{PlayerName: "Player", GgscaleSessionToken: sessionToken})
+	require.NoError(t, err)
+	require.NoError(t, conn.Write(ctx, websocket.MessageBinary, req))
+	for {
+		_, data, err := conn.Read(ctx)
+		require.NoError(t, err, "no JoinAccepted")
+		if msg, err := mapper.Deserialize(data); err == nil {
+			if join, ok := msg.(messages.JoinAccepted); ok {
+				return join
+			}
+		}
+	}
+}
+
+func TestIntegration_register_matchmake_join_and_submit_score(t *testing.T) {
+	fake, baseURL, srv, address := startIntegrationStack(t)
+
+	stop, deregister := startGgscaleRegistration(srv, "Test Server", address, "", "", 4)
+	require.NotNil(t, stop)
+	servers := fake.Servers()
+	require.Len(t, servers, 1)
+	assert.Equal(t, address, servers[0].Address)
+
+	player := loginPlayer(t, baseURL)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ready, err := player.Matchmaker.RequestMatch(ctx, ggscale.MatchRequest{Fleet: "docker-default", GameMode: "deathmatch"})
+	require.NoError(t, err)
+	require.Equal(t, address, ready.Address)
+
+	join := joinGame(t, ready.Address, player.Session().AccessToken)
+	netID := uint32(join.NetworkID)
+
+	// A whole match takes minutes, so the match end is simulated by
+	// calling the hook startGgscaleRegistration installed with the
+	// stats and tokens the server would pass it.
+	gg, err := ggscale.NewClient(ggscale.Options{BaseURL: baseURL, APIKey: integrationSecretKey})
+	require.NoError(t, err)
+	buildSubmitScoresHook(srv, gg, integrationLeaderboardID)(
+		map[uint32]messages.PlayerStats{netID: {KOs: 3}},
+		map[uint32]string{netID: player.Session().AccessToken},
+	)
+	assert.Equal(t, []fakeggscale.Score{{EndUserID: player.Session().EndUserID, Score: 3}}, fake.Scores(integrationLeaderboardID))
+
+	stop()
+	deregister()
+	assert.Empty(t, fake.Servers())
+}
+
+func TestIntegration_failed_score_submit_is_counted(t *testing.T) {
+	fake, baseURL, srv, _ := startIntegrationStack(t)
+	player := loginPlayer(t, baseURL)
+	fake.Fail(fakeggscale.Failure{Route: fakeggscale.RouteSubmitScore, Status: http.StatusServiceUnavailable, Times: 1})
+
+	gg, err := ggscale.NewClient(ggscale.Options{BaseURL: baseURL, APIKey: integrationSecretKey})
+	require.NoError(t, err)
+	hook := buildSubmitScoresHook(srv, gg, integrationLeaderboardID)
+	stats := map[uint32]messages.PlayerStats{1: {KOs: 2}}
+	tokens := map[uint32]string{1: player.Session().AccessToken}
+	hook(stats, tokens)
+	hook(stats, tokens)
+
+	rec := httptest.NewRecorder()
+	srv.MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, rec.Body.String(), `doomerang_leaderboard_submissions_total{result="failure"} 1`)
+	assert.Contains(t, rec.Body.String(), `doomerang_leaderboard_submissions_total{result="success"} 1`)
+	assert.Len(t, fake.Scores(integrationLeaderboardID), 1)
+}
@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
@@ -14,6 +16,7 @@ import (
 	"time"
 
 	"github.com/automoto/doomerang-mp/server/core"
+	"github.com/automoto/doomerang-mp/shared/messages"
 	"github.com/automoto/doomerang-mp/shared/protocol"
 	"github.com/automoto/ggscale-go"
 )
@@ -26,6 +29,7 @@ const (
 func main() {
 	port := flag.Uint("port", 7373, "Server port")
 	tickRate := flag.Int("tickrate", 60, "Server tick rate (updates per second)")
+	snapshotRate := flag.Int("snapshotrate", 30, "Snapshots sent to clients per second (0 or above -tickrate = every tick)")
 	name := flag.String("name", "Doomerang Server", "Server display name")
 	version := flag.String("version", "", "Required client version (empty = accept any)")
 	assetsDir := flag.String("assets", "assets", "Path to assets directory")
@@ -33,6 +37,14 @@ func main() {
 	maxPlayers := flag.Int("maxplayers", 4, "Maximum players")
 	address := flag.String("address", "localhost:7373", "Public address to advertise")
 	numBots := flag.Int("bots", 0, "Number of bots to spawn on startup")
+	maxRooms := flag.Int("maxrooms", 16, "Maximum concurrent rooms hosted by this process")
+	lagComp := flag.Bool("lagcomp", true, "Rewind melee and boomerang hit checks by each attacker's latency")
+	maxRewind := flag.Duration("maxrewind", 200*time.Millisecond, "Furthest back lag compensation may rewind (max 1s)")
+	reconnectGrace := flag.Duration("reconnectgrace", 30*time.Second, "How long a dropped player's slot is held for reconnect (0 = drop immediately)")
+	adminPort := flag.Uint("adminport", 0, "Port for the admin HTTP API (0 = disabled; needs DOOMERANG_ADMIN_TOKEN)")
+	metricsPort := flag.Uint("metricsport", 0, "Port serving Prometheus metrics at /metrics (0 = disabled)")
+	healthPort := flag.Uint("healthport", 0, "Port serving /healthz and /readyz probes (0 = disabled)")
+	redirect := flag.String("redirect", "", `Where players are sent when the server shuts down: a server address, or "ggscale" to re-queue through matchmaking (empty = nowhere)`)
 	flag.Parse()
 
 	// Arm the signal handler before any blocking init (ggscale Register,
@@ -53,6 +65,17 @@ func main() {
 	log.Printf("Loaded %d levels: %v", len(levelNames), levelNames)
 
 	server := core.NewServer(*tickRate, *name, *version, levels, levelNames)
+	server.SetMaxRooms(*maxRooms)
+	server.SetReconnectGrace(*reconnectGrace)
+	server.SetLagCompensation(*lagComp, *maxRewind)
+	server.SetSnapshotRate(*snapshotRate)
+	switch *redirect {
+	case "":
+	case "ggscale":
+		server.SetShutdownRedirect(&messages.ServerRedirect{})
+	default:
+		server.SetShutdownRedirect(&messages.ServerRedirect{Address: *redirect})
+	}
 
 	for i := 0; i < *numBots; i++ {
 		server.SpawnBot(fmt.Sprintf("Bot %d", i+1), 1)
@@ -60,16 +83,23 @@ func main() {
 
 	stopHeartbeat, deregister := startGgscaleRegistration(server, *name, *address, *version, *region, *maxPlayers)
 
-	// Agones lifecycle. The drain callback forwards into sigChan so the
-	// Agones-Shutdown path and the SIGTERM path run the SAME cleanup
-	// goroutine — there is only one shutdown sequence, no duplicate
-	// cleanup, no path that forgets to deregister.
-	agones, err := newAgonesLifecycle(func() {
-		log.Println("[agones] Shutdown state received; signalling shutdown")
+	// The Agones and admin API drain callbacks forward into sigChan so
+	// they and the SIGTERM path run the SAME cleanup goroutine — there is
+	// only one shutdown sequence, no duplicate cleanup, no path that
+	// forgets to deregister.
+	signalShutdown := func() {
 		select {
 		case sigChan <- syscall.SIGTERM:
 		default: // shutdown already underway
 		}
+	}
+	adminAPI := startAdminAPI(server, *adminPort, signalShutdown)
+	metricsAPI := startMetrics(server, *metricsPort)
+	healthAPI := startHealthProbes(server, *healthPort)
+
+	agones, err := newAgonesLifecycle(func() {
+		log.Println("[agones] Shutdown state received; signalling shutdown")
+		signalShutdown()
 	})
 
 	var shutdownOnce sync.Once
@@ -89,6 +119,15 @@ func main() {
 			if agones != nil {
 				agones.Stop()
 			}
+			if adminAPI != nil {
+				_ = adminAPI.Close()
+			}
+			if metricsAPI != nil {
+				_ = metricsAPI.Close()
+			}
+			if healthAPI != nil {
+				_ = healthAPI.Close()
+			}
 		})
 	}
 
@@ -109,6 +148,10 @@ func main() {
 			shutdown()
 			os.Exit(1)
 		}
+		server.SetPlayerHooks(agones.PlayerConnected, agones.PlayerDisconnected)
+		server.SetRoomStatusHook(func(status core.RoomStatus) {
+			agones.RoomChanged(status, server.MatchInProgress())
+		})
 		log.Println("[agones] lifecycle started (watch + Ready sent, health active)")
 	}
 
@@ -165,7 +208,7 @@ func startGgscaleRegistration(srv *core.Server, name, address, version, region s
 		if err != nil {
 			log.Fatalf("[ggscale] GGSCALE_LEADERBOARD_ID must be an integer: %v", err)
 		}
-		srv.SetMatchEndHook(buildSubmitScoresHook(gg, lbID))
+		srv.SetMatchEndHook(buildSubmitScoresHook(srv, gg, lbID))
 		log.Printf("[ggscale] match-end submission to leaderboard=%d enabled", lbID)
 	}
 
@@ -184,13 +227,86 @@ func startGgscaleRegistration(srv *core.Server, name, address, version, region s
 		}
 }
 
+// startAdminAPI serves srv's admin HTTP API on port, unless port is 0.
+// The API's bearer token comes from DOOMERANG_ADMIN_TOKEN (or its _FILE);
+// it refuses to start without one. drain is called for POST /drain.
+func startAdminAPI(srv *core.Server, port uint, drain func()) *http.Server {
+	if port == 0 {
+		return nil
+	}
+	token, err := loadSecret("DOOMERANG_ADMIN_TOKEN")
+	if err != nil {
+		log.Fatalf("[admin] %v", err)
+	}
+	if token == "" {
+		log.Fatal("[admin] -adminport needs DOOMERANG_ADMIN_TOKEN (or DOOMERANG_ADMIN_TOKEN_FILE) set")
+	}
+
+	api := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           srv.AdminHandler(token, drain),
+		ReadHeaderTimeout: registerTimeout,
+	}
+	go func() {
+		if err := api.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("[admin] serve: %v", err)
+		}
+	}()
+	log.Printf("[admin] API listening on port %d", port)
+	return api
+}
+
+// startMetrics serves srv's Prometheus metrics at /metrics on port,
+// unless port is 0.
+func startMetrics(srv *core.Server, port uint) *http.Server {
+	if port == 0 {
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", srv.MetricsHandler())
+	api := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           mux,
+		ReadHeaderTimeout: registerTimeout,
+	}
+	go func() {
+		if err := api.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("[metrics] serve: %v", err)
+		}
+	}()
+	log.Printf("[metrics] serving /metrics on port %d", port)
+	return api
+}
+
+// startHealthProbes serves srv's /healthz and /readyz on port, unless
+// port is 0.
+func startHealthProbes(srv *core.Server, port uint) *http.Server {
+	if port == 0 {
+		return nil
+	}
+	api := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           srv.HealthHandler(),
+		ReadHeaderTimeout: registerTimeout,
+	}
+	go func() {
+		if err := api.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("[health] serve: %v", err)
+		}
+	}()
+	log.Printf("[health] serving /healthz and /readyz on port %d", port)
+	return api
+}
+
 // buildSubmitScoresHook returns a MatchEndHook that submits each
 // player's final KO count to ggscale via Leaderboards.SubmitFor. Each
 // submission carries the player's session token (captured at join
-// time) and the server's own secret-tier API key.
-func buildSubmitScoresHook(gg *ggscale.Client, leaderboardID int64) core.MatchEndHook {
-	return func(scores map[uint32]int, tokens map[uint32]string) {
-		for netID, score := range scores {
+// time) and the server's own secret-tier API key, and is counted in
+// srv's metrics.
+func buildSubmitScoresHook(srv *core.Server, gg *ggscale.Client, leaderboardID int64) core.MatchEndHook {
+	return func(stats map[uint32]messages.PlayerStats, tokens map[uint32]string) {
+		for netID, playerStats := range stats {
+			score := playerStats.KOs
 			tok, ok := tokens[netID]
 			if !ok || tok == "" {
 				continue
@@ -198,6 +314,7 @@ func buildSubmitScoresHook(gg *ggscale.Client, leaderboardID int64) core.MatchEn
 			ctx, cancel := context.WithTimeout(context.Background(), registerTimeout)
 			err := gg.Leaderboards.SubmitFor(ctx, tok, leaderboardID, int64(score))
 			cancel()
+			srv.CountLeaderboardSubmit(err)
 			if err != nil {
 				log.Printf("[ggscale] submit netID=%d: %v", netID, err)
 				continue
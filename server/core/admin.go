@@ -0,0 +1,535 @@
+package core
+
+import (
+	"cmp"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/automoto/doomerang-mp/components"
+	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
+	"github.com/coder/websocket"
+	"github.com/leap-fish/necs/esync"
+	"github.com/leap-fish/necs/router"
+	"github.com/yohamta/donburi"
+)
+
+// adminTimeout bounds how long an admin request waits for a room's game
+// loop to run it.
+const adminTimeout = 5 * time.Second
+
+// gameModes are the modes the admin API can switch a room to.
+var gameModes = []string{"ffa", "1v1", "2v2", "coop"}
+
+// adminError is an admin request the server refused, with the HTTP
+// status to answer it with.
+type adminError struct {
+	status int
+	msg    string
+}
+
+func (e *adminError) Error() string {
+	return e.msg
+}
+
+func adminErrorf(status int, format string, args ...any) error {
+	return &adminError{status: status, msg: fmt.Sprintf(format, args...)}
+}
+
+// AdminPlayer is one player, bot or spectator in GET /players.
+type AdminPlayer struct {
+	Room      string `json:"room"`
+	NetID     uint32 `json:"netId"`
+	Name      string `json:"name"`
+	Slot      int    `json:"slot"` // -1 outside the lobby slots
+	Bot       bool   `json:"bot,omitempty"`
+	Spectator bool   `json:"spectator,omitempty"`
+	PingMs    int64  `json:"pingMs"`
+	KOs       int    `json:"kos"`
+	Deaths    int    `json:"deaths"`
+}
+
+// AdminRoom is one room in GET /rooms.
+type AdminRoom struct {
+	Code    string `json:"code"`
+	Public  bool   `json:"public"`
+	Mode    string `json:"mode"`
+	Level   string `json:"level"`
+	State   string `json:"state"`
+	Players int    `json:"players"`
+}
+
+var matchStateNames = map[netcomponents.MatchStateID]string{
+	netcomponents.MatchStateWaiting:   "waiting",
+	netcomponents.MatchStateCountdown: "countdown",
+	netcomponents.MatchStatePlaying:   "playing",
+	netcomponents.MatchStateRoundEnd:  "round_end",
+	netcomponents.MatchStateFinished:  "finished",
+}
+
+// AdminHandler returns the admin HTTP API. Every request must carry
+// "Authorization: Bearer <token>". Actions on a room are queued on its
+// game loop through cmdCh, like client messages; POST /drain calls drain,
+// which should start the same shutdown a SIGTERM does.
+func (s *Server) AdminHandler(token string, drain func()) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /rooms", s.adminRooms)
+	mux.HandleFunc("GET /players", s.adminPlayers)
+	mux.HandleFunc("POST /players/{netID}/kick", s.adminKick)
+	mux.HandleFunc("POST /players/{netID}/ban", s.adminBan)
+	mux.HandleFunc("POST /rooms/{code}/level", s.adminLevel)
+	mux.HandleFunc("POST /rooms/{code}/mode", s.adminMode)
+	mux.HandleFunc("POST /rooms/{code}/start", s.adminStart)
+	mux.HandleFunc("POST /rooms/{code}/abort", s.adminAbort)
+	mux.HandleFunc("POST /rooms/{code}/bots", s.adminAddBot)
+	mux.HandleFunc("DELETE /rooms/{code}/bots/{netID}", s.adminRemoveBot)
+	mux.HandleFunc("POST /drain", func(w http.ResponseWriter, _ *http.Request) {
+		log.Println("[admin] drain requested")
+		go drain()
+		writeJSON(w, http.StatusAccepted, map[string]bool{"draining": true})
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		bearer, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			writeAdminError(w, adminErrorf(http.StatusUnauthorized, "missing or wrong admin token"))
+			return
+		}
+		mux.ServeHTTP(w, req)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeAdminError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var adminErr *adminError
+	switch {
+	case errors.As(err, &adminErr):
+		status = adminErr.status
+	case errors.Is(err, context.DeadlineExceeded):
+		status = http.StatusGatewayTimeout
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// adminRoom returns the room named by the request's {code}.
+func (s *Server) adminRoom(req *http.Request) (*Room, error) {
+	code := strings.ToUpper(req.PathValue("code"))
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if r, ok := s.rooms[code]; ok {
+		return r, nil
+	}
+	return nil, adminErrorf(http.StatusNotFound, "no room %q", code)
+}
+
+func adminNetID(req *http.Request) (uint32, error) {
+	id, err := strconv.ParseUint(req.PathValue("netID"), 10, 32)
+	if err != nil {
+		return 0, adminErrorf(http.StatusBadRequest, "bad network ID %q", req.PathValue("netID"))
+	}
+	return uint32(id), nil
+}
+
+// decodeAdminBody reads the request's JSON body into v.
+func decodeAdminBody(w http.ResponseWriter, req *http.Request, v any) error {
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, 1<<16)).Decode(v); err != nil {
+		return adminErrorf(http.StatusBadRequest, "bad request body: %v", err)
+	}
+	return nil
+}
+
+// errRoomStopped is run's error for a room whose game loop has stopped,
+// as every room's has once the server drains.
+var errRoomStopped = &adminError{status: http.StatusServiceUnavailable, msg: "room stopped"}
+
+// run queues fn on the room's game loop and waits for it to finish.
+func (r *Room) run(ctx context.Context, fn func() error) error {
+	select {
+	case <-r.loop.stopChan:
+		return errRoomStopped
+	default:
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, adminTimeout)
+	defer cancel()
+	done := make(chan error, 1)
+	select {
+	case r.cmdCh <- func() { done <- fn() }:
+	case <-r.loop.stopChan:
+		return errRoomStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-r.loop.stopChan:
+		return errRoomStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// roomAction answers an admin request for an action on the room named by
+// its {code}, run on that room's game loop.
+func (s *Server) roomAction(w http.ResponseWriter, req *http.Request, fn func(r *Room) error) {
+	r, err := s.adminRoom(req)
+	if err == nil {
+		err = r.run(req.Context(), func() error { return fn(r) })
+	}
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+	log.Printf("[admin] %s %s", req.Method, req.URL.Path)
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+func (s *Server) adminRooms(w http.ResponseWriter, req *http.Request) {
+	rooms := []AdminRoom{}
+	for _, r := range s.roomList() {
+		var room AdminRoom
+		err := r.run(req.Context(), func() error {
+			room = AdminRoom{
+				Code:    r.code,
+				Public:  r.public,
+				Mode:    r.match.GameMode,
+				Level:   r.activeName,
+				State:   matchStateNames[r.match.State],
+				Players: r.PlayerCount(),
+			}
+			return nil
+		})
+		if errors.Is(err, errRoomStopped) {
+			continue
+		}
+		if err != nil {
+			writeAdminError(w, err)
+			return
+		}
+		rooms = append(rooms, room)
+	}
+	slices.SortFunc(rooms, func(a, b AdminRoom) int { return strings.Compare(a.Code, b.Code) })
+	writeJSON(w, http.StatusOK, rooms)
+}
+
+func (s *Server) adminPlayers(w http.ResponseWriter, req *http.Request) {
+	players := []AdminPlayer{}
+	for _, r := range s.roomList() {
+		var roomPlayers []AdminPlayer
+		err := r.run(req.Context(), func() error {
+			roomPlayers = r.adminPlayers()
+			return nil
+		})
+		if errors.Is(err, errRoomStopped) {
+			continue
+		}
+		if err != nil {
+			writeAdminError(w, err)
+			return
+		}
+		players = append(players, roomPlayers...)
+	}
+	slices.SortFunc(players, func(a, b AdminPlayer) int { return cmp.Compare(a.NetID, b.NetID) })
+	writeJSON(w, http.StatusOK, players)
+}
+
+// adminPlayers lists the room's slotted players and bots, then bots
+// outside the slots, then spectators. Must be called on the game loop
+// goroutine.
+func (r *Room) adminPlayers() []AdminPlayer {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var players []AdminPlayer
+	listed := make(map[uint32]bool)
+	add := func(p AdminPlayer) {
+		p.Room = r.code
+		p.KOs = r.match.Scores[p.NetID]
+		p.Deaths = r.match.Deaths[p.NetID]
+		if rtt, _, ok := r.linkQuality(p.NetID); ok {
+			p.PingMs = rtt.Milliseconds()
+		}
+		listed[p.NetID] = true
+		players = append(players, p)
+	}
+
+	for i, slot := range r.match.Slots {
+		if slot.Type == 0 {
+			continue
+		}
+		add(AdminPlayer{NetID: r.match.slotNetID(i), Name: slot.Name, Slot: i, Bot: slot.Type == 2})
+	}
+	components.Bot.Each(r.world, func(entry *donburi.Entry) {
+		if nid := esync.GetNetworkId(entry); nid != nil && !listed[uint32(*nid)] {
+			add(AdminPlayer{NetID: uint32(*nid), Slot: -1, Bot: true})
+		}
+	})
+	for client, spec := range r.spectators {
+		add(AdminPlayer{NetID: r.clientNetworkIDs[client], Name: spec.name, Slot: -1, Spectator: true})
+	}
+	return players
+}
+
+// playerRoom returns the room with a connected client for netID.
+func (s *Server) playerRoom(netID uint32) (*Room, error) {
+	for _, r := range s.roomList() {
+		r.mu.RLock()
+		_, ok := r.networkIDClients[netID]
+		r.mu.RUnlock()
+		if ok {
+			return r, nil
+		}
+	}
+	return nil, adminErrorf(http.StatusNotFound, "no connected player with network ID %d", netID)
+}
+
+func (s *Server) adminKick(w http.ResponseWriter, req *http.Request) {
+	s.removePlayer(w, req, false)
+}
+
+func (s *Server) adminBan(w http.ResponseWriter, req *http.Request) {
+	s.removePlayer(w, req, true)
+}
+
+// removePlayer kicks the player named by the request's {netID}, and with
+// ban keeps its name out until the server restarts. A ban is only a name
+// block: the server never sees a client's address or any identity that
+// outlives its connection, so a player who picks another name gets back
+// in.
+func (s *Server) removePlayer(w http.ResponseWriter, req *http.Request, ban bool) {
+	netID, err := adminNetID(req)
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+	r, err := s.playerRoom(netID)
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+
+	reason := "kicked by admin"
+	if ban {
+		reason = "banned"
+	}
+	var name string
+	err = r.run(req.Context(), func() error {
+		var err error
+		name, err = r.kick(netID, reason)
+		return err
+	})
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+	if ban {
+		s.mu.Lock()
+		s.blockedNames[strings.ToLower(name)] = true
+		s.mu.Unlock()
+	}
+	log.Printf("[admin] %s player %q (nid=%d) from room %s", reason, name, netID, r.code)
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+// nameBlocked reports whether name has been banned through the admin API.
+func (s *Server) nameBlocked(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.blockedNames[strings.ToLower(name)]
+}
+
+// kick disconnects the client for netID, telling it why, and returns the
+// player's name. Its reconnect token is revoked first so the player is
+// dropped rather than held. Must be called on the game loop goroutine.
+func (r *Room) kick(netID uint32, reason string) (string, error) {
+	r.mu.Lock()
+	client, ok := r.networkIDClients[netID]
+	if ok {
+		delete(r.tokenNetIDs, r.clientTokens[client])
+		delete(r.clientTokens, client)
+	}
+	r.mu.Unlock()
+	if !ok {
+		return "", adminErrorf(http.StatusNotFound, "no connected player with network ID %d", netID)
+	}
+
+	name := r.playerName(client, netID)
+	_ = r.server.send(client, messages.JoinRejected{Reason: reason})
+	// Close waits for the client's half of the closing handshake.
+	go func(client *router.NetworkClient) {
+		_ = client.Close(websocket.StatusPolicyViolation, reason)
+	}(client)
+	return name, nil
+}
+
+// playerName returns the name a client joined with, from its lobby slot
+// or as a spectator. Must be called on the game loop goroutine.
+func (r *Room) playerName(client *router.NetworkClient, netID uint32) string {
+	for _, slot := range r.match.Slots {
+		if slot.Type == 1 && slot.PlayerID == netID {
+			return slot.Name
+		}
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if spec, ok := r.spectators[client]; ok {
+		return spec.name
+	}
+	return ""
+}
+
+func (s *Server) adminLevel(w http.ResponseWriter, req *http.Request) {
+	var body struct {
+		Level string `json:"level"`
+	}
+	if err := decodeAdminBody(w, req, &body); err != nil {
+		writeAdminError(w, err)
+		return
+	}
+	index := slices.Index(s.levelNames, body.Level)
+	if index < 0 {
+		writeAdminError(w, adminErrorf(http.StatusBadRequest, "no level %q; have %v", body.Level, s.levelNames))
+		return
+	}
+	s.roomAction(w, req, func(r *Room) error {
+		return r.pinLevel(body.Level, index)
+	})
+}
+
+// pinLevel switches the room to the named level for every later join.
+// Clients load the level as they join, so it only switches while the
+// room has nobody in it. Must be called on the game loop goroutine.
+func (r *Room) pinLevel(name string, index int) error {
+	if r.clientCount() > 0 || len(r.playerPhysics) > 0 {
+		return adminErrorf(http.StatusConflict, "room %s has players; the level can only change while it is empty", r.code)
+	}
+	if name != r.activeName {
+		r.setActiveLevel(name, r.level(name))
+	}
+	r.levelPinned = true
+	r.match.LevelIndex = index
+	return nil
+}
+
+func (s *Server) adminMode(w http.ResponseWriter, req *http.Request) {
+	var body struct {
+		Mode string `json:"mode"`
+	}
+	if err := decodeAdminBody(w, req, &body); err != nil {
+		writeAdminError(w, err)
+		return
+	}
+	if !slices.Contains(gameModes, body.Mode) {
+		writeAdminError(w, adminErrorf(http.StatusBadRequest, "no game mode %q; have %v", body.Mode, gameModes))
+		return
+	}
+	s.roomAction(w, req, func(r *Room) error {
+		if r.match.State != netcomponents.MatchStateWaiting {
+			return adminErrorf(http.StatusConflict, "room %s is mid-match; abort it first", r.code)
+		}
+		r.match.GameMode = body.Mode
+		r.match.autoBalance()
+		r.match.broadcastLobbyUpdate()
+		return nil
+	})
+}
+
+func (s *Server) adminStart(w http.ResponseWriter, req *http.Request) {
+	s.roomAction(w, req, func(r *Room) error {
+		if r.match.State != netcomponents.MatchStateWaiting {
+			return adminErrorf(http.StatusConflict, "room %s is not in the lobby", r.code)
+		}
+		if !slices.ContainsFunc(r.match.Slots[:], func(slot messages.LobbySlot) bool { return slot.Type != 0 }) {
+			return adminErrorf(http.StatusConflict, "room %s has nobody in its slots", r.code)
+		}
+		r.match.startCountdown()
+		return nil
+	})
+}
+
+func (s *Server) adminAbort(w http.ResponseWriter, req *http.Request) {
+	s.roomAction(w, req, func(r *Room) error {
+		if r.match.State == netcomponents.MatchStateWaiting || r.match.State == netcomponents.MatchStateFinished {
+			return adminErrorf(http.StatusConflict, "room %s has no match under way", r.code)
+		}
+		r.match.abortMatch()
+		return nil
+	})
+}
+
+func (s *Server) adminAddBot(w http.ResponseWriter, req *http.Request) {
+	body := struct {
+		Name       string            `json:"name"`
+		Difficulty cfg.BotDifficulty `json:"difficulty"`
+	}{Name: "Bot", Difficulty: 1}
+	if req.ContentLength != 0 {
+		if err := decodeAdminBody(w, req, &body); err != nil {
+			writeAdminError(w, err)
+			return
+		}
+	}
+	if _, ok := cfg.Bot.Difficulties[body.Difficulty]; !ok {
+		writeAdminError(w, adminErrorf(http.StatusBadRequest, "no bot difficulty %d", body.Difficulty))
+		return
+	}
+	s.roomAction(w, req, func(r *Room) error {
+		return r.addBot(body.Name, body.Difficulty)
+	})
+}
+
+// addBot seats a bot in the room's first empty lobby slot, as the host's
+// add_bot lobby action does, and spawns it there straight away, so it
+// keeps its slot and team across rounds. Must be called on the game loop
+// goroutine.
+func (r *Room) addBot(name string, difficulty cfg.BotDifficulty) error {
+	slotIdx := r.match.addBot(name, int(difficulty))
+	if slotIdx < 0 {
+		return adminErrorf(http.StatusConflict, "room %s has no empty slot", r.code)
+	}
+	r.SpawnPlayerAtSlot(slotIdx, r.match.Slots[slotIdx])
+	if r.match.State != netcomponents.MatchStateWaiting {
+		r.match.Lives[r.match.Slots[slotIdx].PlayerID] = cfg.Match.LivesPerRound
+	}
+	r.match.broadcastLobbyUpdate()
+	return nil
+}
+
+func (s *Server) adminRemoveBot(w http.ResponseWriter, req *http.Request) {
+	netID, err := adminNetID(req)
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+	s.roomAction(w, req, func(r *Room) error {
+		return r.removeBot(netID)
+	})
+}
+
+// removeBot removes the bot with netID and frees its lobby slot, if it
+// has one. Must be called on the game loop goroutine.
+func (r *Room) removeBot(netID uint32) error {
+	entity, ok := r.entityForNetID(netID)
+	if !ok || !r.world.Entry(entity).HasComponent(components.Bot) {
+		return adminErrorf(http.StatusNotFound, "no bot with network ID %d in room %s", netID, r.code)
+	}
+	r.dropPlayer(netID)
+	log.Printf("Bot networkID=%d removed from room %s", netID, r.code)
+	return nil
+}
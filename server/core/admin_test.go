@@ -0,0 +1,224 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/automoto/doomerang-mp/components"
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yohamta/donburi"
+)
+
+const testAdminToken = "secret"
+
+// newAdminTestRoom returns a server's admin API and a room on it, set up
+// by setup before every room's loop starts.
+func newAdminTestRoom(t *testing.T, setup func(r *Room)) (http.Handler, *Room) {
+	t.Helper()
+	s := newRoomTestServer(t, emptyTestLevel())
+	r := newIdleTestRoom(t, s)
+	if setup != nil {
+		setup(r)
+	}
+	for _, room := range s.roomList() {
+		room.start()
+	}
+	return s.AdminHandler(testAdminToken, func() {}), r
+}
+
+func adminRequest(t *testing.T, h http.Handler, method, path, body string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code, rec.Body.String()
+}
+
+// onLoop runs fn on r's game loop, so tests can read what the loop owns.
+func onLoop(t *testing.T, r *Room, fn func()) {
+	t.Helper()
+	require.NoError(t, r.run(t.Context(), func() error {
+		fn()
+		return nil
+	}))
+}
+
+func TestAdminHandler_requires_token(t *testing.T) {
+	h, _ := newAdminTestRoom(t, nil)
+
+	for _, header := range []string{"", "Bearer wrong", testAdminToken} {
+		req := httptest.NewRequest(http.MethodGet, "/players", nil)
+		req.Header.Set("Authorization", header)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, "Authorization %q", header)
+	}
+
+	code, _ := adminRequest(t, h, http.MethodGet, "/players", "")
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestAdminHandler_lists_players(t *testing.T) {
+	var netID uint32
+	h, r := newAdminTestRoom(t, func(r *Room) {
+		_, netID = addTestPlayer(t, r, 100, 100)
+		r.match.Slots[2] = messages.LobbySlot{Type: 1, PlayerID: netID, Name: "ace"}
+		r.match.Scores[netID] = 3
+		r.recordRTT(netID, 45*time.Millisecond)
+		r.spawnBot("Bot", 1)
+	})
+
+	code, body := adminRequest(t, h, http.MethodGet, "/players", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, `{"room":"`+r.code+`","netId":`+strconv.Itoa(int(netID))+`,"name":"ace","slot":2,"pingMs":45,"kos":3,"deaths":0}`)
+	assert.Contains(t, body, `"slot":-1,"bot":true`)
+}
+
+func TestAdminHandler_mode_start_and_abort(t *testing.T) {
+	h, r := newAdminTestRoom(t, func(r *Room) {
+		r.match.Slots[0] = messages.LobbySlot{Type: 2, Name: "Bot", Ready: true}
+	})
+	path := "/rooms/" + strings.ToLower(r.code)
+
+	code, _ := adminRequest(t, h, http.MethodPost, path+"/mode", `{"mode":"tag"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = adminRequest(t, h, http.MethodPost, path+"/mode", `{"mode":"2v2"}`)
+	assert.Equal(t, http.StatusOK, code)
+
+	code, _ = adminRequest(t, h, http.MethodPost, path+"/abort", "")
+	assert.Equal(t, http.StatusConflict, code, "nothing to abort")
+	code, _ = adminRequest(t, h, http.MethodPost, path+"/start", "")
+	assert.Equal(t, http.StatusOK, code)
+	onLoop(t, r, func() {
+		assert.Equal(t, "2v2", r.match.GameMode)
+		assert.Equal(t, netcomponents.MatchStateCountdown, r.match.State)
+	})
+
+	code, _ = adminRequest(t, h, http.MethodPost, path+"/mode", `{"mode":"ffa"}`)
+	assert.Equal(t, http.StatusConflict, code, "mid-match")
+	code, _ = adminRequest(t, h, http.MethodPost, path+"/abort", "")
+	assert.Equal(t, http.StatusOK, code)
+	onLoop(t, r, func() {
+		assert.Equal(t, netcomponents.MatchStateWaiting, r.match.State)
+		assert.False(t, r.matchInProgress.Load())
+	})
+
+	code, _ = adminRequest(t, h, http.MethodPost, "/rooms/NOPE1/start", "")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestAdminHandler_adds_and_removes_bots(t *testing.T) {
+	h, r := newAdminTestRoom(t, nil)
+	path := "/rooms/" + r.code + "/bots"
+
+	code, _ := adminRequest(t, h, http.MethodPost, path, `{"name":"Sparring","difficulty":2}`)
+	require.Equal(t, http.StatusOK, code)
+
+	var botID uint32
+	onLoop(t, r, func() {
+		components.Bot.Each(r.world, func(entry *donburi.Entry) {
+			botID = uint32(r.networkID(entry.Entity()))
+		})
+	})
+	require.NotZero(t, botID)
+
+	code, _ = adminRequest(t, h, http.MethodDelete, path+"/"+strconv.Itoa(int(botID)), "")
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = adminRequest(t, h, http.MethodDelete, path+"/"+strconv.Itoa(int(botID)), "")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestAdminHandler_added_bot_keeps_its_slot_across_rounds(t *testing.T) {
+	h, r := newAdminTestRoom(t, func(r *Room) {
+		r.match.GameMode = "2v2"
+		r.match.Slots[0] = messages.LobbySlot{Type: 2, Name: "Bot", Team: 0}
+	})
+
+	code, _ := adminRequest(t, h, http.MethodPost, "/rooms/"+r.code+"/bots", `{"name":"Sparring","difficulty":2}`)
+	require.Equal(t, http.StatusOK, code)
+
+	onLoop(t, r, func() {
+		slot := r.match.Slots[1]
+		assert.Equal(t, 2, slot.Type)
+		assert.Equal(t, "Sparring", slot.Name)
+		assert.Equal(t, 2, slot.Difficulty)
+		assert.Equal(t, 1, slot.Team, "joins the smaller team")
+
+		r.match.startNextRound()
+		var bots int
+		components.Bot.Each(r.world, func(*donburi.Entry) { bots++ })
+		assert.Equal(t, 2, bots, "the round rebuild respawns it from its slot")
+		assert.Equal(t, "Sparring", r.match.Slots[1].Name)
+	})
+
+	for range 2 {
+		code, _ = adminRequest(t, h, http.MethodPost, "/rooms/"+r.code+"/bots", "")
+		require.Equal(t, http.StatusOK, code)
+	}
+	code, _ = adminRequest(t, h, http.MethodPost, "/rooms/"+r.code+"/bots", "")
+	assert.Equal(t, http.StatusConflict, code, "every slot is taken")
+}
+
+func TestAdminHandler_stopped_room_answers_at_once(t *testing.T) {
+	h, r := newAdminTestRoom(t, nil)
+	r.server.Stop()
+
+	start := time.Now()
+	code, _ := adminRequest(t, h, http.MethodPost, "/rooms/"+r.code+"/start", "")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	code, body := adminRequest(t, h, http.MethodGet, "/rooms", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `[]`, body)
+	assert.Less(t, time.Since(start), adminTimeout)
+}
+
+func TestAdminHandler_level_only_changes_in_empty_room(t *testing.T) {
+	h, r := newAdminTestRoom(t, nil)
+	path := "/rooms/" + r.code + "/level"
+
+	code, _ := adminRequest(t, h, http.MethodPost, path, `{"level":"missing"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = adminRequest(t, h, http.MethodPost, path, `{"level":"test"}`)
+	assert.Equal(t, http.StatusOK, code)
+	onLoop(t, r, func() {
+		assert.True(t, r.levelPinned)
+		addTestPlayer(t, r, 100, 100)
+	})
+
+	code, _ = adminRequest(t, h, http.MethodPost, path, `{"level":"test"}`)
+	assert.Equal(t, http.StatusConflict, code)
+}
+
+func TestAdminHandler_ban_kicks_and_blocks_name(t *testing.T) {
+	client, received := newRecordingTestClient(t)
+	const netID = 77
+	h, r := newAdminTestRoom(t, func(r *Room) {
+		r.spectators[client] = &spectator{name: "Griefer"}
+		r.clientNetworkIDs[client] = netID
+		r.networkIDClients[netID] = client
+		r.clientTokens[client] = "token"
+		r.tokenNetIDs["token"] = netID
+	})
+
+	code, _ := adminRequest(t, h, http.MethodPost, "/players/77/ban", "")
+	require.Equal(t, http.StatusOK, code)
+
+	rejected, ok := nextMessage(t, received).(messages.JoinRejected)
+	require.True(t, ok)
+	assert.Equal(t, "banned", rejected.Reason)
+	assert.True(t, r.server.nameBlocked("griefer"))
+	r.mu.RLock()
+	assert.NotContains(t, r.tokenNetIDs, "token", "a kicked player cannot reconnect")
+	r.mu.RUnlock()
+
+	code, _ = adminRequest(t, h, http.MethodPost, "/players/78/kick", "")
+	assert.Equal(t, http.StatusNotFound, code)
+}
@@ -3,54 +3,55 @@ package core
 import (
 	"log"
 	"math"
+	"slices"
 
 	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/enemysim"
 	"github.com/automoto/doomerang-mp/shared/gamemath"
 	"github.com/automoto/doomerang-mp/shared/messages"
 	"github.com/automoto/doomerang-mp/shared/netcomponents"
 	"github.com/automoto/doomerang-mp/shared/netconfig"
 	"github.com/automoto/doomerang-mp/tags"
 	"github.com/leap-fish/necs/esync"
-	"github.com/leap-fish/necs/esync/srvsync"
 	"github.com/yohamta/donburi"
 )
 
 // updateBoomerangs is called from updatePhysics() each server tick.
-func (s *Server) updateBoomerangs() {
-	stepsPerTick := 60 / s.loop.tickRate
+func (r *Room) updateBoomerangs() {
+	stepsPerTick := 60 / r.loop.tickRate
 	if stepsPerTick < 1 {
 		stepsPerTick = 1
 	}
 
 	// Process charge state per player
-	for entity, pp := range s.playerPhysics {
-		if !s.world.Valid(entity) {
+	for entity, pp := range r.playerPhysics {
+		if !r.world.Valid(entity) {
 			continue
 		}
-		s.processBoomerangCharge(entity, pp)
+		r.processBoomerangCharge(entity, pp)
 	}
 
 	// Sub-stepped boomerang physics
 	for step := 0; step < stepsPerTick; step++ {
-		for bEntity, bp := range s.boomerangPhysics {
+		for bEntity, bp := range r.boomerangPhysics {
 			if bp.Destroy {
 				continue
 			}
-			if !s.world.Valid(bEntity) {
+			if !r.world.Valid(bEntity) {
 				bp.Destroy = true
 				continue
 			}
-			s.stepBoomerangPhysics(bEntity, bp)
-			s.checkBoomerangCollisions(bEntity, bp)
+			r.stepBoomerangPhysics(bEntity, bp)
+			r.checkBoomerangCollisions(bEntity, bp)
 		}
 	}
 
 	// Write final positions to net components
-	for bEntity, bp := range s.boomerangPhysics {
-		if bp.Destroy || !s.world.Valid(bEntity) {
+	for bEntity, bp := range r.boomerangPhysics {
+		if bp.Destroy || !r.world.Valid(bEntity) {
 			continue
 		}
-		entry := s.world.Entry(bEntity)
+		entry := r.world.Entry(bEntity)
 		nb := netcomponents.NetBoomerang.Get(entry)
 		nb.X = bp.Object.X
 		nb.Y = bp.Object.Y
@@ -62,20 +63,17 @@ func (s *Server) updateBoomerangs() {
 	}
 
 	// Deferred removal
-	s.destroyFlaggedBoomerangs()
+	r.destroyFlaggedBoomerangs()
 }
 
-// boomChargeVFXFrame is the charge frame at which the charge VFX is spawned on clients.
-const boomChargeVFXFrame = 15
-
-func (s *Server) processBoomerangCharge(entity donburi.Entity, pp *PlayerPhysics) {
+func (r *Room) processBoomerangCharge(entity donburi.Entity, pp *PlayerPhysics) {
 	// Skip if player already has an active boomerang
-	if _, active := s.playerBoomerangs[entity]; active {
+	if _, active := r.playerBoomerangs[entity]; active {
 		pp.BoomerangWasPressed = pp.BoomerangPressed
 		return
 	}
 
-	entry := s.world.Entry(entity)
+	entry := r.world.Entry(entity)
 
 	// Edge detect: press start → begin charging
 	if pp.BoomerangPressed && !pp.BoomerangWasPressed {
@@ -97,12 +95,12 @@ func (s *Server) processBoomerangCharge(entity donburi.Entity, pp *PlayerPhysics
 		}
 
 		// Broadcast charge VFX event after holding for a bit (matches offline: frame 15)
-		if pp.BoomerangChargeTime == boomChargeVFXFrame {
+		if pp.BoomerangChargeTime == netconfig.BoomerangChargeVFXFrame {
 			var ownerNetID uint
 			if nid := esync.GetNetworkId(entry); nid != nil {
 				ownerNetID = uint(*nid)
 			}
-			s.broadcastEvent(messages.BoomerangChargeEvent{
+			r.broadcastEvent(messages.BoomerangChargeEvent{
 				OwnerNetworkID: ownerNetID,
 				X:              pp.Object.X + pp.Object.W/2,
 				Y:              pp.Object.Y + pp.Object.H,
@@ -112,7 +110,7 @@ func (s *Server) processBoomerangCharge(entity donburi.Entity, pp *PlayerPhysics
 
 	// Release: throw
 	if pp.BoomerangCharging && !pp.BoomerangPressed {
-		s.throwBoomerang(entity, pp)
+		r.throwBoomerang(entity, pp)
 		pp.BoomerangCharging = false
 		pp.BoomerangChargeTime = 0
 	}
@@ -120,8 +118,8 @@ func (s *Server) processBoomerangCharge(entity donburi.Entity, pp *PlayerPhysics
 	pp.BoomerangWasPressed = pp.BoomerangPressed
 }
 
-func (s *Server) throwBoomerang(playerEntity donburi.Entity, pp *PlayerPhysics) {
-	if !s.world.Valid(playerEntity) {
+func (r *Room) throwBoomerang(playerEntity donburi.Entity, pp *PlayerPhysics) {
+	if !r.world.Valid(playerEntity) {
 		return
 	}
 
@@ -129,7 +127,7 @@ func (s *Server) throwBoomerang(playerEntity donburi.Entity, pp *PlayerPhysics)
 
 	// Calculate aim direction
 	facingX := 1.0
-	playerEntry := s.world.Entry(playerEntity)
+	playerEntry := r.world.Entry(playerEntity)
 	if playerEntry.HasComponent(netcomponents.NetPlayerState) {
 		state := netcomponents.NetPlayerState.Get(playerEntry)
 		if state.Direction < 0 {
@@ -155,8 +153,8 @@ func (s *Server) throwBoomerang(playerEntity donburi.Entity, pp *PlayerPhysics)
 	velX, velY := gamemath.CalculateThrowVelocity(aimX, aimY, speed, cfg.Boomerang.ThrowLift)
 
 	// Create ECS entity
-	bEntity := s.world.Create(netcomponents.NetBoomerang)
-	bEntry := s.world.Entry(bEntity)
+	bEntity := r.world.Create(netcomponents.NetBoomerang)
+	bEntry := r.world.Entry(bEntity)
 
 	ownerNetID := esync.GetNetworkId(playerEntry)
 	var ownerNetIDVal uint
@@ -175,7 +173,7 @@ func (s *Server) throwBoomerang(playerEntity donburi.Entity, pp *PlayerPhysics)
 	})
 
 	// Create server-side physics
-	bp := newBoomerangPhysics(s.activeLevel, spawnX, spawnY, playerEntity, ownerNetIDVal)
+	bp := newBoomerangPhysics(r.activeLevel, spawnX, spawnY, playerEntity, ownerNetIDVal)
 	bp.VelX = velX
 	bp.VelY = velY
 	bp.State = netconfig.BoomerangOutbound
@@ -184,11 +182,11 @@ func (s *Server) throwBoomerang(playerEntity donburi.Entity, pp *PlayerPhysics)
 	bp.Damage = gamemath.CalculateDamage(cfg.Boomerang.BaseDamage, cfg.Boomerang.MaxChargeDamageBonus, chargeRatio)
 	bp.ChargeRatio = chargeRatio
 
-	s.boomerangPhysics[bEntity] = bp
-	s.playerBoomerangs[playerEntity] = bEntity
+	r.boomerangPhysics[bEntity] = bp
+	r.playerBoomerangs[playerEntity] = bEntity
 
 	// Register for network sync
-	if err := srvsync.NetworkSync(s.world, &bEntity, netcomponents.NetBoomerang); err != nil {
+	if err := r.sync.NetworkSync(bEntity, netcomponents.NetBoomerang); err != nil {
 		log.Printf("Failed to sync boomerang: %v", err)
 		return
 	}
@@ -201,17 +199,19 @@ func (s *Server) throwBoomerang(playerEntity donburi.Entity, pp *PlayerPhysics)
 	pp.LockedStateTimer = 6 // ~200ms at 30Hz ticks
 
 	// Broadcast throw event
-	s.broadcastEvent(messages.BoomerangThrowEvent{
+	r.broadcastEvent(messages.BoomerangThrowEvent{
 		OwnerNetworkID: ownerNetIDVal,
 		X:              spawnX,
 		Y:              spawnY,
 		DirectionX:     aimX,
 		DirectionY:     aimY,
 		ChargeLevel:    chargeRatio,
+		Sequence:       pp.LastInputSeq,
 	})
+	r.match.recordThrow(uint32(ownerNetIDVal))
 }
 
-func (s *Server) stepBoomerangPhysics(bEntity donburi.Entity, bp *BoomerangPhysics) {
+func (r *Room) stepBoomerangPhysics(bEntity donburi.Entity, bp *BoomerangPhysics) {
 	switch bp.State {
 	case netconfig.BoomerangOutbound:
 		// Apply gravity
@@ -229,11 +229,11 @@ func (s *Server) stepBoomerangPhysics(bEntity donburi.Entity, bp *BoomerangPhysi
 
 	case netconfig.BoomerangInbound:
 		// Home toward owner
-		if !s.world.Valid(bp.OwnerEntity) {
+		if !r.world.Valid(bp.OwnerEntity) {
 			bp.Destroy = true
 			return
 		}
-		ownerPP, ok := s.playerPhysics[bp.OwnerEntity]
+		ownerPP, ok := r.playerPhysics[bp.OwnerEntity]
 		if !ok {
 			bp.Destroy = true
 			return
@@ -254,7 +254,7 @@ func (s *Server) stepBoomerangPhysics(bEntity donburi.Entity, bp *BoomerangPhysi
 	bp.Object.Update()
 }
 
-func (s *Server) checkBoomerangCollisions(bEntity donburi.Entity, bp *BoomerangPhysics) {
+func (r *Room) checkBoomerangCollisions(bEntity donburi.Entity, bp *BoomerangPhysics) {
 	if bp.Destroy {
 		return
 	}
@@ -262,56 +262,41 @@ func (s *Server) checkBoomerangCollisions(bEntity donburi.Entity, bp *BoomerangP
 	// Proximity-based catch runs first — always checked for inbound boomerangs
 	// regardless of whether resolv detects an overlap (the 12x12 boomerang can
 	// oscillate past the player at high speed without a frame-perfect overlap).
-	if bp.State == netconfig.BoomerangInbound && s.world.Valid(bp.OwnerEntity) {
-		if ownerPP, ok := s.playerPhysics[bp.OwnerEntity]; ok {
+	if bp.State == netconfig.BoomerangInbound && r.world.Valid(bp.OwnerEntity) {
+		if ownerPP, ok := r.playerPhysics[bp.OwnerEntity]; ok {
 			cx := bp.Object.X + 6
 			cy := bp.Object.Y + 6
 			ox := ownerPP.Object.X + ownerPP.Object.W/2
 			oy := ownerPP.Object.Y + ownerPP.Object.H/2
 			dist := math.Sqrt((cx-ox)*(cx-ox) + (cy-oy)*(cy-oy))
 			if dist < cfg.Boomerang.CatchRadius {
-				s.catchBoomerang(bEntity, bp)
+				r.catchBoomerang(bEntity, bp)
 				return
 			}
 		}
 	}
 
-	check := bp.Object.Check(0, 0, tags.ResolvSolid, "player")
-	if check == nil {
-		return
-	}
-
-	// Wall collision → switch to inbound
-	if bp.State == netconfig.BoomerangOutbound {
-		if solids := check.ObjectsByTags(tags.ResolvSolid); len(solids) > 0 {
-			bp.State = netconfig.BoomerangInbound
-		}
-	}
-
-	// Player collision
-	players := check.ObjectsByTags("player")
-	for _, pObj := range players {
-		// Find which player entity owns this resolv object
-		var hitEntity donburi.Entity
-		var hitFound bool
-		for pEntity, pp := range s.playerPhysics {
-			if pp.Object == pObj {
-				hitEntity = pEntity
-				hitFound = true
-				break
+	if check := bp.Object.Check(0, 0, tags.ResolvSolid, "player"); check != nil {
+		// Wall collision → switch to inbound
+		if bp.State == netconfig.BoomerangOutbound {
+			if solids := check.ObjectsByTags(tags.ResolvSolid); len(solids) > 0 {
+				bp.State = netconfig.BoomerangInbound
 			}
 		}
-		if !hitFound {
-			continue
-		}
 
 		// Owner + inbound → catch (backup for proximity check above)
-		if hitEntity == bp.OwnerEntity && bp.State == netconfig.BoomerangInbound {
-			s.catchBoomerang(bEntity, bp)
-			return
+		if ownerPP, ok := r.playerPhysics[bp.OwnerEntity]; ok && bp.State == netconfig.BoomerangInbound {
+			if slices.Contains(check.ObjectsByTags("player"), ownerPP.Object) {
+				r.catchBoomerang(bEntity, bp)
+				return
+			}
 		}
+	}
 
-		// Owner collision while outbound → skip
+	// Player collision, against targets where the thrower saw them. The
+	// owner is skipped: catching is handled above.
+	past := r.pastHurtboxes(uint32(bp.OwnerNetworkID))
+	for hitEntity, pp := range r.playerPhysics {
 		if hitEntity == bp.OwnerEntity {
 			continue
 		}
@@ -320,19 +305,74 @@ func (s *Server) checkBoomerangCollisions(bEntity donburi.Entity, bp *BoomerangP
 		if _, already := bp.HitPlayers[hitEntity]; already {
 			continue
 		}
+		// Boomerangs pass through teammates unless friendly fire is on
+		if !r.match.canHurt(uint32(bp.OwnerNetworkID), uint32(r.networkID(hitEntity))) {
+			continue
+		}
+
+		if !hurtboxIn(past, hitEntity, pp).overlaps(bp.Object.X, bp.Object.Y, bp.Object.W, bp.Object.H) {
+			continue
+		}
 
 		// Hit enemy player
-		s.hitPlayer(bEntity, bp, hitEntity)
+		r.hitPlayer(bEntity, bp, hitEntity)
+	}
+
+	// Co-op enemy collision
+	for enemyEntity, enemy := range r.enemies {
+		if enemy.Dead() {
+			continue
+		}
+		if _, already := bp.HitEnemies[enemyEntity]; already {
+			continue
+		}
+		obj := enemy.Object
+		if (hurtbox{X: obj.X, Y: obj.Y, W: obj.W, H: obj.H}).overlaps(bp.Object.X, bp.Object.Y, bp.Object.W, bp.Object.H) {
+			r.hitEnemyWithBoomerang(bp, enemyEntity, enemy)
+		}
+	}
+}
+
+// hitEnemyWithBoomerang damages an enemy and knocks it away from the
+// boomerang — mirrors the offline handleEnemyCollision, including its
+// short return rule.
+func (r *Room) hitEnemyWithBoomerang(bp *BoomerangPhysics, enemyEntity donburi.Entity, enemy *enemysim.Enemy) {
+	r.match.recordBoomerangHit(bp)
+	bp.HitEnemies[enemyEntity] = struct{}{}
+
+	knockX := cfg.Boomerang.HitKnockback
+	obj := enemy.Object
+	if obj.X+obj.W/2 < bp.Object.X+bp.Object.W/2 {
+		knockX = -knockX
+	}
+	knockY := cfg.Combat.KnockbackUpwardForce
+
+	r.broadcastEvent(messages.BoomerangHitEvent{
+		AttackerNetworkID: bp.OwnerNetworkID,
+		TargetNetworkID:   r.networkID(enemyEntity),
+		HitX:              obj.X + obj.W/2,
+		HitY:              obj.Y + obj.H/2,
+		ChargeRatio:       bp.ChargeRatio,
+		Damage:            bp.Damage,
+		KnockbackX:        knockX,
+		KnockbackY:        knockY,
+	})
+	r.hitEnemy(enemyEntity, enemy, bp.OwnerNetworkID, "boomerang", bp.Damage, knockX, knockY, cfg.Combat.EnemyInvulnFrames/2)
+
+	// Short return rule
+	if bp.State == netconfig.BoomerangOutbound {
+		bp.MaxRange = min(bp.MaxRange, bp.DistanceTraveled+bp.PierceDistance)
 	}
 }
 
-func (s *Server) hitPlayer(bEntity donburi.Entity, bp *BoomerangPhysics, targetEntity donburi.Entity) {
+func (r *Room) hitPlayer(bEntity donburi.Entity, bp *BoomerangPhysics, targetEntity donburi.Entity) {
+	r.match.recordBoomerangHit(bp)
 	bp.HitPlayers[targetEntity] = struct{}{}
 
-	if !s.world.Valid(targetEntity) {
+	if !r.world.Valid(targetEntity) {
 		return
 	}
-	targetEntry := s.world.Entry(targetEntity)
+	targetEntry := r.world.Entry(targetEntity)
 
 	// Apply damage
 	if targetEntry.HasComponent(netcomponents.NetPlayerState) {
@@ -344,8 +384,9 @@ func (s *Server) hitPlayer(bEntity donburi.Entity, bp *BoomerangPhysics, targetE
 		state.StateID = netconfig.Hit
 	}
 	// Lock hit state animation for a short duration
-	if targetPP, ok := s.playerPhysics[targetEntity]; ok {
+	if targetPP, ok := r.playerPhysics[targetEntity]; ok {
 		targetPP.LockedStateTimer = 10 // ~333ms at 30Hz ticks
+		targetPP.LastAttacker = bp.OwnerNetworkID
 	}
 
 	// Apply knockback
@@ -369,8 +410,9 @@ func (s *Server) hitPlayer(bEntity donburi.Entity, bp *BoomerangPhysics, targetE
 	if nid := esync.GetNetworkId(targetEntry); nid != nil {
 		targetNetID = uint(*nid)
 	}
+	r.match.recordDamage(uint32(bp.OwnerNetworkID), uint32(targetNetID), bp.Damage)
 
-	s.broadcastEvent(messages.BoomerangHitEvent{
+	r.broadcastEvent(messages.BoomerangHitEvent{
 		AttackerNetworkID: bp.OwnerNetworkID,
 		TargetNetworkID:   targetNetID,
 		HitX:              hitX,
@@ -385,8 +427,8 @@ func (s *Server) hitPlayer(bEntity donburi.Entity, bp *BoomerangPhysics, targetE
 	if targetEntry.HasComponent(netcomponents.NetPlayerState) {
 		state := netcomponents.NetPlayerState.Get(targetEntry)
 		if state.Health <= 0 {
-			if targetPP, ok := s.playerPhysics[targetEntity]; ok {
-				s.handlePlayerDeath(targetEntity, targetPP, bp.OwnerNetworkID)
+			if targetPP, ok := r.playerPhysics[targetEntity]; ok {
+				r.handlePlayerDeath(targetEntity, targetPP, bp.OwnerNetworkID, "boomerang")
 			}
 		}
 	}
@@ -398,30 +440,28 @@ func (s *Server) hitPlayer(bEntity donburi.Entity, bp *BoomerangPhysics, targetE
 	}
 }
 
-func (s *Server) catchBoomerang(bEntity donburi.Entity, bp *BoomerangPhysics) {
+func (r *Room) catchBoomerang(bEntity donburi.Entity, bp *BoomerangPhysics) {
 	bp.Destroy = true
 
-	s.broadcastEvent(messages.BoomerangCatchEvent{
+	r.broadcastEvent(messages.BoomerangCatchEvent{
 		OwnerNetworkID: bp.OwnerNetworkID,
 	})
 }
 
 // destroyBoomerang immediately cleans up a boomerang entity.
-func (s *Server) destroyBoomerang(bEntity donburi.Entity) {
-	if bp, ok := s.boomerangPhysics[bEntity]; ok {
-		removeBoomerangPhysics(s.activeLevel, bp)
-		delete(s.boomerangPhysics, bEntity)
-		delete(s.playerBoomerangs, bp.OwnerEntity)
-	}
-	if s.world.Valid(bEntity) {
-		s.world.Remove(bEntity)
+func (r *Room) destroyBoomerang(bEntity donburi.Entity) {
+	if bp, ok := r.boomerangPhysics[bEntity]; ok {
+		removeBoomerangPhysics(r.activeLevel, bp)
+		delete(r.boomerangPhysics, bEntity)
+		delete(r.playerBoomerangs, bp.OwnerEntity)
 	}
+	r.sync.Remove(bEntity)
 }
 
-func (s *Server) destroyFlaggedBoomerangs() {
-	for bEntity, bp := range s.boomerangPhysics {
+func (r *Room) destroyFlaggedBoomerangs() {
+	for bEntity, bp := range r.boomerangPhysics {
 		if bp.Destroy {
-			s.destroyBoomerang(bEntity)
+			r.destroyBoomerang(bEntity)
 		}
 	}
 }
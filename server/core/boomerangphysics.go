@@ -19,6 +19,7 @@ type BoomerangPhysics struct {
 	OwnerEntity      donburi.Entity
 	OwnerNetworkID   uint
 	HitPlayers       map[donburi.Entity]struct{}
+	HitEnemies       map[donburi.Entity]struct{}
 	Destroy          bool // Flagged for deferred removal
 }
 
@@ -32,6 +33,7 @@ func newBoomerangPhysics(level *ServerLevel, x, y float64, ownerEntity donburi.E
 		OwnerEntity:    ownerEntity,
 		OwnerNetworkID: ownerNetworkID,
 		HitPlayers:     make(map[donburi.Entity]struct{}),
+		HitEnemies:     make(map[donburi.Entity]struct{}),
 	}
 }
 
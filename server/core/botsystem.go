@@ -9,27 +9,28 @@ import (
 	"github.com/automoto/doomerang-mp/shared/netcomponents"
 	"github.com/automoto/doomerang-mp/shared/netconfig"
 	"github.com/automoto/doomerang-mp/shared/pathfinding"
+	"github.com/leap-fish/necs/esync"
 	"github.com/yohamta/donburi"
 )
 
 type BotSystem struct {
-	server *Server
-	rng    *rand.Rand
+	room *Room
+	rng  *rand.Rand
 
 	cachedNavGrid *pathfinding.NavGrid
 	levelName     string
 }
 
-func NewBotSystem(server *Server) *BotSystem {
+func NewBotSystem(room *Room) *BotSystem {
 	return &BotSystem{
-		server: server,
-		rng:    rand.New(rand.NewSource(42)),
+		room: room,
+		rng:  rand.New(rand.NewSource(42)),
 	}
 }
 
 func (s *BotSystem) Update() {
-	world := s.server.world
-	level := s.server.activeLevel
+	world := s.room.world
+	level := s.room.activeLevel
 
 	if level == nil {
 		return
@@ -47,6 +48,11 @@ func (s *BotSystem) Update() {
 			playerIndex = components.Player.Get(entry).PlayerIndex
 		}
 
+		team := -1
+		if nid := esync.GetNetworkId(entry); nid != nil {
+			team = s.room.match.teamOf(uint32(*nid))
+		}
+
 		players = append(players, botai.PlayerInfo{
 			Index:        playerIndex,
 			X:            pos.X + 8, // Center (assuming 16x40)
@@ -56,7 +62,7 @@ func (s *BotSystem) Update() {
 			Health:       state.Health,
 			MaxHealth:    100,
 			IsBot:        state.IsBot,
-			Team:         -1, // TODO: team support on server
+			Team:         team,
 			CurrentState: netconfigToStateID(state.StateID),
 		})
 	})
@@ -79,8 +85,9 @@ func (s *BotSystem) Update() {
 			SpeedY:      vel.SpeedY,
 		}
 
-		if pp, ok := s.server.playerPhysics[entry.Entity()]; ok {
-			physicsInfo.OnGround = pp.OnGround
+		if pp, ok := s.room.playerPhysics[entry.Entity()]; ok {
+			physicsInfo.OnGround = pp.Body.OnGround != nil
+			physicsInfo.WallSliding = pp.Body.WallSliding != nil
 		}
 
 		botai.UpdateBotAI(
@@ -98,7 +105,7 @@ func (s *BotSystem) Update() {
 		)
 
 		// Apply inputs to PlayerPhysics
-		if pp, ok := s.server.playerPhysics[entry.Entity()]; ok {
+		if pp, ok := s.room.playerPhysics[entry.Entity()]; ok {
 			pp.Direction = 0
 			if input.CurrentInput[cfg.ActionMoveRight] {
 				pp.Direction = 1
@@ -126,12 +133,12 @@ func (s *BotSystem) getOrCreateNavGrid(level *ServerLevel) *pathfinding.NavGrid
 	// Wait, ServerLevel in server.go has names in the map.
 
 	// For now, let's just always use the active level from the server.
-	if s.cachedNavGrid != nil && s.levelName == s.server.activeName {
+	if s.cachedNavGrid != nil && s.levelName == s.room.activeName {
 		return s.cachedNavGrid
 	}
 
 	s.cachedNavGrid = pathfinding.CreateNavGrid(level.Space, level.MapWidth, level.MapHeight, 32.0)
-	s.levelName = s.server.activeName
+	s.levelName = s.room.activeName
 	return s.cachedNavGrid
 }
 
@@ -5,30 +5,24 @@ import (
 	"time"
 
 	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/enemysim"
 	"github.com/automoto/doomerang-mp/shared/messages"
 	"github.com/automoto/doomerang-mp/shared/netcomponents"
 	"github.com/automoto/doomerang-mp/shared/netconfig"
+	"github.com/automoto/doomerang-mp/shared/playersim"
 	"github.com/leap-fish/necs/esync"
 	"github.com/yohamta/donburi"
 )
 
-// Melee hitbox active window (in ticks at server tick rate).
-const (
-	meleeHitboxStart    = 3
-	meleeHitboxEnd      = 8
-	jumpKickHitboxStart = 2
-	jumpKickHitboxEnd   = 12 // Jump kick has a longer active window (2x)
-)
-
 // updateCombat is called once per server tick, after physics.
-func (s *Server) updateCombat() {
+func (r *Room) updateCombat() {
 	// Only run combat during active gameplay
-	if s.match.State != netcomponents.MatchStatePlaying {
+	if r.match.State != netcomponents.MatchStatePlaying {
 		return
 	}
 
-	for entity, pp := range s.playerPhysics {
-		if !s.world.Valid(entity) {
+	for entity, pp := range r.playerPhysics {
+		if !r.world.Valid(entity) {
 			continue
 		}
 		if pp.Dead {
@@ -40,23 +34,23 @@ func (s *Server) updateCombat() {
 			pp.InvulnFrames--
 		}
 
-		s.processMeleeAttack(entity, pp)
+		r.processMeleeAttack(entity, pp)
 	}
 }
 
 // processMeleeAttack edge-detects the attack button, manages the attack frame
 // counter and hitbox window, and checks for hits. Alternates punch/kick via
 // ComboStep on ground; uses jump kick when airborne.
-func (s *Server) processMeleeAttack(entity donburi.Entity, pp *PlayerPhysics) {
+func (r *Room) processMeleeAttack(entity donburi.Entity, pp *PlayerPhysics) {
 	// Edge detect: new press → start attack
 	if pp.AttackPressed && !pp.AttackWasPressed && pp.AttackFrame == 0 {
 		pp.AttackFrame = 1
 		pp.HitboxActive = false
 		clear(pp.HitTargets)
 
-		entry := s.world.Entry(entity)
+		entry := r.world.Entry(entity)
 
-		if !pp.OnGround {
+		if pp.Body.OnGround == nil {
 			// Airborne → jump kick (always kick config, no combo alternation)
 			pp.AttackIsJumpKick = true
 			pp.AttackIsPunch = false
@@ -87,14 +81,16 @@ func (s *Server) processMeleeAttack(entity donburi.Entity, pp *PlayerPhysics) {
 			pp.LockedStateTimer = 10
 		}
 
-		// Broadcast attack initiation event for SFX
+		// Broadcast attack initiation event for SFX and prediction
 		var attackerNetID uint
 		if nid := esync.GetNetworkId(entry); nid != nil {
 			attackerNetID = uint(*nid)
 		}
-		s.broadcastEvent(messages.MeleeAttackEvent{
+		r.broadcastEvent(messages.MeleeAttackEvent{
 			AttackerNetworkID: attackerNetID,
 			IsPunch:           pp.AttackIsPunch,
+			IsJumpKick:        pp.AttackIsJumpKick,
+			Sequence:          pp.LastInputSeq,
 		})
 	}
 	pp.AttackWasPressed = pp.AttackPressed
@@ -107,16 +103,16 @@ func (s *Server) processMeleeAttack(entity donburi.Entity, pp *PlayerPhysics) {
 	pp.AttackFrame++
 
 	// Hitbox active window (jump kick has a wider window)
-	hitStart := meleeHitboxStart
-	hitEnd := meleeHitboxEnd
+	hitStart := netconfig.MeleeHitboxStart
+	hitEnd := netconfig.MeleeHitboxEnd
 	if pp.AttackIsJumpKick {
-		hitStart = jumpKickHitboxStart
-		hitEnd = jumpKickHitboxEnd
+		hitStart = netconfig.JumpKickHitboxStart
+		hitEnd = netconfig.JumpKickHitboxEnd
 	}
 
 	if pp.AttackFrame >= hitStart && pp.AttackFrame <= hitEnd {
 		pp.HitboxActive = true
-		s.checkMeleeHitbox(entity, pp)
+		r.checkMeleeHitbox(entity, pp)
 	} else {
 		pp.HitboxActive = false
 	}
@@ -133,8 +129,8 @@ func (s *Server) processMeleeAttack(entity donburi.Entity, pp *PlayerPhysics) {
 
 // checkMeleeHitbox builds an AABB in front of the attacker and checks overlap
 // with all other player collision rects.
-func (s *Server) checkMeleeHitbox(attackerEntity donburi.Entity, attackerPP *PlayerPhysics) {
-	entry := s.world.Entry(attackerEntity)
+func (r *Room) checkMeleeHitbox(attackerEntity donburi.Entity, attackerPP *PlayerPhysics) {
+	entry := r.world.Entry(attackerEntity)
 	if !entry.HasComponent(netcomponents.NetPlayerState) {
 		return
 	}
@@ -165,12 +161,19 @@ func (s *Server) checkMeleeHitbox(attackerEntity donburi.Entity, attackerPP *Pla
 	}
 	hitY := attackerPP.Object.Y + (attackerPP.Object.H-hitH)/2
 
+	// Test targets where the attacker saw them
+	var attackerNetID uint32
+	if nid := esync.GetNetworkId(entry); nid != nil {
+		attackerNetID = uint32(*nid)
+	}
+	past := r.pastHurtboxes(attackerNetID)
+
 	// Check against all other players
-	for targetEntity, targetPP := range s.playerPhysics {
+	for targetEntity, targetPP := range r.playerPhysics {
 		if targetEntity == attackerEntity {
 			continue
 		}
-		if !s.world.Valid(targetEntity) {
+		if !r.world.Valid(targetEntity) {
 			continue
 		}
 		if targetPP.Dead {
@@ -182,28 +185,77 @@ func (s *Server) checkMeleeHitbox(attackerEntity donburi.Entity, attackerPP *Pla
 		if _, already := attackerPP.HitTargets[targetEntity]; already {
 			continue
 		}
+		if !r.match.canHurt(attackerNetID, uint32(r.networkID(targetEntity))) {
+			continue
+		}
 
 		// AABB overlap test
-		tX := targetPP.Object.X
-		tY := targetPP.Object.Y
-		tW := targetPP.Object.W
-		tH := targetPP.Object.H
+		if hurtboxIn(past, targetEntity, targetPP).overlaps(hitX, hitY, hitW, hitH) {
+			r.applyMeleeHit(attackerEntity, attackerPP, targetEntity, targetPP, hitX+hitW/2, hitY+hitH/2)
+		}
+	}
 
-		if hitX < tX+tW && hitX+hitW > tX && hitY < tY+tH && hitY+hitH > tY {
-			s.applyMeleeHit(attackerEntity, attackerPP, targetEntity, targetPP, hitX+hitW/2, hitY+hitH/2)
+	// Check against co-op enemies, where they are now
+	for enemyEntity, enemy := range r.enemies {
+		if enemy.Dead() || enemy.InvulnFrames > 0 {
+			continue
+		}
+		if _, already := attackerPP.HitTargets[enemyEntity]; already {
+			continue
+		}
+		obj := enemy.Object
+		if (hurtbox{X: obj.X, Y: obj.Y, W: obj.W, H: obj.H}).overlaps(hitX, hitY, hitW, hitH) {
+			r.applyMeleeHitToEnemy(attackerPP, uint(attackerNetID), enemyEntity, enemy, hitX+hitW/2, hitY+hitH/2)
 		}
 	}
 }
 
+// applyMeleeHitToEnemy damages an enemy and knocks it away from the
+// attacker — mirrors the offline player hitbox against enemies.
+func (r *Room) applyMeleeHitToEnemy(
+	attackerPP *PlayerPhysics, attackerNetID uint,
+	enemyEntity donburi.Entity, enemy *enemysim.Enemy,
+	hitX, hitY float64,
+) {
+	attackerPP.HitTargets[enemyEntity] = struct{}{}
+
+	damage := cfg.Combat.PlayerKickDamage
+	knockbackForce := cfg.Combat.PlayerKickKnockback
+	if attackerPP.AttackIsPunch {
+		damage = cfg.Combat.PlayerPunchDamage
+		knockbackForce = cfg.Combat.PlayerPunchKnockback
+	}
+
+	// Knock the enemy away from the attacker's center
+	knockX := knockbackForce
+	obj := enemy.Object
+	if attackerPP.Object.X+attackerPP.Object.W/2 > obj.X+obj.W/2 {
+		knockX = -knockbackForce
+	}
+	knockY := cfg.Combat.KnockbackUpwardForce
+
+	r.match.recordMeleeHit(uint32(attackerNetID), attackerPP)
+	r.broadcastEvent(messages.MeleeHitEvent{
+		AttackerNetworkID: attackerNetID,
+		TargetNetworkID:   r.networkID(enemyEntity),
+		HitX:              hitX,
+		HitY:              hitY,
+		Damage:            damage,
+		KnockbackX:        knockX,
+		KnockbackY:        knockY,
+	})
+	r.hitEnemy(enemyEntity, enemy, attackerNetID, meleeCause(attackerPP), damage, knockX, knockY, cfg.Combat.EnemyInvulnFrames)
+}
+
 // applyMeleeHit applies damage, knockback, and state changes — mirrors boomerang.go:hitPlayer().
-func (s *Server) applyMeleeHit(
+func (r *Room) applyMeleeHit(
 	attackerEntity donburi.Entity, attackerPP *PlayerPhysics,
 	targetEntity donburi.Entity, targetPP *PlayerPhysics,
 	hitX, hitY float64,
 ) {
 	attackerPP.HitTargets[targetEntity] = struct{}{}
 
-	targetEntry := s.world.Entry(targetEntity)
+	targetEntry := r.world.Entry(targetEntity)
 
 	// Select per-attack damage and knockback
 	var damage int
@@ -231,7 +283,7 @@ func (s *Server) applyMeleeHit(
 	targetPP.InvulnFrames = cfg.Combat.PlayerInvulnFrames
 
 	// Apply knockback
-	attackerEntry := s.world.Entry(attackerEntity)
+	attackerEntry := r.world.Entry(attackerEntity)
 	knockDir := 1.0
 	if attackerEntry.HasComponent(netcomponents.NetPlayerState) {
 		aState := netcomponents.NetPlayerState.Get(attackerEntry)
@@ -257,7 +309,11 @@ func (s *Server) applyMeleeHit(
 		targetNetID = uint(*nid)
 	}
 
-	s.broadcastEvent(messages.MeleeHitEvent{
+	targetPP.LastAttacker = attackerNetID
+	r.match.recordMeleeHit(uint32(attackerNetID), attackerPP)
+	r.match.recordDamage(uint32(attackerNetID), uint32(targetNetID), damage)
+
+	r.broadcastEvent(messages.MeleeHitEvent{
 		AttackerNetworkID: attackerNetID,
 		TargetNetworkID:   targetNetID,
 		HitX:              hitX,
@@ -271,17 +327,30 @@ func (s *Server) applyMeleeHit(
 	if targetEntry.HasComponent(netcomponents.NetPlayerState) {
 		state := netcomponents.NetPlayerState.Get(targetEntry)
 		if state.Health <= 0 {
-			s.handlePlayerDeath(targetEntity, targetPP, attackerNetID)
+			r.handlePlayerDeath(targetEntity, targetPP, attackerNetID, meleeCause(attackerPP))
 		}
 	}
 }
 
+// meleeCause names the attack pp is making, as a DeathEvent.Cause.
+func meleeCause(pp *PlayerPhysics) string {
+	switch {
+	case pp.AttackIsJumpKick:
+		return "jump_kick"
+	case pp.AttackIsPunch:
+		return "punch"
+	default:
+		return "kick"
+	}
+}
+
 // handlePlayerDeath sets the Die state, decrements lives, and either schedules
-// respawn or marks the player as eliminated.
-func (s *Server) handlePlayerDeath(entity donburi.Entity, pp *PlayerPhysics, killerNetID uint) {
+// respawn or marks the player as eliminated. cause is what dealt the final
+// blow, as a DeathEvent.Cause.
+func (r *Room) handlePlayerDeath(entity donburi.Entity, pp *PlayerPhysics, killerNetID uint, cause string) {
 	pp.Dead = true
 
-	entry := s.world.Entry(entity)
+	entry := r.world.Entry(entity)
 	if entry.HasComponent(netcomponents.NetPlayerState) {
 		state := netcomponents.NetPlayerState.Get(entry)
 		state.StateID = netconfig.Die
@@ -293,68 +362,70 @@ func (s *Server) handlePlayerDeath(entity donburi.Entity, pp *PlayerPhysics, kil
 		victimNetID = uint(*nid)
 	}
 
-	s.broadcastEvent(messages.DeathEvent{
+	nid32 := uint32(victimNetID)
+	respawnAt := time.Now().Add(time.Duration(cfg.Match.RespawnDelay) * time.Second / 60)
+	evt := messages.DeathEvent{
 		VictimID: victimNetID,
 		KillerID: killerNetID,
-	})
+		Cause:    cause,
+	}
+	if r.match.Lives[nid32] > 1 { // Otherwise this was the last life
+		evt.RespawnAt = respawnAt.UnixNano()
+	}
+	r.broadcastEvent(evt)
 
 	// Record in match system
-	s.match.AddDeath(uint32(victimNetID))
+	r.match.AddDeath(uint32(victimNetID))
 	if killerNetID != 0 && killerNetID != victimNetID {
-		s.match.AddKO(uint32(killerNetID))
+		r.match.AddKO(uint32(killerNetID))
 	}
 
 	// Decrement lives
-	nid32 := uint32(victimNetID)
-	s.match.Lives[nid32]--
+	r.match.Lives[nid32]--
 
 	if entry.HasComponent(netcomponents.NetPlayerState) {
-		netcomponents.NetPlayerState.Get(entry).Lives = s.match.Lives[nid32]
+		netcomponents.NetPlayerState.Get(entry).Lives = r.match.Lives[nid32]
 	}
 
-	if s.match.Lives[nid32] <= 0 {
+	if r.match.Lives[nid32] <= 0 {
 		// Player is eliminated — no respawn
-		s.match.Lives[nid32] = 0
-		s.match.Eliminated[nid32] = true
+		r.match.Lives[nid32] = 0
+		r.match.Eliminated[nid32] = true
 
-		s.broadcastEvent(messages.MatchEvent{
+		r.broadcastEvent(messages.MatchEvent{
 			Type:        "player_eliminated",
 			PlayerID:    nid32,
-			RoundNumber: s.match.CurrentRound,
+			RoundNumber: r.match.CurrentRound,
 		})
 
-		s.match.checkRoundEndCondition()
+		r.match.checkRoundEndCondition()
 		return
 	}
 
 	// Still has lives — schedule respawn
-	respawnMs := time.Duration(cfg.Match.RespawnDelay) * time.Second / 60
-	time.AfterFunc(respawnMs, func() {
-		s.cmdCh <- func() {
-			s.respawnPlayer(entity)
+	time.AfterFunc(time.Until(respawnAt), func() {
+		r.cmdCh <- func() {
+			r.respawnPlayer(entity)
 		}
 	})
 }
 
 // respawnPlayer resets a dead player to a spawn point with full health.
-func (s *Server) respawnPlayer(entity donburi.Entity) {
-	if !s.world.Valid(entity) {
+func (r *Room) respawnPlayer(entity donburi.Entity) {
+	if !r.world.Valid(entity) {
 		return
 	}
-	pp, ok := s.playerPhysics[entity]
+	pp, ok := r.playerPhysics[entity]
 	if !ok {
 		return
 	}
 
 	// Pick a spawn point
-	spawnX, spawnY := 100.0, 100.0
-	if len(s.activeLevel.SpawnPoints) > 0 {
-		idx := int(entity) % len(s.activeLevel.SpawnPoints)
-		sp := s.activeLevel.SpawnPoints[idx]
-		spawnX, spawnY = sp.X, sp.Y
-	}
+	spawnX, spawnY := r.chooseRespawnPoint(entity, pp)
 
 	// Reset physics
+	pp.Movement.Reset(pp.Object)
+	pp.Body = playersim.Body{}
 	pp.Object.X = spawnX
 	pp.Object.Y = spawnY
 	pp.Object.Update()
@@ -364,8 +435,9 @@ func (s *Server) respawnPlayer(entity donburi.Entity) {
 	pp.ComboStep = 0
 	pp.InvulnFrames = cfg.Player.RespawnInvulnFrames
 	pp.LockedStateTimer = 0
+	pp.LastAttacker = 0
 
-	entry := s.world.Entry(entity)
+	entry := r.world.Entry(entity)
 
 	var playerNetID32 uint32
 	if nid := esync.GetNetworkId(entry); nid != nil {
@@ -387,10 +459,10 @@ func (s *Server) respawnPlayer(entity donburi.Entity) {
 		state := netcomponents.NetPlayerState.Get(entry)
 		state.Health = cfg.Player.Health
 		state.StateID = netconfig.Idle
-		state.Lives = s.match.Lives[playerNetID32]
+		state.Lives = r.match.Lives[playerNetID32]
 	}
 
-	s.broadcastEvent(messages.RespawnEvent{
+	r.broadcastEvent(messages.RespawnEvent{
 		PlayerNetworkID: uint(playerNetID32),
 		X:               spawnX,
 		Y:               spawnY,
@@ -398,7 +470,3 @@ func (s *Server) respawnPlayer(entity donburi.Entity) {
 
 	log.Printf("Player networkID=%d respawned at (%.0f, %.0f)", playerNetID32, spawnX, spawnY)
 }
-
-// TODO: Add lag compensation for melee hitbox checks. Melee range is short,
-// so at typical latencies the AABB check is close enough, but for competitive
-// play we may want to rewind target positions by the attacker's RTT/2.
@@ -0,0 +1,293 @@
+package core
+
+import (
+	"log"
+
+	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/enemysim"
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
+	"github.com/automoto/doomerang-mp/shared/netconfig"
+	"github.com/leap-fish/necs/esync"
+	"github.com/solarlune/resolv"
+	"github.com/yohamta/donburi"
+)
+
+// spawnEnemies replaces any enemies left from the last round with the
+// active level's EnemySpawns. Enemies only take part in co-op.
+func (r *Room) spawnEnemies() {
+	r.clearEnemies()
+	if r.match.GameMode != "coop" {
+		return
+	}
+
+	for _, spawn := range r.activeLevel.EnemySpawns {
+		enemy := enemysim.NewEnemy(spawn, r.activeLevel.PatrolPaths)
+		r.activeLevel.Space.Add(enemy.Object)
+
+		entity := r.world.Create(netcomponents.NetEnemy)
+		netcomponents.NetEnemy.SetValue(r.world.Entry(entity), netcomponents.NetEnemyData{
+			X:         enemy.Object.X,
+			Y:         enemy.Object.Y,
+			TypeName:  enemy.TypeName,
+			State:     int(enemy.AnimationState()),
+			Direction: int(enemy.Direction),
+			Health:    enemy.Health,
+		})
+		if err := r.sync.NetworkSync(entity, netcomponents.NetEnemy); err != nil {
+			log.Printf("Failed to setup network sync for enemy: %v", err)
+		}
+		r.enemies[entity] = enemy
+	}
+}
+
+// clearEnemies removes every enemy and thrown knife from the room.
+func (r *Room) clearEnemies() {
+	for entity, enemy := range r.enemies {
+		r.activeLevel.Space.Remove(enemy.Object)
+		r.sync.Remove(entity)
+	}
+	clear(r.enemies)
+	for entity, knife := range r.knives {
+		r.activeLevel.Space.Remove(knife.Object)
+		r.sync.Remove(entity)
+	}
+	clear(r.knives)
+}
+
+// enemiesAlive reports whether any enemy is still standing.
+func (r *Room) enemiesAlive() bool {
+	for _, enemy := range r.enemies {
+		if !enemy.Dead() {
+			return true
+		}
+	}
+	return false
+}
+
+// updateEnemies runs enemy AI and physics and flies thrown knives. Enemies
+// only fight while a round is being played. Called once per server tick,
+// after physics.
+func (r *Room) updateEnemies() {
+	if len(r.enemies) == 0 && len(r.knives) == 0 {
+		return
+	}
+
+	playing := r.match.State == netcomponents.MatchStatePlaying
+	var targets []*resolv.Object
+	if playing {
+		for entity, pp := range r.playerPhysics {
+			if r.world.Valid(entity) && !pp.Dead {
+				targets = append(targets, pp.Object)
+			}
+		}
+	}
+
+	for step := 0; step < r.stepsPerTick(); step++ {
+		for entity, enemy := range r.enemies {
+			wasDead := enemy.Dead()
+			target := enemysim.NearestTarget(enemy.Object, targets)
+			events := enemy.Step(target)
+			if events.Has(enemysim.EventPunch) {
+				r.broadcastEvent(messages.MeleeAttackEvent{
+					AttackerNetworkID: r.networkID(entity),
+					IsPunch:           true,
+				})
+			}
+			if events.Has(enemysim.EventThrow) && target != nil {
+				r.throwKnife(enemy, target)
+			}
+			if playing {
+				r.checkEnemyPunch(entity, enemy)
+			}
+			if !wasDead && enemy.Dead() {
+				// Fell into a dead zone
+				r.broadcastEvent(messages.DeathEvent{VictimID: r.networkID(entity), Cause: "dead_zone"})
+				r.match.checkRoundEndCondition()
+			}
+		}
+		r.stepKnives(playing)
+	}
+
+	for entity, enemy := range r.enemies {
+		if enemy.Dead() && enemy.DeathTimer == 0 {
+			r.activeLevel.Space.Remove(enemy.Object)
+			r.sync.Remove(entity)
+			delete(r.enemies, entity)
+			continue
+		}
+		ne := netcomponents.NetEnemy.Get(r.world.Entry(entity))
+		ne.X = enemy.Object.X
+		ne.Y = enemy.Object.Y
+		ne.VelX = enemy.Body.SpeedX
+		ne.VelY = enemy.Body.SpeedY
+		ne.State = int(enemy.AnimationState())
+		ne.Direction = int(enemy.Direction)
+		ne.Health = enemy.Health
+	}
+	for entity, knife := range r.knives {
+		nk := netcomponents.NetKnife.Get(r.world.Entry(entity))
+		nk.X = knife.Object.X
+		nk.Y = knife.Object.Y
+		nk.VelX = knife.SpeedX
+		nk.VelY = knife.SpeedY
+	}
+}
+
+// checkEnemyPunch hits players in an attacking enemy's punch hitbox —
+// mirrors the offline enemy hitbox against the player.
+func (r *Room) checkEnemyPunch(entity donburi.Entity, enemy *enemysim.Enemy) {
+	hitX, hitY, hitW, hitH, ok := enemy.PunchHitbox()
+	if !ok {
+		return
+	}
+	for targetEntity, targetPP := range r.playerPhysics {
+		if !r.world.Valid(targetEntity) || targetPP.Dead || targetPP.InvulnFrames > 0 {
+			continue
+		}
+		if !currentHurtbox(targetPP).overlaps(hitX, hitY, hitW, hitH) {
+			continue
+		}
+		knockX := enemy.KnockbackX(targetPP.Object.X+targetPP.Object.W/2, cfg.Combat.PlayerPunchKnockback)
+		damage := cfg.Combat.PlayerPunchDamage
+		if r.hurtPlayerByEnemy(targetEntity, targetPP, damage, knockX) {
+			r.broadcastEvent(messages.MeleeHitEvent{
+				AttackerNetworkID: r.networkID(entity),
+				TargetNetworkID:   r.networkID(targetEntity),
+				HitX:              hitX + hitW/2,
+				HitY:              hitY + hitH/2,
+				Damage:            damage,
+				KnockbackX:        knockX,
+				KnockbackY:        cfg.Combat.KnockbackUpwardForce,
+			})
+			r.checkEnemyKill(targetEntity, targetPP, "enemy")
+		}
+	}
+}
+
+// throwKnife spawns a knife thrown by enemy at the center of target.
+func (r *Room) throwKnife(enemy *enemysim.Enemy, target *resolv.Object) {
+	knife := enemysim.NewKnife(enemy.Object, target.X+target.W/2, target.Y+target.H/2)
+	r.activeLevel.Space.Add(knife.Object)
+
+	entity := r.world.Create(netcomponents.NetKnife)
+	netcomponents.NetKnife.SetValue(r.world.Entry(entity), netcomponents.NetKnifeData{
+		X:    knife.Object.X,
+		Y:    knife.Object.Y,
+		VelX: knife.SpeedX,
+		VelY: knife.SpeedY,
+	})
+	if err := r.sync.NetworkSync(entity, netcomponents.NetKnife); err != nil {
+		log.Printf("Failed to setup network sync for knife: %v", err)
+	}
+	r.knives[entity] = knife
+}
+
+// stepKnives moves every knife by one step, removing knives that hit a
+// wall or a player. A knife breaks on any player, but only hurts one who
+// is not invulnerable — mirrors the offline UpdateKnives.
+func (r *Room) stepKnives(playing bool) {
+	mapW, mapH := float64(r.activeLevel.MapWidth), float64(r.activeLevel.MapHeight)
+	for entity, knife := range r.knives {
+		alive := knife.Step(mapW, mapH)
+		if alive && playing {
+			alive = !r.checkKnifeHit(knife)
+		}
+		if !alive {
+			r.activeLevel.Space.Remove(knife.Object)
+			r.sync.Remove(entity)
+			delete(r.knives, entity)
+		}
+	}
+}
+
+// checkKnifeHit reports whether knife touched a player, hurting them if
+// they are not invulnerable.
+func (r *Room) checkKnifeHit(knife *enemysim.Knife) bool {
+	obj := knife.Object
+	for targetEntity, targetPP := range r.playerPhysics {
+		if !r.world.Valid(targetEntity) || targetPP.Dead {
+			continue
+		}
+		if !currentHurtbox(targetPP).overlaps(obj.X, obj.Y, obj.W, obj.H) {
+			continue
+		}
+		knockX := knife.KnockbackX()
+		if r.hurtPlayerByEnemy(targetEntity, targetPP, knife.Damage, knockX) {
+			r.broadcastEvent(messages.HazardHitEvent{
+				TargetNetworkID: r.networkID(targetEntity),
+				Hazard:          "knife",
+				Damage:          knife.Damage,
+				KnockbackX:      knockX,
+				KnockbackY:      cfg.Combat.KnockbackUpwardForce,
+			})
+			r.checkEnemyKill(targetEntity, targetPP, "knife")
+		}
+		return true
+	}
+	return false
+}
+
+// hurtPlayerByEnemy damages and knocks back a player hit by an enemy or
+// its knife. It returns false, leaving the player untouched, while they
+// are invulnerable.
+func (r *Room) hurtPlayerByEnemy(entity donburi.Entity, pp *PlayerPhysics, damage int, knockX float64) bool {
+	if pp.InvulnFrames > 0 {
+		return false
+	}
+	pp.LockedStateTimer = 10
+	pp.InvulnFrames = cfg.Combat.PlayerInvulnFrames
+	r.match.recordDamage(0, uint32(r.networkID(entity)), damage)
+
+	entry := r.world.Entry(entity)
+	if entry.HasComponent(netcomponents.NetPlayerState) {
+		state := netcomponents.NetPlayerState.Get(entry)
+		state.Health = max(state.Health-damage, 0)
+		state.StateID = netconfig.Hit
+	}
+	if entry.HasComponent(netcomponents.NetVelocity) {
+		vel := netcomponents.NetVelocity.Get(entry)
+		vel.SpeedX = knockX
+		vel.SpeedY = cfg.Combat.KnockbackUpwardForce
+	}
+	return true
+}
+
+// checkEnemyKill knocks out a player an enemy hit down to no health with
+// cause. No player is credited.
+func (r *Room) checkEnemyKill(entity donburi.Entity, pp *PlayerPhysics, cause string) {
+	entry := r.world.Entry(entity)
+	if entry.HasComponent(netcomponents.NetPlayerState) && netcomponents.NetPlayerState.Get(entry).Health <= 0 {
+		r.handlePlayerDeath(entity, pp, 0, cause)
+	}
+}
+
+// hitEnemy damages enemy on behalf of the player attackerNetID, crediting
+// them with a KO if it goes down to cause.
+func (r *Room) hitEnemy(entity donburi.Entity, enemy *enemysim.Enemy, attackerNetID uint, cause string, damage int, knockX, knockY float64, invulnFrames int) {
+	enemy.TakeHit(damage, knockX, knockY, invulnFrames)
+	r.match.recordDamage(uint32(attackerNetID), 0, damage)
+	if !enemy.Dead() {
+		return
+	}
+	r.broadcastEvent(messages.DeathEvent{
+		VictimID: r.networkID(entity),
+		KillerID: attackerNetID,
+		Cause:    cause,
+	})
+	if attackerNetID != 0 {
+		r.match.AddKO(uint32(attackerNetID))
+	}
+	r.match.checkRoundEndCondition()
+}
+
+// networkID returns entity's network ID, or 0 if it is not synced.
+func (r *Room) networkID(entity donburi.Entity) uint {
+	if !r.world.Valid(entity) {
+		return 0
+	}
+	if nid := esync.GetNetworkId(r.world.Entry(entity)); nid != nil {
+		return uint(*nid)
+	}
+	return 0
+}
@@ -0,0 +1,162 @@
+package core
+
+import (
+	"testing"
+
+	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/enemysim"
+	"github.com/automoto/doomerang-mp/shared/leveldata"
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
+	"github.com/automoto/doomerang-mp/shared/netconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// enemyTestLevel is a floor with an enemy of enemyType standing on it at
+// x=200.
+func enemyTestLevel(enemyType string) *leveldata.CollisionData {
+	_, typeCfg := enemysim.Config(enemyType)
+	return &leveldata.CollisionData{
+		MapWidth:    640,
+		MapHeight:   480,
+		SolidRects:  []leveldata.SolidRect{{X: 0, Y: 416, W: 640, H: 16}},
+		EnemySpawns: []leveldata.EnemySpawn{{X: 200, Y: 416 - float64(typeCfg.CollisionHeight), EnemyType: enemyType}},
+		PatrolPaths: map[string][]leveldata.Point{},
+	}
+}
+
+func newEnemyTestRoom(t *testing.T, enemyType string) *Room {
+	t.Helper()
+	r := newIdleTestRoom(t, newRoomTestServer(t, enemyTestLevel(enemyType)))
+	r.match.GameMode = "coop"
+	r.match.State = netcomponents.MatchStatePlaying
+	r.spawnEnemies()
+	return r
+}
+
+func TestRoom_spawnEnemies(t *testing.T) {
+	r := newEnemyTestRoom(t, "Guard")
+	require.Len(t, r.enemies, 1)
+	for entity, enemy := range r.enemies {
+		ne := netcomponents.NetEnemy.Get(r.world.Entry(entity))
+		assert.Equal(t, "Guard", ne.TypeName)
+		assert.Equal(t, enemy.Health, ne.Health)
+		assert.Equal(t, 200.0, ne.X)
+		assert.NotZero(t, r.networkID(entity))
+	}
+
+	// Not outside co-op
+	r.match.GameMode = "ffa"
+	r.spawnEnemies()
+	assert.Empty(t, r.enemies)
+}
+
+func TestRoom_updateEnemies_guard_punches_player(t *testing.T) {
+	r := newEnemyTestRoom(t, "Guard")
+	entity, _ := addTestPlayer(t, r, 240, 376)
+	entry := r.world.Entry(entity)
+	state := netcomponents.NetPlayerState.Get(entry)
+	state.Health = cfg.Player.Health
+	pp := r.playerPhysics[entity]
+
+	for range 120 {
+		r.updateEnemies()
+		if state.Health < cfg.Player.Health {
+			break
+		}
+	}
+
+	assert.Equal(t, cfg.Player.Health-cfg.Combat.PlayerPunchDamage, state.Health)
+	assert.Equal(t, netconfig.Hit, state.StateID)
+	assert.Equal(t, cfg.Combat.PlayerInvulnFrames, pp.InvulnFrames)
+	// Knocked away from the enemy
+	assert.Equal(t, cfg.Combat.PlayerPunchKnockback, netcomponents.NetVelocity.Get(entry).SpeedX)
+}
+
+func TestRoom_updateEnemies_thrower_knife_hits_player(t *testing.T) {
+	r := newEnemyTestRoom(t, "KnifeThrower")
+	entity, _ := addTestPlayer(t, r, 360, 376)
+	state := netcomponents.NetPlayerState.Get(r.world.Entry(entity))
+	state.Health = cfg.Player.Health
+
+	var sawKnife bool
+	for range 120 {
+		r.updateEnemies()
+		sawKnife = sawKnife || len(r.knives) > 0
+		if state.Health < cfg.Player.Health {
+			break
+		}
+	}
+
+	assert.True(t, sawKnife)
+	assert.Equal(t, cfg.Player.Health-cfg.Knife.Damage, state.Health)
+	assert.Empty(t, r.knives)
+}
+
+func TestRoom_updateEnemies_idle_outside_play(t *testing.T) {
+	r := newEnemyTestRoom(t, "Guard")
+	r.match.State = netcomponents.MatchStateCountdown
+	entity, _ := addTestPlayer(t, r, 240, 376)
+	state := netcomponents.NetPlayerState.Get(r.world.Entry(entity))
+	state.Health = cfg.Player.Health
+
+	for range 60 {
+		r.updateEnemies()
+	}
+
+	assert.Equal(t, cfg.Player.Health, state.Health)
+	for _, enemy := range r.enemies {
+		assert.Equal(t, cfg.StatePatrol, enemy.State)
+	}
+}
+
+func TestRoom_hitEnemy_credits_ko_and_ends_round(t *testing.T) {
+	r := newEnemyTestRoom(t, "Guard")
+	_, netID := addTestPlayer(t, r, 40, 376)
+	r.match.Slots[0] = messages.LobbySlot{Type: 1, PlayerID: netID}
+
+	for entity, enemy := range r.enemies {
+		r.hitEnemy(entity, enemy, uint(netID), "punch", enemy.Health, 0, 0, 0)
+	}
+
+	assert.Equal(t, 1, r.match.Scores[netID])
+	assert.Equal(t, netcomponents.MatchStateRoundEnd, r.match.State)
+
+	// The body lingers for its death animation, then goes
+	require.Len(t, r.enemies, 1)
+	for range 60 {
+		r.updateEnemies()
+	}
+	assert.Empty(t, r.enemies)
+}
+
+func TestRoom_checkBoomerangCollisions_hits_enemy(t *testing.T) {
+	r := newEnemyTestRoom(t, "Guard")
+	owner, netID := addTestPlayer(t, r, 40, 376)
+
+	var enemyHealth int
+	for _, enemy := range r.enemies {
+		bp := newBoomerangPhysics(r.activeLevel, enemy.Object.X, enemy.Object.Y+10, owner, uint(netID))
+		bp.Damage = 5
+		bp.MaxRange = 200
+		bp.PierceDistance = 20
+		bp.DistanceTraveled = 50
+		r.checkBoomerangCollisions(r.world.Create(netcomponents.NetBoomerang), bp)
+		r.checkBoomerangCollisions(r.world.Create(netcomponents.NetBoomerang), bp)
+
+		enemyHealth = enemy.Health
+		assert.Equal(t, 70.0, bp.MaxRange)
+		assert.Equal(t, cfg.Combat.EnemyInvulnFrames/2, enemy.InvulnFrames)
+	}
+	// Once per throw
+	assert.Equal(t, cfg.Enemy.Types["Guard"].Health-5, enemyHealth)
+}
+
+func TestRoom_setActiveLevel_clears_enemies(t *testing.T) {
+	r := newEnemyTestRoom(t, "Guard")
+	require.Len(t, r.enemies, 1)
+
+	r.setActiveLevel("empty", NewServerLevel(emptyTestLevel()))
+	assert.Empty(t, r.enemies)
+}
@@ -0,0 +1,108 @@
+package core
+
+import (
+	"log"
+
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/leap-fish/necs/router"
+)
+
+// eventLogSize is how many recent events a room keeps to replay to
+// clients that missed some, several seconds of the busiest fights.
+const eventLogSize = 256
+
+// eventLog is a room's ordered stream of broadcast events. Each event gets
+// the next Seq, and the last eventLogSize are kept as serialized
+// GameEvents to replay. Guarded by Room.eventMu.
+type eventLog struct {
+	seq    uint32
+	events [eventLogSize][]byte // By Seq % eventLogSize
+}
+
+// add stamps payload with the next Seq and returns the GameEvent to send.
+func (l *eventLog) add(payload []byte) ([]byte, error) {
+	evt, err := router.Serialize(messages.GameEvent{Seq: l.seq + 1, Payload: payload})
+	if err != nil {
+		return nil, err
+	}
+	l.seq++
+	l.events[l.seq%eventLogSize] = evt
+	return evt, nil
+}
+
+// since returns the logged GameEvents after Seq after, oldest first. It
+// returns false if some of them have aged out of the log.
+func (l *eventLog) since(after uint32) ([][]byte, bool) {
+	if after > l.seq || l.seq-after > eventLogSize {
+		return nil, false
+	}
+	missed := make([][]byte, 0, l.seq-after)
+	for seq := after + 1; seq <= l.seq; seq++ {
+		missed = append(missed, l.events[seq%eventLogSize])
+	}
+	return missed, true
+}
+
+// broadcastEvent sends msg to all clients in the room, spectators
+// included, as the next event in the room's event stream.
+func (r *Room) broadcastEvent(msg any) {
+	payload, err := router.Serialize(msg)
+	if err != nil {
+		log.Printf("Failed to serialize %T event: %v", msg, err)
+		return
+	}
+
+	// Stamping and sending under eventMu keeps every client's stream in
+	// Seq order.
+	r.eventMu.Lock()
+	defer r.eventMu.Unlock()
+	evt, err := r.events.add(payload)
+	if err != nil {
+		log.Printf("Failed to serialize %T event: %v", msg, err)
+		return
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for client := range r.clientNetworkIDs {
+		_ = r.server.sendBytes(client, "GameEvent", evt)
+	}
+}
+
+// lastEventSeq returns the Seq of the room's latest event. A joining
+// client takes it before being bound, so its stream starts right after.
+func (r *Room) lastEventSeq() uint32 {
+	r.eventMu.Lock()
+	defer r.eventMu.Unlock()
+	return r.events.seq
+}
+
+// onEventResync answers a client that missed events after req.After.
+func (r *Room) onEventResync(client *router.NetworkClient, req messages.EventResyncRequest) {
+	r.cmdCh <- func() {
+		r.resyncEvents(client, req.After)
+	}
+}
+
+// resyncEvents replays the events after Seq after to client, or sends it a
+// SyncGameState once they have aged out of the log. Must be called on the
+// game loop goroutine.
+func (r *Room) resyncEvents(client *router.NetworkClient, after uint32) {
+	r.mu.RLock()
+	_, bound := r.clientNetworkIDs[client]
+	r.mu.RUnlock()
+	if !bound {
+		return
+	}
+
+	r.eventMu.Lock()
+	defer r.eventMu.Unlock()
+	if missed, ok := r.events.since(after); ok {
+		for _, evt := range missed {
+			_ = r.server.sendBytes(client, "GameEvent", evt)
+		}
+		return
+	}
+	log.Printf("Client %s missed events after seq %d in room %s; sending game state", client.Id(), after, r.code)
+	_ = r.server.send(client, r.match.resyncState(r.events.seq))
+}
@@ -0,0 +1,134 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/coder/websocket"
+	"github.com/leap-fish/necs/router"
+	"github.com/leap-fish/necs/typeid"
+	"github.com/leap-fish/necs/typemapper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRecordingTestClient returns a NetworkClient over a real WebSocket
+// whose peer decodes the GameEvents, SyncGameStates, ClockSyncResponses,
+// JoinRejecteds and ServerRedirects it reads onto the returned channel,
+// closing the channel once the connection closes.
+func newRecordingTestClient(t *testing.T) (*router.NetworkClient, <-chan any) {
+	t.Helper()
+	mapper := typemapper.NewMapper(map[uint]any{})
+	for _, msg := range []any{messages.GameEvent{}, messages.SyncGameState{}, messages.ClockSyncResponse{}, messages.JoinRejected{}, messages.ServerRedirect{}} {
+		require.NoError(t, mapper.RegisterType(typeid.GetTypeId(reflect.TypeOf(msg)), reflect.TypeOf(msg)))
+	}
+
+	received := make(chan any, 64)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		conn, err := websocket.Accept(w, req, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.CloseNow() }()
+		defer close(received)
+		for {
+			_, data, err := conn.Read(context.Background())
+			if err != nil {
+				return
+			}
+			if msg, err := mapper.Deserialize(data); err == nil {
+				received <- msg
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	conn, _, err := websocket.Dial(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.CloseNow() })
+	return router.NewNetworkClient(context.Background(), conn), received
+}
+
+func nextMessage(t *testing.T, received <-chan any) any {
+	t.Helper()
+	select {
+	case msg := <-received:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+		return nil
+	}
+}
+
+func TestEventLog_since(t *testing.T) {
+	var l eventLog
+	for range eventLogSize + 44 {
+		_, err := l.add([]byte{1})
+		require.NoError(t, err)
+	}
+
+	missed, ok := l.since(l.seq - 10)
+	assert.True(t, ok)
+	assert.Len(t, missed, 10)
+
+	missed, ok = l.since(l.seq)
+	assert.True(t, ok, "nothing missed")
+	assert.Empty(t, missed)
+
+	_, ok = l.since(l.seq - eventLogSize)
+	assert.True(t, ok, "the whole log can be replayed")
+	_, ok = l.since(l.seq - eventLogSize - 1)
+	assert.False(t, ok, "aged out")
+	_, ok = l.since(l.seq + 1)
+	assert.False(t, ok, "from the future")
+}
+
+func TestRoom_resyncEvents_replays_missed_events(t *testing.T) {
+	r := newIdleTestRoom(t, newRoomTestServer(t, emptyTestLevel()))
+	for i := range 3 {
+		r.broadcastEvent(messages.ScoreEvent{PlayerID: 7, KOs: i + 1})
+	}
+
+	client, received := newRecordingTestClient(t)
+	r.clientNetworkIDs[client] = 7
+	r.resyncEvents(client, 1)
+
+	for _, seq := range []uint32{2, 3} {
+		evt, ok := nextMessage(t, received).(messages.GameEvent)
+		require.True(t, ok)
+		assert.Equal(t, seq, evt.Seq)
+	}
+}
+
+func TestRoom_resyncEvents_sends_state_once_events_age_out(t *testing.T) {
+	r := newIdleTestRoom(t, newRoomTestServer(t, emptyTestLevel()))
+	for range eventLogSize + 10 {
+		r.broadcastEvent(messages.ScoreEvent{PlayerID: 7, KOs: 1})
+	}
+	r.match.Scores[7] = 1
+
+	client, received := newRecordingTestClient(t)
+	r.clientNetworkIDs[client] = 7
+	r.resyncEvents(client, 1)
+
+	sync, ok := nextMessage(t, received).(messages.SyncGameState)
+	require.True(t, ok)
+	assert.Equal(t, uint32(eventLogSize+10), sync.EventSeq)
+	assert.Equal(t, 1, sync.State.Scores[7])
+	assert.Equal(t, r.match.GameMode, sync.Lobby.GameMode)
+	assert.Nil(t, sync.Stats, "no finished match")
+}
+
+func TestRoom_joinAccepted_starts_stream_at_latest_event(t *testing.T) {
+	r := newIdleTestRoom(t, newRoomTestServer(t, emptyTestLevel()))
+	r.broadcastEvent(messages.ScoreEvent{PlayerID: 7, KOs: 1})
+	r.broadcastEvent(messages.ScoreEvent{PlayerID: 7, KOs: 2})
+
+	assert.Equal(t, uint32(2), r.joinAccepted(7, "token").EventSeq)
+}
@@ -0,0 +1,133 @@
+package core
+
+import (
+	"log"
+
+	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/hazards"
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
+	"github.com/automoto/doomerang-mp/shared/netconfig"
+	"github.com/leap-fish/necs/esync"
+	"github.com/yohamta/donburi"
+)
+
+// setActiveLevel switches the room to lvl, replacing the previous level's
+// synced fire entities with lvl's and dropping its enemies. Must be called
+// on the game loop goroutine or before the loop starts.
+func (r *Room) setActiveLevel(name string, lvl *ServerLevel) {
+	r.clearEnemies()
+	for _, entity := range r.fireEntities {
+		r.sync.Remove(entity)
+	}
+	r.fireEntities = r.fireEntities[:0]
+
+	r.activeLevel = lvl
+	r.activeName = name
+
+	for _, fire := range lvl.Fires {
+		entity := r.world.Create(netcomponents.NetFire)
+		netcomponents.NetFire.SetValue(r.world.Entry(entity), netcomponents.NetFireData{
+			X:     fire.Spawn.X,
+			Y:     fire.Spawn.Y,
+			Frame: fire.Frame(),
+		})
+		if err := r.sync.NetworkSync(entity, netcomponents.NetFire); err != nil {
+			log.Printf("Failed to setup network sync for fire: %v", err)
+		}
+		r.fireEntities = append(r.fireEntities, entity)
+	}
+}
+
+// updateHazards animates the active level's fires and hurts players
+// touching fire or a dead zone. Called once per server tick, after physics.
+func (r *Room) updateHazards() {
+	// Fires keep burning between rounds so clients never see them jump
+	for step := 0; step < r.stepsPerTick(); step++ {
+		for _, fire := range r.activeLevel.Fires {
+			fire.Step()
+		}
+	}
+	for i, fire := range r.activeLevel.Fires {
+		netcomponents.NetFire.Get(r.world.Entry(r.fireEntities[i])).Frame = fire.Frame()
+	}
+
+	if r.match.State != netcomponents.MatchStatePlaying {
+		return
+	}
+
+	for entity, pp := range r.playerPhysics {
+		if !r.world.Valid(entity) || pp.Dead {
+			continue
+		}
+		if hazards.InDeadZone(pp.Object) {
+			r.applyDeadZone(entity, pp)
+			continue
+		}
+		if fire := hazards.TouchingFire(pp.Object); fire != nil {
+			r.applyFireHit(entity, pp, fire)
+		}
+	}
+}
+
+// applyDeadZone knocks out a player who fell into a dead zone, crediting
+// whoever hit them last.
+func (r *Room) applyDeadZone(entity donburi.Entity, pp *PlayerPhysics) {
+	entry := r.world.Entry(entity)
+	if entry.HasComponent(netcomponents.NetPlayerState) {
+		netcomponents.NetPlayerState.Get(entry).Health = 0
+	}
+	if pp.LastAttacker == 0 {
+		r.match.recordSelfKO(uint32(r.networkID(entity)))
+	}
+	r.handlePlayerDeath(entity, pp, pp.LastAttacker, "dead_zone")
+}
+
+// applyFireHit knocks a player away from fire and, unless they are
+// invulnerable, burns them — mirrors the offline UpdateFire. A fire death
+// is credited to whoever hit them last.
+func (r *Room) applyFireHit(entity donburi.Entity, pp *PlayerPhysics, fire *hazards.Fire) {
+	entry := r.world.Entry(entity)
+
+	// Always apply knockback to prevent walking through fire
+	knockX := fire.KnockbackX(pp.Object)
+	knockY := cfg.Combat.KnockbackUpwardForce
+	if entry.HasComponent(netcomponents.NetVelocity) {
+		vel := netcomponents.NetVelocity.Get(entry)
+		vel.SpeedX = knockX
+		vel.SpeedY = knockY
+	}
+
+	if pp.InvulnFrames > 0 {
+		return
+	}
+	pp.LockedStateTimer = 10
+	pp.InvulnFrames = cfg.Combat.PlayerInvulnFrames
+
+	var state *netcomponents.NetPlayerStateData
+	if entry.HasComponent(netcomponents.NetPlayerState) {
+		state = netcomponents.NetPlayerState.Get(entry)
+		state.Health = max(state.Health-fire.Damage, 0)
+		state.StateID = netconfig.Hit
+	}
+
+	var targetNetID uint
+	if nid := esync.GetNetworkId(entry); nid != nil {
+		targetNetID = uint(*nid)
+	}
+	r.match.recordDamage(0, uint32(targetNetID), fire.Damage)
+	r.broadcastEvent(messages.HazardHitEvent{
+		TargetNetworkID: targetNetID,
+		Hazard:          "fire",
+		Damage:          fire.Damage,
+		KnockbackX:      knockX,
+		KnockbackY:      knockY,
+	})
+
+	if state != nil && state.Health <= 0 {
+		if pp.LastAttacker == 0 {
+			r.match.recordSelfKO(uint32(targetNetID))
+		}
+		r.handlePlayerDeath(entity, pp, pp.LastAttacker, "fire")
+	}
+}
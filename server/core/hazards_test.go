@@ -0,0 +1,121 @@
+package core
+
+import (
+	"testing"
+
+	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/leveldata"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
+	"github.com/automoto/doomerang-mp/shared/netconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hazardTestLevel is a floor with continuous fire rising from it at
+// x=300 and a dead zone below it.
+func hazardTestLevel() *leveldata.CollisionData {
+	return &leveldata.CollisionData{
+		MapWidth:   640,
+		MapHeight:  480,
+		SolidRects: []leveldata.SolidRect{{X: 0, Y: 416, W: 640, H: 16}},
+		DeadZones:  []leveldata.Rect{{X: 0, Y: 448, W: 640, H: 32}},
+		Fires:      []leveldata.FireSpawn{{X: 300, Y: 416, FireType: "fire_continuous", Direction: "up"}},
+	}
+}
+
+func newHazardTestRoom(t *testing.T) *Room {
+	t.Helper()
+	r := newIdleTestRoom(t, newRoomTestServer(t, hazardTestLevel()))
+	r.match.State = netcomponents.MatchStatePlaying
+	return r
+}
+
+func TestRoom_updateHazards_dead_zone_knocks_out(t *testing.T) {
+	tests := []struct {
+		name         string
+		lastAttacker bool
+		wantKO       bool
+	}{
+		{name: "credits_last_attacker", lastAttacker: true, wantKO: true},
+		{name: "no_credit_without_attacker", lastAttacker: false, wantKO: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newHazardTestRoom(t)
+			_, attackerNetID := addTestPlayer(t, r, 40, 376)
+			victim, victimNetID := addTestPlayer(t, r, 100, 450)
+			r.match.Lives[victimNetID] = 3
+			pp := r.playerPhysics[victim]
+			if tt.lastAttacker {
+				pp.LastAttacker = uint(attackerNetID)
+			}
+
+			r.updateHazards()
+
+			assert.True(t, pp.Dead)
+			state := netcomponents.NetPlayerState.Get(r.world.Entry(victim))
+			assert.Equal(t, 0, state.Health)
+			assert.Equal(t, netconfig.Die, state.StateID)
+			assert.Equal(t, 1, r.match.Deaths[victimNetID])
+			if tt.wantKO {
+				assert.Equal(t, 1, r.match.Scores[attackerNetID])
+			} else {
+				assert.Zero(t, r.match.Scores[attackerNetID])
+			}
+		})
+	}
+}
+
+func TestRoom_updateHazards_fire_burns_and_knocks_back(t *testing.T) {
+	r := newHazardTestRoom(t)
+	entity, _ := addTestPlayer(t, r, 280, 376) // Left of the fire's center
+	entry := r.world.Entry(entity)
+	pp := r.playerPhysics[entity]
+	state := netcomponents.NetPlayerState.Get(entry)
+	state.Health = cfg.Player.Health
+	fireCfg := cfg.Fire.Types["fire_continuous"]
+
+	r.updateHazards()
+
+	vel := netcomponents.NetVelocity.Get(entry)
+	assert.Equal(t, -fireCfg.KnockbackForce, vel.SpeedX)
+	assert.Equal(t, cfg.Combat.KnockbackUpwardForce, vel.SpeedY)
+	assert.Equal(t, cfg.Player.Health-fireCfg.Damage, state.Health)
+	assert.Equal(t, netconfig.Hit, state.StateID)
+	assert.Equal(t, cfg.Combat.PlayerInvulnFrames, pp.InvulnFrames)
+
+	// Invulnerable: still pushed away, not burned again
+	vel.SpeedX, vel.SpeedY = 0, 0
+	r.updateHazards()
+	assert.Equal(t, -fireCfg.KnockbackForce, vel.SpeedX)
+	assert.Equal(t, cfg.Player.Health-fireCfg.Damage, state.Health)
+}
+
+func TestRoom_updateHazards_ignores_players_outside_play(t *testing.T) {
+	r := newHazardTestRoom(t)
+	r.match.State = netcomponents.MatchStateCountdown
+	entity, _ := addTestPlayer(t, r, 100, 450)
+
+	r.updateHazards()
+
+	assert.False(t, r.playerPhysics[entity].Dead)
+}
+
+func TestRoom_setActiveLevel_syncs_fires(t *testing.T) {
+	r := newHazardTestRoom(t)
+	require.Len(t, r.fireEntities, 1)
+	fireEntry := r.world.Entry(r.fireEntities[0])
+	assert.Equal(t, netcomponents.NetFireData{X: 300, Y: 416}, *netcomponents.NetFire.Get(fireEntry))
+
+	// Animation frames last Speed+1 steps, the same as on clients
+	def := cfg.CharacterAnimations["obstacle"][cfg.FireContinuous]
+	for range int(def.Speed) + 1 {
+		r.updateHazards()
+	}
+	assert.Equal(t, 1, netcomponents.NetFire.Get(fireEntry).Frame)
+
+	fire := r.fireEntities[0]
+	r.setActiveLevel("empty", NewServerLevel(emptyTestLevel()))
+	assert.Empty(t, r.fireEntities)
+	assert.False(t, r.world.Valid(fire))
+}
@@ -0,0 +1,127 @@
+package core
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	// tickStallTimeout is how long a room's game loop may go without
+	// ticking before /healthz reports the server stalled.
+	tickStallTimeout = time.Second
+
+	listenPollInterval = 50 * time.Millisecond
+)
+
+// HealthStatus is the body of a /healthz response.
+type HealthStatus struct {
+	Status string `json:"status"` // "ok" or "stalled"
+	// LastTick is the oldest of the rooms' latest ticks; zero before any
+	// room has ticked.
+	LastTick time.Time `json:"lastTick"`
+}
+
+// ReadyStatus is the body of a /readyz response. Ready is true only when
+// every check passes.
+type ReadyStatus struct {
+	Ready         bool `json:"ready"`
+	LevelsLoaded  bool `json:"levelsLoaded"`
+	Listening     bool `json:"listening"`
+	Draining      bool `json:"draining"`
+	RoomAvailable bool `json:"roomAvailable"`
+}
+
+// HealthHandler returns an http.Handler serving GET /healthz, which
+// fails once a room's game loop stops ticking, and GET /readyz, which
+// fails while the server cannot take new players. They are for probes in
+// deployments without Agones, and need no authentication.
+func (s *Server) HealthHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		health := s.health()
+		status := http.StatusOK
+		if health.Status != "ok" {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, health)
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, _ *http.Request) {
+		ready := s.readiness()
+		status := http.StatusOK
+		if !ready.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, ready)
+	})
+	return mux
+}
+
+// health reports the server stalled until a room has ticked, and once
+// any room that has ticked stops for tickStallTimeout. Rooms that have
+// not ticked yet are starting up and are skipped.
+func (s *Server) health() HealthStatus {
+	var oldest int64
+	for _, r := range s.roomList() {
+		if last := r.lastTick.Load(); last != 0 && (oldest == 0 || last < oldest) {
+			oldest = last
+		}
+	}
+	if oldest == 0 {
+		return HealthStatus{Status: "stalled"}
+	}
+	health := HealthStatus{Status: "ok", LastTick: time.Unix(0, oldest).UTC()}
+	if time.Since(health.LastTick) > tickStallTimeout {
+		health.Status = "stalled"
+	}
+	return health
+}
+
+func (s *Server) readiness() ReadyStatus {
+	ready := ReadyStatus{
+		LevelsLoaded:  len(s.levelNames) > 0,
+		Listening:     s.listening.Load(),
+		Draining:      s.draining.Load(),
+		RoomAvailable: s.roomAvailable(),
+	}
+	ready.Ready = ready.LevelsLoaded && ready.Listening && !ready.Draining && ready.RoomAvailable
+	return ready
+}
+
+// roomAvailable reports whether a JoinRequest naming no room would find
+// a seat, in an existing public room or a new one.
+func (s *Server) roomAvailable() bool {
+	for _, r := range s.roomList() {
+		if r.public && r.hasSeat() {
+			return true
+		}
+	}
+	return s.RoomCount() < s.maxRoomCount()
+}
+
+func (s *Server) maxRoomCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.maxRooms
+}
+
+// watchListening sets s.listening once port accepts connections, which
+// the transport does not report, or gives up when done closes.
+func (s *Server) watchListening(port uint, done <-chan struct{}) {
+	addr := fmt.Sprintf("localhost:%d", port)
+	poll := time.NewTicker(listenPollInterval)
+	defer poll.Stop()
+	for {
+		if conn, err := net.DialTimeout("tcp", addr, listenPollInterval); err == nil {
+			_ = conn.Close()
+			s.listening.Store(true)
+			return
+		}
+		select {
+		case <-done:
+			return
+		case <-poll.C:
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func probe[T any](t *testing.T, s *Server, path string) (int, T) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	var body T
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	return rec.Code, body
+}
+
+func TestServer_healthz(t *testing.T) {
+	s := newRoomTestServer(t, emptyTestLevel())
+
+	code, health := probe[HealthStatus](t, s, "/healthz")
+	assert.Equal(t, http.StatusServiceUnavailable, code, "no room has ticked")
+	assert.Equal(t, "stalled", health.Status)
+
+	ticked := time.Now().Add(-100 * time.Millisecond)
+	s.defaultRoom.lastTick.Store(ticked.UnixNano())
+	newIdleTestRoom(t, s) // Not ticking yet; skipped
+	code, health = probe[HealthStatus](t, s, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", health.Status)
+	assert.True(t, health.LastTick.Equal(ticked))
+
+	s.defaultRoom.lastTick.Store(time.Now().Add(-2 * tickStallTimeout).UnixNano())
+	code, health = probe[HealthStatus](t, s, "/healthz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "stalled", health.Status)
+}
+
+func TestServer_readyz(t *testing.T) {
+	s := newRoomTestServer(t, emptyTestLevel())
+
+	code, ready := probe[ReadyStatus](t, s, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, ReadyStatus{LevelsLoaded: true, RoomAvailable: true}, ready, "transport not listening")
+
+	s.listening.Store(true)
+	code, ready = probe[ReadyStatus](t, s, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, ready.Ready)
+
+	s.SetMaxRooms(1)
+	s.defaultRoom.maxPlayers = 0
+	code, ready = probe[ReadyStatus](t, s, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.False(t, ready.RoomAvailable)
+
+	s.defaultRoom.maxPlayers = 4
+	s.Drain()
+	code, ready = probe[ReadyStatus](t, s, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.True(t, ready.Draining)
+}
+
+func TestServer_watchListening(t *testing.T) {
+	s := newRoomTestServer(t, emptyTestLevel())
+	ln, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	done := make(chan struct{})
+	defer close(done)
+	go s.watchListening(uint(ln.Addr().(*net.TCPAddr).Port), done) //nolint:gosec // A TCP port fits in uint
+
+	assert.Eventually(t, s.listening.Load, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
+	"github.com/automoto/doomerang-mp/shared/netconfig"
+	"github.com/yohamta/donburi"
+)
+
+// maxQueuedInputs bounds a player's input queue (~133ms at 60 Hz). A
+// queue longer than this only adds latency, so the oldest inputs are
+// dropped to catch up.
+const maxQueuedInputs = 8
+
+// inputQueue buffers a player's inputs in sequence order so physics can
+// play back exactly one per 60 Hz sub-step. Inputs at or below the highest
+// sequence already accepted are resends and are ignored.
+type inputQueue struct {
+	pending []messages.PlayerInput
+	last    uint32 // highest sequence accepted
+}
+
+// push queues input unless it has been seen before.
+func (q *inputQueue) push(input messages.PlayerInput) {
+	if input.Sequence <= q.last {
+		return
+	}
+	input.Redundant = nil
+	q.last = input.Sequence
+	q.pending = append(q.pending, input)
+	if over := len(q.pending) - maxQueuedInputs; over > 0 {
+		q.pending = append(q.pending[:0], q.pending[over:]...)
+	}
+}
+
+// pop removes and returns the oldest queued input.
+func (q *inputQueue) pop() (messages.PlayerInput, bool) {
+	if len(q.pending) == 0 {
+		return messages.PlayerInput{}, false
+	}
+	input := q.pending[0]
+	q.pending = append(q.pending[:0], q.pending[1:]...)
+	return input, true
+}
+
+// reset drops every queued input and forgets the last sequence, for a
+// client whose numbering may restart.
+func (q *inputQueue) reset() {
+	q.pending = q.pending[:0]
+	q.last = 0
+}
+
+// consumeInput plays back the player's next queued input for one physics
+// sub-step. Movement keys take the input's value. Attack and boomerang are
+// read once per tick by combat, so a press in any sub-step of the tick
+// counts. With nothing queued the previous input stays held. Must be
+// called on the game loop goroutine.
+func (r *Room) consumeInput(entity donburi.Entity, pp *PlayerPhysics, firstStep bool) {
+	input, ok := pp.Inputs.pop()
+	if !ok {
+		return
+	}
+
+	pp.Direction = input.Direction
+	pp.JumpPressed = input.Actions.Has(netconfig.ActionJump)
+	pp.MoveUpPressed = input.Actions.Has(netconfig.ActionMoveUp)
+	pp.CrouchPressed = input.Actions.Has(netconfig.ActionCrouch)
+
+	attack := input.Actions.Has(netconfig.ActionAttack)
+	boomerang := input.Actions.Has(netconfig.ActionBoomerang)
+	if firstStep {
+		pp.AttackPressed = attack
+		pp.BoomerangPressed = boomerang
+	} else {
+		pp.AttackPressed = pp.AttackPressed || attack
+		pp.BoomerangPressed = pp.BoomerangPressed || boomerang
+	}
+	pp.LastInputSeq = input.Sequence
+
+	// Update facing direction in NetPlayerState
+	if input.Direction != 0 && r.world.Valid(entity) {
+		state := netcomponents.NetPlayerState.Get(r.world.Entry(entity))
+		state.Direction = input.Direction
+	}
+}
+
+// drainInputs plays back every queued input at once, for ticks without
+// physics (countdown, round end) so nothing stale is replayed once play
+// resumes. Must be called on the game loop goroutine.
+func (r *Room) drainInputs() {
+	for entity, pp := range r.playerPhysics {
+		for first := true; len(pp.Inputs.pending) > 0; first = false {
+			r.consumeInput(entity, pp, first)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
+	"github.com/automoto/doomerang-mp/shared/netconfig"
+	"github.com/automoto/doomerang-mp/tags"
+	"github.com/solarlune/resolv"
+	"github.com/stretchr/testify/assert"
+)
+
+func testInput(seq uint32, pressed ...netconfig.ActionID) messages.PlayerInput {
+	input := messages.NewPlayerInput(seq)
+	for _, action := range pressed {
+		input.Actions.Set(action, true)
+	}
+	return input
+}
+
+func queuedSeqs(q *inputQueue) []uint32 {
+	var seqs []uint32
+	for _, input := range q.pending {
+		seqs = append(seqs, input.Sequence)
+	}
+	return seqs
+}
+
+func TestInputQueue_push(t *testing.T) {
+	tests := []struct {
+		name string
+		push []uint32
+		want []uint32
+	}{
+		{"in order", []uint32{1, 2, 3}, []uint32{1, 2, 3}},
+		{"resends ignored", []uint32{1, 2, 1, 2, 3, 2}, []uint32{1, 2, 3}},
+		{"gaps kept", []uint32{1, 4, 9}, []uint32{1, 4, 9}},
+		{"oldest dropped past the cap", []uint32{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, []uint32{3, 4, 5, 6, 7, 8, 9, 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var q inputQueue
+			for _, seq := range tt.push {
+				q.push(testInput(seq))
+			}
+			assert.Equal(t, tt.want, queuedSeqs(&q))
+		})
+	}
+}
+
+func TestRoom_updatePhysics_consumes_one_input_per_step(t *testing.T) {
+	s := newRoomTestServer(t, emptyTestLevel())
+	r := newIdleTestRoom(t, s)
+	r.loop.tickRate = 30 // two sub-steps per tick
+	r.match.State = netcomponents.MatchStatePlaying
+
+	entity, _ := addTestPlayer(t, r, 100, 100)
+	pp := r.playerPhysics[entity]
+	floor := resolv.NewObject(0, 140, 320, 16, tags.ResolvSolid)
+	r.activeLevel.Space.Add(floor)
+	pp.Body.OnGround = floor
+
+	// Jump and attack are each pressed for a single frame, and both frames
+	// arrive within one tick: latest-input-wins would lose them.
+	for _, input := range []messages.PlayerInput{
+		testInput(1, netconfig.ActionJump),
+		testInput(2, netconfig.ActionAttack),
+		testInput(3),
+		testInput(4),
+	} {
+		pp.Inputs.push(input)
+	}
+
+	r.updatePhysics()
+	assert.Equal(t, uint32(2), pp.LastInputSeq)
+	assert.Less(t, netcomponents.NetVelocity.Get(r.world.Entry(entity)).SpeedY, 0.0, "jump applied")
+	assert.True(t, pp.AttackPressed, "attack pressed within the tick")
+
+	r.updatePhysics()
+	assert.Equal(t, uint32(4), pp.LastInputSeq)
+	assert.False(t, pp.AttackPressed)
+	assert.Equal(t, uint32(4), netcomponents.NetPlayerState.Get(r.world.Entry(entity)).LastSequence)
+
+	// Nothing queued: the last input stays held.
+	r.updatePhysics()
+	assert.Equal(t, uint32(4), pp.LastInputSeq)
+}
@@ -0,0 +1,205 @@
+package core
+
+import (
+	"time"
+
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/leap-fish/necs/router"
+	"github.com/yohamta/donburi"
+)
+
+// Lag compensation. Each room keeps the last second of player hurtboxes,
+// one frame per tick. Melee and boomerang hit checks test targets where
+// the attacker saw them: one RTT ago (snapshot out, input back) plus the
+// snapshot interval the client spends interpolating remote players, capped
+// by the server's max rewind.
+const (
+	defaultMaxRewind = 200 * time.Millisecond
+	maxRewindCeiling = time.Second
+
+	latencyProbeInterval = time.Second
+	latencyProbeTimeout  = 2 * time.Second
+)
+
+// hurtbox is a player's collision rectangle at one tick.
+type hurtbox struct {
+	X, Y, W, H float64
+}
+
+func (b hurtbox) overlaps(x, y, w, h float64) bool {
+	return x < b.X+b.W && x+w > b.X && y < b.Y+b.H && y+h > b.Y
+}
+
+func currentHurtbox(pp *PlayerPhysics) hurtbox {
+	return hurtbox{X: pp.Object.X, Y: pp.Object.Y, W: pp.Object.W, H: pp.Object.H}
+}
+
+// hurtboxHistory is a ring of per-tick hurtbox frames. Frames are reused
+// as the ring wraps so recording does not allocate once warm.
+type hurtboxHistory struct {
+	frames []map[donburi.Entity]hurtbox
+	next   int // index the next record writes
+	count  int
+}
+
+func newHurtboxHistory(size int) *hurtboxHistory {
+	frames := make([]map[donburi.Entity]hurtbox, size)
+	for i := range frames {
+		frames[i] = make(map[donburi.Entity]hurtbox)
+	}
+	return &hurtboxHistory{frames: frames}
+}
+
+// record stores every player's current hurtbox as the newest frame.
+func (h *hurtboxHistory) record(players map[donburi.Entity]*PlayerPhysics) {
+	frame := h.frames[h.next]
+	clear(frame)
+	for entity, pp := range players {
+		frame[entity] = currentHurtbox(pp)
+	}
+	h.next = (h.next + 1) % len(h.frames)
+	h.count = min(h.count+1, len(h.frames))
+}
+
+// rewind returns the frame recorded ticks ago, where 1 is the previous
+// tick, clamped to the oldest frame kept. Returns nil for ticks <= 0 or
+// an empty history.
+func (h *hurtboxHistory) rewind(ticks int) map[donburi.Entity]hurtbox {
+	if ticks <= 0 || h.count == 0 {
+		return nil
+	}
+	ticks = min(ticks, h.count)
+	return h.frames[(h.next-ticks+len(h.frames))%len(h.frames)]
+}
+
+// clear drops every frame, for when the players are rebuilt.
+func (h *hurtboxHistory) clear() {
+	h.count = 0
+}
+
+// recordHurtboxes snapshots this tick's hurtboxes. Must be called on the
+// game loop goroutine, after physics and combat.
+func (r *Room) recordHurtboxes() {
+	r.hurtboxes.record(r.playerPhysics)
+}
+
+// pastHurtboxes returns the frame the player with netID saw when acting
+// this tick, or nil to test against current positions.
+func (r *Room) pastHurtboxes(netID uint32) map[donburi.Entity]hurtbox {
+	return r.hurtboxes.rewind(r.rewindTicks(netID))
+}
+
+// hurtboxIn returns the target's hurtbox from a rewound frame, falling
+// back to its current one when the frame is nil or predates it.
+func hurtboxIn(past map[donburi.Entity]hurtbox, entity donburi.Entity, pp *PlayerPhysics) hurtbox {
+	if box, ok := past[entity]; ok {
+		return box
+	}
+	return currentHurtbox(pp)
+}
+
+// rewindTicks converts the client's latency estimate and playout delay
+// into how many ticks to rewind its hit checks. Until the client reports
+// its playout delay, one snapshot interval is assumed. Zero when lag
+// compensation is off or the latency is unknown (bots, fresh joins).
+func (r *Room) rewindTicks(netID uint32) int {
+	enabled, maxRewind := r.server.lagCompensationSettings()
+	if !enabled || netID == 0 {
+		return 0
+	}
+
+	r.mu.RLock()
+	rtt, ok := r.latency[netID]
+	interp, reported := r.playoutDelay[netID]
+	r.mu.RUnlock()
+	if !ok {
+		return 0
+	}
+
+	tick := time.Second / time.Duration(r.loop.tickRate)
+	if !reported {
+		interp = tick * time.Duration(r.server.snapshotEvery())
+	}
+	rewind := min(rtt+interp, maxRewind)
+	return int((rewind + tick/2) / tick)
+}
+
+// recordRTT folds a round-trip sample into the client's smoothed latency
+// and its jitter, the mean deviation, weighting new samples 1/8 and 1/4
+// as TCP does for SRTT and RTTVAR.
+func (r *Room) recordRTT(netID uint32, sample time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rtt, ok := r.latency[netID]
+	if !ok {
+		r.latency[netID] = sample
+		r.jitter[netID] = sample / 2
+		return
+	}
+	deviation := rtt - sample
+	if deviation < 0 {
+		deviation = -deviation
+	}
+	r.jitter[netID] = r.jitter[netID] - r.jitter[netID]/4 + deviation/4
+	r.latency[netID] = rtt - rtt/8 + sample/8
+}
+
+// linkQuality returns the client's smoothed round-trip time and jitter,
+// or false before the first Pong. Callers hold mu.
+func (r *Room) linkQuality(netID uint32) (rtt, jitter time.Duration, ok bool) {
+	rtt, ok = r.latency[netID]
+	return rtt, r.jitter[netID], ok
+}
+
+// probeLatency pings every client once per latencyProbeInterval until
+// stop closes. Each Ping carries the client's link quality so far; the
+// Pong that answers it feeds recordRTT.
+func (r *Room) probeLatency(stop <-chan struct{}) {
+	ticker := time.NewTicker(latencyProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		r.mu.RLock()
+		pings := make(map[*router.NetworkClient]messages.Ping, len(r.clientNetworkIDs))
+		for client, netID := range r.clientNetworkIDs {
+			rtt, jitter, _ := r.linkQuality(netID)
+			pings[client] = messages.Ping{RTT: rtt, Jitter: jitter}
+		}
+		r.mu.RUnlock()
+
+		for client, ping := range pings {
+			ping.SentAt = time.Now().UnixNano()
+			_ = r.server.send(client, ping)
+		}
+	}
+}
+
+// onPong records the round trip of one of the room's Pings and the
+// client's playout delay. Pongs that would put the round trip in the
+// future or past latencyProbeTimeout are stale or forged and ignored, as
+// are playout delays outside that range.
+func (r *Room) onPong(client *router.NetworkClient, pong messages.Pong) {
+	sample := time.Since(time.Unix(0, pong.SentAt))
+	if sample < 0 || sample > latencyProbeTimeout {
+		return
+	}
+
+	r.mu.RLock()
+	netID, bound := r.clientNetworkIDs[client]
+	r.mu.RUnlock()
+	if !bound {
+		return
+	}
+	r.recordRTT(netID, sample)
+	if pong.PlayoutDelay > 0 && pong.PlayoutDelay <= latencyProbeTimeout {
+		r.mu.Lock()
+		r.playoutDelay[netID] = pong.PlayoutDelay
+		r.mu.Unlock()
+	}
+}
@@ -0,0 +1,166 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
+	"github.com/leap-fish/necs/router"
+	"github.com/solarlune/resolv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yohamta/donburi"
+)
+
+func TestHurtboxHistory_rewind(t *testing.T) {
+	h := newHurtboxHistory(3)
+	pp := &PlayerPhysics{Object: resolv.NewObject(0, 0, 16, 40)}
+	players := map[donburi.Entity]*PlayerPhysics{1: pp}
+
+	assert.Nil(t, h.rewind(1), "empty history")
+
+	for _, x := range []float64{10, 20, 30, 40} {
+		pp.Object.X = x
+		h.record(players)
+	}
+
+	tests := []struct {
+		name  string
+		ticks int
+		wantX float64
+	}{
+		{"previous tick", 1, 40},
+		{"two ticks back", 2, 30},
+		{"oldest kept", 3, 20},
+		{"clamped to oldest", 10, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame := h.rewind(tt.ticks)
+			require.NotNil(t, frame)
+			assert.Equal(t, tt.wantX, frame[1].X)
+		})
+	}
+
+	assert.Nil(t, h.rewind(0), "zero ticks tests current positions")
+	h.clear()
+	assert.Nil(t, h.rewind(1))
+}
+
+func TestRoom_rewindTicks(t *testing.T) {
+	s := newRoomTestServer(t, emptyTestLevel())
+	r := newIdleTestRoom(t, s)
+	r.latency[7] = 100 * time.Millisecond
+	r.latency[9] = 100 * time.Millisecond
+	r.playoutDelay[9] = 100 * time.Millisecond
+
+	tests := []struct {
+		name      string
+		enabled   bool
+		maxRewind time.Duration
+		netID     uint32
+		want      int
+	}{
+		{"rtt plus one snapshot interval until the client reports its delay", true, time.Second, 7, 7},
+		{"rtt plus the client's playout delay", true, time.Second, 9, 12},
+		{"playout delay capped by max rewind", true, 150 * time.Millisecond, 9, 9},
+		{"capped by max rewind", true, 50 * time.Millisecond, 7, 3},
+		{"disabled", false, time.Second, 7, 0},
+		{"unknown latency", true, time.Second, 8, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.SetLagCompensation(tt.enabled, tt.maxRewind)
+			assert.Equal(t, tt.want, r.rewindTicks(tt.netID))
+		})
+	}
+}
+
+func TestRoom_checkMeleeHitbox_hits_where_attacker_saw_target(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		s := newRoomTestServer(t, emptyTestLevel())
+		s.SetLagCompensation(enabled, time.Second)
+		r := newIdleTestRoom(t, s)
+
+		attacker, attackerNetID := addTestPlayer(t, r, 100, 100)
+		target, _ := addTestPlayer(t, r, 118, 100)
+		netcomponents.NetPlayerState.Get(r.world.Entry(attacker)).Direction = 1
+		r.latency[attackerNetID] = 100 * time.Millisecond
+
+		// The target stood in reach for the last ten ticks, then stepped away.
+		for range 10 {
+			r.recordHurtboxes()
+		}
+		r.playerPhysics[target].Object.X = 200
+
+		attackerPP := r.playerPhysics[attacker]
+		attackerPP.AttackIsPunch = true
+		r.checkMeleeHitbox(attacker, attackerPP)
+
+		_, hit := attackerPP.HitTargets[target]
+		assert.Equal(t, enabled, hit, "lag compensation enabled=%v", enabled)
+	}
+}
+
+func TestRoom_recordRTT_tracks_jitter(t *testing.T) {
+	r := newIdleTestRoom(t, newRoomTestServer(t, emptyTestLevel()))
+
+	r.recordRTT(7, 100*time.Millisecond)
+	rtt, jitter, ok := r.linkQuality(7)
+	require.True(t, ok)
+	assert.Equal(t, 100*time.Millisecond, rtt)
+	assert.Equal(t, 50*time.Millisecond, jitter, "first sample seeds jitter at half the RTT")
+
+	for range 50 {
+		r.recordRTT(7, 100*time.Millisecond)
+	}
+	_, steady, _ := r.linkQuality(7)
+	assert.Less(t, steady, time.Millisecond, "a steady link settles to no jitter")
+
+	for i := range 50 {
+		r.recordRTT(7, time.Duration(60+80*(i%2))*time.Millisecond)
+	}
+	rtt, jitter, _ = r.linkQuality(7)
+	assert.InDelta(t, 100, rtt.Milliseconds(), 10)
+	assert.Greater(t, jitter, 20*time.Millisecond, "alternating samples show up as jitter")
+}
+
+func TestRoom_onPong_records_round_trip(t *testing.T) {
+	r := newIdleTestRoom(t, newRoomTestServer(t, emptyTestLevel()))
+	_, netID := addTestPlayer(t, r, 100, 100)
+	client := &router.NetworkClient{}
+	r.clientNetworkIDs[client] = netID
+
+	r.onPong(client, messages.Pong{SentAt: time.Now().Add(-time.Hour).UnixNano()})
+	r.onPong(client, messages.Pong{SentAt: time.Now().Add(time.Hour).UnixNano()})
+	_, _, ok := r.linkQuality(netID)
+	assert.False(t, ok, "stale and future pongs are ignored")
+
+	r.onPong(client, messages.Pong{SentAt: time.Now().Add(-40 * time.Millisecond).UnixNano(), PlayoutDelay: 80 * time.Millisecond})
+	rtt, _, ok := r.linkQuality(netID)
+	require.True(t, ok)
+	assert.GreaterOrEqual(t, rtt, 40*time.Millisecond)
+	assert.Equal(t, 80*time.Millisecond, r.playoutDelay[netID])
+
+	r.match.Slots[0] = messages.LobbySlot{Type: 1, PlayerID: netID}
+	r.match.syncGameState()
+	gs := netcomponents.NetGameState.Get(r.world.Entry(r.match.gameStateEntity))
+	assert.Equal(t, int(rtt.Milliseconds()), gs.SlotPings[0])
+
+	r.onPong(client, messages.Pong{SentAt: time.Now().UnixNano(), PlayoutDelay: time.Hour})
+	assert.Equal(t, 80*time.Millisecond, r.playoutDelay[netID], "implausible playout delays are ignored")
+}
+
+func TestServer_onClockSync_answers_with_server_time(t *testing.T) {
+	s := newRoomTestServer(t, emptyTestLevel())
+	client, received := newRecordingTestClient(t)
+
+	before := time.Now().UnixNano()
+	s.onClockSync(client, messages.ClockSyncRequest{ClientTime: 42})
+
+	resp, ok := nextMessage(t, received).(messages.ClockSyncResponse)
+	require.True(t, ok)
+	assert.Equal(t, int64(42), resp.ClientTime)
+	assert.GreaterOrEqual(t, resp.ServerTime, before)
+}
@@ -5,45 +5,66 @@ import (
 	"log"
 	"os"
 
+	"github.com/automoto/doomerang-mp/shared/hazards"
 	"github.com/automoto/doomerang-mp/shared/leveldata"
-	"github.com/automoto/doomerang-mp/tags"
+	"github.com/automoto/doomerang-mp/shared/playersim"
 	"github.com/solarlune/resolv"
 )
 
-// ServerLevel holds the server's collision space and spawn data for a level.
+// ServerLevel holds the server's collision space, hazards and spawn data
+// for a level.
 type ServerLevel struct {
 	Space       *resolv.Space
+	Fires       []*hazards.Fire
 	SpawnPoints []leveldata.SpawnPoint
+	EnemySpawns []leveldata.EnemySpawn
+	PatrolPaths map[string][]leveldata.Point
 	MapWidth    int
 	MapHeight   int
+
+	data *leveldata.CollisionData
 }
 
 // NewServerLevel builds a resolv.Space from parsed collision data.
 func NewServerLevel(data *leveldata.CollisionData) *ServerLevel {
+	lvl := buildServerLevel(data)
+
+	log.Printf("Loaded level: %d solid tiles, %d platforms, %d dead zones, %d fires, %d spawn points, %d enemies, %dx%d map",
+		len(data.SolidRects), len(data.Platforms), len(data.DeadZones), len(data.Fires),
+		len(data.SpawnPoints), len(data.EnemySpawns), data.MapWidth, data.MapHeight)
+
+	return lvl
+}
+
+// clone builds an independent copy of the level from the same collision
+// data. Rooms each need their own because player and boomerang bodies
+// are added to Space, and fires animate per room.
+func (l *ServerLevel) clone() *ServerLevel {
+	return buildServerLevel(l.data)
+}
+
+func buildServerLevel(data *leveldata.CollisionData) *ServerLevel {
 	space := resolv.NewSpace(data.MapWidth, data.MapHeight, 16, 16)
 
-	for _, r := range data.SolidRects {
-		var obj *resolv.Object
-		switch r.SlopeType {
-		case tags.Slope45UpRight:
-			obj = resolv.NewObject(r.X, r.Y, r.W, r.H, tags.ResolvRamp, tags.Slope45UpRight)
-		case tags.Slope45UpLeft:
-			obj = resolv.NewObject(r.X, r.Y, r.W, r.H, tags.ResolvRamp, tags.Slope45UpLeft)
-		default:
-			obj = resolv.NewObject(r.X, r.Y, r.W, r.H, tags.ResolvSolid)
-		}
-		obj.SetShape(resolv.NewRectangle(0, 0, r.W, r.H))
-		space.Add(obj)
-	}
+	playersim.AddSolids(space, data.SolidRects)
+	playersim.AddPlatforms(space, data.Platforms)
+	hazards.AddDeadZones(space, data.DeadZones)
 
-	log.Printf("Loaded level: %d solid tiles, %d spawn points, %dx%d map",
-		len(data.SolidRects), len(data.SpawnPoints), data.MapWidth, data.MapHeight)
+	fires := make([]*hazards.Fire, len(data.Fires))
+	for i, spawn := range data.Fires {
+		fires[i] = hazards.NewFire(spawn)
+		space.Add(fires[i].Object)
+	}
 
 	return &ServerLevel{
 		Space:       space,
+		Fires:       fires,
 		SpawnPoints: data.SpawnPoints,
+		EnemySpawns: data.EnemySpawns,
+		PatrolPaths: data.PatrolPaths,
 		MapWidth:    data.MapWidth,
 		MapHeight:   data.MapHeight,
+		data:        data,
 	}
 }
 
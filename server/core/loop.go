@@ -3,22 +3,21 @@ package core
 import (
 	"log"
 	"time"
-
-	"github.com/leap-fish/necs/esync/srvsync"
 )
 
 type GameLoop struct {
-	server    *Server
+	room      *Room
 	botSystem *BotSystem
 	tickRate  int
+	ticks     int
 	running   bool
 	stopChan  chan struct{}
 }
 
-func NewGameLoop(server *Server, tickRate int) *GameLoop {
+func NewGameLoop(room *Room, tickRate int) *GameLoop {
 	return &GameLoop{
-		server:    server,
-		botSystem: NewBotSystem(server),
+		room:      room,
+		botSystem: NewBotSystem(room),
 		tickRate:  tickRate,
 		stopChan:  make(chan struct{}),
 	}
@@ -26,19 +25,26 @@ func NewGameLoop(server *Server, tickRate int) *GameLoop {
 
 func (g *GameLoop) Run() {
 	g.running = true
-	ticker := time.NewTicker(time.Second / time.Duration(g.tickRate))
+	interval := time.Second / time.Duration(g.tickRate)
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
+	g.room.lastTick.Store(time.Now().UnixNano())
 
-	log.Printf("Game loop started at %d ticks/second", g.tickRate)
+	log.Printf("Room %s game loop started at %d ticks/second", g.room.code, g.tickRate)
 
 	for {
 		select {
 		case <-g.stopChan:
 			g.running = false
-			log.Println("Game loop stopped")
+			log.Printf("Room %s game loop stopped", g.room.code)
 			return
 		case <-ticker.C:
+			start := time.Now()
 			g.tick()
+			g.room.server.metrics.observeTick(time.Since(start), interval)
+			g.room.lastTick.Store(time.Now().UnixNano())
+			g.room.reportStatus()
+			g.room.bots.Store(int32(g.room.botCount())) //nolint:gosec // A room holds a handful of bots
 		}
 	}
 }
@@ -49,13 +55,16 @@ func (g *GameLoop) Stop() {
 
 func (g *GameLoop) tick() {
 	dt := 1.0 / float64(g.tickRate)
-	g.server.ProcessCommands()
-	g.server.Match().Update(dt)
+	g.room.ProcessCommands()
+	g.room.Match().Update(dt)
 	g.botSystem.Update()
-	g.server.updatePhysics()
-	g.server.updateCombat()
-
-	if err := srvsync.DoSync(); err != nil {
-		log.Printf("Sync error: %v", err)
+	g.room.updatePhysics()
+	g.room.updateHazards()
+	g.room.updateEnemies()
+	g.room.updateCombat()
+	g.room.recordHurtboxes()
+	if g.ticks%g.room.server.snapshotEvery() == 0 {
+		g.room.broadcastSnapshot()
 	}
+	g.ticks++
 }
@@ -2,30 +2,37 @@ package core
 
 import (
 	"log"
+	"time"
 
 	cfg "github.com/automoto/doomerang-mp/config"
 	"github.com/automoto/doomerang-mp/shared/messages"
 	"github.com/automoto/doomerang-mp/shared/netcomponents"
 	"github.com/leap-fish/necs/esync"
-	"github.com/leap-fish/necs/esync/srvsync"
 	"github.com/yohamta/donburi"
 )
 
 type ServerMatch struct {
-	server *Server
+	room *Room
 
 	State         netcomponents.MatchStateID
 	Timer         float64
 	Duration      float64
 	CountdownTime float64
+	deadline      time.Time // When Timer runs out, for clients to count down to; zero while waiting
 
 	Scores map[uint32]int
 	Deaths map[uint32]int
+	Stats  map[uint32]*messages.PlayerStats // NetworkId -> stats this match
+
+	streaks map[uint32]int // NetworkId -> KOs since last knocked out
+	// lastStats is what endMatch broadcast, kept for resyncState.
+	lastStats *messages.MatchStats
 
 	MinPlayers int
 	MaxPlayers int
 
 	GameMode     string
+	FriendlyFire bool // Teammates can hurt each other
 	MatchMinutes int
 	LevelIndex   int
 	WinnerID     uint32
@@ -42,14 +49,16 @@ type ServerMatch struct {
 	gameStateEntity donburi.Entity
 }
 
-func NewServerMatch(server *Server) *ServerMatch {
+func NewServerMatch(room *Room) *ServerMatch {
 	m := &ServerMatch{
-		server:        server,
+		room:          room,
 		State:         netcomponents.MatchStateWaiting,
 		Duration:      float64(cfg.Match.RoundDuration) / 60.0,
 		CountdownTime: 3.0,
 		Scores:        make(map[uint32]int),
 		Deaths:        make(map[uint32]int),
+		Stats:         make(map[uint32]*messages.PlayerStats),
+		streaks:       make(map[uint32]int),
 		MinPlayers:    2,
 		MaxPlayers:    4,
 		GameMode:      "ffa",
@@ -68,14 +77,14 @@ func NewServerMatch(server *Server) *ServerMatch {
 }
 
 func (m *ServerMatch) createGameStateEntity() {
-	m.gameStateEntity = m.server.world.Create(netcomponents.NetGameState)
-	entry := m.server.world.Entry(m.gameStateEntity)
+	m.gameStateEntity = m.room.world.Create(netcomponents.NetGameState)
+	entry := m.room.world.Entry(m.gameStateEntity)
 	netcomponents.NetGameState.Set(entry, &netcomponents.NetGameStateData{
 		MatchState: m.State,
 		GameMode:   m.GameMode,
 	})
 
-	err := srvsync.NetworkSync(m.server.world, &m.gameStateEntity, netcomponents.NetGameState)
+	err := m.room.sync.NetworkSync(m.gameStateEntity, netcomponents.NetGameState)
 	if err != nil {
 		log.Printf("Failed to setup network sync for game state: %v", err)
 	}
@@ -104,16 +113,16 @@ func (m *ServerMatch) updateWaiting() {
 
 func (m *ServerMatch) startCountdown() {
 	m.State = netcomponents.MatchStateCountdown
-	m.Timer = m.CountdownTime
+	m.setTimer(m.CountdownTime)
 
-	m.server.broadcastEvent(messages.MatchEvent{
+	m.room.broadcastEvent(messages.MatchEvent{
 		Type:    "countdown_start",
 		Message: "Match starting...",
 	})
 }
 
 func (m *ServerMatch) updateCountdown(dt float64) {
-	m.Timer -= dt
+	m.tickTimer(dt)
 
 	if m.Timer <= 0 {
 		m.startMatch()
@@ -122,35 +131,43 @@ func (m *ServerMatch) updateCountdown(dt float64) {
 
 func (m *ServerMatch) startMatch() {
 	m.State = netcomponents.MatchStatePlaying
-	m.server.matchInProgress.Store(true)
-	m.Timer = m.Duration
+	m.room.matchInProgress.Store(true)
+	m.room.server.metrics.matchStarts.add(m.GameMode, 1)
+	m.setTimer(m.Duration)
 
 	m.Scores = make(map[uint32]int)
 	m.Deaths = make(map[uint32]int)
+	m.Stats = make(map[uint32]*messages.PlayerStats)
+	m.streaks = make(map[uint32]int)
 	m.WinnerID = 0
 	m.CurrentRound = 1
 	m.RoundWins = make(map[int]int)
 
+	// Seat players who joined mid-match before spawning from the slots
+	m.room.promoteSpectators()
+
 	// Clear all existing player entities
-	m.server.ClearAllPlayers()
+	m.room.ClearAllPlayers()
 
 	// Spawn players from lobby slots
 	for i, slot := range m.Slots {
 		if slot.Type != 0 {
-			m.server.SpawnPlayerAtSlot(i, slot)
+			m.room.SpawnPlayerAtSlot(i, slot)
 		}
 	}
 
 	m.initLivesForAllPlayers()
+	m.room.spawnEnemies()
 
-	m.server.broadcastEvent(messages.MatchEvent{
+	m.room.broadcastEvent(messages.MatchEvent{
 		Type:    "match_start",
 		Message: "FIGHT!",
 	})
 }
 
 func (m *ServerMatch) updatePlaying(dt float64) {
-	m.Timer -= dt
+	m.tickTimer(dt)
+	m.recordTimeAlive(dt)
 
 	if m.Timer <= 0 {
 		m.handleTimerExpiry()
@@ -159,13 +176,18 @@ func (m *ServerMatch) updatePlaying(dt float64) {
 }
 
 func (m *ServerMatch) handleTimerExpiry() {
+	// Enemies left standing in co-op: nobody wins
+	if m.vsEnemies() {
+		m.endRound(-1)
+		return
+	}
 	winnerTeam := m.determineRoundWinner()
 	m.endRound(winnerTeam)
 }
 
 func (m *ServerMatch) endRound(winnerTeam int) {
 	m.State = netcomponents.MatchStateRoundEnd
-	m.Timer = float64(cfg.Match.RoundEndDelay) / 60.0
+	m.setTimer(float64(cfg.Match.RoundEndDelay) / 60.0)
 
 	if winnerTeam >= 0 {
 		m.RoundWins[winnerTeam]++
@@ -174,7 +196,7 @@ func (m *ServerMatch) endRound(winnerTeam int) {
 	// Find a winner network ID for display
 	winnerNetID := m.netIDForTeam(winnerTeam)
 
-	m.server.broadcastEvent(messages.MatchEvent{
+	m.room.broadcastEvent(messages.MatchEvent{
 		Type:        "round_end",
 		WinnerID:    winnerNetID,
 		RoundNumber: m.CurrentRound,
@@ -183,7 +205,7 @@ func (m *ServerMatch) endRound(winnerTeam int) {
 }
 
 func (m *ServerMatch) updateRoundEnd(dt float64) {
-	m.Timer -= dt
+	m.tickTimer(dt)
 	if m.Timer > 0 {
 		return
 	}
@@ -203,24 +225,23 @@ func (m *ServerMatch) updateRoundEnd(dt float64) {
 func (m *ServerMatch) startNextRound() {
 	m.CurrentRound++
 
-	// Clear and respawn all players
-	m.server.ClearAllPlayers()
+	// Clear and respawn all players, seating queued spectators first
+	m.room.promoteSpectators()
+	m.room.ClearAllPlayers()
 	for i, slot := range m.Slots {
 		if slot.Type != 0 {
-			m.server.SpawnPlayerAtSlot(i, slot)
+			m.room.SpawnPlayerAtSlot(i, slot)
 		}
 	}
 
 	m.initLivesForAllPlayers()
-
-	// Reset round timer
-	m.Timer = m.Duration
+	m.room.spawnEnemies()
 
 	// Go through countdown
 	m.State = netcomponents.MatchStateCountdown
-	m.Timer = m.CountdownTime
+	m.setTimer(m.CountdownTime)
 
-	m.server.broadcastEvent(messages.MatchEvent{
+	m.room.broadcastEvent(messages.MatchEvent{
 		Type:    "countdown_start",
 		Message: "Next round...",
 	})
@@ -228,31 +249,77 @@ func (m *ServerMatch) startNextRound() {
 
 func (m *ServerMatch) endMatch(reason string) {
 	m.State = netcomponents.MatchStateFinished
-	m.server.matchInProgress.Store(false)
+	m.room.matchInProgress.Store(false)
+	m.room.server.metrics.matchEnds.add(m.GameMode, 1)
 
 	// If winner not already set, determine it
 	if m.WinnerID == 0 {
 		m.WinnerID = m.determineWinner()
 	}
 
-	m.server.broadcastEvent(messages.MatchEvent{
+	m.room.broadcastEvent(messages.MatchEvent{
 		Type:     "match_end",
 		WinnerID: m.WinnerID,
 		Reason:   reason,
 		Scores:   m.Scores,
 	})
 
+	stats := m.finalStats()
+	m.lastStats = &messages.MatchStats{
+		WinnerID: m.WinnerID,
+		Players:  stats,
+	}
+	m.room.broadcastEvent(*m.lastStats)
+
 	// Server-authoritative leaderboard submission: hand the final
-	// scores + per-player session tokens to the configured hook,
+	// stats + per-player session tokens to the configured hook,
 	// which calls Leaderboards.SubmitFor. Runs in a goroutine because
 	// the hook makes network calls and must not block the game loop.
-	scoresCopy := make(map[uint32]int, len(m.Scores))
-	for k, v := range m.Scores {
-		scoresCopy[k] = v
+	go m.room.invokeMatchEndHook(stats)
+
+	m.setTimer(10.0)
+}
+
+// setTimer starts Timer counting down from seconds, and sets the deadline
+// clients count down to with it.
+func (m *ServerMatch) setTimer(seconds float64) {
+	m.Timer = seconds
+	m.deadline = time.Now().Add(time.Duration(seconds * float64(time.Second)))
+}
+
+// tickTimer counts Timer down by dt or, while a deadline is set, reads it
+// off the wall clock instead. The ticker drops ticks that overrun, so a
+// sum of dts falls behind the deadline clients count down to, and the
+// server would change phase after they show it.
+func (m *ServerMatch) tickTimer(dt float64) {
+	if m.deadline.IsZero() {
+		m.Timer -= dt
+		return
+	}
+	m.Timer = time.Until(m.deadline).Seconds()
+}
+
+// abortMatch ends the match under way without a result or stats and
+// returns the room to the lobby, seating queued spectators as the end of a
+// match would.
+func (m *ServerMatch) abortMatch() {
+	m.State = netcomponents.MatchStateWaiting
+	m.room.matchInProgress.Store(false)
+	m.Timer = 0
+	m.deadline = time.Time{}
+	for i := range m.Slots {
+		m.Slots[i].Ready = false // Nobody is thrown straight back in
 	}
-	go m.server.invokeMatchEndHook(scoresCopy)
 
-	m.Timer = 10.0
+	m.room.broadcastEvent(messages.MatchEvent{
+		Type:    "match_abort",
+		Message: "Match aborted",
+	})
+
+	for _, slotIdx := range m.room.promoteSpectators() {
+		m.room.SpawnPlayerAtSlot(slotIdx, m.Slots[slotIdx])
+	}
+	m.broadcastLobbyUpdate()
 }
 
 func (m *ServerMatch) determineWinner() uint32 {
@@ -305,10 +372,16 @@ func (m *ServerMatch) determineRoundWinner() int {
 }
 
 // checkRoundEndCondition checks if only one team has alive players.
+// Co-op rounds against enemies instead end once every enemy or every
+// player is down.
 func (m *ServerMatch) checkRoundEndCondition() {
 	if m.State != netcomponents.MatchStatePlaying {
 		return
 	}
+	if m.vsEnemies() {
+		m.checkCoopRoundEnd()
+		return
+	}
 
 	teamsAlive := make(map[int]bool)
 	for i, slot := range m.Slots {
@@ -336,6 +409,32 @@ func (m *ServerMatch) checkRoundEndCondition() {
 	}
 }
 
+// vsEnemies reports whether this is a co-op match on a level with enemies.
+func (m *ServerMatch) vsEnemies() bool {
+	return m.GameMode == "coop" && len(m.room.activeLevel.EnemySpawns) > 0
+}
+
+func (m *ServerMatch) checkCoopRoundEnd() {
+	survivorTeam := -1
+	for i, slot := range m.Slots {
+		if slot.Type == 0 {
+			continue
+		}
+		if nid := m.slotNetID(i); nid != 0 && !m.Eliminated[nid] {
+			survivorTeam = m.getPlayerTeam(i)
+			break
+		}
+	}
+
+	switch {
+	case survivorTeam < 0:
+		// Everyone is out — the enemies take the round
+		m.endRound(-1)
+	case !m.room.enemiesAlive():
+		m.endRound(survivorTeam)
+	}
+}
+
 func (m *ServerMatch) getPlayerTeam(slotIdx int) int {
 	// In FFA mode, each slot is its own team
 	if m.GameMode == "ffa" || m.GameMode == "1v1" {
@@ -349,11 +448,11 @@ func (m *ServerMatch) initLivesForAllPlayers() {
 	m.Lives = make(map[uint32]int)
 	m.Eliminated = make(map[uint32]bool)
 
-	for entity := range m.server.playerPhysics {
-		if !m.server.world.Valid(entity) {
+	for entity := range m.room.playerPhysics {
+		if !m.room.world.Valid(entity) {
 			continue
 		}
-		entry := m.server.world.Entry(entity)
+		entry := m.room.world.Entry(entity)
 		nid := esync.GetNetworkId(entry)
 		if nid != nil {
 			m.Lives[uint32(*nid)] = cfg.Match.LivesPerRound
@@ -385,11 +484,11 @@ func (m *ServerMatch) slotNetID(slotIdx int) uint32 {
 		return slot.PlayerID
 	}
 	// Bot — find entity with matching PlayerIndex
-	for entity := range m.server.playerPhysics {
-		if !m.server.world.Valid(entity) {
+	for entity := range m.room.playerPhysics {
+		if !m.room.world.Valid(entity) {
 			continue
 		}
-		entry := m.server.world.Entry(entity)
+		entry := m.room.world.Entry(entity)
 		nid := esync.GetNetworkId(entry)
 		if nid == nil {
 			continue
@@ -404,23 +503,38 @@ func (m *ServerMatch) slotNetID(slotIdx int) uint32 {
 }
 
 func (m *ServerMatch) updateFinished(dt float64) {
-	m.Timer -= dt
+	m.tickTimer(dt)
 
 	if m.Timer <= 0 {
-		if m.server.PlayerCount() >= m.MinPlayers {
+		// Queued spectators get a body now so they count towards the
+		// next match and show up in the lobby.
+		promoted := m.room.promoteSpectators()
+		for _, slotIdx := range promoted {
+			m.room.SpawnPlayerAtSlot(slotIdx, m.Slots[slotIdx])
+		}
+
+		if m.room.PlayerCount() >= m.MinPlayers {
 			m.startCountdown()
 		} else {
 			m.State = netcomponents.MatchStateWaiting
+			m.deadline = time.Time{}
+			if len(promoted) > 0 {
+				m.broadcastLobbyUpdate()
+			}
 		}
 	}
 }
 
 func (m *ServerMatch) syncGameState() {
-	entry := m.server.world.Entry(m.gameStateEntity)
+	entry := m.room.world.Entry(m.gameStateEntity)
 	state := netcomponents.NetGameState.Get(entry)
 
 	state.MatchState = m.State
 	state.TimeRemaining = m.Timer
+	state.PhaseEndsAt = 0
+	if !m.deadline.IsZero() {
+		state.PhaseEndsAt = m.deadline.UnixNano()
+	}
 	state.Scores = m.Scores
 	state.Deaths = m.Deaths
 	state.WinnerID = m.WinnerID
@@ -434,17 +548,24 @@ func (m *ServerMatch) syncGameState() {
 	state.RoundsToWin = cfg.Match.RoundsToWin
 
 	// Slot info for HUD
+	m.room.mu.RLock()
 	for i, slot := range m.Slots {
 		state.SlotNetIDs[i] = m.slotNetID(i)
 		state.SlotNames[i] = slot.Name
 		state.SlotTypes[i] = slot.Type
 		state.SlotTeams[i] = m.getPlayerTeam(i)
+		rtt, _, _ := m.room.linkQuality(slot.PlayerID)
+		state.SlotPings[i] = int(rtt.Milliseconds())
 	}
+	m.room.mu.RUnlock()
 }
 
 func (m *ServerMatch) AddKO(killerID uint32) {
 	m.Scores[killerID]++
-	m.server.broadcastEvent(messages.ScoreEvent{
+	m.streaks[killerID]++
+	stats := m.playerStats(killerID)
+	stats.LongestStreak = max(stats.LongestStreak, m.streaks[killerID])
+	m.room.broadcastEvent(messages.ScoreEvent{
 		PlayerID: killerID,
 		KOs:      m.Scores[killerID],
 		Deaths:   m.Deaths[killerID],
@@ -453,7 +574,8 @@ func (m *ServerMatch) AddKO(killerID uint32) {
 
 func (m *ServerMatch) AddDeath(victimID uint32) {
 	m.Deaths[victimID]++
-	m.server.broadcastEvent(messages.ScoreEvent{
+	m.streaks[victimID] = 0
+	m.room.broadcastEvent(messages.ScoreEvent{
 		PlayerID: victimID,
 		KOs:      m.Scores[victimID],
 		Deaths:   m.Deaths[victimID],
@@ -490,6 +612,7 @@ func (m *ServerMatch) OnLobbyAction(playerID uint32, action messages.LobbyAction
 			m.Slots[slotIdx].PlayerID = playerID
 			m.Slots[slotIdx].Ready = false
 			m.Slots[slotIdx].Name = action.String
+			m.Slots[slotIdx].Team = m.autoTeam(slotIdx)
 		}
 
 	case "ready":
@@ -509,6 +632,31 @@ func (m *ServerMatch) OnLobbyAction(playerID uint32, action messages.LobbyAction
 	case "change_mode":
 		if playerID == m.HostID {
 			m.GameMode = action.String
+			m.autoBalance()
+		}
+
+	case "change_team":
+		// Players switch their own team; the host can switch anyone's
+		slotIdx := action.Value
+		if slotIdx < 0 || slotIdx >= 4 || !m.teamMode() || m.Slots[slotIdx].Type == 0 {
+			return
+		}
+		if playerID == m.HostID || m.Slots[slotIdx].PlayerID == playerID {
+			if m.Slots[slotIdx].Team == 0 {
+				m.Slots[slotIdx].Team = 1
+			} else {
+				m.Slots[slotIdx].Team = 0
+			}
+		}
+
+	case "auto_balance":
+		if playerID == m.HostID {
+			m.autoBalance()
+		}
+
+	case "toggle_friendly_fire":
+		if playerID == m.HostID {
+			m.FriendlyFire = !m.FriendlyFire
 		}
 
 	case "change_time":
@@ -524,14 +672,7 @@ func (m *ServerMatch) OnLobbyAction(playerID uint32, action messages.LobbyAction
 
 	case "add_bot":
 		if playerID == m.HostID {
-			for i := range m.Slots {
-				if m.Slots[i].Type == 0 {
-					m.Slots[i].Type = 2 // Bot
-					m.Slots[i].Difficulty = action.Value
-					m.Slots[i].Name = "Bot"
-					break
-				}
-			}
+			m.addBot("Bot", action.Value)
 		}
 
 	case "remove_bot":
@@ -559,13 +700,33 @@ func (m *ServerMatch) OnLobbyAction(playerID uint32, action messages.LobbyAction
 }
 
 func (m *ServerMatch) broadcastLobbyUpdate() {
-	m.server.broadcastEvent(messages.LobbyUpdate{
+	m.room.broadcastEvent(m.lobbyUpdate())
+}
+
+func (m *ServerMatch) lobbyUpdate() messages.LobbyUpdate {
+	return messages.LobbyUpdate{
 		Slots:        m.Slots,
 		GameMode:     m.GameMode,
+		FriendlyFire: m.FriendlyFire,
 		MatchMinutes: m.MatchMinutes,
 		LevelIndex:   m.LevelIndex,
 		HostID:       m.HostID,
-	})
+	}
+}
+
+// resyncState is the SyncGameState standing in for every event up to
+// eventSeq, for a client that missed more than the event log keeps.
+func (m *ServerMatch) resyncState(eventSeq uint32) messages.SyncGameState {
+	m.syncGameState()
+	sync := messages.SyncGameState{
+		EventSeq: eventSeq,
+		State:    *netcomponents.NetGameState.Get(m.room.world.Entry(m.gameStateEntity)),
+		Lobby:    m.lobbyUpdate(),
+	}
+	if m.State == netcomponents.MatchStateFinished {
+		sync.Stats = m.lastStats
+	}
+	return sync
 }
 
 func (m *ServerMatch) canStart() bool {
@@ -624,6 +785,20 @@ func (m *ServerMatch) OnDisconnect(playerID uint32) {
 	m.broadcastLobbyUpdate()
 }
 
+// addBot seats a bot in the first empty slot, on the team autoTeam picks,
+// and returns the slot's index, or -1 when every slot is taken.
+func (m *ServerMatch) addBot(name string, difficulty int) int {
+	slotIdx := m.FirstEmptySlot()
+	if slotIdx < 0 {
+		return -1
+	}
+	m.Slots[slotIdx].Type = 2 // Bot
+	m.Slots[slotIdx].Difficulty = difficulty
+	m.Slots[slotIdx].Name = name
+	m.Slots[slotIdx].Team = m.autoTeam(slotIdx)
+	return slotIdx
+}
+
 func (m *ServerMatch) FirstEmptySlot() int {
 	for i := range m.Slots {
 		if m.Slots[i].Type == 0 {
@@ -0,0 +1,323 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/hashicorp/go-msgpack/v2/codec"
+	"github.com/leap-fish/necs/router"
+	"github.com/leap-fish/necs/typeid"
+)
+
+// tickBuckets are the tick duration histogram's upper bounds, in seconds.
+// A 60 Hz tick has 16.7 ms to run.
+var tickBuckets = []float64{0.0005, 0.001, 0.002, 0.004, 0.008, 0.016, 0.033, 0.066}
+
+// serverMetrics counts what the server does, for MetricsHandler. Gauges
+// that can be read off the rooms are computed at scrape time instead.
+type serverMetrics struct {
+	tickSeconds  histogram
+	tickOverruns atomic.Uint64 // Ticks that took longer than the tick interval
+	syncErrors   atomic.Uint64 // Snapshots that failed to encode or send
+
+	// Messages and bytes, keyed by message type.
+	sent, sentBytes, received, receivedBytes counterVec
+
+	matchStarts, matchEnds counterVec // Keyed by game mode
+	leaderboard            counterVec // Keyed by "success" or "failure"
+
+	// messageNames names the message types the router has handlers for,
+	// by type ID, so received messages can be counted by name.
+	namesMu      sync.RWMutex
+	messageNames map[uint]string
+}
+
+func newServerMetrics() *serverMetrics {
+	return &serverMetrics{
+		tickSeconds:  histogram{bounds: tickBuckets, counts: make([]uint64, len(tickBuckets))},
+		messageNames: make(map[uint]string),
+	}
+}
+
+// counterVec is a set of counters keyed by one label's value.
+type counterVec struct {
+	mu     sync.Mutex
+	values map[string]uint64
+}
+
+func (c *counterVec) add(label string, n uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.values == nil {
+		c.values = make(map[string]uint64)
+	}
+	c.values[label] += n
+}
+
+func (c *counterVec) get(label string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[label]
+}
+
+// snapshot returns a copy of the counters and their labels in order.
+func (c *counterVec) snapshot() (map[string]uint64, []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	values := make(map[string]uint64, len(c.values))
+	for label, v := range c.values {
+		values[label] = v
+	}
+	labels := make([]string, 0, len(values))
+	for label := range values {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return values, labels
+}
+
+// histogram counts observations into cumulative buckets, as Prometheus
+// histograms do.
+type histogram struct {
+	mu     sync.Mutex
+	bounds []float64
+	counts []uint64 // Per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+func (h *histogram) observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i, _ := slices.BinarySearch(h.bounds, v); i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += v
+}
+
+// observeTick records a game loop tick that took d against a tick
+// interval of budget.
+func (m *serverMetrics) observeTick(d, budget time.Duration) {
+	m.tickSeconds.observe(d.Seconds())
+	if d > budget {
+		m.tickOverruns.Add(1)
+	}
+}
+
+// nameMessage names type ID id for the received-message counters.
+func (m *serverMetrics) nameMessage(id uint, name string) {
+	m.namesMu.Lock()
+	defer m.namesMu.Unlock()
+	m.messageNames[id] = name
+}
+
+// countReceived counts payload under its message type, read from the
+// type ID the router's serializer writes first.
+func (m *serverMetrics) countReceived(payload []byte) {
+	name := "unknown"
+	var id uint
+	if codec.NewDecoderBytes(payload, &codec.MsgpackHandle{}).Decode(&id) == nil {
+		m.namesMu.RLock()
+		if known, ok := m.messageNames[id]; ok {
+			name = known
+		}
+		m.namesMu.RUnlock()
+	}
+	m.received.add(name, 1)
+	m.receivedBytes.add(name, uint64(len(payload)))
+}
+
+// on registers fn as the router callback for messages of type T, naming
+// T for the received-message counters.
+func on[T any](s *Server, fn func(*router.NetworkClient, T)) {
+	typ := reflect.TypeFor[T]()
+	s.metrics.nameMessage(typeid.GetTypeId(typ), typ.Name())
+	router.On(fn)
+}
+
+// send sends msg to client, counting it under its type.
+func (s *Server) send(client *router.NetworkClient, msg any) error {
+	payload, err := router.Serialize(msg)
+	if err != nil {
+		return fmt.Errorf("unable to serialize message: %w", err)
+	}
+	return s.sendBytes(client, reflect.TypeOf(msg).Name(), payload)
+}
+
+// sendBytes sends a message serialized ahead of time to client, counting
+// it under name.
+func (s *Server) sendBytes(client *router.NetworkClient, name string, payload []byte) error {
+	if err := client.SendMessageBytes(payload); err != nil {
+		return err
+	}
+	s.metrics.sent.add(name, 1)
+	s.metrics.sentBytes.add(name, uint64(len(payload)))
+	return nil
+}
+
+// CountLeaderboardSubmit records the outcome of one leaderboard score
+// submission made by the MatchEndHook.
+func (s *Server) CountLeaderboardSubmit(err error) {
+	if err != nil {
+		s.metrics.leaderboard.add("failure", 1)
+		return
+	}
+	s.metrics.leaderboard.add("success", 1)
+}
+
+// wsEvents passes WebSocket events on to the router, as necs's server
+// transport does, counting the messages received.
+type wsEvents struct {
+	s *Server
+}
+
+func (w wsEvents) OnConnect(_ context.Context, conn *websocket.Conn) {
+	router.CallConnect(conn)
+}
+
+func (w wsEvents) OnDisconnect(_ context.Context, conn *websocket.Conn, err error) {
+	router.CallDisconnect(conn, err)
+}
+
+func (w wsEvents) OnError(_ context.Context, conn *websocket.Conn, err error) {
+	router.CallError(conn, err)
+}
+
+func (w wsEvents) OnMessage(_ context.Context, conn *websocket.Conn, payload []byte) {
+	w.s.metrics.countReceived(payload)
+	if err := router.CallProcessMessage(conn, payload); err != nil {
+		router.CallError(conn, err)
+	}
+}
+
+// MetricsHandler returns an http.Handler that serves the server's metrics
+// in the Prometheus text exposition format. It needs no authentication;
+// serve it on a port only the scraper can reach.
+func (s *Server) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		s.writeMetrics(w)
+	})
+}
+
+// roomGauges is one room's gauges, read at scrape time.
+type roomGauges struct {
+	code       string
+	players    int
+	bots       int
+	queueDepth int
+}
+
+func (s *Server) writeMetrics(w io.Writer) {
+	m := s.metrics
+	e := expositionWriter{w: w}
+
+	s.mu.RLock()
+	pending := len(s.pendingClients)
+	connected := len(s.clientRooms) + pending
+	s.mu.RUnlock()
+
+	rooms := s.roomList()
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].code < rooms[j].code })
+	gauges := make([]roomGauges, 0, len(rooms))
+	for _, r := range rooms {
+		gauges = append(gauges, roomGauges{
+			code:       r.code,
+			players:    r.PlayerCount(),
+			bots:       int(r.bots.Load()),
+			queueDepth: len(r.cmdCh),
+		})
+	}
+
+	e.header("doomerang_clients_connected", "gauge", "Open client connections, in a room or not.")
+	e.sample("doomerang_clients_connected", "", float64(connected))
+	e.header("doomerang_clients_pending", "gauge", "Connections that have not yet picked a room.")
+	e.sample("doomerang_clients_pending", "", float64(pending))
+	e.header("doomerang_rooms", "gauge", "Open rooms, including the default room.")
+	e.sample("doomerang_rooms", "", float64(len(rooms)))
+
+	e.header("doomerang_room_players", "gauge", "Joined human players per room; bots are counted in doomerang_room_bots.")
+	for _, g := range gauges {
+		e.sample("doomerang_room_players", label("room", g.code), float64(g.players))
+	}
+	e.header("doomerang_room_bots", "gauge", "Bots per room.")
+	for _, g := range gauges {
+		e.sample("doomerang_room_bots", label("room", g.code), float64(g.bots))
+	}
+	e.header("doomerang_room_command_queue_depth", "gauge", "Commands queued for each room's game loop.")
+	for _, g := range gauges {
+		e.sample("doomerang_room_command_queue_depth", label("room", g.code), float64(g.queueDepth))
+	}
+
+	e.histogram("doomerang_tick_duration_seconds", "Time each room's game loop takes to run one tick.", &m.tickSeconds)
+	e.header("doomerang_tick_overruns_total", "counter", "Ticks that took longer than the tick interval.")
+	e.sample("doomerang_tick_overruns_total", "", float64(m.tickOverruns.Load()))
+	e.header("doomerang_sync_errors_total", "counter", "Snapshots that failed to encode or send.")
+	e.sample("doomerang_sync_errors_total", "", float64(m.syncErrors.Load()))
+
+	e.counterVec("doomerang_messages_sent_total", "Messages sent to clients, by type.", "type", &m.sent)
+	e.counterVec("doomerang_message_bytes_sent_total", "Bytes sent to clients, by message type.", "type", &m.sentBytes)
+	e.counterVec("doomerang_messages_received_total", "Messages received from clients, by type.", "type", &m.received)
+	e.counterVec("doomerang_message_bytes_received_total", "Bytes received from clients, by message type.", "type", &m.receivedBytes)
+
+	e.counterVec("doomerang_match_starts_total", "Matches started, by game mode.", "mode", &m.matchStarts)
+	e.counterVec("doomerang_match_ends_total", "Matches ended, by game mode.", "mode", &m.matchEnds)
+	e.counterVec("doomerang_leaderboard_submissions_total", "Leaderboard score submissions, by result.", "result", &m.leaderboard)
+}
+
+// expositionWriter writes metrics in the Prometheus text format.
+type expositionWriter struct {
+	w io.Writer
+}
+
+func (e expositionWriter) header(name, kind, help string) {
+	_, _ = fmt.Fprintf(e.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (e expositionWriter) sample(name, labels string, v float64) {
+	_, _ = fmt.Fprintf(e.w, "%s%s %s\n", name, labels, strconv.FormatFloat(v, 'g', -1, 64))
+}
+
+func (e expositionWriter) counterVec(name, help, key string, c *counterVec) {
+	e.header(name, "counter", help)
+	values, labels := c.snapshot()
+	for _, l := range labels {
+		e.sample(name, label(key, l), float64(values[l]))
+	}
+}
+
+func (e expositionWriter) histogram(name, help string, h *histogram) {
+	h.mu.Lock()
+	counts := slices.Clone(h.counts)
+	count, sum := h.count, h.sum
+	h.mu.Unlock()
+
+	e.header(name, "histogram", help)
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += counts[i]
+		e.sample(name+"_bucket", label("le", strconv.FormatFloat(bound, 'g', -1, 64)), float64(cumulative))
+	}
+	e.sample(name+"_bucket", label("le", "+Inf"), float64(count))
+	e.sample(name+"_sum", "", sum)
+	e.sample(name+"_count", "", float64(count))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// label formats a single-label set.
+func label(key, value string) string {
+	return fmt.Sprintf(`{%s="%s"}`, key, labelEscaper.Replace(value))
+}
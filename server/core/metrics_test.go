@@ -0,0 +1,94 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/leap-fish/necs/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrapeMetrics(t *testing.T, s *Server) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain; version=0.0.4")
+	return rec.Body.String()
+}
+
+func TestServer_MetricsHandler_reports_rooms_and_counters(t *testing.T) {
+	s := newRoomTestServer(t, emptyTestLevel())
+	r := newIdleTestRoom(t, s)
+	r.cmdCh <- func() {}
+	r.bots.Store(2)
+	s.CountLeaderboardSubmit(nil)
+	s.CountLeaderboardSubmit(assert.AnError)
+
+	body := scrapeMetrics(t, s)
+
+	assert.Contains(t, body, "# TYPE doomerang_room_command_queue_depth gauge\n")
+	assert.Contains(t, body, `doomerang_room_command_queue_depth{room="`+r.code+`"} 1`+"\n")
+	assert.Contains(t, body, `doomerang_room_bots{room="`+r.code+`"} 2`+"\n")
+	assert.Contains(t, body, "doomerang_rooms 2\n")
+	assert.Contains(t, body, "doomerang_clients_connected 0\n")
+	assert.Contains(t, body, `doomerang_leaderboard_submissions_total{result="failure"} 1`+"\n")
+	assert.Contains(t, body, `doomerang_leaderboard_submissions_total{result="success"} 1`+"\n")
+}
+
+func TestServerMetrics_observeTick(t *testing.T) {
+	s := newRoomTestServer(t, emptyTestLevel())
+	budget := time.Second / 60
+	s.metrics.observeTick(3*time.Millisecond, budget)
+	s.metrics.observeTick(20*time.Millisecond, budget)
+
+	body := scrapeMetrics(t, s)
+
+	assert.Contains(t, body, `doomerang_tick_duration_seconds_bucket{le="0.002"} 0`+"\n")
+	assert.Contains(t, body, `doomerang_tick_duration_seconds_bucket{le="0.004"} 1`+"\n")
+	assert.Contains(t, body, `doomerang_tick_duration_seconds_bucket{le="0.033"} 2`+"\n")
+	assert.Contains(t, body, `doomerang_tick_duration_seconds_bucket{le="+Inf"} 2`+"\n")
+	assert.Contains(t, body, "doomerang_tick_duration_seconds_count 2\n")
+	assert.Contains(t, body, "doomerang_tick_overruns_total 1\n")
+}
+
+func TestServer_send_counts_messages_by_type(t *testing.T) {
+	s := newRoomTestServer(t, emptyTestLevel())
+	client := newTestClient(t)
+
+	require.NoError(t, s.send(client, messages.ClockSyncResponse{ClientTime: 1, ServerTime: 2}))
+	require.NoError(t, s.send(client, messages.ClockSyncResponse{ClientTime: 3, ServerTime: 4}))
+
+	assert.Equal(t, uint64(2), s.metrics.sent.get("ClockSyncResponse"))
+	assert.Positive(t, s.metrics.sentBytes.get("ClockSyncResponse"))
+}
+
+func TestServerMetrics_countReceived_names_handled_types(t *testing.T) {
+	s := newRoomTestServer(t, emptyTestLevel())
+	pong, err := router.Serialize(messages.Pong{SentAt: 1})
+	require.NoError(t, err)
+
+	s.metrics.countReceived(pong)
+	s.metrics.countReceived([]byte{0xc1})
+
+	assert.Equal(t, uint64(1), s.metrics.received.get("Pong"))
+	assert.Equal(t, uint64(len(pong)), s.metrics.receivedBytes.get("Pong"))
+	assert.Equal(t, uint64(1), s.metrics.received.get("unknown"), "undecodable payloads are still counted")
+}
+
+func TestServerMatch_counts_starts_and_ends_by_mode(t *testing.T) {
+	s := newRoomTestServer(t, emptyTestLevel())
+	r := newIdleTestRoom(t, s)
+	r.match.GameMode = "coop"
+
+	r.match.startMatch()
+	r.match.endMatch("time")
+
+	body := scrapeMetrics(t, s)
+	assert.Contains(t, body, `doomerang_match_starts_total{mode="coop"} 1`+"\n")
+	assert.Contains(t, body, `doomerang_match_ends_total{mode="coop"} 1`+"\n")
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
+	"github.com/automoto/doomerang-mp/shared/netconfig"
+	"github.com/automoto/doomerang-mp/shared/playersim/playersimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRoom_updatePhysics_matches_playersim replays the shared movement
+// scripts through the server's input queue and physics tick: the synced
+// trajectory must be exactly what playersim produces on its own.
+func TestRoom_updatePhysics_matches_playersim(t *testing.T) {
+	for _, s := range playersimtest.Scripts {
+		t.Run(s.Name, func(t *testing.T) {
+			levels := map[string]*ServerLevel{"sim": NewServerLevel(playersimtest.Level())}
+			srv := NewServer(60, "sim", "", levels, []string{"sim"})
+			t.Cleanup(srv.Stop)
+			r := newIdleTestRoom(t, srv)
+			r.match.State = netcomponents.MatchStatePlaying
+
+			entity, _ := addTestPlayer(t, r, s.X, s.Y)
+			pp := r.playerPhysics[entity]
+			entry := r.world.Entry(entity)
+
+			got := make([]playersimtest.Frame, 0, len(s.Inputs))
+			for i, in := range s.Inputs {
+				input := messages.NewPlayerInput(uint32(i + 1))
+				input.Direction = in.Direction
+				input.Actions.Set(netconfig.ActionJump, in.Jump)
+				input.Actions.Set(netconfig.ActionCrouch, in.Crouch)
+				input.Actions.Set(netconfig.ActionAttack, in.Attack)
+				pp.Inputs.push(input)
+				r.updatePhysics()
+
+				pos := netcomponents.NetPosition.Get(entry)
+				vel := netcomponents.NetVelocity.Get(entry)
+				got = append(got, playersimtest.Frame{
+					X: pos.X, Y: pos.Y,
+					SpeedX: vel.SpeedX, SpeedY: vel.SpeedY,
+					State: netcomponents.NetPlayerState.Get(entry).StateID,
+				})
+			}
+
+			want := playersimtest.Run(s)
+			for i := range want {
+				want[i].Events = 0 // Not synced
+			}
+			assert.Equal(t, want, got)
+		})
+	}
+}
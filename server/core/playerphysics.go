@@ -1,6 +1,7 @@
 package core
 
 import (
+	"github.com/automoto/doomerang-mp/shared/playersim"
 	"github.com/solarlune/resolv"
 	"github.com/yohamta/donburi"
 )
@@ -9,12 +10,15 @@ import (
 // donburi component — it exists only on the server and is never synced.
 type PlayerPhysics struct {
 	Object   *resolv.Object
-	OnGround bool
+	Body     playersim.Body
+	Movement playersim.Movement
 
-	// Latest input snapshot (written by onPlayerInput, read by physics tick)
-	Direction      int
-	JumpPressed    bool
-	JumpWasPressed bool // previous frame, for edge detection
+	// Inputs queued by onPlayerInput, consumed one per physics sub-step
+	Inputs inputQueue
+
+	// Current input (played back from Inputs, or set directly for bots)
+	Direction   int
+	JumpPressed bool
 
 	// Boomerang input state
 	BoomerangPressed    bool
@@ -35,6 +39,9 @@ type PlayerPhysics struct {
 	HitTargets       map[donburi.Entity]struct{}
 	InvulnFrames     int
 	Dead             bool
+	// LastAttacker is the network ID of the last player to hit this one
+	// since they spawned. Deaths to hazards are credited to them.
+	LastAttacker uint
 
 	// State timer: counts down to unlock a locked animation state (Throw, Hit)
 	LockedStateTimer int
@@ -44,12 +51,12 @@ type PlayerPhysics struct {
 }
 
 func newPlayerPhysics(level *ServerLevel, spawnX, spawnY float64) *PlayerPhysics {
-	obj := resolv.NewObject(spawnX, spawnY, 16, 40, "player")
-	obj.SetShape(resolv.NewRectangle(0, 0, 16, 40))
+	obj := playersim.NewPlayerObject(spawnX, spawnY, "player")
 	level.Space.Add(obj)
 
 	return &PlayerPhysics{
 		Object:     obj,
+		Movement:   playersim.NewMovement(),
 		HitTargets: make(map[donburi.Entity]struct{}),
 	}
 }
@@ -0,0 +1,112 @@
+package core
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/leap-fish/necs/router"
+)
+
+// newReconnectToken returns the random token a client presents to reclaim
+// its slot after a dropped connection.
+func newReconnectToken() string {
+	tokenBytes := make([]byte, 16)
+	_, _ = rand.Read(tokenBytes)
+	return fmt.Sprintf("%x", tokenBytes)
+}
+
+// holds reports whether the room is holding a dropped player for token.
+func (r *Room) holds(token string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.held[token]
+	return ok
+}
+
+// reclaim hands the player held under req.ReconnectToken to client.
+// Returns false if the hold has already expired or been claimed.
+func (r *Room) reclaim(client *router.NetworkClient, req messages.JoinRequest) bool {
+	token := req.ReconnectToken
+
+	r.mu.Lock()
+	timer, ok := r.held[token]
+	if !ok {
+		r.mu.Unlock()
+		return false
+	}
+	timer.Stop()
+	delete(r.held, token)
+	r.joiningClients[client] = true
+	netID := r.tokenNetIDs[token]
+	r.mu.Unlock()
+
+	r.cmdCh <- func() {
+		r.resumePlayer(client, req, netID)
+	}
+	return true
+}
+
+// resumePlayer binds a reconnected client to its held player. Must be
+// called on the game loop goroutine.
+func (r *Room) resumePlayer(client *router.NetworkClient, req messages.JoinRequest, netID uint32) {
+	entity, ok := r.entityForNetID(netID)
+	if !ok {
+		// The entity went away while held (its slot was freed before a
+		// round rebuilt the world); join afresh instead.
+		r.mu.Lock()
+		delete(r.tokenNetIDs, req.ReconnectToken)
+		r.mu.Unlock()
+		r.place(client, req)
+		return
+	}
+
+	accepted := r.joinAccepted(netID, req.ReconnectToken)
+	r.bindClient(client, entity, netID, req.ReconnectToken, req.GgscaleSessionToken)
+	_ = r.server.send(client, accepted)
+
+	log.Printf("Player %q reconnected to room %s as networkID=%d (client %s)",
+		req.PlayerName, r.code, netID, client.Id())
+
+	// Bring the returning client's lobby view up to date.
+	r.match.broadcastLobbyUpdate()
+}
+
+// idlePlayer clears a held player's inputs so the body stands still
+// while its owner is away. Must be called on the game loop goroutine.
+func (r *Room) idlePlayer(netID uint32) {
+	entity, ok := r.entityForNetID(netID)
+	if !ok {
+		return
+	}
+	pp := r.playerPhysics[entity]
+	pp.Inputs.reset()
+	pp.Direction = 0
+	pp.JumpPressed = false
+	pp.AttackPressed = false
+	pp.BoomerangPressed = false
+	pp.MoveUpPressed = false
+	pp.CrouchPressed = false
+}
+
+// expireHold gives up on a dropped player whose grace window ran out.
+// Must be called on the game loop goroutine.
+func (r *Room) expireHold(token string) {
+	r.mu.Lock()
+	_, stillHeld := r.held[token]
+	netID := r.tokenNetIDs[token]
+	if stillHeld {
+		delete(r.held, token)
+		delete(r.tokenNetIDs, token)
+	}
+	r.mu.Unlock()
+
+	if !stillHeld {
+		return
+	}
+
+	log.Printf("Reconnect window expired for nid=%d in room %s", netID, r.code)
+	r.dropPlayer(netID)
+	r.server.closeRoomIfEmpty(r)
+}
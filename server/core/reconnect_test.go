@@ -0,0 +1,93 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/leap-fish/necs/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newHeldTestRoom registers a room whose loop is not running, with one
+// bound player, so tests can drive the reconnect bookkeeping directly.
+func newHeldTestRoom(t *testing.T, grace time.Duration) (*Server, *Room, *router.NetworkClient, uint32) {
+	t.Helper()
+	s := newRoomTestServer(t, emptyTestLevel())
+	s.SetReconnectGrace(grace)
+
+	r := newIdleTestRoom(t, s)
+	entity, netID := addTestPlayer(t, r, 0, 0)
+
+	client := &router.NetworkClient{}
+	r.bindClient(client, entity, netID, "token", "")
+	return s, r, client, netID
+}
+
+func TestRoom_removeClient_holds_player_for_reconnect(t *testing.T) {
+	s, r, client, netID := newHeldTestRoom(t, time.Minute)
+
+	r.removeClient(client)
+	r.ProcessCommands()
+
+	assert.True(t, r.holds("token"))
+	assert.Equal(t, 1, r.clientCount(), "held player keeps its place in the room")
+	_, ok := r.entityForNetID(netID)
+	assert.True(t, ok, "held player's entity stays in the world")
+
+	s.closeRoomIfEmpty(r)
+	assert.Same(t, r, s.roomHolding("token"), "room with a held player stays open")
+
+	assert.False(t, r.reclaim(&router.NetworkClient{}, messages.JoinRequest{ReconnectToken: "other"}))
+	assert.True(t, r.reclaim(&router.NetworkClient{}, messages.JoinRequest{ReconnectToken: "token"}))
+	assert.False(t, r.holds("token"))
+	assert.False(t, r.reclaim(&router.NetworkClient{}, messages.JoinRequest{ReconnectToken: "token"}),
+		"a token can only be reclaimed once")
+}
+
+func TestRoom_expireHold_drops_player_and_closes_room(t *testing.T) {
+	s, r, client, netID := newHeldTestRoom(t, time.Minute)
+
+	r.removeClient(client)
+	r.ProcessCommands()
+	require.True(t, r.holds("token"))
+
+	r.expireHold("token")
+
+	assert.False(t, r.holds("token"))
+	_, ok := r.entityForNetID(netID)
+	assert.False(t, ok, "expired player's entity is removed")
+	assert.Nil(t, s.roomHolding("token"))
+	assert.Equal(t, 1, s.RoomCount(), "empty room closes once the hold expires")
+}
+
+func TestRoom_removeClient_without_grace_drops_immediately(t *testing.T) {
+	_, r, client, netID := newHeldTestRoom(t, 0)
+
+	r.removeClient(client)
+	r.ProcessCommands()
+
+	assert.False(t, r.holds("token"))
+	assert.Equal(t, 0, r.clientCount())
+	_, ok := r.entityForNetID(netID)
+	assert.False(t, ok)
+}
+
+func TestServer_rejected_reconnect_closes_the_connection(t *testing.T) {
+	s := newRoomTestServer(t, emptyTestLevel())
+	client, received := newRecordingTestClient(t)
+	s.onConnect(client)
+
+	s.onJoinRequest(client, messages.JoinRequest{PlayerName: "Player", ReconnectToken: "stale"})
+
+	rejected, ok := nextMessage(t, received).(messages.JoinRejected)
+	require.True(t, ok)
+	assert.Equal(t, "reconnect window expired", rejected.Reason)
+	select {
+	case msg, open := <-received:
+		assert.False(t, open, "unexpected %T", msg)
+	case <-time.After(time.Second):
+		t.Fatal("the rejected connection was left open")
+	}
+}
@@ -0,0 +1,167 @@
+package core
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/automoto/doomerang-mp/shared/hazards"
+	"github.com/automoto/doomerang-mp/shared/leveldata"
+	"github.com/automoto/doomerang-mp/shared/playersim"
+	"github.com/automoto/doomerang-mp/tags"
+	"github.com/solarlune/resolv"
+	"github.com/yohamta/donburi"
+)
+
+const (
+	// respawnMinThreatDist is how close a threat may be before a spawn
+	// point counts as unsafe — matches the offline minDistFromPlayers.
+	respawnMinThreatDist = 150.0
+	// respawnGroundReach is how far below a spawn point the ground may be.
+	// Level spawn points float a few pixels above the floor.
+	respawnGroundReach = 16.0
+)
+
+// respawnSpot is a spawn point open to a respawning player, with what a
+// RespawnPolicy weighs it by.
+type respawnSpot struct {
+	leveldata.SpawnPoint
+	Safe   bool    // Ground underfoot and clear of dead zones
+	Threat float64 // Distance to the nearest threat, +Inf if there is none
+}
+
+// RespawnPolicy picks the spawn point a knocked-out player comes back at.
+// It returns an index into spots, which is never empty.
+type RespawnPolicy func(spots []respawnSpot, entity donburi.Entity) int
+
+// respawnPolicies is the RespawnPolicy for each game mode. Modes missing
+// here use respawnFarthest.
+var respawnPolicies = map[string]RespawnPolicy{
+	"ffa":  respawnFarthest,
+	"1v1":  respawnFarthest,
+	"2v2":  respawnFarthest,
+	"coop": respawnRandomSafe,
+}
+
+// respawnFixed cycles through the spawn points by entity, regardless of
+// threats.
+func respawnFixed(spots []respawnSpot, entity donburi.Entity) int {
+	return int(entity) % len(spots)
+}
+
+// respawnFarthest picks the safe spawn point farthest from any threat,
+// falling back to respawnFixed if none is safe.
+func respawnFarthest(spots []respawnSpot, entity donburi.Entity) int {
+	best := -1
+	for i, spot := range spots {
+		if spot.Safe && (best == -1 || spot.Threat > spots[best].Threat) {
+			best = i
+		}
+	}
+	if best == -1 {
+		return respawnFixed(spots, entity)
+	}
+	return best
+}
+
+// respawnRandomSafe picks a random safe spawn point with no threat within
+// respawnMinThreatDist, falling back to respawnFarthest if there is none.
+func respawnRandomSafe(spots []respawnSpot, entity donburi.Entity) int {
+	var clear []int
+	for i, spot := range spots {
+		if spot.Safe && spot.Threat >= respawnMinThreatDist {
+			clear = append(clear, i)
+		}
+	}
+	if len(clear) == 0 {
+		return respawnFarthest(spots, entity)
+	}
+	return clear[rand.Intn(len(clear))]
+}
+
+// chooseRespawnPoint returns where the knocked-out player entity comes
+// back, using the game mode's RespawnPolicy.
+func (r *Room) chooseRespawnPoint(entity donburi.Entity, pp *PlayerPhysics) (x, y float64) {
+	spots := r.respawnSpots(entity, pp)
+	if len(spots) == 0 {
+		return 100, 100
+	}
+	policy, ok := respawnPolicies[r.match.GameMode]
+	if !ok {
+		policy = respawnFarthest
+	}
+	spot := spots[policy(spots, entity)]
+	return spot.X, spot.Y
+}
+
+// respawnSpots scores the spawn points open to entity. In team modes each
+// team keeps to its own side of the level — team 0 the left half of the
+// spawn points, team 1 the right — as offline 2v2 spawns do.
+func (r *Room) respawnSpots(entity donburi.Entity, pp *PlayerPhysics) []respawnSpot {
+	points := r.activeLevel.SpawnPoints
+	if len(points) >= 2 {
+		switch r.match.teamOf(uint32(r.networkID(entity))) {
+		case 0:
+			points = points[:len(points)/2]
+		case 1:
+			points = points[len(points)/2:]
+		}
+	}
+
+	threats := r.respawnThreats(entity)
+	spots := make([]respawnSpot, len(points))
+	for i, point := range points {
+		threat := math.Inf(1)
+		for _, obj := range threats {
+			threat = min(threat, math.Hypot(point.X-obj.X, point.Y-obj.Y))
+		}
+		spots[i] = respawnSpot{
+			SpawnPoint: point,
+			Safe:       isSpawnSafe(r.activeLevel.Space, point.X, point.Y, pp.Object.W, pp.Object.H),
+			Threat:     threat,
+		}
+	}
+	return spots
+}
+
+// respawnThreats returns everything that could hurt entity on respawn:
+// living opponents and enemies, and boomerangs and knives in flight.
+func (r *Room) respawnThreats(entity donburi.Entity) []*resolv.Object {
+	victim := uint32(r.networkID(entity))
+	var threats []*resolv.Object
+	for other, otherPP := range r.playerPhysics {
+		if other == entity || !r.world.Valid(other) || otherPP.Dead {
+			continue
+		}
+		if r.match.canHurt(uint32(r.networkID(other)), victim) {
+			threats = append(threats, otherPP.Object)
+		}
+	}
+	for _, enemy := range r.enemies {
+		if !enemy.Dead() {
+			threats = append(threats, enemy.Object)
+		}
+	}
+	for _, bp := range r.boomerangPhysics {
+		if bp.OwnerEntity != entity && r.match.canHurt(uint32(bp.OwnerNetworkID), victim) {
+			threats = append(threats, bp.Object)
+		}
+	}
+	for _, knife := range r.knives {
+		threats = append(threats, knife.Object)
+	}
+	return threats
+}
+
+// isSpawnSafe reports whether a w×h body at x, y is clear of dead zones
+// and has ground within respawnGroundReach below — the server's take on
+// the offline isPositionSafe.
+func isSpawnSafe(space *resolv.Space, x, y, w, h float64) bool {
+	obj := resolv.NewObject(x, y, w, h)
+	space.Add(obj)
+	defer space.Remove(obj)
+
+	if hazards.InDeadZone(obj) {
+		return false
+	}
+	return obj.Check(0, respawnGroundReach, tags.ResolvSolid, playersim.TagPlatform, tags.ResolvRamp) != nil
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"math"
+	"testing"
+
+	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/leveldata"
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
+	"github.com/stretchr/testify/assert"
+	"github.com/yohamta/donburi"
+)
+
+func TestRespawnPolicies(t *testing.T) {
+	spots := []respawnSpot{
+		{Safe: true, Threat: 40},
+		{Safe: true, Threat: 200},
+		{Safe: false, Threat: 500},
+		{Safe: true, Threat: 300},
+	}
+
+	assert.Equal(t, 1, respawnFixed(spots, donburi.Entity(5)))
+	assert.Equal(t, 3, respawnFarthest(spots, 0))
+	for range 20 {
+		assert.Contains(t, []int{1, 3}, respawnRandomSafe(spots, 0))
+	}
+
+	// Nothing safe: fall back to fixed spawn points
+	unsafe := []respawnSpot{{Threat: 100}, {Threat: 200}}
+	assert.Equal(t, 1, respawnFarthest(unsafe, donburi.Entity(3)))
+	// Nothing clear: fall back to the farthest
+	crowded := []respawnSpot{{Safe: true, Threat: 10}, {Safe: true, Threat: 20}}
+	assert.Equal(t, 1, respawnRandomSafe(crowded, 0))
+}
+
+// newRespawnTestRoom returns a room on a floor that stops short of its
+// last spawn point, at x=600. Spawn points float a little above the floor,
+// as they do in real levels.
+func newRespawnTestRoom(t *testing.T, gameMode string) *Room {
+	t.Helper()
+	spawnY := 416 - float64(cfg.Player.CollisionHeight) - 8
+	var spawns []leveldata.SpawnPoint
+	for i, x := range []float64{40, 200, 400, 600} {
+		spawns = append(spawns, leveldata.SpawnPoint{X: x, Y: spawnY, Index: i})
+	}
+	r := newIdleTestRoom(t, newRoomTestServer(t, &leveldata.CollisionData{
+		MapWidth:    640,
+		MapHeight:   480,
+		SolidRects:  []leveldata.SolidRect{{X: 0, Y: 416, W: 480, H: 16}},
+		SpawnPoints: spawns,
+	}))
+	r.match.GameMode = gameMode
+	return r
+}
+
+// standOnFloor drops a test player onto the floor of the respawn test room.
+func standOnFloor(t *testing.T, r *Room, x float64) (donburi.Entity, uint32) {
+	t.Helper()
+	entity, netID := addTestPlayer(t, r, x, 0)
+	obj := r.playerPhysics[entity].Object
+	obj.Y = 416 - obj.H
+	obj.Update()
+	return entity, netID
+}
+
+func TestRoom_chooseRespawnPoint_farthest_safe(t *testing.T) {
+	r := newRespawnTestRoom(t, "ffa")
+	victim, _ := standOnFloor(t, r, 300)
+	standOnFloor(t, r, 40)
+
+	// x=600 is farther from the killer, but over the pit
+	x, _ := r.chooseRespawnPoint(victim, r.playerPhysics[victim])
+	assert.Equal(t, 400.0, x)
+}
+
+func TestRoom_chooseRespawnPoint_avoids_boomerangs(t *testing.T) {
+	r := newRespawnTestRoom(t, "ffa")
+	victim, _ := standOnFloor(t, r, 300)
+	killer, killerNetID := standOnFloor(t, r, 40)
+	r.boomerangPhysics[r.world.Create(netcomponents.NetBoomerang)] = newBoomerangPhysics(r.activeLevel, 400, 380, killer, uint(killerNetID))
+
+	x, _ := r.chooseRespawnPoint(victim, r.playerPhysics[victim])
+	assert.Equal(t, 200.0, x)
+}
+
+func TestRoom_chooseRespawnPoint_team_side(t *testing.T) {
+	r := newRespawnTestRoom(t, "2v2")
+	victim, victimNetID := standOnFloor(t, r, 300)
+	_, opponentNetID := standOnFloor(t, r, 40)
+	r.match.Slots[0] = messages.LobbySlot{Type: 1, PlayerID: victimNetID, Team: 0}
+	r.match.Slots[1] = messages.LobbySlot{Type: 1, PlayerID: opponentNetID, Team: 1}
+
+	// Kept to the left half, however close the opponent
+	x, _ := r.chooseRespawnPoint(victim, r.playerPhysics[victim])
+	assert.Equal(t, 200.0, x)
+
+	spots := r.respawnSpots(victim, r.playerPhysics[victim])
+	assert.Len(t, spots, 2)
+	assert.False(t, math.IsInf(spots[0].Threat, 1))
+}
@@ -0,0 +1,810 @@
+package core
+
+import (
+	"crypto/rand"
+	"log"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/automoto/doomerang-mp/components"
+	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/enemysim"
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
+	"github.com/leap-fish/necs/esync"
+	"github.com/leap-fish/necs/router"
+	"github.com/yohamta/donburi"
+)
+
+// Room is one independent game hosted by a Server: its own donburi world,
+// level, ServerMatch, bots and game loop. Router callbacks land on the
+// Server, which forwards them to the client's room.
+type Room struct {
+	server *Server
+
+	id       string
+	code     string
+	password string
+	// public rooms can be picked for a JoinRequest that names no room.
+	public     bool
+	maxPlayers int
+
+	world donburi.World
+	sync  *roomSync
+	loop  *GameLoop
+
+	// levels holds this room's copies of the server's level templates.
+	// Each room needs its own resolv.Space because player and boomerang
+	// bodies are added to it.
+	levels      map[string]*ServerLevel
+	activeLevel *ServerLevel
+	activeName  string
+	// levelPinned is set once the admin API picks the level; joins no
+	// longer switch it.
+	levelPinned bool
+	// fireEntities sync activeLevel.Fires to clients, index for index.
+	fireEntities []donburi.Entity
+	// enemies and knives are co-op's AI enemies and the knives they have
+	// thrown, keyed by their synced entities; see enemies.go.
+	enemies map[donburi.Entity]*enemysim.Enemy
+	knives  map[donburi.Entity]*enemysim.Knife
+
+	playerPhysics    map[donburi.Entity]*PlayerPhysics
+	boomerangPhysics map[donburi.Entity]*BoomerangPhysics
+	playerBoomerangs map[donburi.Entity]donburi.Entity // player → active boomerang
+
+	// hurtboxes, latency and playout delay drive lag compensation; see
+	// lagcomp.go. latency, jitter and playoutDelay are keyed by netID and
+	// guarded by mu.
+	hurtboxes    *hurtboxHistory
+	latency      map[uint32]time.Duration
+	jitter       map[uint32]time.Duration
+	playoutDelay map[uint32]time.Duration
+	// snapshots keeps recently sent states as delta baselines and
+	// snapshotAcks each client's newest acknowledged Seq (guarded by mu);
+	// see snapshot.go.
+	snapshots    messages.SnapshotHistory
+	snapshotSeq  atomic.Uint32
+	snapshotAcks map[*router.NetworkClient]uint32
+
+	clientEntities   map[*router.NetworkClient]donburi.Entity
+	joiningClients   map[*router.NetworkClient]bool   // admitted, waiting for place; true for watch-only spectators
+	clientNetworkIDs map[*router.NetworkClient]uint32 // every bound client, players and spectators
+	networkIDClients map[uint32]*router.NetworkClient
+	// spectators are bound clients with no body and no slot. Queued ones
+	// are promoted into open slots, in join order, at the next round or
+	// match; watch-only ones never are.
+	spectators     map[*router.NetworkClient]*spectator
+	spectatorQueue []*router.NetworkClient
+	// Reconnect bookkeeping. clientTokens maps a live client to the token
+	// issued in its JoinAccepted; held keeps a dropped player's entity,
+	// slot, lives and score until the token is reclaimed or the grace
+	// window expires. tokenNetIDs and held are keyed by reconnect token.
+	clientTokens map[*router.NetworkClient]string
+	tokenNetIDs  map[string]uint32
+	held         map[string]*time.Timer
+	// ggscaleTokens is keyed by netID and holds each player's ggscale
+	// session JWT, captured from JoinRequest. Used at match end to
+	// submit scores via Leaderboards.SubmitFor.
+	ggscaleTokens map[uint32]string
+	match         *ServerMatch
+	mu            sync.RWMutex
+
+	cmdCh chan serverCmd
+
+	// events numbers and logs everything sent with broadcastEvent; see
+	// eventlog.go.
+	eventMu sync.Mutex
+	events  eventLog
+
+	// matchInProgress is true between ServerMatch.startMatch and endMatch.
+	// Server.Drain polls it to wait out an in-flight match before stopping.
+	matchInProgress atomic.Bool
+
+	// bots is the room's bot count as of the last tick, for metrics.
+	bots atomic.Int32
+	// status is the RoomStatus last reported to the RoomStatusHook. Only
+	// touched on the game loop goroutine.
+	status RoomStatus
+
+	// lastTick is when the game loop last ticked, in Unix nanoseconds, for
+	// /healthz. Zero until the loop starts.
+	lastTick atomic.Int64
+
+	// closed is set under mu once the room has been unregistered; admit
+	// refuses new clients after that.
+	closed   bool
+	stopOnce sync.Once
+}
+
+// roomOptions configures a new Room. Zero values fall back to the
+// ServerMatch defaults.
+type roomOptions struct {
+	password   string
+	public     bool
+	gameMode   string
+	maxPlayers int
+	level      string
+}
+
+const (
+	roomCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // no 0/O or 1/I
+	roomCodeLength   = 5
+)
+
+// newRoomCode returns a random, human-typeable room code.
+func newRoomCode() string {
+	b := make([]byte, roomCodeLength)
+	_, _ = rand.Read(b)
+	for i := range b {
+		b[i] = roomCodeAlphabet[int(b[i])%len(roomCodeAlphabet)]
+	}
+	return string(b)
+}
+
+func newRoom(server *Server, id, code string, opts roomOptions) *Room {
+	world := donburi.NewWorld()
+
+	maxPlayers := opts.maxPlayers
+	if maxPlayers <= 0 || maxPlayers > len(messages.LobbyUpdate{}.Slots) {
+		maxPlayers = len(messages.LobbyUpdate{}.Slots)
+	}
+
+	r := &Room{
+		server:           server,
+		id:               id,
+		code:             code,
+		password:         opts.password,
+		public:           opts.public,
+		maxPlayers:       maxPlayers,
+		world:            world,
+		sync:             newRoomSync(world),
+		levels:           make(map[string]*ServerLevel),
+		playerPhysics:    make(map[donburi.Entity]*PlayerPhysics),
+		boomerangPhysics: make(map[donburi.Entity]*BoomerangPhysics),
+		playerBoomerangs: make(map[donburi.Entity]donburi.Entity),
+		enemies:          make(map[donburi.Entity]*enemysim.Enemy),
+		knives:           make(map[donburi.Entity]*enemysim.Knife),
+		hurtboxes:        newHurtboxHistory(int(maxRewindCeiling/(time.Second/time.Duration(server.tickRate))) + 1),
+		latency:          make(map[uint32]time.Duration),
+		jitter:           make(map[uint32]time.Duration),
+		playoutDelay:     make(map[uint32]time.Duration),
+		snapshotAcks:     make(map[*router.NetworkClient]uint32),
+		clientEntities:   make(map[*router.NetworkClient]donburi.Entity),
+		joiningClients:   make(map[*router.NetworkClient]bool),
+		clientNetworkIDs: make(map[*router.NetworkClient]uint32),
+		networkIDClients: make(map[uint32]*router.NetworkClient),
+		spectators:       make(map[*router.NetworkClient]*spectator),
+		ggscaleTokens:    make(map[uint32]string),
+		clientTokens:     make(map[*router.NetworkClient]string),
+		tokenNetIDs:      make(map[string]uint32),
+		held:             make(map[string]*time.Timer),
+		cmdCh:            make(chan serverCmd, 64),
+	}
+
+	levelName := server.levelNames[0]
+	if _, ok := server.levels[opts.level]; ok {
+		levelName = opts.level
+	}
+	r.setActiveLevel(levelName, r.level(levelName))
+
+	r.loop = NewGameLoop(r, server.tickRate)
+	r.match = NewServerMatch(r)
+	r.match.MaxPlayers = maxPlayers
+	if opts.gameMode != "" {
+		r.match.GameMode = opts.gameMode
+	}
+
+	return r
+}
+
+// ID returns the room's stable identifier.
+func (r *Room) ID() string {
+	return r.id
+}
+
+// Code returns the short code players type to join this room.
+func (r *Room) Code() string {
+	return r.code
+}
+
+func (r *Room) start() {
+	go r.loop.Run()
+	go r.probeLatency(r.loop.stopChan)
+}
+
+// stop halts the room's game loop. Safe to call more than once.
+func (r *Room) stop() {
+	r.stopOnce.Do(r.loop.Stop)
+}
+
+// level returns this room's copy of the named level, cloning the
+// server's template on first use. Must be called on the game loop
+// goroutine or before the loop starts.
+func (r *Room) level(name string) *ServerLevel {
+	if lvl, ok := r.levels[name]; ok {
+		return lvl
+	}
+	tmpl, ok := r.server.levels[name]
+	if !ok {
+		return nil
+	}
+	lvl := tmpl.clone()
+	r.levels[name] = lvl
+	return lvl
+}
+
+// admit reserves a place for client. Watch-only spectators are capped
+// separately from players. Returns a reject reason, or "" on success.
+func (r *Room) admit(client *router.NetworkClient, watchOnly bool) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return "room closed"
+	}
+	if watchOnly {
+		if r.watcherCountLocked() >= maxSpectators {
+			return "spectators full"
+		}
+	} else if r.seatCountLocked() >= r.maxPlayers {
+		return "room full"
+	}
+	r.joiningClients[client] = watchOnly
+	return ""
+}
+
+// hasSeat reports whether another player could be admitted.
+func (r *Room) hasSeat() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return !r.closed && r.seatCountLocked() < r.maxPlayers
+}
+
+// seatCountLocked counts everyone who holds or is waiting for a player
+// slot. Callers must hold r.mu.
+func (r *Room) seatCountLocked() int {
+	n := len(r.clientEntities) + len(r.held) + len(r.spectatorQueue)
+	for _, watchOnly := range r.joiningClients {
+		if !watchOnly {
+			n++
+		}
+	}
+	return n
+}
+
+// watcherCountLocked counts watch-only spectators, joined or joining.
+// Callers must hold r.mu.
+func (r *Room) watcherCountLocked() int {
+	n := len(r.spectators) - len(r.spectatorQueue)
+	for _, watchOnly := range r.joiningClients {
+		if watchOnly {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *Room) ProcessCommands() {
+	for {
+		select {
+		case cmd := <-r.cmdCh:
+			cmd()
+		default:
+			return
+		}
+	}
+}
+
+// PlayerCount returns the number of connected human players in the room.
+func (r *Room) PlayerCount() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.clientEntities)
+}
+
+// clientCount includes clients that have been admitted but not yet
+// placed, spectators, and dropped players whose slot is held for reconnect.
+func (r *Room) clientCount() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.clientCountLocked()
+}
+
+func (r *Room) clientCountLocked() int {
+	return len(r.clientEntities) + len(r.joiningClients) + len(r.held) + len(r.spectators)
+}
+
+func (r *Room) World() donburi.World {
+	return r.world
+}
+
+func (r *Room) ActiveLevel() *ServerLevel {
+	return r.activeLevel
+}
+
+func (r *Room) Match() *ServerMatch {
+	return r.match
+}
+
+// snapshotGgscaleTokens returns a copy of the netID→session-token map.
+// Called from match-end paths so the hook can run without holding the
+// room lock.
+func (r *Room) snapshotGgscaleTokens() map[uint32]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make(map[uint32]string, len(r.ggscaleTokens))
+	for k, v := range r.ggscaleTokens {
+		out[k] = v
+	}
+	return out
+}
+
+// invokeMatchEndHook is called by ServerMatch.endMatch with the final
+// stats. Looks up the server's hook under the lock then runs it
+// outside — the hook makes network calls.
+func (r *Room) invokeMatchEndHook(stats map[uint32]messages.PlayerStats) {
+	r.server.mu.RLock()
+	hook := r.server.matchEndHook
+	r.server.mu.RUnlock()
+	if hook == nil {
+		return
+	}
+	hook(stats, r.snapshotGgscaleTokens())
+}
+
+// reportStatus invokes the RoomStatusHook if the room's mode, level or
+// match state changed since it last did. Must be called on the game loop
+// goroutine.
+func (r *Room) reportStatus() {
+	status := RoomStatus{
+		Code:       r.code,
+		Default:    r == r.server.defaultRoom,
+		Mode:       r.match.GameMode,
+		Level:      r.activeName,
+		MatchState: matchStateNames[r.match.State],
+	}
+	if status == r.status {
+		return
+	}
+	r.status = status
+
+	r.server.mu.RLock()
+	hook := r.server.roomStatusHook
+	r.server.mu.RUnlock()
+	if hook != nil {
+		hook(status)
+	}
+}
+
+func (r *Room) GetPlayerPhysics(entity donburi.Entity) *PlayerPhysics {
+	return r.playerPhysics[entity]
+}
+
+func (r *Room) SpawnBot(name string, difficulty cfg.BotDifficulty) {
+	r.cmdCh <- func() {
+		r.spawnBot(name, difficulty)
+	}
+}
+
+// join queues placing an admitted client on the game loop goroutine.
+func (r *Room) join(client *router.NetworkClient, req messages.JoinRequest) {
+	r.cmdCh <- func() {
+		r.place(client, req)
+	}
+}
+
+// place spawns client as a player, or seats it as a spectator when it
+// asked to watch, a match is under way, or every slot is taken. Must be
+// called on the game loop goroutine.
+func (r *Room) place(client *router.NetworkClient, req messages.JoinRequest) {
+	if req.Spectate || r.match.State != netcomponents.MatchStateWaiting || r.match.FirstEmptySlot() < 0 {
+		r.addSpectator(client, req)
+		return
+	}
+
+	// Switch active level if requested and no players connected yet
+	if req.Level != "" && req.Level != r.activeName && len(r.clientEntities) == 0 && !r.levelPinned {
+		if lvl := r.level(req.Level); lvl != nil {
+			r.setActiveLevel(req.Level, lvl)
+			log.Printf("Room %s switched active level to %q", r.code, req.Level)
+		}
+	}
+	r.spawnPlayer(client, req)
+}
+
+// spawnPlayer must be called on the game loop goroutine.
+func (r *Room) spawnPlayer(client *router.NetworkClient, req messages.JoinRequest) {
+	// Pick spawn point round-robin by player count
+	spawnX, spawnY := 100.0, 100.0
+	if len(r.activeLevel.SpawnPoints) > 0 {
+		idx := len(r.clientEntities) % len(r.activeLevel.SpawnPoints)
+		sp := r.activeLevel.SpawnPoints[idx]
+		spawnX, spawnY = sp.X, sp.Y
+	}
+
+	entity := r.world.Create(
+		netcomponents.NetPosition,
+		netcomponents.NetVelocity,
+		netcomponents.NetPlayerState,
+	)
+
+	entry := r.world.Entry(entity)
+	netcomponents.NetPosition.Set(entry, &netcomponents.NetPositionData{X: spawnX, Y: spawnY})
+	netcomponents.NetVelocity.Set(entry, &netcomponents.NetVelocityData{})
+	netcomponents.NetPlayerState.Set(entry, &netcomponents.NetPlayerStateData{
+		Direction: 1,
+		Health:    cfg.Player.Health,
+	})
+
+	// Create server-side physics for this player
+	pp := newPlayerPhysics(r.activeLevel, spawnX, spawnY)
+	r.playerPhysics[entity] = pp
+
+	err := r.sync.NetworkSync(entity,
+		netcomponents.NetPosition,
+		netcomponents.NetVelocity,
+		netcomponents.NetPlayerState,
+	)
+	if err != nil {
+		log.Printf("Failed to setup network sync for player: %v", err)
+		r.mu.Lock()
+		delete(r.joiningClients, client)
+		r.mu.Unlock()
+		r.server.reject(client, "internal server error")
+		return
+	}
+
+	networkID := esync.GetNetworkId(r.world.Entry(entity))
+	reconnectToken := newReconnectToken()
+
+	accepted := r.joinAccepted(uint32(*networkID), reconnectToken)
+	r.bindClient(client, entity, uint32(*networkID), reconnectToken, req.GgscaleSessionToken)
+	_ = r.server.send(client, accepted)
+
+	log.Printf("Player %q joined room %s as entity networkID=%d (client %s)",
+		req.PlayerName, r.code, *networkID, client.Id())
+
+	// Assign to lobby slot
+	r.cmdCh <- func() {
+		r.match.OnLobbyAction(uint32(*networkID), messages.LobbyAction{
+			Action: "pick_slot",
+			Value:  r.match.FirstEmptySlot(),
+			String: req.PlayerName,
+		})
+	}
+}
+
+// bindClient records client as the live connection for the player
+// entity with the given network ID and reconnect token.
+func (r *Room) bindClient(client *router.NetworkClient, entity donburi.Entity, netID uint32, reconnectToken, ggscaleToken string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.joiningClients, client)
+	r.clientEntities[client] = entity
+	r.clientNetworkIDs[client] = netID
+	r.networkIDClients[netID] = client
+	r.clientTokens[client] = reconnectToken
+	r.tokenNetIDs[reconnectToken] = netID
+	if ggscaleToken != "" {
+		r.ggscaleTokens[netID] = ggscaleToken
+	}
+}
+
+func (r *Room) joinAccepted(netID uint32, reconnectToken string) messages.JoinAccepted {
+	return messages.JoinAccepted{
+		NetworkID:      esync.NetworkId(netID),
+		ReconnectToken: reconnectToken,
+		ServerName:     r.server.name,
+		TickRate:       r.loop.tickRate,
+		SnapshotRate:   r.server.snapshotRateHz(),
+		Level:          r.activeName,
+		Levels:         r.server.levelNames,
+		RoomCode:       r.code,
+		EventSeq:       r.lastEventSeq(),
+	}
+}
+
+// removeClient drops client from the room. Its player is held for the
+// server's reconnect grace window, or removed straight away when the
+// window is zero.
+func (r *Room) removeClient(client *router.NetworkClient) {
+	grace := r.server.reconnectGraceWindow()
+
+	r.mu.Lock()
+	delete(r.joiningClients, client)
+	_, exists := r.clientEntities[client]
+	delete(r.clientEntities, client)
+	nid, hasNetID := r.clientNetworkIDs[client]
+	if hasNetID {
+		delete(r.networkIDClients, nid)
+		delete(r.clientNetworkIDs, client)
+		delete(r.latency, nid)
+		delete(r.jitter, nid)
+		delete(r.playoutDelay, nid)
+	}
+	delete(r.snapshotAcks, client)
+	token, hasToken := r.clientTokens[client]
+	delete(r.clientTokens, client)
+	delete(r.spectators, client)
+	r.spectatorQueue = slices.DeleteFunc(r.spectatorQueue, func(c *router.NetworkClient) bool { return c == client })
+	if exists && hasToken && grace > 0 {
+		r.held[token] = time.AfterFunc(grace, func() {
+			r.cmdCh <- func() { r.expireHold(token) }
+		})
+	} else {
+		delete(r.tokenNetIDs, token)
+	}
+	r.mu.Unlock()
+
+	if !exists || !hasNetID {
+		return
+	}
+
+	if hasToken && grace > 0 {
+		log.Printf("Holding player nid=%d in room %s for %v (client %s)", nid, r.code, grace, client.Id())
+		r.cmdCh <- func() { r.idlePlayer(nid) }
+		return
+	}
+
+	r.cmdCh <- func() {
+		r.dropPlayer(nid)
+		log.Printf("Player entity removed for client %s", client.Id())
+	}
+}
+
+// dropPlayer removes the player's entity and frees its lobby slot.
+// Must be called on the game loop goroutine.
+func (r *Room) dropPlayer(netID uint32) {
+	if entity, ok := r.entityForNetID(netID); ok {
+		// Destroy active boomerang owned by this player
+		if bEntity, ok := r.playerBoomerangs[entity]; ok {
+			r.destroyBoomerang(bEntity)
+		}
+
+		if pp, ok := r.playerPhysics[entity]; ok {
+			removePlayerPhysics(r.activeLevel, pp)
+			delete(r.playerPhysics, entity)
+		}
+
+		r.sync.Remove(entity)
+	}
+
+	// Update lobby state
+	r.match.OnDisconnect(netID)
+}
+
+// entityForNetID finds the player entity currently carrying netID.
+func (r *Room) entityForNetID(netID uint32) (donburi.Entity, bool) {
+	for entity := range r.playerPhysics {
+		if !r.world.Valid(entity) {
+			continue
+		}
+		if nid := esync.GetNetworkId(r.world.Entry(entity)); nid != nil && uint32(*nid) == netID {
+			return entity, true
+		}
+	}
+	return 0, false
+}
+
+func (r *Room) onPlayerInput(client *router.NetworkClient, input messages.PlayerInput) {
+	r.mu.RLock()
+	entity, exists := r.clientEntities[client]
+	r.mu.RUnlock()
+
+	if !exists {
+		return
+	}
+
+	r.cmdCh <- func() {
+		pp, ok := r.playerPhysics[entity]
+		if !ok {
+			return
+		}
+		for _, earlier := range input.Redundant {
+			pp.Inputs.push(earlier)
+		}
+		pp.Inputs.push(input)
+	}
+}
+
+func (r *Room) onLobbyAction(client *router.NetworkClient, action messages.LobbyAction) {
+	r.mu.RLock()
+	nid, exists := r.clientNetworkIDs[client]
+	_, spectating := r.spectators[client]
+	r.mu.RUnlock()
+
+	// Spectators have no slot to act on until they are promoted.
+	if !exists || spectating {
+		return
+	}
+
+	r.cmdCh <- func() {
+		r.match.OnLobbyAction(nid, action)
+	}
+}
+
+func (r *Room) spawnBot(name string, difficulty cfg.BotDifficulty) {
+	// Pick spawn point
+	spawnX, spawnY := 100.0, 100.0
+	if len(r.activeLevel.SpawnPoints) > 0 {
+		idx := (len(r.clientEntities) + r.botCount()) % len(r.activeLevel.SpawnPoints)
+		sp := r.activeLevel.SpawnPoints[idx]
+		spawnX, spawnY = sp.X, sp.Y
+	}
+
+	entity := r.world.Create(
+		netcomponents.NetPosition,
+		netcomponents.NetVelocity,
+		netcomponents.NetPlayerState,
+		components.Player,
+		components.PlayerInput,
+		components.Bot,
+	)
+
+	entry := r.world.Entry(entity)
+	netcomponents.NetPosition.Set(entry, &netcomponents.NetPositionData{X: spawnX, Y: spawnY})
+	netcomponents.NetVelocity.Set(entry, &netcomponents.NetVelocityData{})
+	netcomponents.NetPlayerState.Set(entry, &netcomponents.NetPlayerStateData{
+		Direction: 1,
+		Health:    cfg.Player.Health,
+		IsBot:     true,
+	})
+
+	playerData := components.Player.Get(entry)
+	playerData.PlayerIndex = 4 + r.botCount() // Bots indices start at 4
+
+	botData := components.Bot.Get(entry)
+	botData.Difficulty = difficulty
+	// Initialize bot behavior from config
+	if config, ok := cfg.Bot.Difficulties[difficulty]; ok {
+		botData.ReactionDelay = config.ReactionDelay
+		botData.AttackRange = config.AttackRange
+		botData.RetreatThreshold = config.RetreatThreshold
+	}
+
+	// Create server-side physics for this bot
+	pp := newPlayerPhysics(r.activeLevel, spawnX, spawnY)
+	r.playerPhysics[entity] = pp
+
+	err := r.sync.NetworkSync(entity,
+		netcomponents.NetPosition,
+		netcomponents.NetVelocity,
+		netcomponents.NetPlayerState,
+	)
+	if err != nil {
+		log.Printf("Failed to setup network sync for bot: %v", err)
+		return
+	}
+
+	networkID := esync.GetNetworkId(entry)
+	log.Printf("Bot %q spawned in room %s as entity networkID=%d", name, r.code, *networkID)
+}
+
+func (r *Room) ClearAllPlayers() {
+	// First destroy all boomerangs
+	for bEntity := range r.boomerangPhysics {
+		r.destroyBoomerang(bEntity)
+	}
+
+	// Remove all player entities and their physics
+	for entity, pp := range r.playerPhysics {
+		removePlayerPhysics(r.activeLevel, pp)
+		r.sync.Remove(entity)
+	}
+
+	// Clear maps
+	clear(r.playerPhysics)
+	clear(r.boomerangPhysics)
+	clear(r.playerBoomerangs)
+	r.hurtboxes.clear()
+	// We do NOT clear clientEntities because we want to keep the connection -> entity mapping
+	// but we need to update the entity in that map if we spawn new ones.
+}
+
+func (r *Room) SpawnPlayerAtSlot(slotIdx int, slot messages.LobbySlot) {
+	spawnX, spawnY := 100.0, 100.0
+	if len(r.activeLevel.SpawnPoints) > 0 {
+		idx := slotIdx % len(r.activeLevel.SpawnPoints)
+		sp := r.activeLevel.SpawnPoints[idx]
+		spawnX, spawnY = sp.X, sp.Y
+	}
+
+	if slot.Type == 1 { // Human
+		entity := r.world.Create(
+			netcomponents.NetPosition,
+			netcomponents.NetVelocity,
+			netcomponents.NetPlayerState,
+			components.Player,
+			components.PlayerInput,
+		)
+
+		entry := r.world.Entry(entity)
+		netcomponents.NetPosition.Set(entry, &netcomponents.NetPositionData{X: spawnX, Y: spawnY})
+		netcomponents.NetVelocity.Set(entry, &netcomponents.NetVelocityData{})
+		netcomponents.NetPlayerState.Set(entry, &netcomponents.NetPlayerStateData{
+			Direction:   1,
+			Health:      cfg.Player.Health,
+			Lives:       cfg.Match.LivesPerRound,
+			PlayerIndex: slotIdx,
+		})
+
+		playerData := components.Player.Get(entry)
+		playerData.PlayerIndex = slotIdx
+
+		pp := newPlayerPhysics(r.activeLevel, spawnX, spawnY)
+		r.playerPhysics[entity] = pp
+
+		// Keep the network ID the player joined with so clients (and
+		// reconnect tokens) keep pointing at the same player across rounds.
+		_ = r.sync.NetworkSyncWithID(entity, esync.NetworkId(slot.PlayerID),
+			netcomponents.NetPosition,
+			netcomponents.NetVelocity,
+			netcomponents.NetPlayerState,
+		)
+
+		// Update mapping in clientEntities
+		r.mu.Lock()
+		if client, ok := r.networkIDClients[slot.PlayerID]; ok {
+			r.clientEntities[client] = entity
+			log.Printf("Re-associated client %s (nid=%d) with new entity for slot %d", client.Id(), slot.PlayerID, slotIdx)
+		} else {
+			log.Printf("Warning: Could not find client for nid=%d during slot spawning", slot.PlayerID)
+		}
+		r.mu.Unlock()
+
+	} else if slot.Type == 2 { // Bot
+		entity := r.world.Create(
+			netcomponents.NetPosition,
+			netcomponents.NetVelocity,
+			netcomponents.NetPlayerState,
+			components.Player,
+			components.PlayerInput,
+			components.Bot,
+		)
+
+		entry := r.world.Entry(entity)
+		netcomponents.NetPosition.Set(entry, &netcomponents.NetPositionData{X: spawnX, Y: spawnY})
+		netcomponents.NetVelocity.Set(entry, &netcomponents.NetVelocityData{})
+		netcomponents.NetPlayerState.Set(entry, &netcomponents.NetPlayerStateData{
+			Direction:   1,
+			Health:      cfg.Player.Health,
+			Lives:       cfg.Match.LivesPerRound,
+			PlayerIndex: slotIdx,
+			IsBot:       true,
+		})
+
+		playerData := components.Player.Get(entry)
+		playerData.PlayerIndex = slotIdx
+
+		botData := components.Bot.Get(entry)
+		if config, ok := cfg.Bot.Difficulties[cfg.BotDifficulty(slot.Difficulty)]; ok {
+			botData.ReactionDelay = config.ReactionDelay
+			botData.AttackRange = config.AttackRange
+			botData.RetreatThreshold = config.RetreatThreshold
+		}
+
+		pp := newPlayerPhysics(r.activeLevel, spawnX, spawnY)
+		r.playerPhysics[entity] = pp
+
+		_ = r.sync.NetworkSync(entity,
+			netcomponents.NetPosition,
+			netcomponents.NetVelocity,
+			netcomponents.NetPlayerState,
+		)
+
+		// Store bot's network ID into slot for round system lookups
+		if nid := esync.GetNetworkId(r.world.Entry(entity)); nid != nil {
+			r.match.Slots[slotIdx].PlayerID = uint32(*nid)
+		}
+	}
+}
+
+func (r *Room) botCount() int {
+	count := 0
+	components.Bot.Each(r.world, func(entry *donburi.Entry) {
+		count++
+	})
+	return count
+}
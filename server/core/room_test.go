@@ -0,0 +1,243 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/automoto/doomerang-mp/shared/leveldata"
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
+	"github.com/coder/websocket"
+	"github.com/leap-fish/necs/esync"
+	"github.com/leap-fish/necs/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yohamta/donburi"
+)
+
+// emptyTestLevel is a level with nothing in it.
+func emptyTestLevel() *leveldata.CollisionData {
+	return &leveldata.CollisionData{MapWidth: 320, MapHeight: 240}
+}
+
+// newRoomTestServer builds a Server whose only level is level. Rooms it
+// creates run real game loops, so the server is stopped on cleanup.
+func newRoomTestServer(t *testing.T, level *leveldata.CollisionData) *Server {
+	t.Helper()
+	levels := map[string]*ServerLevel{
+		"test": NewServerLevel(level),
+	}
+	s := NewServer(60, "test", "", levels, []string{"test"})
+	t.Cleanup(s.Stop)
+	return s
+}
+
+// newIdleTestRoom registers a room on s without starting its loop, so
+// tests can drive game-loop methods directly.
+func newIdleTestRoom(t *testing.T, s *Server) *Room {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.newRoomLocked(roomOptions{})
+}
+
+// addTestPlayer creates a synced player body at (x, y) in r.
+func addTestPlayer(t *testing.T, r *Room, x, y float64) (donburi.Entity, uint32) {
+	t.Helper()
+	entity := r.world.Create(netcomponents.NetPosition, netcomponents.NetVelocity, netcomponents.NetPlayerState)
+	r.playerPhysics[entity] = newPlayerPhysics(r.activeLevel, x, y)
+	require.NoError(t, r.sync.NetworkSync(entity, netcomponents.NetPosition, netcomponents.NetVelocity, netcomponents.NetPlayerState))
+	return entity, uint32(*esync.GetNetworkId(r.world.Entry(entity)))
+}
+
+// newTestClient returns a NetworkClient over a real WebSocket whose peer
+// discards everything it reads, so code under test can SendMessage to it.
+func newTestClient(t *testing.T) *router.NetworkClient {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		conn, err := websocket.Accept(w, req, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.CloseNow() }()
+		for {
+			if _, _, err := conn.Read(context.Background()); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	conn, _, err := websocket.Dial(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.CloseNow() })
+	return router.NewNetworkClient(context.Background(), conn)
+}
+
+// fillRoom admits clients until r reports "room full".
+func fillRoom(t *testing.T, r *Room) []*router.NetworkClient {
+	t.Helper()
+	var clients []*router.NetworkClient
+	for {
+		c := &router.NetworkClient{}
+		if reason := r.admit(c, false); reason != "" {
+			require.Equal(t, "room full", reason)
+			return clients
+		}
+		clients = append(clients, c)
+	}
+}
+
+func TestNewRoomCode_uses_unambiguous_alphabet(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		code := newRoomCode()
+		require.Len(t, code, roomCodeLength)
+		for _, ch := range code {
+			assert.Truef(t, strings.ContainsRune(roomCodeAlphabet, ch), "unexpected %q in code %q", ch, code)
+		}
+	}
+}
+
+func TestServer_quickPlayRoom(t *testing.T) {
+	s := newRoomTestServer(t, emptyTestLevel())
+
+	assert.Same(t, s.defaultRoom, s.quickPlayRoom(""), "default room is used while it has space")
+
+	clients := fillRoom(t, s.defaultRoom)
+	assert.Len(t, clients, s.defaultRoom.maxPlayers)
+
+	overflow := s.quickPlayRoom("")
+	require.NotNil(t, overflow)
+	assert.NotSame(t, s.defaultRoom, overflow, "a full default room spills into a new public room")
+	assert.True(t, overflow.public)
+	assert.Equal(t, 2, s.RoomCount())
+
+	private := s.createRoom(roomOptions{password: "hunter2"})
+	require.NotNil(t, private)
+	assert.Same(t, overflow, s.quickPlayRoom(""), "password rooms are never picked for quick play")
+}
+
+func TestServer_createRoom_respects_max_rooms(t *testing.T) {
+	s := newRoomTestServer(t, emptyTestLevel())
+	s.SetMaxRooms(2)
+
+	require.NotNil(t, s.createRoom(roomOptions{}))
+	assert.Nil(t, s.createRoom(roomOptions{}), "default room plus one more hits the cap")
+	assert.Equal(t, 2, s.RoomCount())
+}
+
+func TestServer_closeRoomIfEmpty(t *testing.T) {
+	s := newRoomTestServer(t, emptyTestLevel())
+
+	r := s.createRoom(roomOptions{maxPlayers: 2})
+	require.NotNil(t, r)
+	assert.Equal(t, 2, r.maxPlayers)
+
+	c := &router.NetworkClient{}
+	require.Empty(t, r.admit(c, false))
+	s.closeRoomIfEmpty(r)
+	assert.Equal(t, 2, s.RoomCount(), "room with an admitted client stays open")
+
+	r.removeClient(c)
+	s.closeRoomIfEmpty(r)
+	assert.Equal(t, 1, s.RoomCount())
+	assert.Equal(t, "room closed", r.admit(&router.NetworkClient{}, false))
+
+	s.closeRoomIfEmpty(s.defaultRoom)
+	assert.Equal(t, 1, s.RoomCount(), "default room is never closed")
+}
+
+func TestServer_repeated_room_picks_close_abandoned_rooms(t *testing.T) {
+	s := newRoomTestServer(t, emptyTestLevel())
+	s.SetMaxRooms(3)
+	client := newTestClient(t)
+	s.onConnect(client)
+
+	for range 5 {
+		s.onCreateRoomRequest(client, messages.CreateRoomRequest{Password: "pw"})
+	}
+	assert.Equal(t, 2, s.RoomCount(), "each create closes the room it replaces")
+
+	s.onJoinRoomRequest(client, messages.JoinRoomRequest{RoomCode: s.defaultRoom.code})
+	assert.Equal(t, 1, s.RoomCount(), "picking another room closes the created one")
+
+	s.onCreateRoomRequest(client, messages.CreateRoomRequest{})
+	other := newTestClient(t)
+	s.onConnect(other)
+	created := s.roomList()
+	for _, r := range created {
+		if r != s.defaultRoom {
+			s.onJoinRoomRequest(other, messages.JoinRoomRequest{RoomCode: r.code})
+		}
+	}
+	s.onJoinRoomRequest(client, messages.JoinRoomRequest{RoomCode: s.defaultRoom.code})
+	assert.Equal(t, 2, s.RoomCount(), "a room another connection picked stays open")
+
+	s.onDisconnect(other, nil)
+	assert.Equal(t, 1, s.RoomCount())
+}
+
+func TestServerMatch_syncGameState_sends_phase_deadline(t *testing.T) {
+	r := newIdleTestRoom(t, newRoomTestServer(t, emptyTestLevel()))
+	gs := netcomponents.NetGameState.Get(r.world.Entry(r.match.gameStateEntity))
+	r.match.syncGameState()
+	assert.Zero(t, gs.PhaseEndsAt, "no deadline while waiting")
+
+	start := time.Now()
+	r.match.startCountdown()
+	r.match.updateCountdown(0.5)
+	r.match.syncGameState()
+
+	deadline := time.Unix(0, gs.PhaseEndsAt)
+	assert.WithinDuration(t, start.Add(3*time.Second), deadline, 100*time.Millisecond,
+		"the deadline stays put as the timer ticks down")
+	assert.InDelta(t, 3.0, gs.TimeRemaining, 0.1, "the timer follows the wall clock, not dt")
+}
+
+func TestServerMatch_changes_phase_at_the_deadline(t *testing.T) {
+	r := newIdleTestRoom(t, newRoomTestServer(t, emptyTestLevel()))
+	r.match.startCountdown()
+
+	r.match.updateCountdown(10)
+	assert.Equal(t, netcomponents.MatchStateCountdown, r.match.State, "dt alone does not end the countdown")
+
+	r.match.deadline = time.Now().Add(-time.Millisecond)
+	r.match.updateCountdown(1.0 / 60)
+	assert.Equal(t, netcomponents.MatchStatePlaying, r.match.State, "the deadline passed, however few ticks ran")
+}
+
+func TestServer_player_hooks_follow_joins_and_disconnects(t *testing.T) {
+	s := newRoomTestServer(t, emptyTestLevel())
+	var joined, left []string
+	s.SetPlayerHooks(func(id string) { joined = append(joined, id) }, func(id string) { left = append(left, id) })
+	player, stranger := newTestClient(t), newTestClient(t)
+	s.pendingClients[player] = &pendingClient{room: newIdleTestRoom(t, s)}
+	s.pendingClients[stranger] = &pendingClient{}
+
+	s.onJoinRequest(player, messages.JoinRequest{PlayerName: "player"})
+	s.onDisconnect(stranger, nil) // Never joined
+	s.onDisconnect(player, nil)
+
+	assert.Equal(t, []string{player.Id()}, joined)
+	assert.Equal(t, []string{player.Id()}, left)
+}
+
+func TestRoom_reportStatus_reports_changes(t *testing.T) {
+	s := newRoomTestServer(t, emptyTestLevel())
+	r := newIdleTestRoom(t, s)
+	var reported []RoomStatus
+	s.SetRoomStatusHook(func(status RoomStatus) { reported = append(reported, status) })
+
+	r.reportStatus()
+	r.reportStatus()
+	r.match.State = netcomponents.MatchStatePlaying
+	r.reportStatus()
+
+	require.Len(t, reported, 2)
+	assert.Equal(t, RoomStatus{Code: r.code, Mode: "ffa", Level: "test", MatchState: "waiting"}, reported[0])
+	assert.Equal(t, "playing", reported[1].MatchState)
+}
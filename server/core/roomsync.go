@@ -0,0 +1,100 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/leap-fish/necs/esync"
+	"github.com/leap-fish/necs/esync/srvsync"
+	"github.com/yohamta/donburi"
+)
+
+// roomSync is the per-room replacement for srvsync's package-level
+// registry. srvsync keeps one global world and keys synced components by
+// donburi.Entity, which collides as soon as two rooms each own a world.
+// Network IDs still come from srvsync.NetworkIdCounter so they stay
+// unique across every room in the process.
+//
+// All methods must be called on the owning room's game loop goroutine.
+type roomSync struct {
+	world    donburi.World
+	entities map[donburi.Entity][]donburi.IComponentType
+}
+
+func newRoomSync(world donburi.World) *roomSync {
+	return &roomSync{
+		world:    world,
+		entities: make(map[donburi.Entity][]donburi.IComponentType),
+	}
+}
+
+// NetworkSync assigns entity a fresh network ID and marks the given
+// components for inclusion in this room's snapshots.
+func (rs *roomSync) NetworkSync(entity donburi.Entity, components ...donburi.IComponentType) error {
+	return rs.NetworkSyncWithID(entity, esync.NetworkId(srvsync.NetworkIdCounter.Add(1)), components...)
+}
+
+// NetworkSyncWithID is NetworkSync with a caller-chosen network ID, used
+// when a player's entity is rebuilt and must keep the ID clients know it by.
+func (rs *roomSync) NetworkSyncWithID(entity donburi.Entity, networkID esync.NetworkId, components ...donburi.IComponentType) error {
+	entry := rs.world.Entry(entity)
+	for _, comp := range components {
+		if !entry.HasComponent(comp) {
+			return fmt.Errorf("entity %d does not have the component %s", entry.Id(), comp.Name())
+		}
+	}
+
+	entry.AddComponent(esync.NetworkIdComponent)
+	esync.NetworkIdComponent.SetValue(entry, networkID)
+
+	rs.entities[entity] = append(slices.Clone(components), esync.NetworkIdComponent)
+	return nil
+}
+
+// Remove stops syncing entity and removes it from the world.
+func (rs *roomSync) Remove(entity donburi.Entity) {
+	delete(rs.entities, entity)
+	if rs.world.Valid(entity) {
+		rs.world.Remove(entity)
+	}
+}
+
+// Snapshot serializes every synced entity in the room.
+func (rs *roomSync) Snapshot() messages.SnapshotState {
+	snapshot := messages.SnapshotState{}
+	esync.NetworkEntityQuery.Each(rs.world, func(entry *donburi.Entry) {
+		networkID := esync.GetNetworkId(entry)
+		if networkID == nil {
+			return
+		}
+		state, err := rs.entityState(entry)
+		if err != nil {
+			return
+		}
+		snapshot[*networkID] = state
+	})
+	return snapshot
+}
+
+func (rs *roomSync) entityState(entry *donburi.Entry) (esync.EntityState, error) {
+	synced := rs.entities[entry.Entity()]
+	state := make(esync.EntityState, len(synced))
+	for _, component := range donburi.GetComponents(entry) {
+		t := reflect.TypeOf(component)
+		if t == reflect.TypeOf(struct{}{}) {
+			continue
+		}
+		if !slices.ContainsFunc(synced, func(c donburi.IComponentType) bool { return c.Typ() == t }) {
+			continue
+		}
+		data, err := esync.Mapper.Serialize(component)
+		if err != nil {
+			return nil, err
+		}
+		state[esync.ComponentId(esync.Mapper.LookupId(t))] = bytes.Clone(data)
+	}
+	return state, nil
+}
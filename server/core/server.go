@@ -1,67 +1,80 @@
 package core
 
 import (
-	"crypto/rand"
+	"crypto/subtle"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/automoto/doomerang-mp/components"
 	cfg "github.com/automoto/doomerang-mp/config"
 	"github.com/automoto/doomerang-mp/shared/messages"
-	"github.com/automoto/doomerang-mp/shared/netcomponents"
-	"github.com/automoto/doomerang-mp/shared/netconfig"
-	"github.com/leap-fish/necs/esync"
-	"github.com/leap-fish/necs/esync/srvsync"
+	"github.com/coder/websocket"
 	"github.com/leap-fish/necs/router"
-	"github.com/leap-fish/necs/transports"
-	"github.com/yohamta/donburi"
+	"github.com/leap-fish/necs/wrapws"
 )
 
 const (
 	defaultDrainTimeout = 30 * time.Second
 	drainPollInterval   = 25 * time.Millisecond
+	defaultMaxRooms     = 16
+
+	// defaultReconnectGrace is how long a dropped player's entity, slot
+	// and score are held for a JoinRequest carrying its reconnect token.
+	defaultReconnectGrace = 30 * time.Second
 )
 
-// serverCmd is queued from router goroutines and executed on the game loop goroutine.
+// serverCmd is queued from router goroutines and executed on a room's game loop goroutine.
 type serverCmd func()
 
+// Server owns the WebSocket transport and the router callbacks, and hosts
+// any number of independent Rooms. Clients pick a room before joining:
+// CreateRoomRequest makes a new one, JoinRoomRequest selects one by code,
+// and a JoinRequest with neither lands in a public room with space.
 type Server struct {
-	world     donburi.World
-	loop      *GameLoop
-	transport *transports.WsServerTransport
-
-	name    string
-	version string
-
-	levels      map[string]*ServerLevel
-	levelNames  []string
-	activeLevel *ServerLevel
-	activeName  string
-
-	playerPhysics    map[donburi.Entity]*PlayerPhysics
-	boomerangPhysics map[donburi.Entity]*BoomerangPhysics
-	playerBoomerangs map[donburi.Entity]donburi.Entity // player → active boomerang
-
-	clientEntities   map[*router.NetworkClient]donburi.Entity
-	pendingClients   map[*router.NetworkClient]bool
-	clientNetworkIDs map[*router.NetworkClient]uint32
-	networkIDClients map[uint32]*router.NetworkClient
-	// ggscaleTokens is keyed by netID and holds each player's ggscale
-	// session JWT, captured from JoinRequest. Used at match end to
-	// submit scores via Leaderboards.SubmitFor.
-	ggscaleTokens map[uint32]string
-	matchEndHook  MatchEndHook
-	match         *ServerMatch
-	mu            sync.RWMutex
-
-	cmdCh chan serverCmd
-
-	// matchInProgress is true between ServerMatch.startMatch and endMatch.
-	// Drain polls it to wait out an in-flight match before stopping the loop.
-	matchInProgress atomic.Bool
+	transport *wrapws.WebSocketServer
+	listening atomic.Bool // The transport is accepting connections
+	metrics   *serverMetrics
+
+	name     string
+	version  string
+	tickRate int
+
+	// levels are templates; each Room clones the ones it uses.
+	levels     map[string]*ServerLevel
+	levelNames []string
+
+	// defaultRoom is created with the server, never closed, and is
+	// where --bots and code-less joins go first.
+	defaultRoom *Room
+	rooms       map[string]*Room // keyed by room code
+	nextRoomID  int
+	maxRooms    int
+
+	reconnectGrace time.Duration
+
+	lagCompensation bool
+	maxRewind       time.Duration
+
+	// snapshotRate is how many snapshots per second rooms send; 0 sends
+	// one every tick.
+	snapshotRate int
+
+	clientRooms    map[*router.NetworkClient]*Room
+	pendingClients map[*router.NetworkClient]*pendingClient
+
+	// blockedNames are lowercased player names the admin API's ban
+	// refuses; see admin.go.
+	blockedNames map[string]bool
+
+	matchEndHook   MatchEndHook
+	playerJoined   PlayerHook
+	playerLeft     PlayerHook
+	roomStatusHook RoomStatusHook
+	mu             sync.RWMutex
 
 	// draining is set once Drain() begins; onJoinRequest checks it to
 	// reject new players with "server draining".
@@ -69,43 +82,69 @@ type Server struct {
 	drainOnce    sync.Once
 	drainDone    chan struct{}
 	drainTimeout time.Duration // 0 means defaultDrainTimeout
+
+	// redirect is sent to every client once Drain has waited out the
+	// matches; nil sends none.
+	redirect *messages.ServerRedirect
+}
+
+// pendingClient is a connection that has not yet joined a room. room is
+// set by CreateRoomRequest or JoinRoomRequest and consumed by JoinRequest.
+type pendingClient struct {
+	room *Room
 }
 
-// MatchEndHook is invoked once per match end with the final scores and
-// the per-player ggscale session tokens captured at join time. The
-// dedicated game-server binary supplies a hook that calls
-// Leaderboards.SubmitFor; tests/dev binaries leave it nil.
-type MatchEndHook func(scores map[uint32]int, ggscaleTokens map[uint32]string)
+// MatchEndHook is invoked once per match end with every player's final
+// stats, keyed by network ID, and the per-player ggscale session tokens
+// captured at join time. The dedicated game-server binary supplies a
+// hook that calls Leaderboards.SubmitFor; tests/dev binaries leave it nil.
+type MatchEndHook func(stats map[uint32]messages.PlayerStats, ggscaleTokens map[uint32]string)
+
+// PlayerHook is invoked with a client's connection ID as it joins a room,
+// spectators and reconnects included, and again as it disconnects. The
+// dedicated game-server binary uses it for Agones player tracking.
+type PlayerHook func(playerID string)
+
+// RoomStatus is what a RoomStatusHook is told about a room.
+type RoomStatus struct {
+	Code       string
+	Default    bool // The server's default room
+	Mode       string
+	Level      string
+	MatchState string // As named by the admin API: "waiting", "playing", ...
+}
+
+// RoomStatusHook is invoked on a room's game loop after its first tick
+// and whenever the room's mode, level or match state changes. It must
+// not block the game loop; hand slow work off to another goroutine.
+type RoomStatusHook func(RoomStatus)
 
 func NewServer(tickRate int, name, version string, levels map[string]*ServerLevel, levelNames []string) *Server {
 	if len(levelNames) == 0 {
 		log.Fatal("NewServer: no levels provided")
 	}
-	world := donburi.NewWorld()
 
 	s := &Server{
-		world:            world,
-		name:             name,
-		version:          version,
-		levels:           levels,
-		levelNames:       levelNames,
-		activeLevel:      levels[levelNames[0]],
-		activeName:       levelNames[0],
-		playerPhysics:    make(map[donburi.Entity]*PlayerPhysics),
-		boomerangPhysics: make(map[donburi.Entity]*BoomerangPhysics),
-		playerBoomerangs: make(map[donburi.Entity]donburi.Entity),
-		clientEntities:   make(map[*router.NetworkClient]donburi.Entity),
-		pendingClients:   make(map[*router.NetworkClient]bool),
-		clientNetworkIDs: make(map[*router.NetworkClient]uint32),
-		networkIDClients: make(map[uint32]*router.NetworkClient),
-		ggscaleTokens:    make(map[uint32]string),
-		cmdCh:            make(chan serverCmd, 64),
-		drainDone:        make(chan struct{}),
-	}
-	s.loop = NewGameLoop(s, tickRate)
-
-	srvsync.UseEsync(world)
-	s.match = NewServerMatch(s)
+		name:            name,
+		version:         version,
+		tickRate:        tickRate,
+		levels:          levels,
+		levelNames:      levelNames,
+		rooms:           make(map[string]*Room),
+		maxRooms:        defaultMaxRooms,
+		reconnectGrace:  defaultReconnectGrace,
+		lagCompensation: true,
+		maxRewind:       defaultMaxRewind,
+		clientRooms:     make(map[*router.NetworkClient]*Room),
+		pendingClients:  make(map[*router.NetworkClient]*pendingClient),
+		blockedNames:    make(map[string]bool),
+		drainDone:       make(chan struct{}),
+		metrics:         newServerMetrics(),
+	}
+
+	s.mu.Lock()
+	s.defaultRoom = s.newRoomLocked(roomOptions{public: true})
+	s.mu.Unlock()
 	s.setupRouterCallbacks()
 
 	return s
@@ -116,21 +155,96 @@ func (s *Server) LevelNames() []string {
 	return s.levelNames
 }
 
-func (s *Server) Start(port uint) error {
-	go s.loop.Run()
+// SetMaxRooms caps how many rooms may exist at once, including the
+// default room. Call before Start.
+func (s *Server) SetMaxRooms(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxRooms = n
+}
+
+// SetReconnectGrace sets how long a dropped player is held for
+// reconnect. Zero removes players as soon as they disconnect.
+func (s *Server) SetReconnectGrace(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reconnectGrace = d
+}
+
+// SetLagCompensation turns hit-check rewinding on or off and caps how far
+// back it may go. maxRewind is clamped to one second, the history kept.
+func (s *Server) SetLagCompensation(enabled bool, maxRewind time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lagCompensation = enabled
+	s.maxRewind = min(max(maxRewind, 0), maxRewindCeiling)
+}
+
+// SetSnapshotRate sets how many snapshots per second each room sends,
+// independent of the tick rate. The rate is rounded to a whole number of
+// ticks between snapshots; zero, or anything above the tick rate, sends
+// one every tick. Call before Start.
+func (s *Server) SetSnapshotRate(rate int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshotRate = rate
+}
 
-	s.transport = transports.NewWsServerTransport(port, "", nil)
-	return s.transport.Start()
+// snapshotEvery returns how many ticks pass between snapshots.
+func (s *Server) snapshotEvery() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.snapshotRate <= 0 || s.snapshotRate >= s.tickRate {
+		return 1
+	}
+	return (s.tickRate + s.snapshotRate/2) / s.snapshotRate
+}
+
+// snapshotRateHz returns the snapshot rate clients actually see.
+func (s *Server) snapshotRateHz() int {
+	return s.tickRate / s.snapshotEvery()
+}
+
+func (s *Server) lagCompensationSettings() (bool, time.Duration) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lagCompensation, s.maxRewind
+}
+
+func (s *Server) reconnectGraceWindow() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.reconnectGrace
+}
+
+func (s *Server) Start(port uint) error {
+	s.defaultRoom.start()
+
+	s.transport = wrapws.NewWebSocketServer(wsEvents{s: s}, nil)
+	done := make(chan struct{})
+	go s.watchListening(port, done)
+	err := s.transport.Serve(fmt.Sprintf(":%d", port))
+	close(done)
+	s.listening.Store(false)
+	if err != nil {
+		return fmt.Errorf("could not start server transport: %w", err)
+	}
+	return nil
 }
 
+// Stop halts every room's game loop.
 func (s *Server) Stop() {
-	s.loop.Stop()
+	for _, r := range s.roomList() {
+		r.stop()
+	}
 }
 
-// Drain stops accepting new player joins, waits for any in-progress
-// match to end (bounded by drainTimeout, default 30 s), then stops the
-// game loop. Safe to call concurrently and multiple times — the first
-// caller performs the drain; subsequent callers block until it finishes.
+// Drain stops accepting new player joins, warns every room with a
+// ServerShutdownNotice, waits for any in-progress match in any room to
+// end (bounded by drainTimeout, default 30 s), sends the shutdown
+// redirect if one is set, then stops the game loops. Safe to call concurrently and multiple times —
+// the first caller performs the drain; subsequent callers block until it
+// finishes.
 //
 // Wired into both the Agones Shutdown watcher and the SIGTERM handler so
 // the same shutdown path runs whether Agones triggers it or a local
@@ -139,20 +253,69 @@ func (s *Server) Drain() {
 	s.draining.Store(true)
 	s.drainOnce.Do(func() {
 		defer close(s.drainDone)
+		s.announceShutdown()
 		s.waitForMatchEnd()
+		s.redirectClients()
 		s.Stop()
 	})
 	<-s.drainDone
 }
 
-func (s *Server) waitForMatchEnd() {
-	if !s.matchInProgress.Load() {
+func (s *Server) drainTimeoutOrDefault() time.Duration {
+	if s.drainTimeout == 0 {
+		return defaultDrainTimeout
+	}
+	return s.drainTimeout
+}
+
+// announceShutdown sends every room a ServerShutdownNotice giving the
+// latest the server will stop: now if no match is in progress, otherwise
+// once drainTimeout runs out.
+func (s *Server) announceShutdown() {
+	shutdownAt := time.Now()
+	if s.MatchInProgress() {
+		shutdownAt = shutdownAt.Add(s.drainTimeoutOrDefault())
+	}
+	for _, r := range s.roomList() {
+		r.broadcastEvent(messages.ServerShutdownNotice{ShutdownAt: shutdownAt.UnixNano()})
+	}
+}
+
+// SetShutdownRedirect sets the ServerRedirect Drain sends every client
+// before stopping. Pass nil to send none.
+func (s *Server) SetShutdownRedirect(redirect *messages.ServerRedirect) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.redirect = redirect
+}
+
+// redirectClients sends the shutdown redirect, if set, to every client in
+// every room.
+func (s *Server) redirectClients() {
+	s.mu.RLock()
+	redirect := s.redirect
+	s.mu.RUnlock()
+	if redirect == nil {
 		return
 	}
-	timeout := s.drainTimeout
-	if timeout == 0 {
-		timeout = defaultDrainTimeout
+	for _, r := range s.roomList() {
+		r.mu.RLock()
+		clients := make([]*router.NetworkClient, 0, len(r.clientNetworkIDs))
+		for client := range r.clientNetworkIDs {
+			clients = append(clients, client)
+		}
+		r.mu.RUnlock()
+		for _, client := range clients {
+			_ = s.send(client, *redirect)
+		}
 	}
+}
+
+func (s *Server) waitForMatchEnd() {
+	if !s.MatchInProgress() {
+		return
+	}
+	timeout := s.drainTimeoutOrDefault()
 	deadline := time.NewTimer(timeout)
 	defer deadline.Stop()
 	poll := time.NewTicker(drainPollInterval)
@@ -163,85 +326,199 @@ func (s *Server) waitForMatchEnd() {
 			log.Printf("[drain] timeout (%v) elapsed while waiting for match end; stopping anyway", timeout)
 			return
 		case <-poll.C:
-			if !s.matchInProgress.Load() {
+			if !s.MatchInProgress() {
 				return
 			}
 		}
 	}
 }
 
-func (s *Server) ProcessCommands() {
-	for {
-		select {
-		case cmd := <-s.cmdCh:
-			cmd()
-		default:
-			return
+// MatchInProgress reports whether any room is between startMatch and endMatch.
+func (s *Server) MatchInProgress() bool {
+	for _, r := range s.roomList() {
+		if r.matchInProgress.Load() {
+			return true
 		}
 	}
+	return false
 }
 
+// PlayerCount returns the number of joined players across all rooms.
 func (s *Server) PlayerCount() int {
+	count := 0
+	for _, r := range s.roomList() {
+		count += r.PlayerCount()
+	}
+	return count
+}
+
+// RoomCount returns the number of open rooms, including the default room.
+func (s *Server) RoomCount() int {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return len(s.clientEntities)
+	return len(s.rooms)
 }
 
-func (s *Server) World() donburi.World {
-	return s.world
+func (s *Server) roomList() []*Room {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*Room, 0, len(s.rooms))
+	for _, r := range s.rooms {
+		out = append(out, r)
+	}
+	return out
 }
 
-func (s *Server) ActiveLevel() *ServerLevel {
-	return s.activeLevel
+// SetMatchEndHook installs f as the callback that every room's
+// ServerMatch invokes at match end. Wire to a function that submits
+// leaderboard scores via ggscale.Leaderboards.SubmitFor. Pass nil to clear.
+func (s *Server) SetMatchEndHook(f MatchEndHook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.matchEndHook = f
 }
 
-func (s *Server) Match() *ServerMatch {
-	return s.match
+// SetPlayerHooks installs the callbacks invoked as clients join a room
+// and disconnect from one. Either may be nil.
+func (s *Server) SetPlayerHooks(joined, left PlayerHook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.playerJoined = joined
+	s.playerLeft = left
 }
 
-// SetMatchEndHook installs f as the callback that ServerMatch invokes
-// at match end. Wire to a function that submits leaderboard scores via
-// ggscale.Leaderboards.SubmitFor. Pass nil to clear.
-func (s *Server) SetMatchEndHook(f MatchEndHook) {
+// SetRoomStatusHook installs f as the callback every room's game loop
+// invokes as its status changes. Pass nil to clear.
+func (s *Server) SetRoomStatusHook(f RoomStatusHook) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.matchEndHook = f
+	s.roomStatusHook = f
 }
 
-// snapshotGgscaleTokens returns a copy of the netID→session-token map.
-// Called from match-end paths so the hook can run without holding the
-// server lock.
-func (s *Server) snapshotGgscaleTokens() map[uint32]string {
+// invokePlayerHook calls the joined or left hook, if set, for client.
+func (s *Server) invokePlayerHook(client *router.NetworkClient, joined bool) {
 	s.mu.RLock()
-	defer s.mu.RUnlock()
-	out := make(map[uint32]string, len(s.ggscaleTokens))
-	for k, v := range s.ggscaleTokens {
-		out[k] = v
+	hook := s.playerLeft
+	if joined {
+		hook = s.playerJoined
+	}
+	s.mu.RUnlock()
+	if hook != nil {
+		hook(client.Id())
 	}
-	return out
 }
 
-// invokeMatchEndHook is called by ServerMatch.endMatch with the final
-// score map. Looks up the installed hook under the lock then runs it
-// outside — the hook makes network calls.
-func (s *Server) invokeMatchEndHook(scores map[uint32]int) {
-	s.mu.RLock()
-	hook := s.matchEndHook
-	s.mu.RUnlock()
-	if hook == nil {
+// SpawnBot adds a bot to the default room.
+func (s *Server) SpawnBot(name string, difficulty cfg.BotDifficulty) {
+	s.defaultRoom.SpawnBot(name, difficulty)
+}
+
+// newRoomLocked registers a new room under a fresh code. Caller holds s.mu
+// and is responsible for starting it.
+func (s *Server) newRoomLocked(opts roomOptions) *Room {
+	code := newRoomCode()
+	for s.rooms[code] != nil {
+		code = newRoomCode()
+	}
+	s.nextRoomID++
+	r := newRoom(s, strconv.Itoa(s.nextRoomID), code, opts)
+	s.rooms[code] = r
+	log.Printf("Room %s created (id=%s, public=%v, max players=%d)", code, r.id, r.public, r.maxPlayers)
+	return r
+}
+
+// createRoom registers and starts a new room, or returns nil when the
+// server is at maxRooms.
+func (s *Server) createRoom(opts roomOptions) *Room {
+	s.mu.Lock()
+	if len(s.rooms) >= s.maxRooms {
+		s.mu.Unlock()
+		return nil
+	}
+	r := s.newRoomLocked(opts)
+	s.mu.Unlock()
+
+	r.start()
+	return r
+}
+
+// quickPlayRoom picks a public room with space for a JoinRequest that
+// named no room, preferring the default room, and creates a new public
+// room when every existing one is full.
+func (s *Server) quickPlayRoom(level string) *Room {
+	if s.defaultRoom.hasSeat() {
+		return s.defaultRoom
+	}
+	for _, r := range s.roomList() {
+		if r.public && r.hasSeat() {
+			return r
+		}
+	}
+	return s.createRoom(roomOptions{public: true, level: level})
+}
+
+// closeRoomIfEmpty unregisters and stops r once its last client has left
+// and no connection still waiting to join has picked it. The default room
+// is never closed.
+func (s *Server) closeRoomIfEmpty(r *Room) {
+	if r == s.defaultRoom {
 		return
 	}
-	hook(scores, s.snapshotGgscaleTokens())
+
+	s.mu.Lock()
+	r.mu.Lock()
+	empty := !r.closed && r.clientCountLocked() == 0 && !s.pickedLocked(r)
+	if empty {
+		r.closed = true
+		delete(s.rooms, r.code)
+	}
+	r.mu.Unlock()
+	s.mu.Unlock()
+
+	if empty {
+		r.stop()
+		log.Printf("Room %s closed", r.code)
+	}
 }
 
-func (s *Server) GetPlayerPhysics(entity donburi.Entity) *PlayerPhysics {
-	return s.playerPhysics[entity]
+// pickedLocked reports whether a pending client has picked r for its
+// JoinRequest. Callers must hold s.mu.
+func (s *Server) pickedLocked(r *Room) bool {
+	for _, pending := range s.pendingClients {
+		if pending.room == r {
+			return true
+		}
+	}
+	return false
 }
 
-func (s *Server) SpawnBot(name string, difficulty cfg.BotDifficulty) {
-	s.cmdCh <- func() {
-		s.spawnBot(name, difficulty)
+// pickRoom sets the room client's JoinRequest will join, closing the one
+// it picked before if nobody else is using it.
+func (s *Server) pickRoom(pending *pendingClient, r *Room) {
+	s.mu.Lock()
+	previous := pending.room
+	pending.room = r
+	s.mu.Unlock()
+
+	if previous != nil && previous != r {
+		s.closeRoomIfEmpty(previous)
+	}
+}
+
+// roomHolding returns the room holding a dropped player for token, or nil.
+func (s *Server) roomHolding(token string) *Room {
+	for _, r := range s.roomList() {
+		if r.holds(token) {
+			return r
+		}
 	}
+	return nil
+}
+
+func (s *Server) roomForClient(client *router.NetworkClient) *Room {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.clientRooms[client]
 }
 
 func (s *Server) setupRouterCallbacks() {
@@ -253,16 +530,57 @@ func (s *Server) setupRouterCallbacks() {
 		s.onDisconnect(client, err)
 	})
 
-	router.On(func(client *router.NetworkClient, req messages.JoinRequest) {
+	on(s, func(client *router.NetworkClient, req messages.CreateRoomRequest) {
+		s.onCreateRoomRequest(client, req)
+	})
+
+	on(s, func(client *router.NetworkClient, req messages.JoinRoomRequest) {
+		s.onJoinRoomRequest(client, req)
+	})
+
+	on(s, func(client *router.NetworkClient, req messages.JoinRequest) {
 		s.onJoinRequest(client, req)
 	})
 
-	router.On(func(client *router.NetworkClient, input messages.PlayerInput) {
-		s.onPlayerInput(client, input)
+	on(s, func(client *router.NetworkClient, frame messages.InputFrame) {
+		r := s.roomForClient(client)
+		if r == nil {
+			return
+		}
+		input, err := messages.DecodeInput(frame)
+		if err != nil {
+			log.Printf("Dropping input from client %s: %v", client.Id(), err)
+			return
+		}
+		r.onPlayerInput(client, input)
+	})
+
+	on(s, func(client *router.NetworkClient, ack messages.SnapshotAck) {
+		if r := s.roomForClient(client); r != nil {
+			r.onSnapshotAck(client, ack)
+		}
+	})
+
+	on(s, func(client *router.NetworkClient, action messages.LobbyAction) {
+		if r := s.roomForClient(client); r != nil {
+			r.onLobbyAction(client, action)
+		}
+	})
+
+	on(s, func(client *router.NetworkClient, pong messages.Pong) {
+		if r := s.roomForClient(client); r != nil {
+			r.onPong(client, pong)
+		}
 	})
 
-	router.On(func(client *router.NetworkClient, action messages.LobbyAction) {
-		s.onLobbyAction(client, action)
+	on(s, func(client *router.NetworkClient, req messages.ClockSyncRequest) {
+		s.onClockSync(client, req)
+	})
+
+	on(s, func(client *router.NetworkClient, req messages.EventResyncRequest) {
+		if r := s.roomForClient(client); r != nil {
+			r.onEventResync(client, req)
+		}
 	})
 
 	router.OnError(func(client *router.NetworkClient, err error) {
@@ -274,411 +592,191 @@ func (s *Server) onConnect(client *router.NetworkClient) {
 	log.Printf("Client connected: %s (pending join)", client.Id())
 
 	s.mu.Lock()
-	s.pendingClients[client] = true
+	s.pendingClients[client] = &pendingClient{}
 	s.mu.Unlock()
 }
 
-func (s *Server) onJoinRequest(client *router.NetworkClient, req messages.JoinRequest) {
-	s.mu.Lock()
-	isPending := s.pendingClients[client]
-	s.mu.Unlock()
+// onCreateRoomRequest creates a room and selects it for the client's
+// upcoming JoinRequest. A room without a password is public.
+func (s *Server) onCreateRoomRequest(client *router.NetworkClient, req messages.CreateRoomRequest) {
+	s.mu.RLock()
+	pending := s.pendingClients[client]
+	s.mu.RUnlock()
 
-	if !isPending {
-		log.Printf("Join request from non-pending client %s, ignoring", client.Id())
+	if pending == nil {
+		log.Printf("Create room request from non-pending client %s, ignoring", client.Id())
 		return
 	}
 
 	if s.draining.Load() {
-		log.Printf("Client %s rejected: server draining", client.Id())
-		_ = client.SendMessage(messages.JoinRejected{Reason: "server draining"})
-		return
-	}
-
-	if s.version != "" && req.Version != s.version {
-		log.Printf("Client %s version mismatch: got %q, want %q", client.Id(), req.Version, s.version)
-		_ = client.SendMessage(messages.JoinRejected{
-			Reason: fmt.Sprintf("Version mismatch: server=%s client=%s", s.version, req.Version),
-		})
+		s.reject(client, "server draining")
 		return
 	}
 
-	s.cmdCh <- func() {
-		// Switch active level if requested and no players connected yet
-		if req.Level != "" && len(s.clientEntities) == 0 {
-			if lvl, ok := s.levels[req.Level]; ok {
-				s.activeLevel = lvl
-				s.activeName = req.Level
-				log.Printf("Switched active level to %q", req.Level)
-			}
-		}
-		s.spawnPlayer(client, req)
-	}
-}
-
-// spawnPlayer must be called on the game loop goroutine.
-func (s *Server) spawnPlayer(client *router.NetworkClient, req messages.JoinRequest) {
-	// Pick spawn point round-robin by player count
-	spawnX, spawnY := 100.0, 100.0
-	if len(s.activeLevel.SpawnPoints) > 0 {
-		idx := len(s.clientEntities) % len(s.activeLevel.SpawnPoints)
-		sp := s.activeLevel.SpawnPoints[idx]
-		spawnX, spawnY = sp.X, sp.Y
-	}
-
-	entity := s.world.Create(
-		netcomponents.NetPosition,
-		netcomponents.NetVelocity,
-		netcomponents.NetPlayerState,
-	)
-
-	entry := s.world.Entry(entity)
-	netcomponents.NetPosition.Set(entry, &netcomponents.NetPositionData{X: spawnX, Y: spawnY})
-	netcomponents.NetVelocity.Set(entry, &netcomponents.NetVelocityData{})
-	netcomponents.NetPlayerState.Set(entry, &netcomponents.NetPlayerStateData{
-		Direction: 1,
-		Health:    cfg.Player.Health,
+	r := s.createRoom(roomOptions{
+		password:   req.Password,
+		public:     req.Password == "",
+		gameMode:   req.GameMode,
+		maxPlayers: req.MaxPlayers,
 	})
-
-	// Create server-side physics for this player
-	pp := newPlayerPhysics(s.activeLevel, spawnX, spawnY)
-	s.playerPhysics[entity] = pp
-
-	// NOTE: Do not use WithInterp — InterpData is unregistered with esync.Mapper,
-	// causing buildEntityState to silently skip the entire entity.
-	err := srvsync.NetworkSync(s.world, &entity,
-		netcomponents.NetPosition,
-		netcomponents.NetVelocity,
-		netcomponents.NetPlayerState,
-	)
-	if err != nil {
-		log.Printf("Failed to setup network sync for player: %v", err)
-		_ = client.SendMessage(messages.JoinRejected{Reason: "internal server error"})
+	if r == nil {
+		log.Printf("Client %s rejected: room limit reached", client.Id())
+		s.reject(client, "server full")
 		return
 	}
 
-	networkID := esync.GetNetworkId(s.world.Entry(entity))
+	s.pickRoom(pending, r)
+	_ = s.send(client, messages.RoomCreated{RoomID: r.id, RoomCode: r.code})
+}
 
-	tokenBytes := make([]byte, 16)
-	_, _ = rand.Read(tokenBytes)
-	reconnectToken := fmt.Sprintf("%x", tokenBytes)
+// onJoinRoomRequest selects an existing room by code for the client's
+// upcoming JoinRequest, checking the room password if it has one.
+func (s *Server) onJoinRoomRequest(client *router.NetworkClient, req messages.JoinRoomRequest) {
+	code := strings.ToUpper(strings.TrimSpace(req.RoomCode))
 
 	s.mu.Lock()
-	delete(s.pendingClients, client)
-	s.clientEntities[client] = entity
-	s.clientNetworkIDs[client] = uint32(*networkID)
-	s.networkIDClients[uint32(*networkID)] = client
-	if req.GgscaleSessionToken != "" {
-		s.ggscaleTokens[uint32(*networkID)] = req.GgscaleSessionToken
-	}
+	pending := s.pendingClients[client]
+	r := s.rooms[code]
 	s.mu.Unlock()
 
-	_ = client.SendMessage(messages.JoinAccepted{
-		NetworkID:      *networkID,
-		ReconnectToken: reconnectToken,
-		ServerName:     s.name,
-		TickRate:       s.loop.tickRate,
-		Level:          s.activeName,
-		Levels:         s.levelNames,
-	})
-
-	log.Printf("Player %q joined as entity networkID=%d (client %s)",
-		req.PlayerName, *networkID, client.Id())
-
-	// Assign to lobby slot
-	s.cmdCh <- func() {
-		s.match.OnLobbyAction(uint32(*networkID), messages.LobbyAction{
-			Action: "pick_slot",
-			Value:  s.match.FirstEmptySlot(),
-			String: req.PlayerName,
-		})
-	}
-}
-
-// broadcastEvent sends a message to all connected clients.
-func (s *Server) broadcastEvent(msg any) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	for client := range s.clientEntities {
-		_ = client.SendMessage(msg)
-	}
-}
-
-func (s *Server) onDisconnect(client *router.NetworkClient, err error) {
-	if err != nil {
-		log.Printf("Client %s disconnected: %v", client.Id(), err)
-	} else {
-		log.Printf("Client %s disconnected", client.Id())
+	if pending == nil {
+		log.Printf("Join room request from non-pending client %s, ignoring", client.Id())
+		return
 	}
 
-	s.mu.Lock()
-	delete(s.pendingClients, client)
-	entity, exists := s.clientEntities[client]
-	if exists {
-		delete(s.clientEntities, client)
-	}
-	if nid, ok := s.clientNetworkIDs[client]; ok {
-		delete(s.networkIDClients, nid)
-		delete(s.clientNetworkIDs, client)
+	if r == nil {
+		log.Printf("Client %s rejected: no room %q", client.Id(), code)
+		s.reject(client, "room not found")
+		return
 	}
-	s.mu.Unlock()
 
-	if !exists {
+	if subtle.ConstantTimeCompare([]byte(req.Password), []byte(r.password)) != 1 {
+		log.Printf("Client %s rejected: wrong password for room %s", client.Id(), code)
+		s.reject(client, "wrong room password")
 		return
 	}
 
-	s.cmdCh <- func() {
-		// Destroy active boomerang owned by this player
-		if bEntity, ok := s.playerBoomerangs[entity]; ok {
-			s.destroyBoomerang(bEntity)
-		}
-
-		if pp, ok := s.playerPhysics[entity]; ok {
-			removePlayerPhysics(s.activeLevel, pp)
-			delete(s.playerPhysics, entity)
-		}
-
-		// Update lobby state
-		entry := s.world.Entry(entity)
-		if nid := esync.GetNetworkId(entry); nid != nil {
-			s.match.OnDisconnect(uint32(*nid))
-		}
-
-		if s.world.Valid(entity) {
-			s.world.Remove(entity)
-			log.Printf("Player entity removed for client %s", client.Id())
-		}
-	}
+	s.pickRoom(pending, r)
 }
 
-func (s *Server) onPlayerInput(client *router.NetworkClient, input messages.PlayerInput) {
+func (s *Server) onJoinRequest(client *router.NetworkClient, req messages.JoinRequest) {
 	s.mu.RLock()
-	entity, exists := s.clientEntities[client]
+	pending := s.pendingClients[client]
 	s.mu.RUnlock()
 
-	if !exists {
+	if pending == nil {
+		log.Printf("Join request from non-pending client %s, ignoring", client.Id())
 		return
 	}
 
-	s.cmdCh <- func() {
-		pp, ok := s.playerPhysics[entity]
-		if !ok {
-			return
-		}
-		pp.Direction = input.Direction
-		pp.JumpPressed = input.Actions[netconfig.ActionJump]
-		pp.AttackPressed = input.Actions[netconfig.ActionAttack]
-		pp.BoomerangPressed = input.Actions[netconfig.ActionBoomerang]
-		pp.MoveUpPressed = input.Actions[netconfig.ActionMoveUp]
-		pp.CrouchPressed = input.Actions[netconfig.ActionCrouch]
-		pp.LastInputSeq = input.Sequence
-
-		// Update facing direction in NetPlayerState
-		if input.Direction != 0 && s.world.Valid(entity) {
-			entry := s.world.Entry(entity)
-			state := netcomponents.NetPlayerState.Get(entry)
-			state.Direction = input.Direction
-		}
-	}
-}
-
-func (s *Server) onLobbyAction(client *router.NetworkClient, action messages.LobbyAction) {
-	s.mu.RLock()
-	entity, exists := s.clientEntities[client]
-	s.mu.RUnlock()
-
-	if !exists {
+	if s.version != "" && req.Version != s.version {
+		log.Printf("Client %s version mismatch: got %q, want %q", client.Id(), req.Version, s.version)
+		s.reject(client, fmt.Sprintf("Version mismatch: server=%s client=%s", s.version, req.Version))
 		return
 	}
 
-	s.cmdCh <- func() {
-		entry := s.world.Entry(entity)
-		nid := esync.GetNetworkId(entry)
-		if nid == nil {
+	// Reconnects are honoured while draining so a dropped player can
+	// finish the match the drain is waiting on.
+	if req.ReconnectToken != "" {
+		if r := s.roomHolding(req.ReconnectToken); r != nil && r.reclaim(client, req) {
+			s.mu.Lock()
+			delete(s.pendingClients, client)
+			s.clientRooms[client] = r
+			s.mu.Unlock()
+			s.invokePlayerHook(client, true)
 			return
 		}
-		s.match.OnLobbyAction(uint32(*nid), action)
-	}
-}
-
-func (s *Server) spawnBot(name string, difficulty cfg.BotDifficulty) {
-	// Pick spawn point
-	spawnX, spawnY := 100.0, 100.0
-	if len(s.activeLevel.SpawnPoints) > 0 {
-		idx := (len(s.clientEntities) + s.botCount()) % len(s.activeLevel.SpawnPoints)
-		sp := s.activeLevel.SpawnPoints[idx]
-		spawnX, spawnY = sp.X, sp.Y
-	}
-
-	entity := s.world.Create(
-		netcomponents.NetPosition,
-		netcomponents.NetVelocity,
-		netcomponents.NetPlayerState,
-		components.Player,
-		components.PlayerInput,
-		components.Bot,
-	)
-
-	entry := s.world.Entry(entity)
-	netcomponents.NetPosition.Set(entry, &netcomponents.NetPositionData{X: spawnX, Y: spawnY})
-	netcomponents.NetVelocity.Set(entry, &netcomponents.NetVelocityData{})
-	netcomponents.NetPlayerState.Set(entry, &netcomponents.NetPlayerStateData{
-		Direction: 1,
-		Health:    cfg.Player.Health,
-		IsBot:     true,
-	})
-
-	playerData := components.Player.Get(entry)
-	playerData.PlayerIndex = 4 + s.botCount() // Bots indices start at 4
-
-	botData := components.Bot.Get(entry)
-	botData.Difficulty = difficulty
-	// Initialize bot behavior from config
-	if config, ok := cfg.Bot.Difficulties[difficulty]; ok {
-		botData.ReactionDelay = config.ReactionDelay
-		botData.AttackRange = config.AttackRange
-		botData.RetreatThreshold = config.RetreatThreshold
+		log.Printf("Client %s rejected: reconnect token expired or unknown", client.Id())
+		s.reject(client, messages.RejectReconnectExpired)
+		return
 	}
 
-	// Create server-side physics for this bot
-	pp := newPlayerPhysics(s.activeLevel, spawnX, spawnY)
-	s.playerPhysics[entity] = pp
-
-	err := srvsync.NetworkSync(s.world, &entity,
-		netcomponents.NetPosition,
-		netcomponents.NetVelocity,
-		netcomponents.NetPlayerState,
-	)
-	if err != nil {
-		log.Printf("Failed to setup network sync for bot: %v", err)
+	if s.nameBlocked(req.PlayerName) {
+		log.Printf("Client %s rejected: the name %q is banned", client.Id(), req.PlayerName)
+		s.reject(client, "banned")
 		return
 	}
 
-	networkID := esync.GetNetworkId(entry)
-	log.Printf("Bot %q spawned as entity networkID=%d", name, *networkID)
-}
+	if s.draining.Load() {
+		log.Printf("Client %s rejected: server draining", client.Id())
+		s.reject(client, "server draining")
+		return
+	}
 
-func (s *Server) ClearAllPlayers() {
-	// First destroy all boomerangs
-	for bEntity := range s.boomerangPhysics {
-		s.destroyBoomerang(bEntity)
+	r := pending.room
+	if r == nil && req.Spectate {
+		// Spectators without a room code watch the default room.
+		r = s.defaultRoom
+	}
+	if r == nil {
+		r = s.quickPlayRoom(req.Level)
+	}
+	if r == nil {
+		log.Printf("Client %s rejected: no room available", client.Id())
+		s.reject(client, "server full")
+		return
 	}
 
-	// Remove all player entities and their physics
-	for entity, pp := range s.playerPhysics {
-		removePlayerPhysics(s.activeLevel, pp)
-		if s.world.Valid(entity) {
-			s.world.Remove(entity)
-		}
+	if reason := r.admit(client, req.Spectate); reason != "" {
+		log.Printf("Client %s rejected from room %s: %s", client.Id(), r.code, reason)
+		s.reject(client, reason)
+		// A room quick play just created for this client must not outlive it.
+		s.closeRoomIfEmpty(r)
+		return
 	}
 
-	// Clear maps
-	clear(s.playerPhysics)
-	clear(s.boomerangPhysics)
-	clear(s.playerBoomerangs)
-	// We do NOT clear clientEntities because we want to keep the connection -> entity mapping
-	// but we need to update the entity in that map if we spawn new ones.
-}
-
-func (s *Server) SpawnPlayerAtSlot(slotIdx int, slot messages.LobbySlot) {
-	spawnX, spawnY := 100.0, 100.0
-	if len(s.activeLevel.SpawnPoints) > 0 {
-		idx := slotIdx % len(s.activeLevel.SpawnPoints)
-		sp := s.activeLevel.SpawnPoints[idx]
-		spawnX, spawnY = sp.X, sp.Y
-	}
-
-	if slot.Type == 1 { // Human
-		entity := s.world.Create(
-			netcomponents.NetPosition,
-			netcomponents.NetVelocity,
-			netcomponents.NetPlayerState,
-			components.Player,
-			components.PlayerInput,
-		)
-
-		entry := s.world.Entry(entity)
-		netcomponents.NetPosition.Set(entry, &netcomponents.NetPositionData{X: spawnX, Y: spawnY})
-		netcomponents.NetVelocity.Set(entry, &netcomponents.NetVelocityData{})
-		netcomponents.NetPlayerState.Set(entry, &netcomponents.NetPlayerStateData{
-			Direction:   1,
-			Health:      cfg.Player.Health,
-			Lives:       cfg.Match.LivesPerRound,
-			PlayerIndex: slotIdx,
-		})
-
-		playerData := components.Player.Get(entry)
-		playerData.PlayerIndex = slotIdx
-
-		pp := newPlayerPhysics(s.activeLevel, spawnX, spawnY)
-		s.playerPhysics[entity] = pp
-
-		_ = srvsync.NetworkSync(s.world, &entity,
-			netcomponents.NetPosition,
-			netcomponents.NetVelocity,
-			netcomponents.NetPlayerState,
-		)
-
-		// Update mapping in clientEntities
-		s.mu.Lock()
-		if client, ok := s.networkIDClients[slot.PlayerID]; ok {
-			s.clientEntities[client] = entity
-			log.Printf("Re-associated client %s (nid=%d) with new entity for slot %d", client.Id(), slot.PlayerID, slotIdx)
-		} else {
-			log.Printf("Warning: Could not find client for nid=%d during slot spawning", slot.PlayerID)
-		}
-		s.mu.Unlock()
+	s.mu.Lock()
+	delete(s.pendingClients, client)
+	s.clientRooms[client] = r
+	s.mu.Unlock()
+	s.invokePlayerHook(client, true)
 
-	} else if slot.Type == 2 { // Bot
-		entity := s.world.Create(
-			netcomponents.NetPosition,
-			netcomponents.NetVelocity,
-			netcomponents.NetPlayerState,
-			components.Player,
-			components.PlayerInput,
-			components.Bot,
-		)
-
-		entry := s.world.Entry(entity)
-		netcomponents.NetPosition.Set(entry, &netcomponents.NetPositionData{X: spawnX, Y: spawnY})
-		netcomponents.NetVelocity.Set(entry, &netcomponents.NetVelocityData{})
-		netcomponents.NetPlayerState.Set(entry, &netcomponents.NetPlayerStateData{
-			Direction:   1,
-			Health:      cfg.Player.Health,
-			Lives:       cfg.Match.LivesPerRound,
-			PlayerIndex: slotIdx,
-			IsBot:       true,
-		})
-
-		playerData := components.Player.Get(entry)
-		playerData.PlayerIndex = slotIdx
-
-		botData := components.Bot.Get(entry)
-		if config, ok := cfg.Bot.Difficulties[cfg.BotDifficulty(slot.Difficulty)]; ok {
-			botData.ReactionDelay = config.ReactionDelay
-			botData.AttackRange = config.AttackRange
-			botData.RetreatThreshold = config.RetreatThreshold
-		}
+	r.join(client, req)
+}
 
-		pp := newPlayerPhysics(s.activeLevel, spawnX, spawnY)
-		s.playerPhysics[entity] = pp
+// onClockSync answers a client estimating the server's clock, which the
+// deadlines in game state and events are on. Answered straight away, in
+// or out of a room, so the round trip is all network.
+func (s *Server) onClockSync(client *router.NetworkClient, req messages.ClockSyncRequest) {
+	_ = s.send(client, messages.ClockSyncResponse{
+		ClientTime: req.ClientTime,
+		ServerTime: time.Now().UnixNano(),
+	})
+}
 
-		_ = srvsync.NetworkSync(s.world, &entity,
-			netcomponents.NetPosition,
-			netcomponents.NetVelocity,
-			netcomponents.NetPlayerState,
-		)
+// reject tells a client why it cannot join and closes its connection, so
+// a rejected client cannot linger as a pending one.
+func (s *Server) reject(client *router.NetworkClient, reason string) {
+	_ = s.send(client, messages.JoinRejected{Reason: reason})
+	// Close waits for the client's half of the closing handshake.
+	go func() {
+		_ = client.Close(websocket.StatusNormalClosure, "join rejected")
+	}()
+}
 
-		// Store bot's network ID into slot for round system lookups
-		if nid := esync.GetNetworkId(s.world.Entry(entity)); nid != nil {
-			s.match.Slots[slotIdx].PlayerID = uint32(*nid)
-		}
+func (s *Server) onDisconnect(client *router.NetworkClient, err error) {
+	if err != nil {
+		log.Printf("Client %s disconnected: %v", client.Id(), err)
+	} else {
+		log.Printf("Client %s disconnected", client.Id())
 	}
-}
 
-func (s *Server) botCount() int {
-	count := 0
-	components.Bot.Each(s.world, func(entry *donburi.Entry) {
-		count++
-	})
-	return count
+	s.mu.Lock()
+	r, joined := s.clientRooms[client]
+	delete(s.clientRooms, client)
+	if pending := s.pendingClients[client]; pending != nil && r == nil {
+		// A room created for this client but never joined would otherwise leak.
+		r = pending.room
+	}
+	delete(s.pendingClients, client)
+	s.mu.Unlock()
+
+	if joined {
+		s.invokePlayerHook(client, false)
+	}
+	if r == nil {
+		return
+	}
+	r.removeClient(client)
+	s.closeRoomIfEmpty(r)
 }
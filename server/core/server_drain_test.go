@@ -1,30 +1,38 @@
 package core
 
 import (
+	"reflect"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/leap-fish/necs/typeid"
+	"github.com/leap-fish/necs/typemapper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // newDrainTestServer builds the minimum Server needed to exercise Drain:
-// an atomic match-in-progress flag, a stop-able loop, and the drain
-// bookkeeping fields. NewServer is avoided because it requires real
-// levels and starts background goroutines.
-func newDrainTestServer(timeout time.Duration) *Server {
-	return &Server{
-		loop:         &GameLoop{stopChan: make(chan struct{})},
+// one room with an atomic match-in-progress flag and a stop-able loop,
+// plus the drain bookkeeping fields. NewServer is avoided because it
+// requires real levels and starts background goroutines.
+func newDrainTestServer(timeout time.Duration) (*Server, *Room) {
+	r := &Room{code: "TEST", loop: &GameLoop{stopChan: make(chan struct{})}}
+	s := &Server{
+		rooms:        map[string]*Room{r.code: r},
 		drainDone:    make(chan struct{}),
 		drainTimeout: timeout,
 	}
+	r.server = s
+	return s, r
 }
 
 func TestServerDrain(t *testing.T) {
 	tests := []struct {
 		name             string
 		matchInProgress  bool
-		duringDrain      func(s *Server)
+		duringDrain      func(r *Room)
 		drainTimeout     time.Duration
 		minDrainDuration time.Duration
 		maxDrainDuration time.Duration
@@ -38,9 +46,9 @@ func TestServerDrain(t *testing.T) {
 		{
 			name:            "waits for active match to complete",
 			matchInProgress: true,
-			duringDrain: func(s *Server) {
+			duringDrain: func(r *Room) {
 				time.Sleep(80 * time.Millisecond)
-				s.matchInProgress.Store(false)
+				r.matchInProgress.Store(false)
 			},
 			drainTimeout:     5 * time.Second,
 			minDrainDuration: 60 * time.Millisecond,
@@ -57,8 +65,8 @@ func TestServerDrain(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := newDrainTestServer(tt.drainTimeout)
-			s.matchInProgress.Store(tt.matchInProgress)
+			s, r := newDrainTestServer(tt.drainTimeout)
+			r.matchInProgress.Store(tt.matchInProgress)
 
 			done := make(chan struct{})
 			start := time.Now()
@@ -67,7 +75,7 @@ func TestServerDrain(t *testing.T) {
 				close(done)
 			}()
 			if tt.duringDrain != nil {
-				go tt.duringDrain(s)
+				go tt.duringDrain(r)
 			}
 
 			select {
@@ -84,13 +92,13 @@ func TestServerDrain(t *testing.T) {
 			}
 			assert.LessOrEqualf(t, elapsed, tt.maxDrainDuration,
 				"Drain took too long (%v > %v)", elapsed, tt.maxDrainDuration)
-			assertChannelClosed(t, s.loop.stopChan, "loop.stopChan")
+			assertChannelClosed(t, r.loop.stopChan, "loop.stopChan")
 		})
 	}
 }
 
 func TestServerDrain_concurrent_calls_are_idempotent(t *testing.T) {
-	s := newDrainTestServer(5 * time.Second)
+	s, r := newDrainTestServer(5 * time.Second)
 
 	const callers = 4
 	var wg sync.WaitGroup
@@ -114,7 +122,7 @@ func TestServerDrain_concurrent_calls_are_idempotent(t *testing.T) {
 	}
 
 	assert.True(t, s.draining.Load())
-	assertChannelClosed(t, s.loop.stopChan, "loop.stopChan")
+	assertChannelClosed(t, r.loop.stopChan, "loop.stopChan")
 }
 
 func assertChannelClosed(t *testing.T, ch <-chan struct{}, name string) {
@@ -126,3 +134,25 @@ func assertChannelClosed(t *testing.T, ch <-chan struct{}, name string) {
 		t.Fatalf("%s is not closed", name)
 	}
 }
+
+func TestServerDrain_warns_then_redirects_clients(t *testing.T) {
+	s := newRoomTestServer(t, emptyTestLevel())
+	s.drainTimeout = 50 * time.Millisecond
+	s.SetShutdownRedirect(&messages.ServerRedirect{Address: "other.example:7373"})
+	client, received := newRecordingTestClient(t)
+	s.defaultRoom.clientNetworkIDs[client] = 7
+	s.defaultRoom.matchInProgress.Store(true)
+
+	start := time.Now()
+	s.Drain()
+
+	evt, ok := nextMessage(t, received).(messages.GameEvent)
+	require.True(t, ok)
+	mapper := typemapper.NewMapper(map[uint]any{})
+	require.NoError(t, mapper.Register(typeid.GetTypeId(reflect.TypeOf(messages.ServerShutdownNotice{})), messages.ServerShutdownNotice{}))
+	notice, err := mapper.Deserialize(evt.Payload)
+	require.NoError(t, err)
+	assert.WithinDuration(t, start.Add(s.drainTimeout), time.Unix(0, notice.(messages.ServerShutdownNotice).ShutdownAt), 20*time.Millisecond)
+
+	assert.Equal(t, messages.ServerRedirect{Address: "other.example:7373"}, nextMessage(t, received))
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"log"
+	"sync"
+
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/leap-fish/necs/router"
+)
+
+// broadcastSnapshot sends the room's world snapshot to all of its clients,
+// each as a delta against the newest snapshot it acknowledged. Must be
+// called on the game loop goroutine.
+func (r *Room) broadcastSnapshot() {
+	state := r.sync.Snapshot()
+	seq := r.snapshotSeq.Add(1)
+	r.snapshots.Put(seq, state)
+
+	r.mu.RLock()
+	acks := make(map[*router.NetworkClient]uint32, len(r.clientNetworkIDs))
+	for client := range r.clientNetworkIDs {
+		acks[client] = r.snapshotAcks[client]
+	}
+	r.mu.RUnlock()
+
+	// Clients on the same baseline share one encoded delta.
+	payloads := make(map[uint32][]byte)
+	for _, ack := range acks {
+		if _, ok := payloads[ack]; ok {
+			continue
+		}
+		delta := messages.DiffSnapshot(seq, ack, r.snapshots.Get(ack), state)
+		payload, err := router.Serialize(delta)
+		if err != nil {
+			log.Printf("Snapshot encode error (room %s): %v", r.code, err)
+			r.server.metrics.syncErrors.Add(1)
+			return
+		}
+		payloads[ack] = payload
+	}
+
+	var wg sync.WaitGroup
+	for client, ack := range acks {
+		wg.Go(func() {
+			if err := r.server.sendBytes(client, "Snapshot", payloads[ack]); err != nil {
+				log.Printf("Sync error (room %s, client %s): %v", r.code, client.Id(), err)
+				r.server.metrics.syncErrors.Add(1)
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// onSnapshotAck makes the acknowledged snapshot client's baseline for the
+// next delta. Acks for snapshots never sent are ignored.
+func (r *Room) onSnapshotAck(client *router.NetworkClient, ack messages.SnapshotAck) {
+	if ack.Seq > r.snapshotSeq.Load() {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, bound := r.clientNetworkIDs[client]; bound && ack.Seq > r.snapshotAcks[client] {
+		r.snapshotAcks[client] = ack.Seq
+	}
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_snapshotEvery(t *testing.T) {
+	tests := []struct {
+		name     string
+		rate     int
+		want     int
+		wantRate int
+	}{
+		{"unset sends every tick", 0, 1, 60},
+		{"tick rate", 60, 1, 60},
+		{"above tick rate", 120, 1, 60},
+		{"half", 30, 2, 30},
+		{"third", 20, 3, 20},
+		{"rounded to whole ticks", 25, 2, 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newRoomTestServer(t, emptyTestLevel())
+			s.SetSnapshotRate(tt.rate)
+			assert.Equal(t, tt.want, s.snapshotEvery())
+			assert.Equal(t, tt.wantRate, s.snapshotRateHz())
+		})
+	}
+}
+
+func TestRoom_onSnapshotAck(t *testing.T) {
+	s := newRoomTestServer(t, emptyTestLevel())
+	r := newIdleTestRoom(t, s)
+	client := newTestClient(t)
+	r.mu.Lock()
+	r.clientNetworkIDs[client] = 1
+	r.mu.Unlock()
+
+	for range 3 {
+		r.broadcastSnapshot()
+	}
+
+	r.onSnapshotAck(client, messages.SnapshotAck{Seq: 2})
+	assert.Equal(t, uint32(2), r.snapshotAcks[client])
+
+	r.onSnapshotAck(client, messages.SnapshotAck{Seq: 1})
+	assert.Equal(t, uint32(2), r.snapshotAcks[client], "older ack ignored")
+
+	r.onSnapshotAck(client, messages.SnapshotAck{Seq: 9})
+	assert.Equal(t, uint32(2), r.snapshotAcks[client], "never sent")
+
+	stranger := newTestClient(t)
+	r.onSnapshotAck(stranger, messages.SnapshotAck{Seq: 3})
+	assert.NotContains(t, r.snapshotAcks, stranger)
+}
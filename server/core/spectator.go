@@ -0,0 +1,95 @@
+package core
+
+import (
+	"log"
+
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/leap-fish/necs/esync/srvsync"
+	"github.com/leap-fish/necs/router"
+)
+
+// maxSpectators caps watch-only spectators per room. Clients queued for a
+// slot count against maxPlayers instead.
+const maxSpectators = 8
+
+// spectator is a client watching the room without a body or slot.
+type spectator struct {
+	name      string
+	watchOnly bool // asked to spectate; never promoted into a slot
+}
+
+// addSpectator binds client as a spectator. It gets snapshots and events
+// but no physics body, and unless it asked to only watch it is queued for
+// the next open slot. Must be called on the game loop goroutine.
+func (r *Room) addSpectator(client *router.NetworkClient, req messages.JoinRequest) {
+	netID := uint32(srvsync.NetworkIdCounter.Add(1))
+	reconnectToken := newReconnectToken()
+	accepted := r.joinAccepted(netID, reconnectToken)
+	accepted.Spectator = true
+
+	r.mu.Lock()
+	delete(r.joiningClients, client)
+	r.spectators[client] = &spectator{name: req.PlayerName, watchOnly: req.Spectate}
+	if !req.Spectate {
+		r.spectatorQueue = append(r.spectatorQueue, client)
+	}
+	r.clientNetworkIDs[client] = netID
+	r.networkIDClients[netID] = client
+	// The token only reclaims a slot once the spectator has been promoted;
+	// until then removeClient has no player to hold.
+	r.clientTokens[client] = reconnectToken
+	r.tokenNetIDs[reconnectToken] = netID
+	if req.GgscaleSessionToken != "" {
+		r.ggscaleTokens[netID] = req.GgscaleSessionToken
+	}
+	r.mu.Unlock()
+
+	_ = r.server.send(client, accepted)
+
+	if req.Spectate {
+		log.Printf("Spectator %q joined room %s as networkID=%d (client %s)",
+			req.PlayerName, r.code, netID, client.Id())
+	} else {
+		log.Printf("Player %q queued as spectator in room %s as networkID=%d (client %s)",
+			req.PlayerName, r.code, netID, client.Id())
+	}
+}
+
+// promoteSpectators seats queued spectators in empty slots, in join
+// order, and returns the slot indices it filled. The caller spawns their
+// bodies. Must be called on the game loop goroutine.
+func (r *Room) promoteSpectators() []int {
+	var filled []int
+
+	r.mu.Lock()
+	for len(r.spectatorQueue) > 0 {
+		slotIdx := r.match.FirstEmptySlot()
+		if slotIdx < 0 {
+			break
+		}
+		client := r.spectatorQueue[0]
+		r.spectatorQueue = r.spectatorQueue[1:]
+		spec := r.spectators[client]
+		delete(r.spectators, client)
+
+		r.match.Slots[slotIdx] = messages.LobbySlot{
+			Type:     1, // Human
+			PlayerID: r.clientNetworkIDs[client],
+			Name:     spec.name,
+		}
+		r.match.Slots[slotIdx].Team = r.match.autoTeam(slotIdx)
+		filled = append(filled, slotIdx)
+	}
+	r.mu.Unlock()
+
+	for _, slotIdx := range filled {
+		slot := r.match.Slots[slotIdx]
+		log.Printf("Spectator nid=%d promoted to slot %d in room %s", slot.PlayerID, slotIdx, r.code)
+		r.broadcastEvent(messages.MatchEvent{
+			Type:     "spectator_promoted",
+			PlayerID: slot.PlayerID,
+			Message:  slot.Name + " joins the fight",
+		})
+	}
+	return filled
+}
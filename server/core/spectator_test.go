@@ -0,0 +1,93 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
+	"github.com/leap-fish/necs/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSpectatorTestRoom registers a room whose loop is not running, with
+// its match already under way.
+func newSpectatorTestRoom(t *testing.T) *Room {
+	t.Helper()
+	r := newIdleTestRoom(t, newRoomTestServer(t, emptyTestLevel()))
+	r.match.State = netcomponents.MatchStatePlaying
+	return r
+}
+
+// joinRoom admits client and places it as the game loop would.
+func joinRoom(t *testing.T, r *Room, client *router.NetworkClient, req messages.JoinRequest) {
+	t.Helper()
+	require.Empty(t, r.admit(client, req.Spectate))
+	r.place(client, req)
+}
+
+func TestRoom_place_mid_match_joins_as_spectator(t *testing.T) {
+	r := newSpectatorTestRoom(t)
+	late := newTestClient(t)
+	watcher := newTestClient(t)
+
+	joinRoom(t, r, late, messages.JoinRequest{PlayerName: "late"})
+	joinRoom(t, r, watcher, messages.JoinRequest{PlayerName: "watcher", Spectate: true})
+
+	assert.Equal(t, 0, r.PlayerCount(), "spectators have no body")
+	assert.Empty(t, r.playerPhysics)
+	assert.Equal(t, 2, r.clientCount())
+	assert.Equal(t, []*router.NetworkClient{late}, r.spectatorQueue, "only non-watchers queue for a slot")
+
+	r.onLobbyAction(late, messages.LobbyAction{Action: "pick_slot", Value: 0})
+	assert.Empty(t, r.cmdCh, "spectator lobby actions are ignored")
+}
+
+func TestRoom_promoteSpectators_fills_empty_slots_in_join_order(t *testing.T) {
+	r := newSpectatorTestRoom(t)
+	for i := range 3 {
+		r.match.Slots[i] = messages.LobbySlot{Type: 2, Name: "Bot"}
+	}
+
+	first, second, watcher := newTestClient(t), newTestClient(t), newTestClient(t)
+	joinRoom(t, r, first, messages.JoinRequest{PlayerName: "first"})
+	joinRoom(t, r, watcher, messages.JoinRequest{PlayerName: "watcher", Spectate: true})
+	joinRoom(t, r, second, messages.JoinRequest{PlayerName: "second"})
+
+	assert.Equal(t, []int{3}, r.promoteSpectators())
+
+	slot := r.match.Slots[3]
+	assert.Equal(t, 1, slot.Type)
+	assert.Equal(t, r.clientNetworkIDs[first], slot.PlayerID)
+	assert.Equal(t, "first", slot.Name)
+	assert.NotContains(t, r.spectators, first)
+	assert.Equal(t, []*router.NetworkClient{second}, r.spectatorQueue, "no slot left for the second in line")
+	assert.Contains(t, r.spectators, watcher, "watch-only spectators are never promoted")
+
+	r.SpawnPlayerAtSlot(3, slot)
+	assert.Equal(t, 1, r.PlayerCount(), "promoted spectator gets a body")
+}
+
+func TestRoom_promoteSpectators_joins_the_smaller_team(t *testing.T) {
+	r := newSpectatorTestRoom(t)
+	r.match.GameMode = "2v2"
+	r.match.Slots[0] = messages.LobbySlot{Type: 2, Name: "Bot", Team: 0}
+	r.match.Slots[1] = messages.LobbySlot{Type: 2, Name: "Bot", Team: 0}
+	r.match.Slots[2] = messages.LobbySlot{Type: 2, Name: "Bot", Team: 1}
+
+	late := newTestClient(t)
+	joinRoom(t, r, late, messages.JoinRequest{PlayerName: "late"})
+
+	require.Equal(t, []int{3}, r.promoteSpectators())
+	assert.Equal(t, 1, r.match.Slots[3].Team)
+}
+
+func TestRoom_admit_caps_watchers_separately(t *testing.T) {
+	r := newSpectatorTestRoom(t)
+	fillRoom(t, r)
+
+	for range maxSpectators {
+		require.Empty(t, r.admit(&router.NetworkClient{}, true), "a full room still takes watchers")
+	}
+	assert.Equal(t, "spectators full", r.admit(&router.NetworkClient{}, true))
+}
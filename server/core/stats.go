@@ -0,0 +1,105 @@
+package core
+
+import (
+	"github.com/automoto/doomerang-mp/shared/messages"
+)
+
+// Match statistics. ServerMatch.Stats gathers a messages.PlayerStats for
+// every player over a match; endMatch sends them to clients in a
+// MatchStats message and hands them to the MatchEndHook. A network ID of 0
+// stands for whatever isn't a player — hazards and co-op enemies — and is
+// never recorded.
+
+// playerStats returns netID's stats, creating them on first use.
+func (m *ServerMatch) playerStats(netID uint32) *messages.PlayerStats {
+	stats, ok := m.Stats[netID]
+	if !ok {
+		stats = &messages.PlayerStats{}
+		m.Stats[netID] = stats
+	}
+	return stats
+}
+
+// recordDamage records damage dealt by attacker to target.
+func (m *ServerMatch) recordDamage(attacker, target uint32, damage int) {
+	if attacker != 0 && attacker != target {
+		m.playerStats(attacker).DamageDealt += damage
+	}
+	if target != 0 {
+		m.playerStats(target).DamageTaken += damage
+	}
+}
+
+// recordMeleeHit records a melee hit landed by attacker with the attack
+// pp is making.
+func (m *ServerMatch) recordMeleeHit(attacker uint32, pp *PlayerPhysics) {
+	if attacker == 0 {
+		return
+	}
+	stats := m.playerStats(attacker)
+	switch {
+	case pp.AttackIsJumpKick:
+		stats.JumpKickHits++
+	case pp.AttackIsPunch:
+		stats.PunchHits++
+	default:
+		stats.KickHits++
+	}
+}
+
+// recordThrow records a boomerang thrown by owner.
+func (m *ServerMatch) recordThrow(owner uint32) {
+	if owner != 0 {
+		m.playerStats(owner).BoomerangThrows++
+	}
+}
+
+// recordBoomerangHit records bp hitting a target. Only a throw's first
+// hit counts, so accuracy is the share of throws that hit anything. Call
+// it before adding the target to bp's hit sets.
+func (m *ServerMatch) recordBoomerangHit(bp *BoomerangPhysics) {
+	if bp.OwnerNetworkID == 0 || len(bp.HitPlayers) > 0 || len(bp.HitEnemies) > 0 {
+		return
+	}
+	m.playerStats(uint32(bp.OwnerNetworkID)).BoomerangHits++
+}
+
+// recordSelfKO records victim being knocked out by a hazard with nobody
+// to credit.
+func (m *ServerMatch) recordSelfKO(victim uint32) {
+	if victim != 0 {
+		m.playerStats(victim).SelfKOs++
+	}
+}
+
+// recordTimeAlive adds dt seconds to every living player's time alive.
+func (m *ServerMatch) recordTimeAlive(dt float64) {
+	for entity, pp := range m.room.playerPhysics {
+		if !m.room.world.Valid(entity) || pp.Dead {
+			continue
+		}
+		if netID := uint32(m.room.networkID(entity)); netID != 0 {
+			m.playerStats(netID).TimeAlive += dt
+		}
+	}
+}
+
+// finalStats returns a copy of every player's stats with their KOs and
+// deaths filled in.
+func (m *ServerMatch) finalStats() map[uint32]messages.PlayerStats {
+	out := make(map[uint32]messages.PlayerStats, len(m.Stats))
+	for netID, stats := range m.Stats {
+		out[netID] = *stats
+	}
+	for netID, kos := range m.Scores {
+		stats := out[netID]
+		stats.KOs = kos
+		out[netID] = stats
+	}
+	for netID, deaths := range m.Deaths {
+		stats := out[netID]
+		stats.Deaths = deaths
+		out[netID] = stats
+	}
+	return out
+}
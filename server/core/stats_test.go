@@ -0,0 +1,118 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoom_checkMeleeHitbox_records_stats(t *testing.T) {
+	r := newIdleTestRoom(t, newRoomTestServer(t, emptyTestLevel()))
+	attacker, attackerNetID := addTestPlayer(t, r, 100, 100)
+	target, targetNetID := addTestPlayer(t, r, 118, 100)
+	netcomponents.NetPlayerState.Get(r.world.Entry(attacker)).Direction = 1
+	netcomponents.NetPlayerState.Get(r.world.Entry(target)).Health = cfg.Player.Health
+
+	attackerPP := r.playerPhysics[attacker]
+	attackerPP.AttackIsPunch = true
+	r.checkMeleeHitbox(attacker, attackerPP)
+
+	stats := r.match.finalStats()
+	assert.Equal(t, 1, stats[attackerNetID].PunchHits)
+	assert.Zero(t, stats[attackerNetID].KickHits)
+	assert.Equal(t, cfg.Combat.PlayerPunchDamage, stats[attackerNetID].DamageDealt)
+	assert.Equal(t, cfg.Combat.PlayerPunchDamage, stats[targetNetID].DamageTaken)
+}
+
+func TestRoom_checkBoomerangCollisions_records_accuracy(t *testing.T) {
+	r := newIdleTestRoom(t, newRoomTestServer(t, emptyTestLevel()))
+	owner, ownerNetID := addTestPlayer(t, r, 40, 100)
+	first, _ := addTestPlayer(t, r, 100, 100)
+	second, _ := addTestPlayer(t, r, 106, 100)
+	netcomponents.NetPlayerState.Get(r.world.Entry(first)).Health = cfg.Player.Health
+	netcomponents.NetPlayerState.Get(r.world.Entry(second)).Health = cfg.Player.Health
+
+	// Two throws, the first piercing both players
+	r.match.recordThrow(ownerNetID)
+	r.match.recordThrow(ownerNetID)
+	obj := r.playerPhysics[first].Object
+	bp := newBoomerangPhysics(r.activeLevel, obj.X+4, obj.Y+10, owner, uint(ownerNetID))
+	bp.Damage = 5
+	r.checkBoomerangCollisions(r.world.Create(netcomponents.NetBoomerang), bp)
+	require.Len(t, bp.HitPlayers, 2)
+
+	stats := r.match.finalStats()[ownerNetID]
+	assert.Equal(t, 2, stats.BoomerangThrows)
+	assert.Equal(t, 1, stats.BoomerangHits)
+	assert.Equal(t, 0.5, stats.BoomerangAccuracy())
+	assert.Equal(t, 10, stats.DamageDealt)
+}
+
+func TestServerMatch_stats_streaks_and_self_kos(t *testing.T) {
+	r := newIdleTestRoom(t, newRoomTestServer(t, emptyTestLevel()))
+	entity, netID := addTestPlayer(t, r, 100, 100)
+	m := r.match
+
+	m.AddKO(netID)
+	m.AddKO(netID)
+	m.AddDeath(netID)
+	m.AddKO(netID)
+
+	// Falling with nobody to credit is a self-KO, after a hit it is not
+	pp := r.playerPhysics[entity]
+	m.Lives[netID] = 3
+	r.applyDeadZone(entity, pp)
+	pp.Dead = false
+	pp.LastAttacker = 99
+	r.applyDeadZone(entity, pp)
+
+	stats := m.finalStats()[netID]
+	assert.Equal(t, 3, stats.KOs)
+	assert.Equal(t, 3, stats.Deaths)
+	assert.Equal(t, 2, stats.LongestStreak)
+	assert.Equal(t, 1, stats.SelfKOs)
+}
+
+func TestServerMatch_updatePlaying_records_time_alive(t *testing.T) {
+	r := newIdleTestRoom(t, newRoomTestServer(t, emptyTestLevel()))
+	_, aliveNetID := addTestPlayer(t, r, 100, 100)
+	dead, deadNetID := addTestPlayer(t, r, 200, 100)
+	r.playerPhysics[dead].Dead = true
+	r.match.State = netcomponents.MatchStatePlaying
+	r.match.Timer = 60
+
+	for range 30 {
+		r.match.updatePlaying(0.1)
+	}
+
+	stats := r.match.finalStats()
+	assert.InDelta(t, 3.0, stats[aliveNetID].TimeAlive, 1e-9)
+	assert.Zero(t, stats[deadNetID].TimeAlive)
+}
+
+func TestServerMatch_endMatch_sends_stats(t *testing.T) {
+	s := newRoomTestServer(t, emptyTestLevel())
+	hookStats := make(chan map[uint32]messages.PlayerStats, 1)
+	s.SetMatchEndHook(func(stats map[uint32]messages.PlayerStats, _ map[uint32]string) {
+		hookStats <- stats
+	})
+	r := newIdleTestRoom(t, s)
+	_, netID := addTestPlayer(t, r, 100, 100)
+	r.match.AddKO(netID)
+	r.match.recordThrow(netID)
+
+	r.match.endMatch("time")
+
+	select {
+	case stats := <-hookStats:
+		assert.Equal(t, 1, stats[netID].KOs)
+		assert.Equal(t, 1, stats[netID].BoomerangThrows)
+	case <-time.After(time.Second):
+		t.Fatal("match end hook not called")
+	}
+}
@@ -0,0 +1,82 @@
+package core
+
+// Team assignment for the team modes, "2v2" and "coop". Slots carry their
+// team in LobbySlot.Team; outside team modes every slot's team is -1 and
+// everyone fights everyone, as offline.
+
+// teamMode reports whether the match's mode is played in teams.
+func (m *ServerMatch) teamMode() bool {
+	return m.GameMode == "2v2" || m.GameMode == "coop"
+}
+
+// autoTeam returns the team a newly filled slot joins: humans against
+// bots in co-op, otherwise the smaller team — mirrors the offline
+// AutoAssignTeams.
+func (m *ServerMatch) autoTeam(slotIdx int) int {
+	switch m.GameMode {
+	case "2v2":
+		var sizes [2]int
+		for i, slot := range m.Slots {
+			if i != slotIdx && slot.Type != 0 && slot.Team >= 0 && slot.Team < 2 {
+				sizes[slot.Team]++
+			}
+		}
+		if sizes[1] < sizes[0] {
+			return 1
+		}
+		return 0
+	case "coop":
+		if m.Slots[slotIdx].Type == 2 { // Bot
+			return 1
+		}
+		return 0
+	default:
+		return -1
+	}
+}
+
+// autoBalance reassigns every filled slot's team: alternating between the
+// two teams in slot order for 2v2, humans against bots for co-op, and no
+// teams otherwise.
+func (m *ServerMatch) autoBalance() {
+	filled := 0
+	for i := range m.Slots {
+		slot := &m.Slots[i]
+		switch {
+		case slot.Type == 0 || !m.teamMode():
+			slot.Team = -1
+		case m.GameMode == "2v2":
+			slot.Team = filled % 2
+			filled++
+		default:
+			slot.Team = m.autoTeam(i)
+		}
+	}
+}
+
+// teamOf returns the team of the player or bot with netID, or -1 if they
+// have none.
+func (m *ServerMatch) teamOf(netID uint32) int {
+	if !m.teamMode() || netID == 0 {
+		return -1
+	}
+	for _, slot := range m.Slots {
+		if slot.Type != 0 && slot.PlayerID == netID {
+			return slot.Team
+		}
+	}
+	return -1
+}
+
+// areTeammates reports whether two players are on the same team — mirrors
+// the offline areTeammates.
+func (m *ServerMatch) areTeammates(a, b uint32) bool {
+	team := m.teamOf(a)
+	return team != -1 && team == m.teamOf(b)
+}
+
+// canHurt reports whether attacker may damage target: always, unless they
+// are teammates and friendly fire is off.
+func (m *ServerMatch) canHurt(attacker, target uint32) bool {
+	return m.FriendlyFire || !m.areTeammates(attacker, target)
+}
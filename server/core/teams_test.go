@@ -0,0 +1,98 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yohamta/donburi"
+)
+
+func TestServerMatch_lobby_teams(t *testing.T) {
+	r := newIdleTestRoom(t, newRoomTestServer(t, emptyTestLevel()))
+	m := r.match
+	const host, guest = 1, 2
+
+	m.OnLobbyAction(host, messages.LobbyAction{Action: "change_mode", String: "2v2"})
+	m.OnLobbyAction(host, messages.LobbyAction{Action: "pick_slot", Value: 0})
+	m.OnLobbyAction(guest, messages.LobbyAction{Action: "pick_slot", Value: 1})
+	m.OnLobbyAction(host, messages.LobbyAction{Action: "add_bot", Value: 1})
+	assert.Equal(t, []int{0, 1, 0}, []int{m.Slots[0].Team, m.Slots[1].Team, m.Slots[2].Team})
+
+	// Players switch themselves, the host switches anyone
+	m.OnLobbyAction(guest, messages.LobbyAction{Action: "change_team", Value: 1})
+	assert.Equal(t, 0, m.Slots[1].Team)
+	m.OnLobbyAction(guest, messages.LobbyAction{Action: "change_team", Value: 2})
+	assert.Equal(t, 0, m.Slots[2].Team)
+	m.OnLobbyAction(host, messages.LobbyAction{Action: "change_team", Value: 2})
+	assert.Equal(t, 1, m.Slots[2].Team)
+
+	m.OnLobbyAction(host, messages.LobbyAction{Action: "auto_balance"})
+	assert.Equal(t, []int{0, 1, 0}, []int{m.Slots[0].Team, m.Slots[1].Team, m.Slots[2].Team})
+
+	// Co-op puts the humans against the bots
+	m.OnLobbyAction(host, messages.LobbyAction{Action: "change_mode", String: "coop"})
+	assert.Equal(t, []int{0, 0, 1, -1}, []int{m.Slots[0].Team, m.Slots[1].Team, m.Slots[2].Team, m.Slots[3].Team})
+
+	// No teams in free-for-all
+	m.OnLobbyAction(host, messages.LobbyAction{Action: "change_mode", String: "ffa"})
+	assert.False(t, m.areTeammates(host, guest))
+	m.OnLobbyAction(host, messages.LobbyAction{Action: "change_team", Value: 0})
+	assert.Equal(t, -1, m.Slots[0].Team)
+}
+
+// addTeamPlayers seats an attacker and a target in slots 0 and 1 of a
+// 2v2 match, on the same team, standing within punching distance of each
+// other.
+func addTeamPlayers(t *testing.T, r *Room) (attacker, target donburi.Entity) {
+	t.Helper()
+	r.match.GameMode = "2v2"
+	attacker, attackerNetID := addTestPlayer(t, r, 100, 100)
+	target, targetNetID := addTestPlayer(t, r, 118, 100)
+	r.match.Slots[0] = messages.LobbySlot{Type: 1, PlayerID: attackerNetID, Team: 0}
+	r.match.Slots[1] = messages.LobbySlot{Type: 1, PlayerID: targetNetID, Team: 0}
+	netcomponents.NetPlayerState.Get(r.world.Entry(attacker)).Direction = 1
+	return attacker, target
+}
+
+func TestRoom_checkMeleeHitbox_friendly_fire(t *testing.T) {
+	for _, friendlyFire := range []bool{false, true} {
+		r := newIdleTestRoom(t, newRoomTestServer(t, emptyTestLevel()))
+		attacker, target := addTeamPlayers(t, r)
+		r.match.FriendlyFire = friendlyFire
+
+		attackerPP := r.playerPhysics[attacker]
+		attackerPP.AttackIsPunch = true
+		r.checkMeleeHitbox(attacker, attackerPP)
+
+		_, hit := attackerPP.HitTargets[target]
+		assert.Equal(t, friendlyFire, hit, "friendly fire=%v", friendlyFire)
+	}
+}
+
+func TestRoom_checkBoomerangCollisions_friendly_fire(t *testing.T) {
+	for _, friendlyFire := range []bool{false, true} {
+		r := newIdleTestRoom(t, newRoomTestServer(t, emptyTestLevel()))
+		owner, target := addTeamPlayers(t, r)
+		r.match.FriendlyFire = friendlyFire
+
+		obj := r.playerPhysics[target].Object
+		bp := newBoomerangPhysics(r.activeLevel, obj.X, obj.Y+10, owner, r.networkID(owner))
+		r.checkBoomerangCollisions(r.world.Create(netcomponents.NetBoomerang), bp)
+
+		_, hit := bp.HitPlayers[target]
+		assert.Equal(t, friendlyFire, hit, "friendly fire=%v", friendlyFire)
+	}
+}
+
+func TestServerMatch_teamOf_spawned_bot(t *testing.T) {
+	r := newIdleTestRoom(t, newRoomTestServer(t, emptyTestLevel()))
+	r.match.GameMode = "2v2"
+	r.match.Slots[0] = messages.LobbySlot{Type: 2, Team: 1}
+	r.SpawnPlayerAtSlot(0, r.match.Slots[0])
+	require.NotZero(t, r.match.Slots[0].PlayerID)
+
+	assert.Equal(t, 1, r.match.teamOf(r.match.Slots[0].PlayerID))
+}
@@ -0,0 +1,422 @@
+// Package fakeggscale is an in-memory stand-in for the parts of
+// ggscale-server doomerang uses: anonymous auth, fleet registration,
+// matchmaking and leaderboards. Any route can be told to fail on demand,
+// so the game server, the client and integration tests can run the whole
+// join → match → score-submit flow on one machine, offline.
+//
+// It implements just enough of each route for the ggscale-go SDK calls
+// doomerang makes. Nothing is persisted, and auth only checks that
+// requests carry a key and, where ggscale needs one, a session token the
+// fake issued.
+package fakeggscale
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The routes the fake serves, as http.ServeMux patterns. Pass them to
+// Fail to script failures.
+const (
+	RouteAnonymousAuth = "POST /v1/auth/anonymous"
+	RouteRegister      = "POST /v1/fleet/servers"
+	RouteHeartbeat     = "PUT /v1/fleet/servers/{id}/heartbeat"
+	RouteDeregister    = "DELETE /v1/fleet/servers/{id}"
+	RouteListServers   = "GET /v1/fleet/servers"
+	RouteCreateTicket  = "POST /v1/matchmaker/tickets"
+	RouteGetTicket     = "GET /v1/matchmaker/tickets/{id}"
+	RouteSubmitScore   = "POST /v1/leaderboards/{id}/scores"
+	RouteListScores    = "GET /v1/leaderboards/{id}/scores"
+)
+
+// The control routes script failures. They never fail themselves and
+// need no API key.
+const (
+	routeFail          = "POST /fake/failures"
+	routeClearFailures = "DELETE /fake/failures"
+)
+
+// sessionHeader carries a player's access token alongside the API key.
+const sessionHeader = "X-Session-Token"
+
+// Ticket statuses.
+const (
+	TicketQueued  = "queued"
+	TicketMatched = "matched"
+)
+
+// Options configures a Server.
+type Options struct {
+	// APIKey, when set, is the only API key requests may carry. Empty
+	// accepts any key.
+	APIKey string
+	// MatchAddress is the game server address every matchmaker ticket
+	// is matched to. Empty matches tickets to the most recently
+	// registered fleet server, and leaves them queued until one is.
+	MatchAddress string
+}
+
+// FleetServer is a registered game server.
+type FleetServer struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Address       string    `json:"address"`
+	Version       string    `json:"version"`
+	Region        string    `json:"region"`
+	MaxPlayers    int       `json:"max_players"`
+	LastHeartbeat time.Time `json:"last_heartbeat"`
+}
+
+// Ticket is a matchmaker ticket.
+type Ticket struct {
+	ID           string `json:"id"`
+	Fleet        string `json:"fleet"`
+	GameMode     string `json:"game_mode"`
+	Status       string `json:"status"`
+	MatchAddress string `json:"match_address,omitempty"`
+}
+
+// Score is an end user's best score on a leaderboard.
+type Score struct {
+	EndUserID int64 `json:"end_user_id"`
+	Score     int64 `json:"score"`
+}
+
+// Session is the response to an anonymous login.
+type Session struct {
+	AccessToken string `json:"access_token"`
+	EndUserID   int64  `json:"end_user_id"`
+}
+
+// Failure is a scripted failure: the next Times requests to Route are
+// answered with Status. Times <= 0 fails every request until the
+// failures are cleared.
+type Failure struct {
+	Route  string `json:"route"`
+	Status int    `json:"status"`
+	Times  int    `json:"times"`
+}
+
+// Server is the fake ggscale HTTP service. It is an http.Handler.
+type Server struct {
+	opts Options
+	mux  *http.ServeMux
+
+	mu       sync.Mutex
+	nextID   int64
+	sessions map[string]int64 // Access token → end user ID
+	servers  []*FleetServer   // In registration order
+	tickets  map[string]*Ticket
+	scores   map[int64]map[int64]int64 // Leaderboard → end user → best score
+	failures map[string]*Failure       // Keyed by route
+}
+
+// New returns a fake ggscale service with nothing registered.
+func New(opts Options) *Server {
+	s := &Server{
+		opts:     opts,
+		mux:      http.NewServeMux(),
+		sessions: make(map[string]int64),
+		tickets:  make(map[string]*Ticket),
+		scores:   make(map[int64]map[int64]int64),
+		failures: make(map[string]*Failure),
+	}
+	s.mux.HandleFunc(RouteAnonymousAuth, s.handleAnonymousAuth)
+	s.mux.HandleFunc(RouteRegister, s.handleRegister)
+	s.mux.HandleFunc(RouteHeartbeat, s.handleHeartbeat)
+	s.mux.HandleFunc(RouteDeregister, s.handleDeregister)
+	s.mux.HandleFunc(RouteListServers, s.handleListServers)
+	s.mux.HandleFunc(RouteCreateTicket, s.handleCreateTicket)
+	s.mux.HandleFunc(RouteGetTicket, s.handleGetTicket)
+	s.mux.HandleFunc(RouteSubmitScore, s.handleSubmitScore)
+	s.mux.HandleFunc(RouteListScores, s.handleListScores)
+	s.mux.HandleFunc(routeFail, s.handleFail)
+	s.mux.HandleFunc(routeClearFailures, func(w http.ResponseWriter, _ *http.Request) {
+		s.ClearFailures()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return s
+}
+
+// ServeHTTP answers a scripted failure for the route if there is one,
+// then checks the API key, then serves the route.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_, route := s.mux.Handler(r)
+	if route != "" && route != routeFail && route != routeClearFailures {
+		if status, ok := s.takeFailure(route); ok {
+			writeError(w, status, "scripted failure")
+			return
+		}
+		if !s.authorized(r) {
+			writeError(w, http.StatusUnauthorized, "missing or invalid API key")
+			return
+		}
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && key != "" && (s.opts.APIKey == "" || key == s.opts.APIKey)
+}
+
+// Fail scripts f. It replaces any failure already scripted for the same
+// route.
+func (s *Server) Fail(f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[f.Route] = &f
+}
+
+// ClearFailures removes every scripted failure.
+func (s *Server) ClearFailures() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.failures)
+}
+
+func (s *Server) takeFailure(route string) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.failures[route]
+	if !ok {
+		return 0, false
+	}
+	if f.Times > 0 {
+		f.Times--
+		if f.Times == 0 {
+			delete(s.failures, route)
+		}
+	}
+	return f.Status, true
+}
+
+// Servers returns the registered fleet servers, in registration order.
+func (s *Server) Servers() []FleetServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.serverListLocked()
+}
+
+// Scores returns leaderboardID's best score per end user, highest first.
+func (s *Server) Scores(leaderboardID int64) []Score {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	scores := make([]Score, 0, len(s.scores[leaderboardID]))
+	for user, score := range s.scores[leaderboardID] {
+		scores = append(scores, Score{EndUserID: user, Score: score})
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].EndUserID < scores[j].EndUserID
+	})
+	return scores
+}
+
+func (s *Server) serverListLocked() []FleetServer {
+	servers := make([]FleetServer, 0, len(s.servers))
+	for _, fs := range s.servers {
+		servers = append(servers, *fs)
+	}
+	return servers
+}
+
+func (s *Server) serverIndexLocked(id string) int {
+	return slices.IndexFunc(s.servers, func(fs *FleetServer) bool { return fs.ID == id })
+}
+
+func (s *Server) newIDLocked(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s-%d", prefix, s.nextID)
+}
+
+// endUser returns the end user whose session token r carries.
+func (s *Server) endUser(r *http.Request) (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.sessions[r.Header.Get(sessionHeader)]
+	return user, ok
+}
+
+func (s *Server) handleAnonymousAuth(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	s.nextID++
+	session := Session{
+		AccessToken: fmt.Sprintf("fake-session-%d", s.nextID),
+		EndUserID:   s.nextID,
+	}
+	s.sessions[session.AccessToken] = session.EndUserID
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, session)
+}
+
+func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	var fs FleetServer
+	if err := json.NewDecoder(r.Body).Decode(&fs); err != nil || fs.Address == "" {
+		writeError(w, http.StatusBadRequest, "body must be a server with an address")
+		return
+	}
+	s.mu.Lock()
+	fs.ID = s.newIDLocked("srv")
+	fs.LastHeartbeat = time.Now().UTC()
+	s.servers = append(s.servers, &fs)
+	s.mu.Unlock()
+	writeJSON(w, http.StatusCreated, map[string]string{"id": fs.ID})
+}
+
+func (s *Server) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	i := s.serverIndexLocked(r.PathValue("id"))
+	ok := i >= 0
+	if ok {
+		s.servers[i].LastHeartbeat = time.Now().UTC()
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "no such server")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDeregister(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	if i := s.serverIndexLocked(r.PathValue("id")); i >= 0 {
+		s.servers = slices.Delete(s.servers, i, i+1)
+	}
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleListServers(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.endUser(r); !ok {
+		writeError(w, http.StatusUnauthorized, "missing or invalid session token")
+		return
+	}
+	s.mu.Lock()
+	servers := s.serverListLocked()
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string][]FleetServer{"servers": servers})
+}
+
+func (s *Server) handleCreateTicket(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.endUser(r); !ok {
+		writeError(w, http.StatusUnauthorized, "missing or invalid session token")
+		return
+	}
+	var t Ticket
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		writeError(w, http.StatusBadRequest, "body must be a ticket request")
+		return
+	}
+	s.mu.Lock()
+	t.ID = s.newIDLocked("tkt")
+	t.Status = TicketQueued
+	t.MatchAddress = ""
+	s.matchLocked(&t)
+	s.tickets[t.ID] = &t
+	s.mu.Unlock()
+	writeJSON(w, http.StatusCreated, t)
+}
+
+func (s *Server) handleGetTicket(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.endUser(r); !ok {
+		writeError(w, http.StatusUnauthorized, "missing or invalid session token")
+		return
+	}
+	s.mu.Lock()
+	t, ok := s.tickets[r.PathValue("id")]
+	var ticket Ticket
+	if ok {
+		s.matchLocked(t)
+		ticket = *t
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "no such ticket")
+		return
+	}
+	writeJSON(w, http.StatusOK, ticket)
+}
+
+// matchLocked matches a queued ticket to a game server, if there is one.
+func (s *Server) matchLocked(t *Ticket) {
+	if t.Status != TicketQueued {
+		return
+	}
+	address := s.opts.MatchAddress
+	if address == "" && len(s.servers) > 0 {
+		address = s.servers[len(s.servers)-1].Address
+	}
+	if address != "" {
+		t.Status = TicketMatched
+		t.MatchAddress = address
+	}
+}
+
+func (s *Server) handleSubmitScore(w http.ResponseWriter, r *http.Request) {
+	lbID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "no such leaderboard")
+		return
+	}
+	user, ok := s.endUser(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "missing or invalid session token")
+		return
+	}
+	var body struct {
+		Score int64 `json:"score"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "body must be a score")
+		return
+	}
+	s.mu.Lock()
+	if s.scores[lbID] == nil {
+		s.scores[lbID] = make(map[int64]int64)
+	}
+	if best, ok := s.scores[lbID][user]; !ok || body.Score > best {
+		s.scores[lbID][user] = body.Score
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusCreated, Score{EndUserID: user, Score: body.Score})
+}
+
+func (s *Server) handleListScores(w http.ResponseWriter, r *http.Request) {
+	lbID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "no such leaderboard")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]Score{"scores": s.Scores(lbID)})
+}
+
+func (s *Server) handleFail(w http.ResponseWriter, r *http.Request) {
+	var f Failure
+	if err := json.NewDecoder(r.Body).Decode(&f); err != nil || f.Route == "" || f.Status < 400 {
+		writeError(w, http.StatusBadRequest, "body must be a failure with a route and an error status")
+		return
+	}
+	s.Fail(f)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,131 @@
+package fakeggscale
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKey = "test-key"
+
+// call sends a request to h with the test API key and, if non-empty, a
+// session token, and decodes a JSON response body into out when given.
+func call(t *testing.T, h http.Handler, method, path, session, body string, out any) int {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testKey)
+	if session != "" {
+		req.Header.Set(sessionHeader, session)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if out != nil {
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(out))
+	}
+	return rec.Code
+}
+
+func login(t *testing.T, h http.Handler) Session {
+	t.Helper()
+	var session Session
+	require.Equal(t, http.StatusOK, call(t, h, http.MethodPost, "/v1/auth/anonymous", "", "{}", &session))
+	require.NotEmpty(t, session.AccessToken)
+	return session
+}
+
+func TestServer_fleet_register_heartbeat_deregister(t *testing.T) {
+	s := New(Options{APIKey: testKey})
+	session := login(t, s)
+
+	var registered struct{ ID string }
+	code := call(t, s, http.MethodPost, "/v1/fleet/servers", "", `{"name":"Test Server","address":"localhost:7373","max_players":4}`, &registered)
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, http.StatusNoContent, call(t, s, http.MethodPut, "/v1/fleet/servers/"+registered.ID+"/heartbeat", "", "", nil))
+	assert.Equal(t, http.StatusNotFound, call(t, s, http.MethodPut, "/v1/fleet/servers/srv-99/heartbeat", "", "", nil))
+
+	var list struct{ Servers []FleetServer }
+	require.Equal(t, http.StatusOK, call(t, s, http.MethodGet, "/v1/fleet/servers", session.AccessToken, "", &list))
+	require.Len(t, list.Servers, 1)
+	assert.Equal(t, "localhost:7373", list.Servers[0].Address)
+	assert.Equal(t, 4, list.Servers[0].MaxPlayers)
+
+	assert.Equal(t, http.StatusNoContent, call(t, s, http.MethodDelete, "/v1/fleet/servers/"+registered.ID, "", "", nil))
+	assert.Empty(t, s.Servers())
+}
+
+func TestServer_rejects_wrong_key_and_session(t *testing.T) {
+	s := New(Options{APIKey: testKey})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/anonymous", nil)
+	req.Header.Set("Authorization", "Bearer other-key")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	assert.Equal(t, http.StatusUnauthorized, call(t, s, http.MethodGet, "/v1/fleet/servers", "", "", nil), "no session token")
+	assert.Equal(t, http.StatusUnauthorized, call(t, s, http.MethodPost, "/v1/leaderboards/1/scores", "made-up", `{"score":1}`, nil))
+}
+
+func TestServer_matchmaker_matches_tickets_to_the_last_registered_server(t *testing.T) {
+	s := New(Options{})
+	session := login(t, s)
+
+	var ticket Ticket
+	require.Equal(t, http.StatusCreated, call(t, s, http.MethodPost, "/v1/matchmaker/tickets", session.AccessToken, `{"fleet":"docker-default","game_mode":"deathmatch"}`, &ticket))
+	assert.Equal(t, TicketQueued, ticket.Status, "no server registered yet")
+
+	call(t, s, http.MethodPost, "/v1/fleet/servers", "", `{"address":"localhost:7373"}`, nil)
+	call(t, s, http.MethodPost, "/v1/fleet/servers", "", `{"address":"localhost:7374"}`, nil)
+	require.Equal(t, http.StatusOK, call(t, s, http.MethodGet, "/v1/matchmaker/tickets/"+ticket.ID, session.AccessToken, "", &ticket))
+	assert.Equal(t, TicketMatched, ticket.Status)
+	assert.Equal(t, "localhost:7374", ticket.MatchAddress)
+	assert.Equal(t, "docker-default", ticket.Fleet)
+
+	fixed := New(Options{MatchAddress: "10.0.0.5:7373"})
+	session = login(t, fixed)
+	require.Equal(t, http.StatusCreated, call(t, fixed, http.MethodPost, "/v1/matchmaker/tickets", session.AccessToken, `{}`, &ticket))
+	assert.Equal(t, "10.0.0.5:7373", ticket.MatchAddress)
+}
+
+func TestServer_leaderboard_keeps_each_users_best_score(t *testing.T) {
+	s := New(Options{})
+	alice, bob := login(t, s), login(t, s)
+
+	for _, submit := range []struct {
+		session Session
+		score   string
+	}{{alice, "3"}, {bob, "5"}, {alice, "7"}, {alice, "2"}} {
+		require.Equal(t, http.StatusCreated, call(t, s, http.MethodPost, "/v1/leaderboards/1/scores", submit.session.AccessToken, `{"score":`+submit.score+`}`, nil))
+	}
+
+	var list struct{ Scores []Score }
+	require.Equal(t, http.StatusOK, call(t, s, http.MethodGet, "/v1/leaderboards/1/scores", "", "", &list))
+	assert.Equal(t, []Score{{EndUserID: alice.EndUserID, Score: 7}, {EndUserID: bob.EndUserID, Score: 5}}, list.Scores)
+	assert.Empty(t, s.Scores(2))
+}
+
+func TestServer_scripted_failures(t *testing.T) {
+	s := New(Options{})
+	s.Fail(Failure{Route: RouteRegister, Status: http.StatusServiceUnavailable, Times: 2})
+
+	register := func() int {
+		return call(t, s, http.MethodPost, "/v1/fleet/servers", "", `{"address":"localhost:7373"}`, nil)
+	}
+	assert.Equal(t, http.StatusServiceUnavailable, register())
+	assert.Equal(t, http.StatusServiceUnavailable, register())
+	assert.Equal(t, http.StatusCreated, register(), "the failure ran out")
+
+	assert.Equal(t, http.StatusNoContent, call(t, s, http.MethodPost, "/fake/failures", "", `{"route":"POST /v1/auth/anonymous","status":500}`, nil))
+	for range 3 {
+		assert.Equal(t, http.StatusInternalServerError, call(t, s, http.MethodPost, "/v1/auth/anonymous", "", "", nil))
+	}
+	assert.Equal(t, http.StatusNoContent, call(t, s, http.MethodDelete, "/fake/failures", "", "", nil))
+	login(t, s)
+
+	assert.Equal(t, http.StatusBadRequest, call(t, s, http.MethodPost, "/fake/failures", "", `{"route":"POST /v1/auth/anonymous","status":200}`, nil))
+}
@@ -13,6 +13,8 @@ require (
 	agones.dev/agones v1.57.0
 	github.com/automoto/doomerang-mp v0.0.0
 	github.com/automoto/ggscale-go v0.0.0-00010101000000-000000000000
+	github.com/coder/websocket v1.8.12
+	github.com/hashicorp/go-msgpack/v2 v2.1.2
 	github.com/leap-fish/necs v0.0.5-0.20250625124528-82c5928cb7a1
 	github.com/solarlune/resolv v0.6.0
 	github.com/stretchr/testify v1.11.1
@@ -22,7 +24,6 @@ require (
 
 require (
 	github.com/beefsack/go-astar v0.0.0-20200827232313-4ecf9e304482 // indirect
-	github.com/coder/websocket v1.8.12 // indirect
 	github.com/davecgh/go-spew v1.1.2-0.20180830191138-d8f796af33cc // indirect
 	github.com/disintegration/imaging v1.6.2 // indirect
 	github.com/ebitengine/gomobile v0.0.0-20250923094054-ea854a63cce1 // indirect
@@ -31,7 +32,6 @@ require (
 	github.com/ebitengine/purego v0.9.0 // indirect
 	github.com/grpc-ecosystem/grpc-gateway/v2 v2.27.3 // indirect
 	github.com/hajimehoshi/ebiten/v2 v2.9.7 // indirect
-	github.com/hashicorp/go-msgpack/v2 v2.1.2 // indirect
 	github.com/jezek/xgb v1.1.1 // indirect
 	github.com/jfreymuth/oggvorbis v1.0.5 // indirect
 	github.com/jfreymuth/vorbis v1.0.2 // indirect
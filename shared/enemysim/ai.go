@@ -0,0 +1,195 @@
+package enemysim
+
+import (
+	"math"
+
+	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/playersim"
+	"github.com/automoto/doomerang-mp/tags"
+	"github.com/solarlune/resolv"
+)
+
+// throwRecovery is how long a thrower holds its throw pose after the
+// knife leaves its hand.
+const throwRecovery = 15
+
+// Think runs one step of the AI state machine toward target (nil when
+// there is no one to fight), setting Body.SpeedX but moving nothing. Step
+// calls it; the offline systems/enemy.go calls it directly, because
+// offline enemies move through the shared physics system.
+func (e *Enemy) Think(target *resolv.Object) Events {
+	e.StateTimer++
+	if e.AttackCooldown > 0 {
+		e.AttackCooldown--
+	}
+
+	// No AI if no one to fight
+	if target == nil {
+		return 0
+	}
+
+	distance := math.Abs(target.X - e.Object.X)
+	if e.Type.IsRanged {
+		return e.thinkRanged(target, distance)
+	}
+
+	// Melee enemies don't chase or attack targets on another floor
+	if e.Type.MaxVerticalChase > 0 && math.Abs(target.Y-e.Object.Y) > e.Type.MaxVerticalChase {
+		if e.State == cfg.StateChase || e.State == cfg.StateAttackingPunch {
+			e.setState(cfg.StatePatrol)
+		}
+		return 0
+	}
+
+	switch e.State {
+	case cfg.StatePatrol:
+		if distance <= e.Type.ChaseRange {
+			e.setState(cfg.StateChase)
+			return 0
+		}
+		e.patrol()
+	case cfg.StateChase:
+		e.chase(target, distance)
+	case cfg.StateAttackingPunch:
+		// No movement input mid-punch; friction slows the enemy down
+		if e.StateTimer >= e.Type.AttackDuration {
+			e.setState(cfg.StateChase)
+			e.AttackCooldown = e.Type.AttackCooldown
+		} else if e.StateTimer == PunchHitboxStart {
+			return EventPunch
+		}
+	case cfg.Hit:
+		if e.StateTimer > e.Type.HitstunDuration {
+			e.setState(cfg.StateChase)
+		}
+	}
+	return 0
+}
+
+func (e *Enemy) chase(target *resolv.Object, distance float64) {
+	if distance <= e.Type.AttackRange && e.AttackCooldown == 0 {
+		e.setState(cfg.StateAttackingPunch)
+		return
+	}
+
+	// Hysteresis keeps the enemy from flapping between chase and patrol
+	if distance > e.Type.ChaseRange*cfg.Enemy.HysteresisMultiplier {
+		e.setState(cfg.StatePatrol)
+		return
+	}
+
+	e.face(target)
+	if distance > e.Type.StoppingDistance {
+		e.Body.SpeedX = e.Type.ChaseSpeed * e.Direction
+	}
+}
+
+func (e *Enemy) thinkRanged(target *resolv.Object, distance float64) Events {
+	switch e.State {
+	case cfg.StatePatrol, cfg.Idle:
+		if distance > e.Type.ThrowRange || e.AttackCooldown > 0 {
+			e.patrol()
+			return 0
+		}
+		e.face(target)
+		if e.belowLedge(target) {
+			e.setState(cfg.StateApproachEdge)
+			return 0
+		}
+		e.setState(cfg.Throw)
+		e.Body.SpeedX = 0
+
+	case cfg.StateApproachEdge:
+		e.face(target)
+		if distance > e.Type.ThrowRange || !e.belowLedge(target) {
+			e.setState(cfg.StatePatrol)
+			return 0
+		}
+		if !AtPlatformEdge(e.Object, e.Direction) {
+			e.Body.SpeedX = e.Type.EdgeApproachSpeed * e.Direction
+			return 0
+		}
+		e.Body.SpeedX = 0
+		if distance <= e.Type.EdgeThrowDistance && e.AttackCooldown == 0 {
+			e.setState(cfg.Throw)
+		}
+
+	case cfg.Throw:
+		var events Events
+		if e.StateTimer == e.Type.ThrowWindupTime {
+			events = EventThrow
+		}
+		if e.StateTimer >= e.Type.ThrowWindupTime+throwRecovery {
+			e.setState(cfg.StatePatrol)
+			e.AttackCooldown = e.Type.ThrowCooldown
+		}
+		return events
+
+	case cfg.Hit:
+		if e.StateTimer > e.Type.HitstunDuration {
+			e.setState(cfg.StatePatrol)
+		}
+		e.Body.SpeedX = 0
+	}
+	return 0
+}
+
+// belowLedge reports whether target is far enough below that a thrower
+// should walk to the edge of its platform before throwing.
+func (e *Enemy) belowLedge(target *resolv.Object) bool {
+	return e.Type.MinVerticalToThrow > 0 && target.Y-e.Object.Y > e.Type.MinVerticalToThrow
+}
+
+// patrol walks back and forth along the custom path, or between the
+// default patrol bounds.
+func (e *Enemy) patrol() {
+	if e.Path == nil {
+		e.Body.SpeedX = e.Type.PatrolSpeed * e.Direction
+		if e.Direction > 0 && e.Object.X >= e.PatrolRight {
+			e.Direction = -1
+		} else if e.Direction < 0 && e.Object.X <= e.PatrolLeft {
+			e.Direction = 1
+		}
+		return
+	}
+
+	// Walk toward the end in the facing direction, turning on arrival or
+	// once past it
+	left, right := e.Path[0].X, e.Path[1].X
+	if left > right {
+		left, right = right, left
+	}
+	targetX := left
+	if e.Direction > 0 {
+		targetX = right
+	}
+
+	e.Body.SpeedX = e.Type.PatrolSpeed * e.Direction
+	switch {
+	case math.Abs(targetX-e.Object.X) < e.Type.PatrolSpeed:
+		e.Direction *= -1
+	case e.Direction > 0 && e.Object.X > targetX:
+		e.Direction = -1
+	case e.Direction < 0 && e.Object.X < targetX:
+		e.Direction = 1
+	}
+}
+
+func (e *Enemy) face(target *resolv.Object) {
+	if target.X > e.Object.X {
+		e.Direction = 1
+	} else {
+		e.Direction = -1
+	}
+}
+
+func (e *Enemy) setState(state cfg.StateID) {
+	e.State = state
+	e.StateTimer = 0
+}
+
+// AtPlatformEdge reports whether there is no floor just ahead of obj in
+// direction.
+func AtPlatformEdge(obj *resolv.Object, direction float64) bool {
+	return obj.Check(8*direction, obj.H+4, tags.ResolvSolid, playersim.TagPlatform) == nil
+}
@@ -0,0 +1,238 @@
+// Package enemysim is the AI enemy simulation the dedicated server runs
+// for online co-op: patrols, chasing, punching, knife throwing and the
+// enemies' own physics. Offline play drives its enemies and knives
+// through the same Think and Knife.Step, from systems/enemy.go and
+// systems/knife.go, so an enemy behaves the same whichever side drives
+// it.
+//
+// Like playersim, the package is headless: drivers call Step once per
+// 60 Hz step and play effects from the Events it returns.
+package enemysim
+
+import (
+	"math"
+
+	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/leveldata"
+	"github.com/automoto/doomerang-mp/shared/playersim"
+	"github.com/automoto/doomerang-mp/tags"
+	"github.com/solarlune/resolv"
+)
+
+// Punch window: an attacking enemy's hitbox is out on these steps of the
+// attack state, online and in the offline createEnemyHitboxes.
+const (
+	PunchHitboxStart = 10
+	PunchHitboxEnd   = 15
+)
+
+// DeathFrames is how many steps a defeated enemy lingers, playing its
+// death animation, before it should be removed.
+const DeathFrames = 60
+
+// Events reports what happened during a step, for effects.
+type Events uint8
+
+const (
+	EventPunch Events = 1 << iota // The punch hitbox came out
+	EventThrow                    // A knife should be thrown at the target
+)
+
+// Has reports whether e includes every event in want.
+func (e Events) Has(want Events) bool {
+	return e&want == want
+}
+
+// Enemy is one AI-controlled enemy.
+type Enemy struct {
+	TypeName string
+	Type     cfg.EnemyTypeConfig
+	Object   *resolv.Object
+	Body     playersim.Body
+
+	State      cfg.StateID // AI state: StatePatrol, StateChase, Hit, ...
+	StateTimer int
+	Direction  float64 // -1 facing left, 1 facing right
+
+	Health         int
+	AttackCooldown int
+	InvulnFrames   int
+	// DeathTimer counts down the death animation once Health reaches 0.
+	DeathTimer int
+
+	// Default patrol turns around at these X bounds. Path, when set, is a
+	// custom patrol polyline the enemy walks back and forth along instead.
+	PatrolLeft, PatrolRight float64
+	Path                    []leveldata.Point
+}
+
+// Config returns the configuration for typeName, falling back to "Guard"
+// for unknown types.
+func Config(typeName string) (string, cfg.EnemyTypeConfig) {
+	if typeCfg, ok := cfg.Enemy.Types[typeName]; ok {
+		return typeName, typeCfg
+	}
+	return "Guard", cfg.Enemy.Types["Guard"]
+}
+
+// NewEnemy returns a patrolling enemy for spawn, facing left. paths holds
+// the level's patrol polylines. The collision box is not added to any
+// space.
+func NewEnemy(spawn leveldata.EnemySpawn, paths map[string][]leveldata.Point) *Enemy {
+	typeName, typeCfg := Config(spawn.EnemyType)
+
+	w, h := float64(typeCfg.CollisionWidth), float64(typeCfg.CollisionHeight)
+	obj := resolv.NewObject(spawn.X, spawn.Y, w, h, tags.ResolvEnemy)
+	obj.SetShape(resolv.NewRectangle(0, 0, w, h))
+
+	e := &Enemy{
+		TypeName:    typeName,
+		Type:        typeCfg,
+		Object:      obj,
+		State:       cfg.StatePatrol,
+		Direction:   -1,
+		Health:      typeCfg.Health,
+		PatrolLeft:  spawn.X - cfg.Enemy.DefaultPatrolDistance,
+		PatrolRight: spawn.X + cfg.Enemy.DefaultPatrolDistance,
+	}
+	// Only 2-point polylines are patrolled; anything else falls back
+	if path := paths[spawn.PatrolPath]; len(path) >= 2 {
+		e.Path = path[:2]
+	}
+	obj.Data = e
+	return e
+}
+
+// Dead reports whether the enemy has been defeated.
+func (e *Enemy) Dead() bool {
+	return e.Health <= 0
+}
+
+// Step advances the enemy by one 60 Hz step: AI toward target (nil when
+// there is no one to fight), then friction, gravity and collision. A dead
+// enemy only falls and counts down DeathTimer.
+func (e *Enemy) Step(target *resolv.Object) Events {
+	var events Events
+	if e.Dead() {
+		if e.DeathTimer > 0 {
+			e.DeathTimer--
+		}
+	} else {
+		if e.InvulnFrames > 0 {
+			e.InvulnFrames--
+		}
+		events = e.Think(target)
+	}
+	e.move()
+
+	// Fell into a dead zone
+	if !e.Dead() && e.Object.Check(0, 0, tags.ResolvDeadZone) != nil {
+		e.Kill()
+	}
+	return events
+}
+
+// TakeHit damages the enemy, knocks it back and stuns it. Callers skip
+// enemies with InvulnFrames left.
+func (e *Enemy) TakeHit(damage int, knockX, knockY float64, invulnFrames int) {
+	e.Health = max(e.Health-damage, 0)
+	e.State = cfg.Hit
+	e.StateTimer = 0
+	e.Body.SpeedX = knockX
+	e.Body.SpeedY = knockY
+	e.InvulnFrames = invulnFrames
+	if e.Dead() {
+		e.Kill()
+	}
+}
+
+// Kill defeats the enemy and starts its death animation.
+func (e *Enemy) Kill() {
+	e.Health = 0
+	e.State = cfg.Die
+	e.StateTimer = 0
+	e.DeathTimer = DeathFrames
+}
+
+// PunchHitbox returns the enemy's punch hitbox while one is out.
+func (e *Enemy) PunchHitbox() (x, y, w, h float64, ok bool) {
+	if e.State != cfg.StateAttackingPunch || e.StateTimer < PunchHitboxStart || e.StateTimer > PunchHitboxEnd {
+		return 0, 0, 0, 0, false
+	}
+	obj := e.Object
+	w, h = cfg.Combat.PunchHitboxWidth, cfg.Combat.PunchHitboxHeight
+	if e.Direction > 0 {
+		x = obj.X + obj.W
+	} else {
+		x = obj.X - w
+	}
+	return x, obj.Y + (obj.H-h)/2, w, h, true
+}
+
+// KnockbackX returns the horizontal knockback for something at centerX
+// hit by force, pushing it away from the enemy's center.
+func (e *Enemy) KnockbackX(centerX, force float64) float64 {
+	if centerX < e.Object.X+e.Object.W/2 {
+		return -force
+	}
+	return force
+}
+
+// AnimationState returns the sprite animation for the enemy's current
+// state and movement.
+func (e *Enemy) AnimationState() cfg.StateID {
+	switch e.State {
+	case cfg.Die:
+		return cfg.Die
+	case cfg.StateAttackingPunch:
+		return cfg.Punch01
+	case cfg.Throw:
+		return cfg.Throw
+	case cfg.Hit:
+		return cfg.Hit
+	case cfg.StateApproachEdge:
+		return cfg.Walk
+	}
+	switch {
+	case e.Body.OnGround == nil:
+		return cfg.Jump
+	case e.Body.SpeedX != 0:
+		return cfg.Running
+	default:
+		return cfg.Idle
+	}
+}
+
+// NearestTarget returns the candidate closest to obj, or nil if there are
+// none.
+func NearestTarget(obj *resolv.Object, candidates []*resolv.Object) *resolv.Object {
+	var nearest *resolv.Object
+	best := math.Inf(1)
+	for _, c := range candidates {
+		if d := math.Hypot(c.X-obj.X, c.Y-obj.Y); d < best {
+			nearest, best = c, d
+		}
+	}
+	return nearest
+}
+
+// move applies friction, the type's speed cap and gravity, then moves
+// the enemy through the level.
+func (e *Enemy) move() {
+	b := &e.Body
+	friction := e.Type.Friction
+	switch {
+	case b.SpeedX > friction:
+		b.SpeedX -= friction
+	case b.SpeedX < -friction:
+		b.SpeedX += friction
+	default:
+		b.SpeedX = 0
+	}
+	b.SpeedX = math.Max(math.Min(b.SpeedX, e.Type.MaxSpeed), -e.Type.MaxSpeed)
+	b.SpeedY += e.Type.Gravity
+
+	b.MoveX(e.Object, false)
+	b.MoveY(e.Object)
+	e.Object.Update()
+}
@@ -0,0 +1,220 @@
+package enemysim_test
+
+import (
+	"math"
+	"testing"
+
+	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/enemysim"
+	"github.com/automoto/doomerang-mp/shared/leveldata"
+	"github.com/automoto/doomerang-mp/shared/playersim"
+	"github.com/automoto/doomerang-mp/shared/playersim/playersimtest"
+	"github.com/automoto/doomerang-mp/tags"
+	"github.com/solarlune/resolv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// spawnOnFloor adds an enemy of enemyType standing on playersimtest's
+// floor at x.
+func spawnOnFloor(t *testing.T, space *resolv.Space, x float64, enemyType string, paths map[string][]leveldata.Point) *enemysim.Enemy {
+	t.Helper()
+	_, typeCfg := enemysim.Config(enemyType)
+	e := enemysim.NewEnemy(leveldata.EnemySpawn{
+		X:          x,
+		Y:          playersimtest.FloorY - float64(typeCfg.CollisionHeight),
+		EnemyType:  enemyType,
+		PatrolPath: "path",
+	}, paths)
+	space.Add(e.Object)
+	return e
+}
+
+func TestNewEnemy_falls_back_to_guard(t *testing.T) {
+	e := enemysim.NewEnemy(leveldata.EnemySpawn{X: 100, Y: 50, EnemyType: "Dragon"}, nil)
+
+	assert.Equal(t, "Guard", e.TypeName)
+	assert.Equal(t, cfg.Enemy.Types["Guard"].Health, e.Health)
+	assert.Equal(t, cfg.StatePatrol, e.State)
+	assert.Equal(t, 100-cfg.Enemy.DefaultPatrolDistance, e.PatrolLeft)
+	assert.Nil(t, e.Path)
+}
+
+// farAway is a target out of every enemy type's range.
+var farAway = playersim.NewPlayerObject(2000, playersimtest.FloorY-40)
+
+func TestEnemy_Step_idles_without_target(t *testing.T) {
+	space := playersimtest.NewSpace()
+	e := spawnOnFloor(t, space, 200, "Guard", nil)
+
+	for range 30 {
+		e.Step(nil)
+	}
+	assert.Equal(t, 200.0, e.Object.X)
+	assert.Equal(t, cfg.Idle, e.AnimationState())
+}
+
+func TestEnemy_Step_default_patrol_turns_at_bounds(t *testing.T) {
+	space := playersimtest.NewSpace()
+	e := spawnOnFloor(t, space, 200, "Guard", nil)
+
+	minX, maxX := e.Object.X, e.Object.X
+	for range 120 {
+		e.Step(farAway)
+		minX = math.Min(minX, e.Object.X)
+		maxX = math.Max(maxX, e.Object.X)
+	}
+
+	// Turns on the first step past a bound
+	speed := cfg.Enemy.Types["Guard"].PatrolSpeed
+	assert.InDelta(t, e.PatrolLeft, minX, 2*speed)
+	assert.InDelta(t, e.PatrolRight, maxX, 2*speed)
+	assert.NotNil(t, e.Body.OnGround)
+}
+
+func TestEnemy_Step_custom_patrol_path(t *testing.T) {
+	space := playersimtest.NewSpace()
+	paths := map[string][]leveldata.Point{"path": {{X: 230, Y: 400}, {X: 150, Y: 400}}}
+	e := spawnOnFloor(t, space, 200, "Guard", paths)
+
+	minX, maxX := e.Object.X, e.Object.X
+	for range 200 {
+		e.Step(farAway)
+		minX = math.Min(minX, e.Object.X)
+		maxX = math.Max(maxX, e.Object.X)
+	}
+
+	speed := cfg.Enemy.Types["Guard"].PatrolSpeed
+	assert.InDelta(t, 150, minX, 2*speed)
+	assert.InDelta(t, 230, maxX, 2*speed)
+}
+
+func TestEnemy_Step_guard_chases_and_punches(t *testing.T) {
+	space := playersimtest.NewSpace()
+	e := spawnOnFloor(t, space, 200, "Guard", nil)
+	target := playersim.NewPlayerObject(260, playersimtest.FloorY-40)
+
+	var punched bool
+	for range 120 {
+		if e.Step(target).Has(enemysim.EventPunch) {
+			punched = true
+			break
+		}
+	}
+	require.True(t, punched)
+	assert.Equal(t, cfg.StateAttackingPunch, e.State)
+	assert.Equal(t, 1.0, e.Direction)
+
+	x, _, w, _, ok := e.PunchHitbox()
+	require.True(t, ok)
+	assert.Equal(t, e.Object.X+e.Object.W, x)
+	assert.Equal(t, cfg.Combat.PunchHitboxWidth, w)
+
+	// Ends with a cooldown
+	for e.State == cfg.StateAttackingPunch {
+		e.Step(target)
+	}
+	assert.Equal(t, cfg.StateChase, e.State)
+	assert.Equal(t, cfg.Enemy.Types["Guard"].AttackCooldown, e.AttackCooldown)
+}
+
+func TestEnemy_Step_guard_ignores_other_floors(t *testing.T) {
+	space := playersimtest.NewSpace()
+	e := spawnOnFloor(t, space, 200, "Guard", nil)
+	target := playersim.NewPlayerObject(220, playersimtest.FloorY-40-cfg.Enemy.Types["Guard"].MaxVerticalChase-1)
+
+	for range 60 {
+		e.Step(target)
+	}
+	assert.Equal(t, cfg.StatePatrol, e.State)
+}
+
+func TestEnemy_Step_thrower_throws_after_windup(t *testing.T) {
+	space := playersimtest.NewSpace()
+	e := spawnOnFloor(t, space, 200, "KnifeThrower", nil)
+	target := playersim.NewPlayerObject(400, playersimtest.FloorY-40)
+	typeCfg := cfg.Enemy.Types["KnifeThrower"]
+
+	throwStep := -1
+	for step := range 60 {
+		if e.Step(target).Has(enemysim.EventThrow) {
+			throwStep = step
+			break
+		}
+	}
+	// One step to decide, then the windup
+	assert.Equal(t, typeCfg.ThrowWindupTime, throwStep)
+	assert.Equal(t, cfg.Throw, e.State)
+	assert.Equal(t, cfg.Throw, e.AnimationState())
+
+	for e.State == cfg.Throw {
+		e.Step(target)
+	}
+	assert.Equal(t, typeCfg.ThrowCooldown, e.AttackCooldown)
+}
+
+func TestEnemy_TakeHit(t *testing.T) {
+	space := playersimtest.NewSpace()
+	e := spawnOnFloor(t, space, 200, "LightGuard", nil)
+
+	e.TakeHit(10, -5, cfg.Combat.KnockbackUpwardForce, cfg.Combat.EnemyInvulnFrames)
+	assert.Equal(t, cfg.Enemy.Types["LightGuard"].Health-10, e.Health)
+	assert.Equal(t, cfg.Hit, e.State)
+	assert.Equal(t, -5.0, e.Body.SpeedX)
+	assert.Equal(t, cfg.Combat.EnemyInvulnFrames, e.InvulnFrames)
+
+	e.TakeHit(e.Health, 0, 0, 0)
+	assert.True(t, e.Dead())
+	assert.Equal(t, cfg.Die, e.AnimationState())
+	for range enemysim.DeathFrames {
+		e.Step(nil)
+	}
+	assert.Zero(t, e.DeathTimer)
+}
+
+func TestKnife(t *testing.T) {
+	space := playersimtest.NewSpace()
+	thrower := resolv.NewObject(100, 300, 16, 40)
+
+	// Aimed steeply down: clamped to the max downward angle
+	k := enemysim.NewKnife(thrower, 110, 400)
+	space.Add(k.Object)
+	assert.InDelta(t, cfg.Knife.MaxDownwardAngle, math.Atan2(k.SpeedY, k.SpeedX), 1e-9)
+	assert.InDelta(t, cfg.Knife.Speed, math.Hypot(k.SpeedX, k.SpeedY), 1e-9)
+	assert.Equal(t, cfg.Knife.KnockbackForce, k.KnockbackX())
+
+	// Flies until it hits a solid
+	steps := 0
+	for k.Step(640, 480) {
+		steps++
+		require.Less(t, steps, 100)
+	}
+	assert.NotNil(t, k.Object.Check(0, 0, tags.ResolvSolid))
+
+	// Flying off the level
+	k = enemysim.NewKnife(resolv.NewObject(600, 100, 16, 40), 700, 108)
+	assert.True(t, k.Step(640, 480))
+	for k.Object.X < 740 {
+		k.Step(640, 480)
+	}
+	assert.False(t, k.Step(640, 480))
+}
+
+func TestAtPlatformEdge(t *testing.T) {
+	space := playersimtest.NewSpace()
+	// At the right end of the one-way platform from x=96 to 144
+	obj := resolv.NewObject(136, playersimtest.FloorY-56-40, 16, 40)
+	space.Add(obj)
+
+	assert.False(t, enemysim.AtPlatformEdge(obj, -1))
+	assert.True(t, enemysim.AtPlatformEdge(obj, 1))
+}
+
+func TestNearestTarget(t *testing.T) {
+	obj := resolv.NewObject(100, 100, 16, 40)
+	near := resolv.NewObject(150, 100, 16, 40)
+	far := resolv.NewObject(20, 180, 16, 40)
+
+	assert.Same(t, near, enemysim.NearestTarget(obj, []*resolv.Object{far, near}))
+	assert.Nil(t, enemysim.NearestTarget(obj, nil))
+}
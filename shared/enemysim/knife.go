@@ -0,0 +1,82 @@
+package enemysim
+
+import (
+	"math"
+
+	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/tags"
+	"github.com/solarlune/resolv"
+)
+
+// knifeMargin is how far past the level's edges a knife may fly before it
+// is discarded.
+const knifeMargin = 100
+
+// Knife is a thrown knife flying in a straight line.
+type Knife struct {
+	Object         *resolv.Object
+	SpeedX, SpeedY float64
+	Damage         int
+}
+
+// NewKnife returns a knife leaving the center of thrower, aimed at
+// (targetX, targetY). Aim is clamped to cfg.Knife.MaxDownwardAngle so
+// knives thrown down at a target don't stick in the floor first. The
+// collision box is not added to any space.
+func NewKnife(thrower *resolv.Object, targetX, targetY float64) *Knife {
+	startX := thrower.X + thrower.W/2
+	startY := thrower.Y + thrower.H/2
+
+	obj := resolv.NewObject(
+		startX-cfg.Knife.Width/2,
+		startY-cfg.Knife.Height/2,
+		cfg.Knife.Width,
+		cfg.Knife.Height,
+		tags.ResolvKnife,
+	)
+
+	dx := targetX - startX
+	dy := targetY - startY
+	if dy > 0 && cfg.Knife.MaxDownwardAngle > 0 {
+		if math.Atan2(dy, math.Abs(dx)) > cfg.Knife.MaxDownwardAngle {
+			dy = math.Abs(dx) * math.Tan(cfg.Knife.MaxDownwardAngle)
+		}
+	}
+	if length := math.Hypot(dx, dy); length > 0 {
+		dx /= length
+		dy /= length
+	}
+
+	k := &Knife{
+		Object: obj,
+		SpeedX: cfg.Knife.Speed * dx,
+		SpeedY: cfg.Knife.Speed * dy,
+		Damage: cfg.Knife.Damage,
+	}
+	obj.Data = k
+	return k
+}
+
+// Step moves the knife by one 60 Hz step. It returns false once the knife
+// has hit a wall or left a mapW x mapH level, and should be removed.
+func (k *Knife) Step(mapW, mapH float64) bool {
+	obj := k.Object
+	obj.X += k.SpeedX
+	obj.Y += k.SpeedY
+	obj.Update()
+
+	if obj.X < -knifeMargin || obj.X > mapW+knifeMargin ||
+		obj.Y < -knifeMargin || obj.Y > mapH+knifeMargin {
+		return false
+	}
+	return obj.Check(0, 0, tags.ResolvSolid) == nil
+}
+
+// KnockbackX returns the horizontal knockback for whoever the knife hits,
+// in the direction it was flying.
+func (k *Knife) KnockbackX() float64 {
+	if k.SpeedX < 0 {
+		return -cfg.Knife.KnockbackForce
+	}
+	return cfg.Knife.KnockbackForce
+}
@@ -0,0 +1,21 @@
+package hazards
+
+import (
+	"github.com/automoto/doomerang-mp/shared/leveldata"
+	"github.com/automoto/doomerang-mp/tags"
+	"github.com/solarlune/resolv"
+)
+
+// AddDeadZones adds a level's dead zones to space.
+func AddDeadZones(space *resolv.Space, rects []leveldata.Rect) {
+	for _, r := range rects {
+		obj := resolv.NewObject(r.X, r.Y, r.W, r.H, tags.ResolvDeadZone)
+		obj.SetShape(resolv.NewRectangle(0, 0, r.W, r.H))
+		space.Add(obj)
+	}
+}
+
+// InDeadZone reports whether obj is touching a dead zone.
+func InDeadZone(obj *resolv.Object) bool {
+	return obj.Check(0, 0, tags.ResolvDeadZone) != nil
+}
@@ -0,0 +1,169 @@
+// Package hazards holds the level obstacles that hurt players — fire and
+// dead zones — shared by offline play and the server so both size and
+// apply them the same way.
+package hazards
+
+import (
+	"github.com/automoto/doomerang-mp/assets/animations"
+	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/leveldata"
+	"github.com/automoto/doomerang-mp/tags"
+	"github.com/solarlune/resolv"
+)
+
+// Fire is a fire obstacle's hitbox. Pulsing fire grows and shrinks with
+// its sprite's animation frame and is harmless between bursts.
+type Fire struct {
+	Spawn          leveldata.FireSpawn
+	Object         *resolv.Object
+	Damage         int
+	KnockbackForce float64
+	Active         bool // Currently dangerous
+
+	spriteCenterX, spriteCenterY float64
+	baseW, baseH                 float64               // Full-size hitbox
+	phases                       []cfg.FireHitboxPhase // nil = static hitbox
+	anim                         *animations.Animation
+}
+
+// FireConfig returns the configuration for fireType, falling back to
+// continuous fire for unknown types.
+func FireConfig(fireType string) cfg.FireTypeConfig {
+	if fireCfg, ok := cfg.Fire.Types[fireType]; ok {
+		return fireCfg
+	}
+	return cfg.Fire.Types["fire_continuous"]
+}
+
+// NewFire returns an active, full-size fire for spawn. The hitbox is not
+// added to any space; its Data points back at the Fire.
+func NewFire(spawn leveldata.FireSpawn) *Fire {
+	fireCfg := FireConfig(spawn.FireType)
+
+	hitboxScale := fireCfg.HitboxScale
+	if hitboxScale == 0 {
+		hitboxScale = 1.0
+	}
+	// Sprites face right; vertical fire swaps width and height
+	w := float64(fireCfg.FrameWidth) * hitboxScale
+	h := float64(fireCfg.FrameHeight) * hitboxScale
+	if spawn.Direction == "up" || spawn.Direction == "down" {
+		w, h = h, w
+	}
+
+	cx, cy := FireSpriteCenter(spawn.X, spawn.Y, float64(fireCfg.FrameWidth), spawn.Direction)
+	obj := resolv.NewObject(cx-w/2, cy-h/2, w, h, tags.ResolvFire)
+	obj.SetShape(resolv.NewRectangle(0, 0, w, h))
+
+	def := cfg.CharacterAnimations["obstacle"][fireCfg.State]
+	f := &Fire{
+		Spawn:          spawn,
+		Object:         obj,
+		Damage:         fireCfg.Damage,
+		KnockbackForce: fireCfg.KnockbackForce,
+		Active:         true,
+		spriteCenterX:  cx,
+		spriteCenterY:  cy,
+		baseW:          w,
+		baseH:          h,
+		phases:         fireCfg.HitboxPhases,
+		anim:           animations.NewAnimation(def.First, def.Last, def.Step, def.Speed),
+	}
+	obj.Data = f
+	return f
+}
+
+// FireSpriteCenter calculates the sprite center position from origin, direction, and frame width.
+// This is the single source of truth for sprite center calculation.
+func FireSpriteCenter(originX, originY, frameWidth float64, direction string) (x, y float64) {
+	hw := frameWidth / 2
+	switch direction {
+	case "left":
+		return originX - hw, originY
+	case "up":
+		return originX, originY - hw
+	case "down":
+		return originX, originY + hw
+	default: // "right"
+		return originX + hw, originY
+	}
+}
+
+// SpriteCenter returns the point the fire's sprite is drawn around.
+func (f *Fire) SpriteCenter() (x, y float64) {
+	return f.spriteCenterX, f.spriteCenterY
+}
+
+// Step advances the fire's own copy of its sprite animation by one 60 Hz
+// step and sizes the hitbox for the new frame. The server has no sprite
+// to follow, so this keeps it in time with what clients draw.
+func (f *Fire) Step() {
+	f.anim.Update()
+	f.SetFrame(f.anim.Frame())
+}
+
+// Frame returns the animation frame of the last Step.
+func (f *Fire) Frame() int {
+	return f.anim.Frame()
+}
+
+// SetFrame sizes the hitbox for an animation frame, centered on the
+// sprite. Fire with no hitbox phases keeps its full size.
+func (f *Fire) SetFrame(frame int) {
+	if f.phases == nil {
+		return
+	}
+	scale := FireHitboxScale(frame, f.phases)
+	if scale == 0 {
+		f.Active = false
+		return
+	}
+	f.Active = true
+
+	w, h := f.baseW*scale, f.baseH*scale
+	f.Object.X = f.spriteCenterX - w/2
+	f.Object.Y = f.spriteCenterY - h/2
+	f.Object.W = w
+	f.Object.H = h
+	f.Object.Update()
+}
+
+// FireHitboxScale returns the hitbox scale (0.0-1.0) for the given animation frame
+// Returns 0 if the frame is not in any phase (hitbox disabled)
+func FireHitboxScale(frame int, phases []cfg.FireHitboxPhase) float64 {
+	for _, phase := range phases {
+		if frame >= phase.StartFrame && frame <= phase.EndFrame {
+			// Linear interpolation between start and end scale
+			frameRange := float64(phase.EndFrame - phase.StartFrame)
+			if frameRange == 0 {
+				return phase.StartScale
+			}
+			progress := float64(frame-phase.StartFrame) / frameRange
+			return phase.StartScale + (phase.EndScale-phase.StartScale)*progress
+		}
+	}
+	return 0 // Frame not in any phase = no hitbox
+}
+
+// TouchingFire returns the first active fire overlapping obj, or nil.
+func TouchingFire(obj *resolv.Object) *Fire {
+	check := obj.Check(0, 0, tags.ResolvFire)
+	if check == nil {
+		return nil
+	}
+	for _, o := range check.ObjectsByTags(tags.ResolvFire) {
+		if f, ok := o.Data.(*Fire); ok && f.Active {
+			return f
+		}
+	}
+	return nil
+}
+
+// KnockbackX returns the horizontal knockback for a player touching the
+// fire, pushing them away from its center.
+func (f *Fire) KnockbackX(player *resolv.Object) float64 {
+	if player.X+player.W/2 < f.Object.X+f.Object.W/2 {
+		return -f.KnockbackForce
+	}
+	return f.KnockbackForce
+}
@@ -0,0 +1,96 @@
+package hazards_test
+
+import (
+	"testing"
+
+	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/hazards"
+	"github.com/automoto/doomerang-mp/shared/leveldata"
+	"github.com/solarlune/resolv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFireHitboxScale(t *testing.T) {
+	phases := []cfg.FireHitboxPhase{
+		{StartFrame: 0, EndFrame: 10, StartScale: 0.5, EndScale: 1.0},
+		{StartFrame: 11, EndFrame: 11, StartScale: 0.25, EndScale: 0.25},
+	}
+	tests := []struct {
+		name  string
+		frame int
+		want  float64
+	}{
+		{name: "phase_start", frame: 0, want: 0.5},
+		{name: "interpolates", frame: 5, want: 0.75},
+		{name: "phase_end", frame: 10, want: 1.0},
+		{name: "single_frame_phase", frame: 11, want: 0.25},
+		{name: "outside_phases", frame: 12, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, hazards.FireHitboxScale(tt.frame, phases), 1e-9)
+		})
+	}
+}
+
+func TestFire_Step_follows_hitbox_phases(t *testing.T) {
+	tests := []struct {
+		fireType     string
+		wantInactive bool
+	}{
+		{fireType: "fire_pulsing", wantInactive: true},
+		{fireType: "fire_continuous", wantInactive: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fireType, func(t *testing.T) {
+			f := hazards.NewFire(leveldata.FireSpawn{X: 100, Y: 100, FireType: tt.fireType, Direction: "right"})
+			fullW := f.Object.W
+
+			def := cfg.CharacterAnimations["obstacle"][hazards.FireConfig(tt.fireType).State]
+			cycle := (def.Last - def.First + 1) * (int(def.Speed) + 1)
+			sawInactive := false
+			for range cycle {
+				f.Step()
+				if !f.Active {
+					sawInactive = true
+					continue
+				}
+				assert.LessOrEqual(t, f.Object.W, fullW)
+				cx, _ := f.SpriteCenter()
+				assert.InDelta(t, cx, f.Object.X+f.Object.W/2, 1e-9, "hitbox stays centered")
+			}
+			assert.Equal(t, tt.wantInactive, sawInactive)
+		})
+	}
+}
+
+func TestTouchingFire(t *testing.T) {
+	space := resolv.NewSpace(320, 240, 16, 16)
+	f := hazards.NewFire(leveldata.FireSpawn{X: 100, Y: 100, FireType: "fire_continuous", Direction: "right"})
+	space.Add(f.Object)
+
+	left := resolv.NewObject(f.Object.X-8, f.Object.Y, 16, 16)
+	right := resolv.NewObject(f.Object.Right()-8, f.Object.Y, 16, 16)
+	away := resolv.NewObject(280, 200, 16, 16)
+	space.Add(left, right, away)
+
+	assert.Same(t, f, hazards.TouchingFire(left))
+	assert.Equal(t, -f.KnockbackForce, f.KnockbackX(left))
+	assert.Equal(t, f.KnockbackForce, f.KnockbackX(right))
+	assert.Nil(t, hazards.TouchingFire(away))
+
+	f.Active = false
+	assert.Nil(t, hazards.TouchingFire(left))
+}
+
+func TestInDeadZone(t *testing.T) {
+	space := resolv.NewSpace(320, 240, 16, 16)
+	hazards.AddDeadZones(space, []leveldata.Rect{{X: 0, Y: 224, W: 320, H: 16}})
+
+	in := resolv.NewObject(100, 220, 16, 16)
+	above := resolv.NewObject(100, 100, 16, 16)
+	space.Add(in, above)
+
+	assert.True(t, hazards.InDeadZone(in))
+	assert.False(t, hazards.InDeadZone(above))
+}
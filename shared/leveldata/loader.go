@@ -10,8 +10,8 @@ import (
 	"github.com/lafriks/go-tiled"
 )
 
-// LoadCollisionData parses a TMX file and returns collision data (solid tiles
-// and player spawn points). It takes an fs.FS so callers can pass embed.FS
+// LoadCollisionData parses a TMX file and returns collision data (solid tiles,
+// hazards, one-way platforms, player and enemy spawn points and patrol paths). It takes an fs.FS so callers can pass embed.FS
 // (client) or os.DirFS (server).
 func LoadCollisionData(fsys fs.FS, tmxPath string) (*CollisionData, error) {
 	levelMap, err := tiled.LoadFile(tmxPath, tiled.WithFileSystem(fsys))
@@ -20,8 +20,9 @@ func LoadCollisionData(fsys fs.FS, tmxPath string) (*CollisionData, error) {
 	}
 
 	data := &CollisionData{
-		MapWidth:  levelMap.Width * levelMap.TileWidth,
-		MapHeight: levelMap.Height * levelMap.TileHeight,
+		MapWidth:    levelMap.Width * levelMap.TileWidth,
+		MapHeight:   levelMap.Height * levelMap.TileHeight,
+		PatrolPaths: make(map[string][]Point),
 	}
 
 	// Parse solid tiles from wg-tiles layer
@@ -55,18 +56,68 @@ func LoadCollisionData(fsys fs.FS, tmxPath string) (*CollisionData, error) {
 		break
 	}
 
-	// Parse player spawn points from PlayerSpawn object group
+	// Parse object groups: spawns, hazards and one-way platforms
 	for _, og := range levelMap.ObjectGroups {
-		if og.Name != "PlayerSpawn" {
-			continue
-		}
-		for _, o := range og.Objects {
-			spawnIndex := o.Properties.GetInt("spawnIndex")
-			data.SpawnPoints = append(data.SpawnPoints, SpawnPoint{
-				X:     o.X,
-				Y:     o.Y,
-				Index: spawnIndex,
-			})
+		switch og.Name {
+		case "PlayerSpawn":
+			for _, o := range og.Objects {
+				spawnIndex := o.Properties.GetInt("spawnIndex")
+				data.SpawnPoints = append(data.SpawnPoints, SpawnPoint{
+					X:     o.X,
+					Y:     o.Y,
+					Index: spawnIndex,
+				})
+			}
+		case "EnemySpawn":
+			for _, o := range og.Objects {
+				data.EnemySpawns = append(data.EnemySpawns, EnemySpawn{
+					X:          o.X,
+					Y:          o.Y,
+					EnemyType:  o.Properties.GetString("enemyType"),
+					PatrolPath: o.Properties.GetString("pathName"),
+				})
+			}
+		case "PatrolPaths":
+			// Polyline points are relative to the object
+			for _, o := range og.Objects {
+				if len(o.PolyLines) == 0 || o.PolyLines[0].Points == nil || len(*o.PolyLines[0].Points) < 2 {
+					continue
+				}
+				points := make([]Point, len(*o.PolyLines[0].Points))
+				for i, p := range *o.PolyLines[0].Points {
+					points[i] = Point{X: o.X + p.X, Y: o.Y + p.Y}
+				}
+				data.PatrolPaths[o.Name] = points
+			}
+		case "DeadZones":
+			for _, o := range og.Objects {
+				data.DeadZones = append(data.DeadZones, Rect{X: o.X, Y: o.Y, W: o.Width, H: o.Height})
+			}
+		case "Platforms":
+			for _, o := range og.Objects {
+				data.Platforms = append(data.Platforms, Rect{X: o.X, Y: o.Y, W: o.Width, H: o.Height})
+			}
+		case "Obstacles":
+			for _, o := range og.Objects {
+				// TMX files set the fire type with the type= attribute
+				fireType := o.Class
+				if fireType == "" {
+					fireType = o.Type //nolint:staticcheck // TMX uses type= attribute
+				}
+				if fireType != "fire_pulsing" && fireType != "fire_continuous" {
+					continue
+				}
+				direction := o.Properties.GetString("Direction")
+				if direction == "" {
+					direction = "right"
+				}
+				data.Fires = append(data.Fires, FireSpawn{
+					X:         o.X,
+					Y:         o.Y,
+					FireType:  fireType,
+					Direction: direction,
+				})
+			}
 		}
 	}
 
@@ -0,0 +1,40 @@
+package leveldata_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/automoto/doomerang-mp/shared/leveldata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadCollisionData_parses_hazards(t *testing.T) {
+	data, err := leveldata.LoadCollisionData(os.DirFS("../../assets"), "levels/level1.tmx")
+	require.NoError(t, err)
+
+	assert.Len(t, data.SpawnPoints, 4)
+	assert.Len(t, data.DeadZones, 6)
+	require.Len(t, data.Fires, 6)
+	for _, f := range data.Fires {
+		assert.Contains(t, []string{"fire_pulsing", "fire_continuous"}, f.FireType)
+		assert.Contains(t, []string{"up", "down", "left", "right"}, f.Direction)
+	}
+	for _, dz := range data.DeadZones {
+		assert.Positive(t, dz.W)
+		assert.Positive(t, dz.H)
+	}
+}
+
+func TestLoadCollisionData_parses_enemies(t *testing.T) {
+	data, err := leveldata.LoadCollisionData(os.DirFS("testdata"), "enemies.tmx")
+	require.NoError(t, err)
+
+	assert.Equal(t, []leveldata.EnemySpawn{
+		{X: 120, Y: 376, EnemyType: "KnifeThrower", PatrolPath: "ledge"},
+		{X: 400, Y: 376},
+	}, data.EnemySpawns)
+	assert.Equal(t, map[string][]leveldata.Point{
+		"ledge": {{X: 96, Y: 400}, {X: 160, Y: 400}},
+	}, data.PatrolPaths)
+}
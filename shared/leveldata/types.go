@@ -5,7 +5,12 @@ package leveldata
 // CollisionData holds all collision-relevant data parsed from a TMX level file.
 type CollisionData struct {
 	SolidRects  []SolidRect
+	Platforms   []Rect // One-way platforms
+	DeadZones   []Rect
+	Fires       []FireSpawn
 	SpawnPoints []SpawnPoint
+	EnemySpawns []EnemySpawn
+	PatrolPaths map[string][]Point // Keyed by polyline name
 	MapWidth    int
 	MapHeight   int
 }
@@ -16,8 +21,33 @@ type SolidRect struct {
 	SlopeType  string // "", "45_up_right", "45_up_left"
 }
 
+// Rect is an axis-aligned area from an object layer.
+type Rect struct {
+	X, Y, W, H float64
+}
+
+// FireSpawn represents a fire obstacle. X, Y is the base edge the fire
+// emanates from.
+type FireSpawn struct {
+	X, Y      float64
+	FireType  string // "fire_pulsing" or "fire_continuous"
+	Direction string // "up", "down", "left", "right"
+}
+
 // SpawnPoint represents a player spawn location.
 type SpawnPoint struct {
 	X, Y  float64
 	Index int
 }
+
+// EnemySpawn represents an AI enemy's starting position.
+type EnemySpawn struct {
+	X, Y       float64
+	EnemyType  string // Key into config.Enemy.Types; "" means "Guard"
+	PatrolPath string // Name of a PatrolPaths polyline, or "" for the default patrol
+}
+
+// Point is a position in world coordinates.
+type Point struct {
+	X, Y float64
+}
@@ -1,5 +1,20 @@
 package messages
 
+// GameEvent carries one server event in a room's ordered event stream.
+// Seq counts up from 1 in each room, so a client that sees it skip knows
+// it missed events. Payload is the event serialized as its own message.
+type GameEvent struct {
+	Seq     uint32
+	Payload []byte
+}
+
+// EventResyncRequest is sent by a client that missed events, asking for
+// every event after After. The server replays them from its event log, or
+// sends a SyncGameState once they have aged out of it.
+type EventResyncRequest struct {
+	After uint32
+}
+
 // HitEvent is broadcast when an attack connects
 type HitEvent struct {
 	AttackerID uint // NetworkId of attacker
@@ -11,8 +26,12 @@ type HitEvent struct {
 
 // DeathEvent is broadcast when a player/enemy dies
 type DeathEvent struct {
-	VictimID uint // NetworkId of victim
-	KillerID uint // NetworkId of killer (0 if environmental)
+	VictimID uint   // NetworkId of victim
+	KillerID uint   // NetworkId of killer (0 if environmental)
+	Cause    string // "punch", "kick", "jump_kick", "boomerang", "fire", "dead_zone", "enemy", "knife"
+	// RespawnAt is when the victim respawns, server clock UnixNano; 0 if
+	// it is out of lives or not a player.
+	RespawnAt int64
 }
 
 // SpawnEvent is broadcast when a new entity spawns
@@ -40,6 +59,7 @@ type BoomerangThrowEvent struct {
 	DirectionX     float64 // Normalized direction
 	DirectionY     float64
 	ChargeLevel    float64 // 0.0 to 1.0
+	Sequence       uint32  // Owner's input that released the throw
 }
 
 // BoomerangCatchEvent is sent when a boomerang returns to owner
@@ -58,10 +78,13 @@ type BoomerangHitEvent struct {
 	KnockbackY        float64
 }
 
-// MeleeAttackEvent is broadcast when a player initiates a melee attack (for SFX).
+// MeleeAttackEvent is broadcast when a player initiates a melee attack, for
+// SFX and to confirm the attacker's predicted attack.
 type MeleeAttackEvent struct {
 	AttackerNetworkID uint
-	IsPunch           bool // true = punch, false = kick
+	IsPunch           bool   // true = punch, false = kick
+	IsJumpKick        bool   // aerial jump kick
+	Sequence          uint32 // Attacker's input that started the attack
 }
 
 // MeleeHitEvent is broadcast when a melee attack connects
@@ -74,6 +97,16 @@ type MeleeHitEvent struct {
 	KnockbackY        float64
 }
 
+// HazardHitEvent is broadcast when a level hazard or an enemy's knife
+// damages a player
+type HazardHitEvent struct {
+	TargetNetworkID uint
+	Hazard          string // "fire", "knife"
+	Damage          int
+	KnockbackX      float64
+	KnockbackY      float64
+}
+
 // RespawnEvent is broadcast when a player respawns after death
 type RespawnEvent struct {
 	PlayerNetworkID uint
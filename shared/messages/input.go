@@ -1,20 +1,143 @@
 package messages
 
-import "github.com/automoto/doomerang-mp/shared/netconfig"
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
 
-// PlayerInput is sent from client to server each frame with the player's input state.
-// Used for server-side movement processing and client-side prediction reconciliation.
+	"github.com/automoto/doomerang-mp/shared/netconfig"
+)
+
+// InputVersion is the wire format version of InputFrame. Bump it whenever
+// the layout written by EncodeInput changes.
+const InputVersion = 1
+
+// ActionMask is a set of pressed actions, one bit per netconfig.ActionID.
+type ActionMask uint16
+
+// Has reports whether action is pressed.
+func (m ActionMask) Has(action netconfig.ActionID) bool {
+	return m&(1<<action) != 0
+}
+
+// Set marks action as pressed or released.
+func (m *ActionMask) Set(action netconfig.ActionID, pressed bool) {
+	if pressed {
+		*m |= 1 << action
+	} else {
+		*m &^= 1 << action
+	}
+}
+
+// PlayerInput is one frame of the player's input state. The client sends it
+// to the server every frame, encoded as an InputFrame. Used for server-side
+// movement processing and client-side prediction reconciliation.
 type PlayerInput struct {
-	Sequence  uint32                      // Incrementing ID for reconciliation
-	Actions   map[netconfig.ActionID]bool // Which actions are currently pressed
-	Direction int                         // -1 left, 0 none, 1 right
-	Timestamp int64                       // Client timestamp (Unix ms)
+	Sequence  uint32     // Incrementing ID for reconciliation
+	Actions   ActionMask // Which actions are currently pressed
+	Direction int        // -1 left, 0 none, 1 right
+
+	// Redundant holds earlier inputs the server has not acknowledged yet,
+	// oldest first. Every packet resends them so one late packet never
+	// costs the server a frame.
+	Redundant []PlayerInput
 }
 
-// NewPlayerInput creates a PlayerInput with initialized map
+// NewPlayerInput creates an empty PlayerInput for frame seq.
 func NewPlayerInput(seq uint32) PlayerInput {
-	return PlayerInput{
-		Sequence: seq,
-		Actions:  make(map[netconfig.ActionID]bool),
+	return PlayerInput{Sequence: seq}
+}
+
+// InputFrame is the wire form of a PlayerInput and its redundancy window.
+//
+// Layout (little endian):
+//
+//	[0]    InputVersion
+//	[1]    frame count n, newest frame first
+//	[2:6]  sequence of the newest frame
+//	n × 3 bytes:
+//	  [0]   how many sequences older than the newest this frame is
+//	  [1:3] ActionMask, with Direction folded into MoveLeft/MoveRight
+type InputFrame struct {
+	Data []byte
+}
+
+const (
+	inputHeaderSize = 6
+	inputFrameSize  = 3
+	maxInputFrames  = 255
+)
+
+var errInputTooShort = errors.New("input frame too short")
+
+// EncodeInput packs input and as much of its redundancy window as fits:
+// frames more than 255 sequences older than input are dropped.
+func EncodeInput(input PlayerInput) InputFrame {
+	n := 1 + len(input.Redundant)
+	data := make([]byte, inputHeaderSize, inputHeaderSize+min(n, maxInputFrames)*inputFrameSize)
+	data[0] = InputVersion
+	binary.LittleEndian.PutUint32(data[2:], input.Sequence)
+
+	data = appendInputFrame(data, input, 0)
+	count := 1
+	for i := len(input.Redundant) - 1; i >= 0 && count < maxInputFrames; i-- {
+		earlier := input.Redundant[i]
+		back := input.Sequence - earlier.Sequence
+		if earlier.Sequence >= input.Sequence || back > 255 {
+			continue
+		}
+		data = appendInputFrame(data, earlier, byte(back))
+		count++
+	}
+	data[1] = byte(count)
+	return InputFrame{Data: data}
+}
+
+func appendInputFrame(data []byte, input PlayerInput, back byte) []byte {
+	actions := input.Actions
+	actions.Set(netconfig.ActionMoveLeft, input.Direction < 0)
+	actions.Set(netconfig.ActionMoveRight, input.Direction > 0)
+	return binary.LittleEndian.AppendUint16(append(data, back), uint16(actions))
+}
+
+// DecodeInput unpacks an InputFrame written by EncodeInput. Redundant
+// frames come back oldest first, as they were sent.
+func DecodeInput(frame InputFrame) (PlayerInput, error) {
+	data := frame.Data
+	if len(data) < inputHeaderSize {
+		return PlayerInput{}, errInputTooShort
+	}
+	if data[0] != InputVersion {
+		return PlayerInput{}, fmt.Errorf("unsupported input version %d", data[0])
+	}
+	n := int(data[1])
+	if n == 0 || len(data) < inputHeaderSize+n*inputFrameSize {
+		return PlayerInput{}, errInputTooShort
+	}
+	newest := binary.LittleEndian.Uint32(data[2:])
+	data = data[inputHeaderSize:]
+
+	input := decodeInputFrame(data, newest)
+	if n > 1 {
+		input.Redundant = make([]PlayerInput, n-1)
+		for i := 1; i < n; i++ {
+			input.Redundant[n-1-i] = decodeInputFrame(data[i*inputFrameSize:], newest)
+		}
+	}
+	return input, nil
+}
+
+func decodeInputFrame(data []byte, newest uint32) PlayerInput {
+	actions := ActionMask(binary.LittleEndian.Uint16(data[1:]))
+	input := PlayerInput{Sequence: newest - uint32(data[0])}
+	switch {
+	case actions.Has(netconfig.ActionMoveLeft) && !actions.Has(netconfig.ActionMoveRight):
+		input.Direction = -1
+	case actions.Has(netconfig.ActionMoveRight) && !actions.Has(netconfig.ActionMoveLeft):
+		input.Direction = 1
 	}
+	actions.Set(netconfig.ActionMoveLeft, false)
+	actions.Set(netconfig.ActionMoveRight, false)
+	input.Actions = actions
+	return input
 }
@@ -0,0 +1,152 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/automoto/doomerang-mp/shared/netconfig"
+	"github.com/leap-fish/necs/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeInput_round_trip(t *testing.T) {
+	var jumpAttack ActionMask
+	jumpAttack.Set(netconfig.ActionJump, true)
+	jumpAttack.Set(netconfig.ActionAttack, true)
+
+	tests := []struct {
+		name  string
+		input PlayerInput
+	}{
+		{"idle", PlayerInput{Sequence: 1}},
+		{"moving left", PlayerInput{Sequence: 7, Direction: -1}},
+		{"moving right with actions", PlayerInput{Sequence: 1 << 30, Direction: 1, Actions: jumpAttack}},
+		{"redundancy window", PlayerInput{
+			Sequence:  42,
+			Direction: 1,
+			Redundant: []PlayerInput{
+				{Sequence: 38, Direction: -1},
+				{Sequence: 40, Actions: jumpAttack},
+				{Sequence: 41, Direction: 1, Actions: jumpAttack},
+			},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeInput(EncodeInput(tt.input))
+			require.NoError(t, err)
+			assert.Equal(t, tt.input, got)
+		})
+	}
+}
+
+func TestEncodeInput_drops_frames_out_of_window(t *testing.T) {
+	input := PlayerInput{
+		Sequence:  1000,
+		Redundant: []PlayerInput{{Sequence: 500}, {Sequence: 999}, {Sequence: 1000}},
+	}
+	got, err := DecodeInput(EncodeInput(input))
+	require.NoError(t, err)
+	assert.Equal(t, []PlayerInput{{Sequence: 999}}, got.Redundant)
+}
+
+func TestDecodeInput_rejects_bad_frames(t *testing.T) {
+	valid := EncodeInput(PlayerInput{Sequence: 3, Redundant: []PlayerInput{{Sequence: 2}}}).Data
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"short header", valid[:4]},
+		{"truncated frames", valid[:len(valid)-1]},
+		{"unknown version", append([]byte{InputVersion + 1}, valid[1:]...)},
+		{"no frames", []byte{InputVersion, 0, 3, 0, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeInput(InputFrame{Data: tt.data})
+			assert.Error(t, err)
+		})
+	}
+}
+
+// mapPlayerInput is the PlayerInput wire format InputFrame replaced: the
+// struct itself, msgpack-encoded by the router.
+type mapPlayerInput struct {
+	Sequence  uint32
+	Actions   map[netconfig.ActionID]bool
+	Direction int
+	Timestamp int64
+	Redundant []mapPlayerInput
+}
+
+func newMapPlayerInput(seq uint32) mapPlayerInput {
+	return mapPlayerInput{
+		Sequence:  seq,
+		Direction: 1,
+		Timestamp: 1_700_000_000_000,
+		Actions: map[netconfig.ActionID]bool{
+			netconfig.ActionJump:      true,
+			netconfig.ActionAttack:    false,
+			netconfig.ActionBoomerang: false,
+			netconfig.ActionCrouch:    false,
+			netconfig.ActionMoveUp:    false,
+		},
+	}
+}
+
+func benchInput(seq uint32) PlayerInput {
+	input := PlayerInput{Sequence: seq, Direction: 1}
+	input.Actions.Set(netconfig.ActionJump, true)
+	return input
+}
+
+// BenchmarkInputEncoding compares a frame with four redundant inputs, as
+// systems.NewNetworkInputSystem sends it, in both encodings: the client's
+// serialize cost, the server's receive cost and bytes on the wire.
+func BenchmarkInputEncoding(b *testing.B) {
+	legacy := newMapPlayerInput(100)
+	frame := benchInput(100)
+	for seq := uint32(96); seq < 100; seq++ {
+		legacy.Redundant = append(legacy.Redundant, newMapPlayerInput(seq))
+		frame.Redundant = append(frame.Redundant, benchInput(seq))
+	}
+
+	router.On(func(*router.NetworkClient, mapPlayerInput) {})
+	router.On(func(_ *router.NetworkClient, f InputFrame) { _, _ = DecodeInput(f) })
+
+	encodings := []struct {
+		name string
+		msg  func() any
+	}{
+		{"map", func() any { return legacy }},
+		{"binary", func() any { return EncodeInput(frame) }},
+	}
+	for _, enc := range encodings {
+		b.Run(enc.name+"/send", func(b *testing.B) {
+			b.ReportAllocs()
+			var size int
+			for b.Loop() {
+				data, err := router.Serialize(enc.msg())
+				if err != nil {
+					b.Fatal(err)
+				}
+				size = len(data)
+			}
+			b.ReportMetric(float64(size), "wire-bytes")
+		})
+		b.Run(enc.name+"/receive", func(b *testing.B) {
+			data, err := router.Serialize(enc.msg())
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			for b.Loop() {
+				if err := router.ProcessMessage(nil, data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ type JoinRequest struct {
 	PlayerName     string
 	ReconnectToken string
 	Level          string // Requested level name (empty = server default)
+	Spectate       bool   // Watch only: no body or slot, never promoted
 
 	// GgscaleSessionToken is the player's ggscale access token (the JWT
 	// from /v1/auth/anonymous or any other Authenticator). The
@@ -24,11 +25,20 @@ type JoinAccepted struct {
 	ReconnectToken string
 	ServerName     string
 	TickRate       int
+	SnapshotRate   int      // Snapshots per second; remote players interpolate over one interval
 	Level          string   // Active level name
 	Levels         []string // All available level names
+	RoomCode       string   // Code other players use to join the same room
+	Spectator      bool     // No body or slot yet; see MatchEvent "spectator_promoted"
+	EventSeq       uint32   // Last GameEvent sent before the join; the client's stream starts after it
 }
 
-// JoinRejected is sent by the server when a client's join request is rejected.
+// JoinRejected is sent by the server when a client's join request is
+// rejected. The server closes the connection after sending it.
 type JoinRejected struct {
 	Reason string
 }
+
+// RejectReconnectExpired is the JoinRejected reason for a reconnect token
+// whose slot is no longer held.
+const RejectReconnectExpired = "reconnect window expired"
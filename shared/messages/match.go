@@ -4,13 +4,13 @@ import "github.com/automoto/doomerang-mp/shared/netcomponents"
 
 // MatchEvent is broadcast for match flow transitions
 type MatchEvent struct {
-	Type        string // "countdown_start", "match_start", "match_end", "round_end", "player_eliminated"
+	Type        string // "countdown_start", "match_start", "match_end", "match_abort", "round_end", "player_eliminated", "spectator_promoted"
 	Message     string
 	WinnerID    uint32
 	Reason      string
 	Scores      map[uint32]int
 	RoundNumber int    // Which round (for "round_end", "player_eliminated")
-	PlayerID    uint32 // Eliminated player (for "player_eliminated")
+	PlayerID    uint32 // Eliminated or promoted player (for "player_eliminated", "spectator_promoted")
 }
 
 // ScoreEvent is broadcast when a player's score changes
@@ -20,6 +20,38 @@ type ScoreEvent struct {
 	Deaths   int
 }
 
+// PlayerStats is one player's record over a match, gathered by the server
+type PlayerStats struct {
+	KOs             int
+	Deaths          int
+	SelfKOs         int // Knocked out by a hazard with nobody to credit
+	DamageDealt     int
+	DamageTaken     int
+	PunchHits       int
+	KickHits        int
+	JumpKickHits    int
+	BoomerangThrows int
+	BoomerangHits   int     // Throws that hit at least one target
+	LongestStreak   int     // Most KOs without being knocked out
+	TimeAlive       float64 // Seconds alive while rounds were being played
+}
+
+// BoomerangAccuracy returns the fraction of boomerang throws that hit,
+// or 0 before the first throw.
+func (s PlayerStats) BoomerangAccuracy() float64 {
+	if s.BoomerangThrows == 0 {
+		return 0
+	}
+	return float64(s.BoomerangHits) / float64(s.BoomerangThrows)
+}
+
+// MatchStats is broadcast once a match ends, alongside the "match_end"
+// MatchEvent
+type MatchStats struct {
+	WinnerID uint32
+	Players  map[uint32]PlayerStats // NetworkId -> stats
+}
+
 // CreateRoomRequest is sent by a client to create a private or public room
 type CreateRoomRequest struct {
 	GameMode   string
@@ -41,7 +73,7 @@ type JoinRoomRequest struct {
 
 // LobbyAction represents an action taken in the lobby (picking slot, readying up, etc.)
 type LobbyAction struct {
-	Action string // "pick_slot", "ready", "unready", "change_mode", "change_time", "change_level", "add_bot", "remove_bot"
+	Action string // "pick_slot", "ready", "unready", "change_mode", "change_team", "auto_balance", "toggle_friendly_fire", "change_time", "change_level", "add_bot", "remove_bot"
 	Value  int    // Slot index, or value for the action
 	String string // For actions requiring string values
 }
@@ -50,6 +82,7 @@ type LobbyAction struct {
 type LobbyUpdate struct {
 	Slots        [4]LobbySlot
 	GameMode     string
+	FriendlyFire bool
 	MatchMinutes int
 	LevelIndex   int
 	HostID       uint32
@@ -64,7 +97,11 @@ type LobbySlot struct {
 	Name       string
 }
 
-// SyncGameState is sent periodically or on change to ensure clients have latest info
+// SyncGameState is sent in answer to an EventResyncRequest the event log
+// can no longer replay. It stands in for every event up to EventSeq.
 type SyncGameState struct {
-	State netcomponents.NetGameStateData
+	EventSeq uint32
+	State    netcomponents.NetGameStateData
+	Lobby    LobbyUpdate
+	Stats    *MatchStats // The finished match's stats while its results show, else nil
 }
@@ -0,0 +1,34 @@
+package messages
+
+import "time"
+
+// Ping is sent by the server to every client about once a second. The
+// client answers with a Pong echoing SentAt, and learns its own link
+// quality from RTT and Jitter, the server's estimates so far.
+type Ping struct {
+	SentAt int64         // Server clock, UnixNano
+	RTT    time.Duration // Smoothed round-trip time; 0 until measured
+	Jitter time.Duration // Mean deviation of the round-trip time
+}
+
+// Pong answers a Ping.
+type Pong struct {
+	SentAt int64 // The Ping's SentAt
+	// PlayoutDelay is how far behind the newest snapshot the client draws
+	// remote players; 0 until it has one. The server rewinds the client's
+	// hit checks by it.
+	PlayoutDelay time.Duration
+}
+
+// ClockSyncRequest asks the server for its clock. The client sends one on
+// joining and with every Pong, and estimates the offset between the two
+// clocks from the ClockSyncResponse.
+type ClockSyncRequest struct {
+	ClientTime int64 // Client clock when sent, UnixNano
+}
+
+// ClockSyncResponse answers a ClockSyncRequest.
+type ClockSyncResponse struct {
+	ClientTime int64 // The request's ClientTime
+	ServerTime int64 // Server clock when answered, UnixNano
+}
@@ -0,0 +1,16 @@
+package messages
+
+// ServerShutdownNotice warns a room's clients that the server is
+// draining, as an event. Play carries on until the match ends or the
+// server gives up waiting for it at ShutdownAt, a server UnixNano.
+type ServerShutdownNotice struct {
+	ShutdownAt int64
+}
+
+// ServerRedirect is sent to each client as a draining server stops,
+// telling it where to go next. The client closes the connection and
+// joins Address fresh, or re-queues through ggscale matchmaking when
+// Address is empty.
+type ServerRedirect struct {
+	Address string
+}
@@ -0,0 +1,135 @@
+package messages
+
+import (
+	"bytes"
+	"maps"
+	"slices"
+
+	"github.com/leap-fish/necs/esync"
+)
+
+// SnapshotState is the full synced state of a room at one snapshot: every
+// entity's serialized components, keyed by network ID. States are never
+// modified once built, so consecutive states share unchanged entities.
+type SnapshotState map[esync.NetworkId]esync.EntityState
+
+// Snapshot is sent by the server at the snapshot rate. It holds only what
+// changed since Baseline, the newest snapshot the client acknowledged, or
+// the whole state when Baseline is 0.
+type Snapshot struct {
+	Seq      uint32
+	Baseline uint32              // Seq this is a delta against; 0 = full snapshot
+	Entities esync.WorldSnapshot // Changed entities, with only their changed components
+	Removed  []esync.NetworkId   // Entities in the baseline that no longer exist
+}
+
+// SnapshotAck is sent by the client once it has rebuilt a Snapshot, making
+// that snapshot the baseline for the next delta.
+type SnapshotAck struct {
+	Seq uint32
+}
+
+// DiffSnapshot builds snapshot seq of state as a delta against base, the
+// state at snapshot baseline. A nil base produces a full snapshot.
+func DiffSnapshot(seq, baseline uint32, base, state SnapshotState) Snapshot {
+	if base == nil {
+		baseline = 0
+	}
+	snap := Snapshot{Seq: seq, Baseline: baseline}
+
+	for _, id := range slices.Sorted(maps.Keys(state)) {
+		changed := diffEntity(base[id], state[id])
+		if len(changed) > 0 {
+			snap.Entities = append(snap.Entities, esync.SerializedEntity{Id: id, State: changed})
+		}
+	}
+	for id := range base {
+		if _, ok := state[id]; !ok {
+			snap.Removed = append(snap.Removed, id)
+		}
+	}
+	slices.Sort(snap.Removed)
+	return snap
+}
+
+func diffEntity(base, current esync.EntityState) esync.EntityState {
+	if base == nil {
+		return current
+	}
+	var changed esync.EntityState
+	for component, data := range current {
+		if bytes.Equal(base[component], data) {
+			continue
+		}
+		if changed == nil {
+			changed = make(esync.EntityState)
+		}
+		changed[component] = data
+	}
+	return changed
+}
+
+// Apply rebuilds the full state snap describes on top of base, the state
+// at snap.Baseline (nil for a full snapshot).
+func (snap Snapshot) Apply(base SnapshotState) SnapshotState {
+	state := make(SnapshotState, len(base)+len(snap.Entities))
+	for id, entity := range base {
+		state[id] = entity
+	}
+	for _, id := range snap.Removed {
+		delete(state, id)
+	}
+	for _, ent := range snap.Entities {
+		prev, ok := state[ent.Id]
+		if !ok {
+			state[ent.Id] = ent.State
+			continue
+		}
+		merged := maps.Clone(prev)
+		maps.Copy(merged, ent.State)
+		state[ent.Id] = merged
+	}
+	return state
+}
+
+// World converts the state into the WorldSnapshot form applied by clients,
+// ordered by network ID.
+func (state SnapshotState) World() esync.WorldSnapshot {
+	world := make(esync.WorldSnapshot, 0, len(state))
+	for _, id := range slices.Sorted(maps.Keys(state)) {
+		world = append(world, esync.SerializedEntity{Id: id, State: state[id]})
+	}
+	return world
+}
+
+// SnapshotHistorySize is how many snapshots SnapshotHistory keeps. A
+// client whose last ack has aged out gets a full snapshot.
+const SnapshotHistorySize = 32
+
+// SnapshotHistory is a ring of the most recent snapshot states by Seq,
+// kept by the server as delta baselines and by the client to rebuild them.
+type SnapshotHistory struct {
+	seqs   [SnapshotHistorySize]uint32
+	states [SnapshotHistorySize]SnapshotState
+}
+
+// Put stores state as snapshot seq, evicting the oldest.
+func (h *SnapshotHistory) Put(seq uint32, state SnapshotState) {
+	i := seq % SnapshotHistorySize
+	h.seqs[i] = seq
+	h.states[i] = state
+}
+
+// Get returns the state of snapshot seq, or nil if it is not kept.
+func (h *SnapshotHistory) Get(seq uint32) SnapshotState {
+	i := seq % SnapshotHistorySize
+	if seq == 0 || h.seqs[i] != seq {
+		return nil
+	}
+	return h.states[i]
+}
+
+// Reset forgets every stored snapshot.
+func (h *SnapshotHistory) Reset() {
+	*h = SnapshotHistory{}
+}
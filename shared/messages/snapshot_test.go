@@ -0,0 +1,93 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/leap-fish/necs/esync"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Component IDs as registered by shared/protocol.
+const (
+	testPosition  esync.ComponentId = 10
+	testGameState esync.ComponentId = 15
+)
+
+func TestDiffSnapshot(t *testing.T) {
+	base := SnapshotState{
+		1: {testPosition: []byte{1}, testGameState: []byte{7}},
+		2: {testPosition: []byte{2}},
+		3: {testPosition: []byte{3}},
+	}
+	state := SnapshotState{
+		1: {testPosition: []byte{9}, testGameState: []byte{7}},
+		2: {testPosition: []byte{2}},
+		4: {testPosition: []byte{4}},
+	}
+
+	tests := []struct {
+		name         string
+		baseline     uint32
+		base         SnapshotState
+		wantBaseline uint32
+		wantEntities esync.WorldSnapshot
+		wantRemoved  []esync.NetworkId
+	}{
+		{
+			name:         "full without a baseline",
+			baseline:     5,
+			wantEntities: state.World(),
+		},
+		{
+			name:         "delta skips unchanged entities and components",
+			baseline:     5,
+			base:         base,
+			wantBaseline: 5,
+			wantEntities: esync.WorldSnapshot{
+				{Id: 1, State: esync.EntityState{testPosition: []byte{9}}},
+				{Id: 4, State: esync.EntityState{testPosition: []byte{4}}},
+			},
+			wantRemoved: []esync.NetworkId{3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snap := DiffSnapshot(6, tt.baseline, tt.base, state)
+			assert.Equal(t, uint32(6), snap.Seq)
+			assert.Equal(t, tt.wantBaseline, snap.Baseline)
+			assert.Equal(t, tt.wantEntities, snap.Entities)
+			assert.Equal(t, tt.wantRemoved, snap.Removed)
+			assert.Equal(t, state, snap.Apply(tt.base), "rebuilt state")
+		})
+	}
+}
+
+func TestSnapshot_Apply_leaves_base_untouched(t *testing.T) {
+	base := SnapshotState{1: {testPosition: []byte{1}, testGameState: []byte{7}}}
+	snap := Snapshot{
+		Seq:      2,
+		Baseline: 1,
+		Entities: esync.WorldSnapshot{{Id: 1, State: esync.EntityState{testPosition: []byte{2}}}},
+	}
+
+	state := snap.Apply(base)
+	assert.Equal(t, []byte{2}, state[1][testPosition])
+	assert.Equal(t, []byte{7}, state[1][testGameState])
+	assert.Equal(t, []byte{1}, base[1][testPosition])
+}
+
+func TestSnapshotHistory(t *testing.T) {
+	var h SnapshotHistory
+	for seq := uint32(1); seq <= SnapshotHistorySize+1; seq++ {
+		h.Put(seq, SnapshotState{esync.NetworkId(seq): nil})
+	}
+
+	assert.Nil(t, h.Get(0))
+	assert.Nil(t, h.Get(1), "evicted by the newest")
+	require.NotNil(t, h.Get(2))
+	assert.Contains(t, h.Get(SnapshotHistorySize+1), esync.NetworkId(SnapshotHistorySize+1))
+
+	h.Reset()
+	assert.Nil(t, h.Get(2))
+}
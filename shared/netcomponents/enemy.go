@@ -2,11 +2,15 @@ package netcomponents
 
 import "github.com/yohamta/donburi"
 
+// NetEnemyData is a server-driven AI enemy. X, Y is the top-left of its
+// collision box; State is the animation to draw, not the AI state.
 type NetEnemyData struct {
-	X, Y     float64
-	TypeName string // "Guard", "HeavyGuard", etc.
-	State    int
-	Health   int
+	X, Y       float64
+	VelX, VelY float64 // Client extrapolation between snapshots
+	TypeName   string  // "Guard", "HeavyGuard", etc.
+	State      int
+	Direction  int // -1 facing left, 1 facing right
+	Health     int
 }
 
 var NetEnemy = donburi.NewComponentType[NetEnemyData]()
@@ -14,10 +18,31 @@ var NetEnemy = donburi.NewComponentType[NetEnemyData]()
 // LerpNetEnemy interpolates between two enemy states
 func LerpNetEnemy(from, to NetEnemyData, t float64) *NetEnemyData {
 	return &NetEnemyData{
-		X:        from.X + (to.X-from.X)*t,
-		Y:        from.Y + (to.Y-from.Y)*t,
-		TypeName: to.TypeName,
-		State:    to.State,
-		Health:   to.Health,
+		X:         from.X + (to.X-from.X)*t,
+		Y:         from.Y + (to.Y-from.Y)*t,
+		VelX:      to.VelX,
+		VelY:      to.VelY,
+		TypeName:  to.TypeName,
+		State:     to.State,
+		Direction: to.Direction,
+		Health:    to.Health,
+	}
+}
+
+// NetKnifeData is a knife thrown by an enemy, flying in a straight line.
+type NetKnifeData struct {
+	X, Y       float64 // Top-left of the knife's hitbox
+	VelX, VelY float64
+}
+
+var NetKnife = donburi.NewComponentType[NetKnifeData]()
+
+// LerpNetKnife interpolates between two knife states
+func LerpNetKnife(from, to NetKnifeData, t float64) *NetKnifeData {
+	return &NetKnifeData{
+		X:    from.X + (to.X-from.X)*t,
+		Y:    from.Y + (to.Y-from.Y)*t,
+		VelX: to.VelX,
+		VelY: to.VelY,
 	}
 }
@@ -15,6 +15,7 @@ const (
 type NetGameStateData struct {
 	MatchState    MatchStateID
 	TimeRemaining float64
+	PhaseEndsAt   int64          // Server clock (UnixNano) when TimeRemaining runs out; 0 while waiting
 	Scores        map[uint32]int // NetworkId -> KO count
 	Deaths        map[uint32]int // NetworkId -> death count
 	WinnerID      uint32         // 0 if no winner yet
@@ -32,6 +33,7 @@ type NetGameStateData struct {
 	SlotNames  [4]string
 	SlotTypes  [4]int // 0=Empty, 1=Human, 2=Bot
 	SlotTeams  [4]int
+	SlotPings  [4]int // Smoothed round-trip time in ms; 0 for bots and before it is measured
 }
 
 var NetGameState = donburi.NewComponentType[NetGameStateData]()
@@ -0,0 +1,13 @@
+package netcomponents
+
+import "github.com/yohamta/donburi"
+
+// NetFireData is a fire obstacle's animation frame. Clients build fires
+// from their own copy of the level and match them to these by origin, so
+// the flames they draw are the ones the server is burning players with.
+type NetFireData struct {
+	X, Y  float64 // Tiled origin - base edge the fire emanates from
+	Frame int
+}
+
+var NetFire = donburi.NewComponentType[NetFireData]()
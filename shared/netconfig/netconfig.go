@@ -135,6 +135,19 @@ const (
 	BoomerangInbound  = 1
 )
 
+// BoomerangChargeVFXFrame is the charge frame at which the charge VFX is
+// spawned (matches offline).
+const BoomerangChargeVFXFrame = 15
+
+// Melee hitbox active window, in frames since the attack started. Shared so
+// client prediction ends attacks on the same frame as the server.
+const (
+	MeleeHitboxStart    = 3
+	MeleeHitboxEnd      = 8
+	JumpKickHitboxStart = 2
+	JumpKickHitboxEnd   = 12 // Jump kick has a longer active window (2x)
+)
+
 // ActionID represents a logical game action.
 type ActionID int
 
@@ -0,0 +1,206 @@
+// Package playersim is the player movement simulation shared by the
+// dedicated server, client-side prediction and offline play. It covers
+// acceleration, friction, gravity, jumping, wall slides and wall kicks,
+// crouching and sliding, and collision against a level's resolv.Space.
+//
+// The package is headless: drivers feed it one Input per 60 Hz step and
+// play whatever sounds, particles and animations they like from the
+// Events it returns.
+package playersim
+
+import (
+	"math"
+
+	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/gamemath"
+	"github.com/automoto/doomerang-mp/tags"
+	"github.com/solarlune/resolv"
+)
+
+// Resolv tags the simulation collides with besides tags.ResolvSolid and
+// tags.ResolvRamp.
+const (
+	TagPlatform  = "platform"  // One-way: landed on from above, dropped through with crouch+jump
+	TagCharacter = "character" // Other bodies: pushed away from rather than stopped by
+)
+
+// Body is the velocity and contact state of anything that moves through a
+// level. Players, enemies and bots all resolve collisions through it.
+type Body struct {
+	SpeedX         float64
+	SpeedY         float64
+	OnGround       *resolv.Object // What the body stands on, nil when airborne
+	WallSliding    *resolv.Object // The wall the body is sliding down
+	IgnorePlatform *resolv.Object // A platform being dropped through
+}
+
+// MoveX moves obj by SpeedX, walking up and down ramps and stopping flush
+// against walls. An airborne body that hits a wall starts sliding down it
+// when wallSlide is set.
+func (b *Body) MoveX(obj *resolv.Object, wallSlide bool) {
+	dx := b.SpeedX
+	if dx == 0 {
+		return
+	}
+
+	// Ramp in front (walking uphill), then ramp below (downhill or staying on it)
+	for _, dy := range []float64{0, 1} {
+		if check := obj.Check(dx, dy, tags.ResolvRamp); check != nil {
+			if ramps := check.ObjectsByTags(tags.ResolvRamp); len(ramps) > 0 {
+				obj.X += dx
+				b.snapToSlope(obj, ramps[0])
+				return
+			}
+		}
+	}
+
+	move := dx
+	check := obj.Check(dx, 0, tags.ResolvSolid, TagCharacter)
+	if check == nil {
+		obj.X += dx
+		return
+	}
+
+	if wall := blockingSolid(obj, check); wall != nil {
+		dx = check.ContactWithObject(wall).X()
+		b.SpeedX = 0
+		if wallSlide && b.OnGround == nil {
+			b.WallSliding = wall
+		}
+	}
+
+	if characters := check.ObjectsByTags(TagCharacter); len(characters) > 0 {
+		if contact := check.ContactWithObject(characters[0]); contact.X() != 0 {
+			// Overlapping: a small fixed push-back instead of a hard stop
+			if move > 0 {
+				dx = -1
+			} else {
+				dx = 1
+			}
+		} else {
+			dx = 0
+		}
+	}
+
+	obj.X += dx
+}
+
+// MoveY moves obj by SpeedY, landing on ramps, platforms and solids and
+// bumping heads on ceilings. It sets OnGround for the step.
+func (b *Body) MoveY(obj *resolv.Object) {
+	b.OnGround = nil
+	dy := math.Max(math.Min(b.SpeedY, cfg.Physics.MaxVertSpeed), -cfg.Physics.MaxVertSpeed)
+
+	checkDistance := dy
+	if dy >= 0 {
+		checkDistance++
+	}
+
+	check := obj.Check(0, checkDistance, tags.ResolvSolid, TagPlatform, tags.ResolvRamp)
+	if check == nil {
+		obj.Y += dy
+		return
+	}
+
+	if dy < 0 {
+		dy = b.hitCeiling(obj, check)
+	} else {
+		dy = b.land(obj, check, dy)
+	}
+	obj.Y += dy
+}
+
+// blockingSolid returns the first solid in check that overlaps obj
+// vertically, ignoring floors and ceilings it only touches.
+func blockingSolid(obj *resolv.Object, check *resolv.Collision) *resolv.Object {
+	for _, solid := range check.ObjectsByTags(tags.ResolvSolid) {
+		if obj.Bottom() > solid.Y && obj.Y < solid.Y+solid.H {
+			return solid
+		}
+	}
+	return nil
+}
+
+// inColumn returns the first object in check with tag that overlaps obj
+// horizontally: a floor or ceiling rather than a wall beside it.
+func inColumn(obj *resolv.Object, check *resolv.Collision, tag string) *resolv.Object {
+	for _, o := range check.ObjectsByTags(tag) {
+		if obj.Right() > o.X && obj.X < o.X+o.W {
+			return o
+		}
+	}
+	return nil
+}
+
+// solidInPath returns the first solid in check that obj would run into
+// moving up (dy < 0) or down: one in its column on that side of it.
+func solidInPath(obj *resolv.Object, check *resolv.Collision, dy float64) *resolv.Object {
+	const slop = 1.0 // Tolerates rounding error in resting contact
+	for _, o := range check.ObjectsByTags(tags.ResolvSolid) {
+		if obj.Right() <= o.X || obj.X >= o.X+o.W {
+			continue
+		}
+		if (dy < 0 && o.Y+o.H <= obj.Y+slop) || (dy >= 0 && o.Y >= obj.Bottom()-slop) {
+			return o
+		}
+	}
+	return nil
+}
+
+func (b *Body) hitCeiling(obj *resolv.Object, check *resolv.Collision) float64 {
+	if ceiling := solidInPath(obj, check, -1); ceiling != nil {
+		b.SpeedY = 0
+		return check.ContactWithObject(ceiling).Y()
+	}
+
+	// Tile-based ceilings
+	if len(check.Cells) > 0 && check.Cells[0].ContainsTags(tags.ResolvSolid) {
+		b.SpeedY = 0
+		if slide := check.SlideAgainstCell(check.Cells[0], tags.ResolvSolid); slide != nil {
+			obj.X += slide.X()
+			return slide.Y()
+		}
+		return 0
+	}
+
+	return b.SpeedY
+}
+
+// land resolves a downward step in priority order: ramps, platforms, solids.
+func (b *Body) land(obj *resolv.Object, check *resolv.Collision, dy float64) float64 {
+	if ramps := check.ObjectsByTags(tags.ResolvRamp); len(ramps) > 0 {
+		surfaceY := gamemath.GetSlopeSurfaceY(obj, ramps[0], tags.Slope45UpRight, tags.Slope45UpLeft)
+		if obj.Bottom()+dy >= surfaceY {
+			b.setGround(ramps[0])
+			return surfaceY - obj.Bottom() + cfg.Physics.SlopeSurfaceOffset
+		}
+	}
+
+	if platform := inColumn(obj, check, TagPlatform); platform != nil &&
+		platform != b.IgnorePlatform && b.SpeedY >= 0 && obj.Bottom() < platform.Y+4 {
+		b.setGround(platform)
+		return check.ContactWithObject(platform).Y()
+	}
+
+	if floor := solidInPath(obj, check, dy); floor != nil && b.SpeedY >= 0 {
+		b.setGround(floor)
+		return check.ContactWithObject(floor).Y()
+	}
+
+	return dy
+}
+
+// snapToSlope keeps obj on the surface of the ramp it is walking along.
+func (b *Body) snapToSlope(obj, ramp *resolv.Object) {
+	surfaceY := gamemath.GetSlopeSurfaceY(obj, ramp, tags.Slope45UpRight, tags.Slope45UpLeft)
+	obj.Y = gamemath.SnapToSlopeY(obj.H, surfaceY, cfg.Physics.SlopeSurfaceOffset)
+	b.setGround(ramp)
+}
+
+// setGround lands the body. Landing ends any wall slide or drop-through.
+func (b *Body) setGround(ground *resolv.Object) {
+	b.OnGround = ground
+	b.SpeedY = 0
+	b.WallSliding = nil
+	b.IgnorePlatform = nil
+}
@@ -0,0 +1,66 @@
+package playersim
+
+import (
+	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/leveldata"
+	"github.com/automoto/doomerang-mp/tags"
+	"github.com/solarlune/resolv"
+)
+
+// AddSolids adds a level's solid and ramp tiles to space.
+func AddSolids(space *resolv.Space, rects []leveldata.SolidRect) {
+	for _, r := range rects {
+		var obj *resolv.Object
+		switch r.SlopeType {
+		case tags.Slope45UpRight:
+			obj = resolv.NewObject(r.X, r.Y, r.W, r.H, tags.ResolvRamp, tags.Slope45UpRight)
+		case tags.Slope45UpLeft:
+			obj = resolv.NewObject(r.X, r.Y, r.W, r.H, tags.ResolvRamp, tags.Slope45UpLeft)
+		default:
+			obj = resolv.NewObject(r.X, r.Y, r.W, r.H, tags.ResolvSolid)
+		}
+		obj.SetShape(resolv.NewRectangle(0, 0, r.W, r.H))
+		space.Add(obj)
+	}
+}
+
+// AddPlatforms adds a level's one-way platforms to space.
+func AddPlatforms(space *resolv.Space, rects []leveldata.Rect) {
+	for _, r := range rects {
+		obj := resolv.NewObject(r.X, r.Y, r.W, r.H, TagPlatform)
+		obj.SetShape(resolv.NewRectangle(0, 0, r.W, r.H))
+		space.Add(obj)
+	}
+}
+
+// NewPlayerObject returns a full-height player collision box at (x, y).
+func NewPlayerObject(x, y float64, objTags ...string) *resolv.Object {
+	w, h := float64(cfg.Player.CollisionWidth), float64(cfg.Player.CollisionHeight)
+	obj := resolv.NewObject(x, y, w, h, objTags...)
+	obj.SetShape(resolv.NewRectangle(0, 0, w, h))
+	return obj
+}
+
+// Position returns the top-left of obj's full-height box. A crouching
+// player's hitbox is shorter but their feet stay put, so positions sent
+// over the network stay feet-anchored whatever the stance.
+func Position(obj *resolv.Object) (x, y float64) {
+	return obj.X, obj.Bottom() - float64(cfg.Player.CollisionHeight)
+}
+
+// Place moves obj so that Position returns (x, y).
+func Place(obj *resolv.Object, x, y float64) {
+	obj.X = x
+	obj.Y = y + float64(cfg.Player.CollisionHeight) - obj.H
+	obj.Update()
+}
+
+// Ground returns what obj is resting on, or nil if a step without
+// vertical speed would leave it falling. obj is not moved.
+func Ground(obj *resolv.Object) *resolv.Object {
+	var b Body
+	y := obj.Y
+	b.MoveY(obj)
+	obj.Y = y
+	return b.OnGround
+}
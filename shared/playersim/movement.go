@@ -0,0 +1,303 @@
+package playersim
+
+import (
+	"math"
+
+	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/gamemath"
+	"github.com/automoto/doomerang-mp/shared/netconfig"
+	"github.com/automoto/doomerang-mp/tags"
+	"github.com/solarlune/resolv"
+)
+
+// Mode is the player's stance.
+type Mode int
+
+const (
+	Standing  Mode = iota
+	Crouching      // Reduced hitbox, slow walk
+	Sliding        // Reduced hitbox, low friction, no input
+)
+
+// Action is what the rest of the player is doing, as far as movement cares.
+type Action int
+
+const (
+	Free      Action = iota
+	Attacking        // Melee: slow acceleration, attack friction, no jumping
+	Charging         // Boomerang charge or throw: extra friction, no jumping
+	Stunned          // Hit or knocked back: no jumping
+)
+
+// ActionFor returns the Action a player's animation state implies.
+func ActionFor(state netconfig.StateID) Action {
+	switch state {
+	case netconfig.StateAttackingPunch, netconfig.StateAttackingKick, netconfig.StateAttackingJump:
+		return Attacking
+	case netconfig.StateChargingBoomerang, netconfig.Throw:
+		return Charging
+	case netconfig.Hit, netconfig.Stunned, netconfig.Knockback:
+		return Stunned
+	default:
+		return Free
+	}
+}
+
+// Input is one step's worth of held controls.
+type Input struct {
+	Direction int // -1 left, 0 none, 1 right
+	Jump      bool
+	Crouch    bool
+	Attack    bool
+	Action    Action
+}
+
+// Events reports what happened during a step, for effects.
+type Events uint8
+
+const (
+	Jumped Events = 1 << iota
+	WallJumped
+	WallKicked // Wall jump by attacking; the driver starts the jump kick
+	DroppedThrough
+	SlideStarted
+	Landed
+)
+
+// Has reports whether e includes event.
+func (e Events) Has(event Events) bool {
+	return e&event != 0
+}
+
+// Movement is a player's input-driven movement state.
+type Movement struct {
+	Facing     float64 // -1 or 1
+	Mode       Mode
+	ModeFrames int // Steps spent in Mode
+
+	JumpWasPressed   bool
+	AttackWasPressed bool
+	CrouchWasPressed bool
+}
+
+// NewMovement returns a standing Movement facing right.
+func NewMovement() Movement {
+	return Movement{Facing: 1}
+}
+
+// Reset stands the player back up at full height, as on respawn.
+func (m *Movement) Reset(obj *resolv.Object) {
+	*m = Movement{Facing: m.Facing}
+	restoreHitbox(obj)
+}
+
+// Step advances the player by one 60 Hz step: jumping, acceleration,
+// stance, friction and gravity, then collision.
+func (m *Movement) Step(b *Body, obj *resolv.Object, in Input) Events {
+	var events Events
+	wasOnGround := b.OnGround != nil
+
+	jumpPressed := in.Jump && !m.JumpWasPressed
+	attackPressed := in.Attack && !m.AttackWasPressed
+	crouchPressed := in.Crouch && !m.CrouchWasPressed
+	m.JumpWasPressed, m.AttackWasPressed, m.CrouchWasPressed = in.Jump, in.Attack, in.Crouch
+	m.ModeFrames++
+
+	if in.Action == Free && m.Mode != Sliding {
+		events |= m.jump(b, obj, jumpPressed, attackPressed, in.Crouch)
+		if events.Has(WallKicked) {
+			in.Action = Attacking
+		}
+	}
+	m.accelerate(b, in)
+	events |= m.updateMode(b, obj, in, crouchPressed)
+	m.applyForces(b, in)
+
+	b.MoveX(obj, true)
+	b.MoveY(obj)
+	if b.WallSliding != nil && obj.Check(m.Facing, 0, tags.ResolvSolid) == nil {
+		b.WallSliding = nil
+	}
+
+	if !wasOnGround && b.OnGround != nil {
+		events |= Landed
+	}
+	return events
+}
+
+func (m *Movement) jump(b *Body, obj *resolv.Object, jumpPressed, attackPressed, crouch bool) Events {
+	switch {
+	case b.WallSliding != nil && attackPressed:
+		m.Facing = awayFrom(b.WallSliding, obj)
+		b.SpeedX = m.Facing * cfg.Player.MaxSpeed
+		b.SpeedY = -cfg.Player.JumpSpeed
+		b.WallSliding = nil
+		return WallKicked
+	case !jumpPressed:
+		return 0
+	case b.OnGround != nil && crouch && b.OnGround.HasTags(TagPlatform):
+		b.IgnorePlatform = b.OnGround
+		return DroppedThrough
+	case b.OnGround != nil:
+		b.SpeedY = -cfg.Player.JumpSpeed
+		b.OnGround = nil
+		return Jumped
+	case b.WallSliding != nil:
+		b.SpeedX = awayFrom(b.WallSliding, obj) * cfg.Player.MaxSpeed
+		b.SpeedY = -cfg.Player.JumpSpeed
+		b.WallSliding = nil
+		return WallJumped
+	}
+	return 0
+}
+
+// awayFrom returns the direction pointing from wall past obj.
+func awayFrom(wall, obj *resolv.Object) float64 {
+	if wall.X+wall.W/2 > obj.X+obj.W/2 {
+		return -1
+	}
+	return 1
+}
+
+func (m *Movement) accelerate(b *Body, in Input) {
+	if b.WallSliding != nil || m.Mode != Standing || in.Direction == 0 {
+		return
+	}
+	accel := cfg.Player.Acceleration
+	if in.Action == Attacking {
+		accel = cfg.Player.AttackAccel
+	}
+	m.Facing = float64(in.Direction)
+	b.SpeedX += m.Facing * accel
+}
+
+func (m *Movement) updateMode(b *Body, obj *resolv.Object, in Input, crouchPressed bool) Events {
+	switch m.Mode {
+	case Standing:
+		if in.Action == Charging {
+			b.SpeedX = gamemath.ApplyFriction(b.SpeedX, cfg.Player.Friction)
+		}
+		if in.Action != Free || b.OnGround == nil {
+			return 0
+		}
+		if crouchPressed && math.Abs(b.SpeedX) >= cfg.Player.SlideSpeedThreshold {
+			m.setMode(Sliding, obj)
+			return SlideStarted
+		}
+		if crouchPressed || (in.Crouch && b.SpeedX == 0) {
+			m.setMode(Crouching, obj)
+		}
+
+	case Crouching:
+		if in.Direction != 0 {
+			m.Facing = float64(in.Direction)
+			b.SpeedX = m.Facing * cfg.Player.CrouchWalkSpeed
+		} else {
+			b.SpeedX = gamemath.ApplyFriction(b.SpeedX, cfg.Player.Friction)
+		}
+		if !in.Crouch && m.standUp(obj) {
+			m.setMode(Standing, obj)
+		}
+
+	case Sliding:
+		stopped := math.Abs(b.SpeedX) < cfg.Player.SlideMinSpeed
+		if !stopped && (in.Crouch || m.ModeFrames <= cfg.Player.SlideRecoveryFrames) {
+			return 0
+		}
+		switch {
+		case !m.standUp(obj):
+			// Blocked above: keep the low hitbox
+			b.SpeedX = 0
+			m.setMode(Crouching, obj)
+		case stopped && in.Crouch:
+			m.setMode(Crouching, obj)
+		default:
+			m.setMode(Standing, obj)
+		}
+	}
+	return 0
+}
+
+// setMode switches stance, shrinking the hitbox for crouching and sliding.
+// Standing back up must go through standUp first.
+func (m *Movement) setMode(mode Mode, obj *resolv.Object) {
+	m.Mode = mode
+	m.ModeFrames = 0
+	if mode == Standing {
+		return
+	}
+	if low := cfg.Player.SlideHitboxHeight; obj.H > low {
+		obj.Y += obj.H - low
+		obj.H = low
+	}
+}
+
+// standUp restores the full-height hitbox, nudging the player sideways
+// out from under a low ceiling if it has to. It returns false if there is
+// no room to stand.
+func (m *Movement) standUp(obj *resolv.Object) bool {
+	grow := float64(cfg.Player.CollisionHeight) - obj.H
+	if grow <= 0 {
+		return true
+	}
+
+	const maxNudge = 12.0
+	if obj.Check(0, -grow, tags.ResolvSolid) == nil {
+		restoreHitbox(obj)
+		return true
+	}
+	for _, dir := range []float64{m.Facing, -m.Facing} {
+		for nudge := 1.0; nudge <= maxNudge; nudge++ {
+			if obj.Check(nudge*dir, -grow, tags.ResolvSolid) == nil {
+				obj.X += nudge * dir
+				restoreHitbox(obj)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func restoreHitbox(obj *resolv.Object) {
+	full := float64(cfg.Player.CollisionHeight)
+	obj.Y -= full - obj.H
+	obj.H = full
+}
+
+// applyForces applies friction, the speed limits, gravity and the wall
+// slide cap.
+func (m *Movement) applyForces(b *Body, in Input) {
+	friction := cfg.Player.Friction
+	switch {
+	case m.Mode == Sliding:
+		friction = cfg.Player.SlideFriction
+	case in.Action == Attacking:
+		friction = cfg.Player.AttackFriction
+	}
+	b.SpeedX = gamemath.ApplyFriction(b.SpeedX, friction)
+	b.SpeedX = gamemath.ClampSpeed(b.SpeedX, cfg.Player.MaxSpeed)
+
+	b.SpeedY = math.Min(b.SpeedY+cfg.Player.Gravity, cfg.Physics.MaxFallSpeed)
+	if b.WallSliding != nil {
+		b.SpeedY = math.Min(b.SpeedY, cfg.Physics.WallSlideSpeed)
+	}
+}
+
+// MovementState returns the animation state for a player that is not
+// attacking, charging or stunned.
+func MovementState(b *Body, m *Movement) netconfig.StateID {
+	switch {
+	case m.Mode == Sliding:
+		return netconfig.StateSliding
+	case m.Mode == Crouching:
+		return netconfig.Crouch
+	case b.WallSliding != nil:
+		return netconfig.WallSlide
+	case b.OnGround == nil:
+		return netconfig.Jump
+	case math.Abs(b.SpeedX) >= 0.1:
+		return netconfig.Running
+	default:
+		return netconfig.Idle
+	}
+}
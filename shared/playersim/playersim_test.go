@@ -0,0 +1,66 @@
+package playersim_test
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/automoto/doomerang-mp/shared/playersim"
+	"github.com/automoto/doomerang-mp/shared/playersim/playersimtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/*.golden from the current simulation")
+
+var eventNames = []struct {
+	event playersim.Events
+	name  string
+}{
+	{playersim.Jumped, "jumped"},
+	{playersim.WallJumped, "wall_jumped"},
+	{playersim.WallKicked, "wall_kicked"},
+	{playersim.DroppedThrough, "dropped_through"},
+	{playersim.SlideStarted, "slide_started"},
+	{playersim.Landed, "landed"},
+}
+
+func formatTrajectory(frames []playersimtest.Frame) string {
+	var sb strings.Builder
+	for i, f := range frames {
+		fmt.Fprintf(&sb, "%3d x=%.3f y=%.3f vx=%.3f vy=%.3f %s", i, f.X, f.Y, f.SpeedX, f.SpeedY, f.State)
+		for _, e := range eventNames {
+			if f.Events.Has(e.event) {
+				sb.WriteString(" " + e.name)
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// TestScripts_match_golden_trajectories pins down the movement every
+// driver shares. Run with -update after a deliberate tuning change.
+func TestScripts_match_golden_trajectories(t *testing.T) {
+	for _, s := range playersimtest.Scripts {
+		t.Run(s.Name, func(t *testing.T) {
+			got := formatTrajectory(playersimtest.Run(s))
+			path := filepath.Join("testdata", s.Name+".golden")
+			if *update {
+				require.NoError(t, os.WriteFile(path, []byte(got), 0o644))
+			}
+			want, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), got)
+		})
+	}
+}
+
+func TestScripts_replay_deterministically(t *testing.T) {
+	for _, s := range playersimtest.Scripts {
+		assert.Equal(t, playersimtest.Run(s), playersimtest.Run(s), s.Name)
+	}
+}
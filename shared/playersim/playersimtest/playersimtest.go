@@ -0,0 +1,203 @@
+// Package playersimtest holds a synthetic level and scripted input
+// sequences for checking that every driver of playersim moves a player
+// the same way.
+package playersimtest
+
+import (
+	"github.com/automoto/doomerang-mp/shared/leveldata"
+	"github.com/automoto/doomerang-mp/shared/netconfig"
+	"github.com/automoto/doomerang-mp/shared/playersim"
+	"github.com/automoto/doomerang-mp/tags"
+	"github.com/solarlune/resolv"
+)
+
+// FloorY is the top of Level's floor.
+const FloorY = 416.0
+
+// Level is a 640x480 room: a floor between two walls, a low ceiling only
+// crouching players fit under, a plateau with ramps on both sides and a
+// one-way platform.
+func Level() *leveldata.CollisionData {
+	return &leveldata.CollisionData{
+		MapWidth:  640,
+		MapHeight: 480,
+		SolidRects: []leveldata.SolidRect{
+			{X: 0, Y: FloorY, W: 640, H: 16},       // Floor
+			{X: 0, Y: 0, W: 16, H: FloorY},         // Left wall
+			{X: 624, Y: 0, W: 16, H: FloorY},       // Right wall
+			{X: 256, Y: FloorY - 36, W: 64, H: 16}, // Low ceiling, 20px above the floor
+			{X: 400, Y: FloorY - 16, W: 16, H: 16, SlopeType: tags.Slope45UpRight},
+			{X: 416, Y: FloorY - 32, W: 16, H: 16, SlopeType: tags.Slope45UpRight},
+			{X: 416, Y: FloorY - 16, W: 16, H: 16}, // Under the upper ramp tile
+			{X: 432, Y: FloorY - 32, W: 64, H: 32}, // Plateau
+			{X: 496, Y: FloorY - 32, W: 16, H: 16, SlopeType: tags.Slope45UpLeft},
+			{X: 496, Y: FloorY - 16, W: 16, H: 16}, // Under the upper ramp tile
+			{X: 512, Y: FloorY - 16, W: 16, H: 16, SlopeType: tags.Slope45UpLeft},
+		},
+		Platforms: []leveldata.Rect{
+			{X: 96, Y: FloorY - 56, W: 48, H: 8},
+		},
+	}
+}
+
+// NewSpace builds a space holding Level's solids and platforms.
+func NewSpace() *resolv.Space {
+	lvl := Level()
+	space := resolv.NewSpace(lvl.MapWidth, lvl.MapHeight, 16, 16)
+	playersim.AddSolids(space, lvl.SolidRects)
+	playersim.AddPlatforms(space, lvl.Platforms)
+	return space
+}
+
+// Script is a player spawned at (X, Y) and fed one Input per step.
+type Script struct {
+	Name   string
+	X, Y   float64
+	Inputs []playersim.Input
+}
+
+// Hold returns in repeated for the given number of steps.
+func Hold(steps int, in playersim.Input) []playersim.Input {
+	inputs := make([]playersim.Input, steps)
+	for i := range inputs {
+		inputs[i] = in
+	}
+	return inputs
+}
+
+func seq(parts ...[]playersim.Input) []playersim.Input {
+	var inputs []playersim.Input
+	for _, p := range parts {
+		inputs = append(inputs, p...)
+	}
+	return inputs
+}
+
+var (
+	none      = playersim.Input{}
+	left      = playersim.Input{Direction: -1}
+	right     = playersim.Input{Direction: 1}
+	jump      = playersim.Input{Jump: true}
+	crouch    = playersim.Input{Crouch: true}
+	standingY = FloorY - 40
+)
+
+// Scripts covers each movement mechanic at least once.
+var Scripts = []Script{
+	{
+		Name:   "run_into_ceiling_edge",
+		X:      48,
+		Y:      standingY,
+		Inputs: seq(Hold(50, right), Hold(20, none)),
+	},
+	{
+		Name:   "jump_in_place",
+		X:      48,
+		Y:      standingY,
+		Inputs: seq(Hold(5, none), Hold(1, jump), Hold(50, none)),
+	},
+	{
+		Name: "wall_slide_and_wall_jump",
+		X:    40,
+		Y:    standingY,
+		Inputs: seq(
+			Hold(3, none),
+			Hold(1, playersim.Input{Direction: -1, Jump: true}),
+			Hold(25, left),
+			Hold(1, playersim.Input{Direction: -1, Jump: true}),
+			Hold(40, none),
+		),
+	},
+	{
+		Name: "wall_kick",
+		X:    40,
+		Y:    standingY,
+		Inputs: seq(
+			Hold(3, none),
+			Hold(1, playersim.Input{Direction: -1, Jump: true}),
+			Hold(25, left),
+			Hold(1, playersim.Input{Direction: -1, Attack: true}),
+			Hold(40, none),
+		),
+	},
+	{
+		Name: "crouch_walk_under_ceiling",
+		X:    200,
+		Y:    standingY,
+		Inputs: seq(
+			Hold(5, crouch),
+			Hold(80, playersim.Input{Direction: 1, Crouch: true}),
+			Hold(40, right), // Released under the ceiling: stays low until clear
+			Hold(10, none),
+		),
+	},
+	{
+		Name: "slide_under_ceiling",
+		X:    120,
+		Y:    standingY,
+		Inputs: seq(
+			Hold(20, right),
+			Hold(70, crouch),
+			Hold(20, none),
+		),
+	},
+	{
+		Name: "slide_and_stand",
+		X:    16,
+		Y:    standingY,
+		Inputs: seq(
+			Hold(20, right),
+			Hold(1, crouch),
+			Hold(40, none),
+		),
+	},
+	{
+		Name: "jump_onto_platform_and_drop_through",
+		X:    112,
+		Y:    standingY,
+		Inputs: seq(
+			Hold(5, none),
+			Hold(1, jump),
+			Hold(50, none),
+			Hold(5, crouch),
+			Hold(1, playersim.Input{Crouch: true, Jump: true}),
+			Hold(40, none),
+		),
+	},
+	{
+		Name:   "ramps_over_plateau",
+		X:      340,
+		Y:      standingY,
+		Inputs: seq(Hold(45, right), Hold(60, left), Hold(20, none)),
+	},
+}
+
+// Frame is a player's state after one step.
+type Frame struct {
+	X, Y           float64
+	SpeedX, SpeedY float64
+	State          netconfig.StateID
+	Events         playersim.Events
+}
+
+// Run plays s through playersim directly.
+func Run(s Script) []Frame {
+	space := NewSpace()
+	obj := playersim.NewPlayerObject(s.X, s.Y)
+	space.Add(obj)
+
+	var body playersim.Body
+	movement := playersim.NewMovement()
+	frames := make([]Frame, 0, len(s.Inputs))
+	for _, in := range s.Inputs {
+		events := movement.Step(&body, obj, in)
+		x, y := playersim.Position(obj)
+		frames = append(frames, Frame{
+			X: x, Y: y,
+			SpeedX: body.SpeedX, SpeedY: body.SpeedY,
+			State:  playersim.MovementState(&body, &movement),
+			Events: events,
+		})
+	}
+	return frames
+}
@@ -13,6 +13,8 @@ const (
 	SyncIDNetBoomerang   uint = 13
 	SyncIDNetEnemy       uint = 14
 	SyncIDNetGameState   uint = 15
+	SyncIDNetFire        uint = 16
+	SyncIDNetKnife       uint = 17
 )
 
 // Interpolation IDs (uint8 for WithInterpFn)
@@ -21,6 +23,7 @@ const (
 	InterpIDNetVelocity  uint8 = 11
 	InterpIDNetBoomerang uint8 = 13
 	InterpIDNetEnemy     uint8 = 14
+	InterpIDNetKnife     uint8 = 17
 )
 
 // RegisterComponents registers all network components with necs for serialization.
@@ -72,6 +75,15 @@ func RegisterComponents() error {
 		return err
 	}
 
+	if err := esync.RegisterComponent(
+		SyncIDNetKnife,
+		netcomponents.NetKnifeData{},
+		netcomponents.NetKnife,
+		esync.WithInterpFn(InterpIDNetKnife, netcomponents.LerpNetKnife),
+	); err != nil {
+		return err
+	}
+
 	// GameState: no interpolation (discrete state)
 	if err := esync.RegisterComponent(
 		SyncIDNetGameState,
@@ -81,5 +93,14 @@ func RegisterComponents() error {
 		return err
 	}
 
+	// Fire: no interpolation (discrete animation frames)
+	if err := esync.RegisterComponent(
+		SyncIDNetFire,
+		netcomponents.NetFireData{},
+		netcomponents.NetFire,
+	); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/automoto/doomerang-mp/archetypes"
 	"github.com/automoto/doomerang-mp/components"
 	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/enemysim"
 	"github.com/automoto/doomerang-mp/systems/factory"
 	"github.com/automoto/doomerang-mp/tags"
 	"github.com/hajimehoshi/ebiten/v2"
@@ -75,7 +76,7 @@ func createEnemyHitboxes(ecs *ecs.ECS) {
 		enemyObject := components.Object.Get(enemyEntry).Object
 
 		// Enemies only punch for now
-		if state.CurrentState == cfg.StateAttackingPunch && state.StateTimer >= 10 && state.StateTimer <= 15 {
+		if state.CurrentState == cfg.StateAttackingPunch && state.StateTimer >= enemysim.PunchHitboxStart && state.StateTimer <= enemysim.PunchHitboxEnd {
 			if !hasActiveHitbox(ecs, enemyEntry) {
 				CreateHitbox(ecs, enemyEntry, enemyObject, "punch", false)
 			}
@@ -159,7 +159,9 @@ func RespawnPlayerNearDeath(ecs *ecs.ECS, e *donburi.Entry) {
 }
 
 func resetPlayerAtPosition(e *donburi.Entry, spawnX, spawnY float64) {
+	player := components.Player.Get(e)
 	obj := components.Object.Get(e)
+	player.Movement.Reset(obj.Object)
 	obj.X = spawnX
 	obj.Y = spawnY
 
@@ -170,7 +172,6 @@ func resetPlayerAtPosition(e *donburi.Entry, spawnX, spawnY float64) {
 	physics.WallSliding = nil
 	physics.IgnorePlatform = nil
 
-	player := components.Player.Get(e)
 	player.InvulnFrames = cfg.Player.RespawnInvulnFrames
 
 	state := components.State.Get(e)
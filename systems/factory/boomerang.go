@@ -8,6 +8,7 @@ import (
 	"github.com/automoto/doomerang-mp/components"
 	"github.com/automoto/doomerang-mp/config"
 	"github.com/automoto/doomerang-mp/shared/gamemath"
+	"github.com/automoto/doomerang-mp/shared/playersim"
 	"github.com/automoto/doomerang-mp/tags"
 	"github.com/solarlune/resolv"
 	"github.com/yohamta/donburi"
@@ -62,8 +63,7 @@ func CreateBoomerang(ecs *ecs.ECS, owner *donburi.Entry, chargeFrames float64, a
 	velocityX, velocityY := gamemath.CalculateThrowVelocity(aimX, aimY, speed, config.Boomerang.ThrowLift)
 
 	components.Physics.Set(b, &components.PhysicsData{
-		SpeedX:   velocityX,
-		SpeedY:   velocityY,
+		Body:     playersim.Body{SpeedX: velocityX, SpeedY: velocityY},
 		Gravity:  config.Boomerang.Gravity,
 		Friction: 0,
 		MaxSpeed: speed * 2, // Allow high speed
@@ -8,9 +8,9 @@ import (
 	"github.com/automoto/doomerang-mp/assets/animations"
 	"github.com/automoto/doomerang-mp/components"
 	cfg "github.com/automoto/doomerang-mp/config"
-	"github.com/automoto/doomerang-mp/tags"
+	"github.com/automoto/doomerang-mp/shared/hazards"
+	"github.com/automoto/doomerang-mp/shared/leveldata"
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/solarlune/resolv"
 	"github.com/yohamta/donburi"
 	"github.com/yohamta/donburi/ecs"
 )
@@ -19,54 +19,22 @@ import (
 // x, y is the BASE EDGE where fire emanates from. Fire extends outward in the specified direction.
 func CreateFire(ecs *ecs.ECS, x, y float64, fireType, direction string) *donburi.Entry {
 	fire := archetypes.Fire.Spawn(ecs)
+	fireCfg := hazards.FireConfig(fireType)
 
-	// Get config for this fire type
-	fireCfg, ok := cfg.Fire.Types[fireType]
-	if !ok {
-		// Default to continuous if unknown type
-		fireCfg = cfg.Fire.Types["fire_continuous"]
-	}
-
-	// Hitbox scale factor (default to 1.0 if not set)
-	hitboxScale := fireCfg.HitboxScale
-	if hitboxScale == 0 {
-		hitboxScale = 1.0
-	}
-
-	// Hitbox dimensions (swap width/height for vertical directions)
-	hitboxW := float64(fireCfg.FrameWidth) * hitboxScale
-	hitboxH := float64(fireCfg.FrameHeight) * hitboxScale
-	if direction == "up" || direction == "down" {
-		hitboxW, hitboxH = hitboxH, hitboxW
-	}
-
-	// Calculate sprite center and hitbox position
-	frameW := float64(fireCfg.FrameWidth)
-	spriteCenterX, spriteCenterY := components.FireSpriteCenter(x, y, frameW, direction)
-	hitboxX := spriteCenterX - hitboxW/2
-	hitboxY := spriteCenterY - hitboxH/2
-
-	// Create resolv collision object
-	obj := resolv.NewObject(hitboxX, hitboxY, hitboxW, hitboxH, tags.ResolvFire)
-	obj.SetShape(resolv.NewRectangle(0, 0, hitboxW, hitboxH))
-	obj.Data = fire
-
+	hitbox := hazards.NewFire(leveldata.FireSpawn{X: x, Y: y, FireType: fireType, Direction: direction})
+	obj := hitbox.Object
 	components.Object.SetValue(fire, components.ObjectData{Object: obj})
 
+	spriteCenterX, spriteCenterY := hitbox.SpriteCenter()
 	components.Fire.SetValue(fire, components.FireData{
-		FireType:       fireType,
-		Active:         true,
-		Damage:         fireCfg.Damage,
-		KnockbackForce: fireCfg.KnockbackForce,
-		Direction:      direction,
-		BaseWidth:      hitboxW,
-		BaseHeight:     hitboxH,
-		OriginX:        x,
-		OriginY:        y,
-		FrameWidth:     frameW,
-		SpriteCenterX:  spriteCenterX,
-		SpriteCenterY:  spriteCenterY,
-		HitboxPhases:   fireCfg.HitboxPhases,
+		FireType:      fireType,
+		Direction:     direction,
+		OriginX:       x,
+		OriginY:       y,
+		FrameWidth:    float64(fireCfg.FrameWidth),
+		SpriteCenterX: spriteCenterX,
+		SpriteCenterY: spriteCenterY,
+		Hitbox:        hitbox,
 	})
 
 	// Set up animation
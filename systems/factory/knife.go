@@ -7,8 +7,8 @@ import (
 	"github.com/automoto/doomerang-mp/assets"
 	"github.com/automoto/doomerang-mp/components"
 	"github.com/automoto/doomerang-mp/config"
-	"github.com/automoto/doomerang-mp/tags"
-	"github.com/solarlune/resolv"
+	"github.com/automoto/doomerang-mp/shared/enemysim"
+	"github.com/automoto/doomerang-mp/shared/playersim"
 	"github.com/yohamta/donburi"
 	"github.com/yohamta/donburi/ecs"
 )
@@ -17,50 +17,18 @@ import (
 func CreateKnife(ecs *ecs.ECS, owner *donburi.Entry, targetX, targetY float64) *donburi.Entry {
 	k := archetypes.Knife.Spawn(ecs)
 
-	// Get owner position
-	ownerObj := components.Object.Get(owner).Object
-
-	// Start position (center of enemy)
-	startX := ownerObj.X + ownerObj.W/2
-	startY := ownerObj.Y + ownerObj.H/2
-
-	// Create collision object
-	obj := resolv.NewObject(
-		startX-config.Knife.Width/2,
-		startY-config.Knife.Height/2,
-		config.Knife.Width,
-		config.Knife.Height,
-		tags.ResolvKnife,
-	)
+	// Aimed as the server's knives are
+	knife := enemysim.NewKnife(components.Object.Get(owner).Object, targetX, targetY)
+	obj := knife.Object
 	obj.Data = k
 	components.Object.Set(k, &components.ObjectData{Object: obj})
 
 	// Add to space
 	components.Space.Get(components.Space.MustFirst(ecs.World)).Add(obj)
 
-	dx := targetX - startX
-	dy := targetY - startY
-
-	// Clamp downward angle to prevent throwing into ground
-	if dy > 0 && config.Knife.MaxDownwardAngle > 0 {
-		angle := math.Atan2(dy, math.Abs(dx))
-		if angle > config.Knife.MaxDownwardAngle {
-			dy = math.Abs(dx) * math.Tan(config.Knife.MaxDownwardAngle)
-		}
-	}
-
-	length := math.Sqrt(dx*dx + dy*dy)
-	if length > 0 {
-		dx /= length
-		dy /= length
-	}
-
-	velocityX := config.Knife.Speed * dx
-	velocityY := config.Knife.Speed * dy
-
+	velocityX, velocityY := knife.SpeedX, knife.SpeedY
 	components.Physics.Set(k, &components.PhysicsData{
-		SpeedX:   velocityX,
-		SpeedY:   velocityY,
+		Body:     playersim.Body{SpeedX: velocityX, SpeedY: velocityY},
 		Gravity:  0, // Knife travels in straight line
 		Friction: 0,
 		MaxSpeed: config.Knife.Speed * 2,
@@ -3,6 +3,7 @@ package factory
 import (
 	"github.com/automoto/doomerang-mp/archetypes"
 	"github.com/automoto/doomerang-mp/components"
+	"github.com/automoto/doomerang-mp/shared/playersim"
 	"github.com/solarlune/resolv"
 	"github.com/tanema/gween"
 	"github.com/tanema/gween/ease"
@@ -10,10 +11,20 @@ import (
 	"github.com/yohamta/donburi/ecs"
 )
 
-func CreatePlatform(ecs *ecs.ECS, object *resolv.Object) *donburi.Entry {
+// CreatePlatform creates a one-way platform: players land on it from above
+// and drop through it with crouch+jump.
+func CreatePlatform(ecs *ecs.ECS, x, y, w, h float64) *donburi.Entry {
 	platform := archetypes.Platform.Spawn(ecs)
-	object.Data = platform
-	components.Object.SetValue(platform, components.ObjectData{Object: object})
+
+	obj := resolv.NewObject(x, y, w, h, playersim.TagPlatform)
+	obj.SetShape(resolv.NewRectangle(0, 0, w, h))
+	obj.Data = platform
+
+	components.Object.SetValue(platform, components.ObjectData{Object: obj})
+
+	if spaceEntry, ok := components.Space.First(ecs.World); ok {
+		components.Space.Get(spaceEntry).Add(obj)
+	}
 
 	return platform
 }
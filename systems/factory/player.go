@@ -4,6 +4,7 @@ import (
 	"github.com/automoto/doomerang-mp/archetypes"
 	"github.com/automoto/doomerang-mp/components"
 	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/playersim"
 	"github.com/automoto/doomerang-mp/tags"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/solarlune/resolv"
@@ -32,6 +33,7 @@ func CreatePlayer(ecs *ecs.ECS, x, y float64, inputCfg PlayerInputConfig) *donbu
 		Direction:    components.Vector{X: 1, Y: 0},
 		ComboCounter: 0,
 		InvulnFrames: 0,
+		Movement:     playersim.NewMovement(),
 	})
 	var boundGamepad *int
 	if inputCfg.GamepadID != nil {
@@ -0,0 +1,214 @@
+package systems
+
+import (
+	"image/color"
+	"strconv"
+
+	"github.com/automoto/doomerang-mp/assets"
+	"github.com/automoto/doomerang-mp/components"
+	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/fonts"
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text" //nolint:staticcheck // TODO: migrate to text/v2
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/leap-fish/necs/esync"
+	"github.com/yohamta/donburi/ecs"
+)
+
+// killFeedCauseLabels are shown between killer and victim for causes
+// without an icon.
+var killFeedCauseLabels = map[string]string{
+	"punch":     "PUNCH",
+	"kick":      "KICK",
+	"jump_kick": "JUMP KICK",
+	"fire":      "FIRE",
+	"dead_zone": "FELL",
+	"enemy":     "ENEMY",
+}
+
+// killFeedCauseIcons are the object images shown for weapon causes.
+var killFeedCauseIcons = map[string]string{
+	"boomerang": "boom_green.png",
+	"knife":     "knife-green.png",
+}
+
+// KillFeed holds the recent KOs and floating damage numbers drawn over an
+// online match. The combat and boomerang event systems fill it from
+// server events and NewKillFeedRenderer draws it.
+type KillFeed struct {
+	entries []killFeedEntry // Newest first
+	numbers []damageNumber
+}
+
+type killFeedEntry struct {
+	killer, victim           string
+	killerColor, victimColor color.RGBA
+	cause                    string
+	frames                   int // Frames left on screen
+}
+
+type damageNumber struct {
+	x, y   float64 // World position
+	damage int
+	frames int // Frames left on screen
+}
+
+func NewKillFeed() *KillFeed {
+	return &KillFeed{}
+}
+
+// AddKO lists the KO in evt. A KO with no killer is environmental and
+// shows the cause and victim only.
+func (f *KillFeed) AddKO(e *ecs.ECS, evt messages.DeathEvent) {
+	entry := killFeedEntry{cause: evt.Cause, frames: cfg.KillFeed.EntryFrames}
+	entry.victim, entry.victimColor = netEntityLabel(e, evt.VictimID)
+	if evt.KillerID != 0 && evt.KillerID != evt.VictimID {
+		entry.killer, entry.killerColor = netEntityLabel(e, evt.KillerID)
+	}
+
+	f.entries = append([]killFeedEntry{entry}, f.entries...)
+	if len(f.entries) > cfg.KillFeed.MaxEntries {
+		f.entries = f.entries[:cfg.KillFeed.MaxEntries]
+	}
+}
+
+// AddDamage floats damage up from the world position x, y, if damage
+// numbers are on.
+func (f *KillFeed) AddDamage(x, y float64, damage int) {
+	if !cfg.KillFeed.ShowDamageNumbers || damage <= 0 {
+		return
+	}
+	f.numbers = append(f.numbers, damageNumber{x: x, y: y, damage: damage, frames: cfg.KillFeed.DamageNumberFrames})
+}
+
+// Update ages KOs and damage numbers by a frame, dropping expired ones.
+func (f *KillFeed) Update() {
+	entries := f.entries[:0]
+	for _, entry := range f.entries {
+		if entry.frames--; entry.frames > 0 {
+			entries = append(entries, entry)
+		}
+	}
+	f.entries = entries
+
+	numbers := f.numbers[:0]
+	for _, n := range f.numbers {
+		n.y -= cfg.KillFeed.DamageNumberRise
+		if n.frames--; n.frames > 0 {
+			numbers = append(numbers, n)
+		}
+	}
+	f.numbers = numbers
+}
+
+// netEntityLabel names the player or co-op enemy with netID, in the
+// color the HUD uses for them.
+func netEntityLabel(e *ecs.ECS, netID uint) (string, color.RGBA) {
+	if gameEntry, ok := netcomponents.NetGameState.First(e.World); ok {
+		gs := netcomponents.NetGameState.Get(gameEntry)
+		for i, nid := range gs.SlotNetIDs {
+			if gs.SlotTypes[i] == 0 || uint(nid) != netID {
+				continue
+			}
+			name := gs.SlotNames[i]
+			if name == "" {
+				name = "P" + strconv.Itoa(i+1)
+			}
+			return name, cfg.PlayerColors.Colors[i%len(cfg.PlayerColors.Colors)].RGBA
+		}
+	}
+
+	entity := esync.FindByNetworkId(e.World, esync.NetworkId(netID))
+	if e.World.Valid(entity) {
+		if entry := e.World.Entry(entity); entry.HasComponent(netcomponents.NetEnemy) {
+			return netcomponents.NetEnemy.Get(entry).TypeName, cfg.LightRed
+		}
+	}
+	return getNetworkPlayerName(e, uint32(netID)), cfg.White //nolint:gosec // NetworkId fits in uint32 for the foreseeable player counts
+}
+
+// NewKillFeedRenderer returns a renderer that lists f's KOs in the top
+// right corner and floats its damage numbers over the level.
+func NewKillFeedRenderer(f *KillFeed) func(*ecs.ECS, *ebiten.Image) {
+	return func(e *ecs.ECS, screen *ebiten.Image) {
+		drawDamageNumbers(e, screen, f.numbers)
+		drawKillFeed(screen, f.entries)
+	}
+}
+
+func drawKillFeed(screen *ebiten.Image, entries []killFeedEntry) {
+	const gap = 6
+	face := fonts.ExcelSmall.Get()
+	right := screen.Bounds().Dx() - netHudMargin
+	y := 40
+
+	for _, entry := range entries {
+		// Killer, cause icon or label, victim — right-aligned
+		var icon *ebiten.Image
+		var label string
+		var causeW int
+		if name, ok := killFeedCauseIcons[entry.cause]; ok {
+			icon = assets.GetObjectImage(name)
+			causeW = icon.Bounds().Dx() * 10 / icon.Bounds().Dy()
+		} else {
+			label = "[KO]"
+			if l, ok := killFeedCauseLabels[entry.cause]; ok {
+				label = "[" + l + "]"
+			}
+			causeW = text.BoundString(face, label).Dx()
+		}
+		width := causeW + gap + text.BoundString(face, entry.victim).Dx()
+		if entry.killer != "" {
+			width += text.BoundString(face, entry.killer).Dx() + gap
+		}
+
+		x := right - width
+		vector.FillRect(screen, float32(x-3), float32(y-10), float32(width+6), 13, color.RGBA{0, 0, 0, 120}, false)
+
+		if entry.killer != "" {
+			text.Draw(screen, entry.killer, face, x, y, entry.killerColor)
+			x += text.BoundString(face, entry.killer).Dx() + gap
+		}
+		if icon != nil {
+			scale := 10 / float64(icon.Bounds().Dy())
+			netHudDrawOp.GeoM.Reset()
+			netHudDrawOp.ColorScale.Reset()
+			netHudDrawOp.GeoM.Scale(scale, scale)
+			netHudDrawOp.GeoM.Translate(float64(x), float64(y-9))
+			screen.DrawImage(icon, netHudDrawOp)
+		} else {
+			text.Draw(screen, label, face, x, y, cfg.White)
+		}
+		x += causeW + gap
+		text.Draw(screen, entry.victim, face, x, y, entry.victimColor)
+
+		y += 14
+	}
+}
+
+func drawDamageNumbers(e *ecs.ECS, screen *ebiten.Image, numbers []damageNumber) {
+	if len(numbers) == 0 {
+		return
+	}
+	cameraEntry, ok := components.Camera.First(e.World)
+	if !ok {
+		return
+	}
+	camera := components.Camera.Get(cameraEntry)
+	zoom := camera.Zoom
+	if zoom == 0 {
+		zoom = 1.0
+	}
+	screenW := float64(screen.Bounds().Dx())
+	screenH := float64(screen.Bounds().Dy())
+	face := fonts.ExcelBold.Get()
+
+	for _, n := range numbers {
+		label := strconv.Itoa(n.damage)
+		sx := (n.x-camera.Position.X)*zoom + screenW/2 - float64(text.BoundString(face, label).Dx())/2
+		sy := (n.y-camera.Position.Y)*zoom + screenH/2
+		text.Draw(screen, label, face, int(sx), int(sy), cfg.BrightYellow)
+	}
+}
@@ -1,8 +1,11 @@
 package systems
 
 import (
+	"math"
+
 	"github.com/automoto/doomerang-mp/components"
 	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/enemysim"
 	"github.com/automoto/doomerang-mp/tags"
 	"github.com/yohamta/donburi"
 	"github.com/yohamta/donburi/ecs"
@@ -11,8 +14,9 @@ import (
 func UpdateKnives(ecs *ecs.ECS) {
 	var toRemove []*donburi.Entry
 
-	// Cache level dimensions outside the loop
-	var levelWidth, levelHeight float64
+	// Cache level dimensions outside the loop; without a level, knives
+	// fly until they hit something
+	levelWidth, levelHeight := math.Inf(1), math.Inf(1)
 	if level, hasLevel := components.Level.First(ecs.World); hasLevel {
 		levelData := components.Level.Get(level)
 		levelWidth = float64(levelData.CurrentLevel.Width * 16)   // tile width * tile size
@@ -20,25 +24,21 @@ func UpdateKnives(ecs *ecs.ECS) {
 	}
 
 	components.Knife.Each(ecs.World, func(e *donburi.Entry) {
-		physics := components.Physics.Get(e)
-		obj := components.Object.Get(e)
-
-		// Update position (straight line movement, no gravity)
-		obj.X += physics.SpeedX
-		obj.Y += physics.SpeedY
-		obj.Update()
+		knife := knifeSim(e)
 
-		// Check if knife is off-screen (with buffer)
-		if levelWidth > 0 {
-			if obj.X < -100 || obj.X > levelWidth+100 ||
-				obj.Y < -100 || obj.Y > levelHeight+100 {
-				toRemove = append(toRemove, e)
-				return
-			}
+		// Flies as the server's knives do; gone once off-level or in a wall
+		if !knife.Step(levelWidth, levelHeight) {
+			toRemove = append(toRemove, e)
+			return
 		}
 
-		// Check collisions
-		if shouldDestroy := checkKnifeCollisions(ecs, e, obj); shouldDestroy {
+		if players := knife.Object.Check(0, 0, tags.ResolvPlayer); players != nil {
+			for _, playerObj := range players.ObjectsByTags(tags.ResolvPlayer) {
+				playerEntry, ok := playerObj.Data.(*donburi.Entry)
+				if ok && playerEntry != nil && playerEntry.Valid() {
+					handleKnifePlayerHit(ecs, knife, playerEntry)
+				}
+			}
 			toRemove = append(toRemove, e)
 		}
 	})
@@ -49,52 +49,29 @@ func UpdateKnives(ecs *ecs.ECS) {
 	}
 }
 
-func checkKnifeCollisions(ecs *ecs.ECS, knifeEntry *donburi.Entry, obj *components.ObjectData) bool {
-	knife := components.Knife.Get(knifeEntry)
-
-	// Check for collisions with walls and player
-	check := obj.Check(0, 0, tags.ResolvSolid, tags.ResolvPlayer)
-	if check == nil {
-		return false
-	}
-
-	// Wall collision - destroy knife
-	if solids := check.ObjectsByTags(tags.ResolvSolid); len(solids) > 0 {
-		return true
+// knifeSim returns the shared/enemysim.Knife for a knife entity's
+// components.
+func knifeSim(knifeEntry *donburi.Entry) *enemysim.Knife {
+	physics := components.Physics.Get(knifeEntry)
+	return &enemysim.Knife{
+		Object: components.Object.Get(knifeEntry).Object,
+		SpeedX: physics.SpeedX,
+		SpeedY: physics.SpeedY,
+		Damage: components.Knife.Get(knifeEntry).Damage,
 	}
-
-	// Player collision - deal damage and destroy
-	if players := check.ObjectsByTags(tags.ResolvPlayer); len(players) > 0 {
-		for _, playerObj := range players {
-			playerEntry, ok := playerObj.Data.(*donburi.Entry)
-			if ok && playerEntry != nil && playerEntry.Valid() {
-				handleKnifePlayerHit(ecs, knifeEntry, knife, playerEntry)
-			}
-		}
-		return true
-	}
-
-	return false
 }
 
-func handleKnifePlayerHit(ecs *ecs.ECS, knifeEntry *donburi.Entry, knife *components.KnifeData, playerEntry *donburi.Entry) {
+func handleKnifePlayerHit(ecs *ecs.ECS, knife *enemysim.Knife, playerEntry *donburi.Entry) {
 	// Check player invulnerability
 	player := components.Player.Get(playerEntry)
 	if player.InvulnFrames > 0 {
 		return
 	}
 
-	// Calculate knockback direction (based on knife velocity)
-	knifePhysics := components.Physics.Get(knifeEntry)
-	knockbackX := cfg.Knife.KnockbackForce
-	if knifePhysics.SpeedX < 0 {
-		knockbackX = -knockbackX
-	}
-
 	// Apply damage via DamageEvent
 	donburi.Add(playerEntry, components.DamageEvent, &components.DamageEventData{
 		Amount:     knife.Damage,
-		KnockbackX: knockbackX,
+		KnockbackX: knife.KnockbackX(),
 		KnockbackY: cfg.Combat.KnockbackUpwardForce,
 	})
 
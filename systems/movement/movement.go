@@ -0,0 +1,100 @@
+// Package movement drives playersim for the client: offline players and
+// online prediction. It does not depend on ebiten, so both drivers can be
+// tested against playersimtest without cgo (go test -tags nogui).
+package movement
+
+import (
+	"math"
+
+	"github.com/automoto/doomerang-mp/components"
+	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/network"
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
+	"github.com/automoto/doomerang-mp/shared/netconfig"
+	"github.com/automoto/doomerang-mp/shared/playersim"
+	"github.com/solarlune/resolv"
+)
+
+// StepPlayer applies one offline movement step from the player's held
+// controls and turns them to face the way they moved. state is the
+// player's state before the step.
+func StepPlayer(input *components.PlayerInputData, player *components.PlayerData, physics *components.PhysicsData, state cfg.StateID, obj *resolv.Object) playersim.Events {
+	events := player.Movement.Step(&physics.Body, obj, playersim.Input{
+		Direction: direction(input),
+		Jump:      input.CurrentInput[cfg.ActionJump],
+		Crouch:    input.CurrentInput[cfg.ActionCrouch],
+		Attack:    input.CurrentInput[cfg.ActionAttack],
+		Action:    playersim.ActionFor(state),
+	})
+	player.Direction.X = player.Movement.Facing
+	return events
+}
+
+// direction returns -1, 0 or 1 for the held movement keys.
+func direction(input *components.PlayerInputData) int {
+	left := input.CurrentInput[cfg.ActionMoveLeft]
+	right := input.CurrentInput[cfg.ActionMoveRight]
+	switch {
+	case left && !right:
+		return -1
+	case right && !left:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Prediction is the local player's movement, stepped ahead of the server
+// by the same simulation the server runs.
+type Prediction struct {
+	Buffer *network.PredictionBuffer
+
+	Body     playersim.Body
+	Movement playersim.Movement
+	Events   playersim.Events // From the latest step, for effects
+
+	// Collision space for prediction. PlayerObj moves freely until the
+	// level is added.
+	Space     *resolv.Space
+	PlayerObj *resolv.Object
+}
+
+// NewPrediction creates a prediction with no level.
+func NewPrediction() Prediction {
+	return Prediction{
+		Buffer:    &network.PredictionBuffer{},
+		Movement:  playersim.NewMovement(),
+		PlayerObj: playersim.NewPlayerObject(0, 0, "player"),
+	}
+}
+
+// PredictStep applies one 60 Hz physics sub-step using the given input and
+// updates the local player entity's NetPosition. state is the player's
+// state before the step, as the server sees it. It stores the result in
+// the prediction buffer for later reconciliation.
+func (p *Prediction) PredictStep(input messages.PlayerInput, pos *netcomponents.NetPositionData, state netconfig.StateID) {
+	playersim.Place(p.PlayerObj, pos.X, pos.Y)
+	p.Events = p.Movement.Step(&p.Body, p.PlayerObj, playersim.Input{
+		Direction: input.Direction,
+		Jump:      input.Actions.Has(netconfig.ActionJump),
+		Crouch:    input.Actions.Has(netconfig.ActionCrouch),
+		Attack:    input.Actions.Has(netconfig.ActionAttack),
+		Action:    playersim.ActionFor(state),
+	})
+	p.PlayerObj.Update()
+	pos.X, pos.Y = playersim.Position(p.PlayerObj)
+
+	p.Buffer.Store(input, pos.X, pos.Y)
+}
+
+// Correct moves the collision body to a reconciled position. The server
+// only sends velocity, so ground contact is re-derived: serverSpeedY near
+// zero means the player is resting on whatever is below them.
+func (p *Prediction) Correct(x, y, serverSpeedY float64) {
+	playersim.Place(p.PlayerObj, x, y)
+	p.Body.OnGround = nil
+	if math.Abs(serverSpeedY) < 0.1 {
+		p.Body.OnGround = playersim.Ground(p.PlayerObj)
+	}
+}
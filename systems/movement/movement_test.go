@@ -0,0 +1,89 @@
+package movement
+
+import (
+	"testing"
+
+	"github.com/automoto/doomerang-mp/components"
+	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
+	"github.com/automoto/doomerang-mp/shared/netconfig"
+	"github.com/automoto/doomerang-mp/shared/playersim"
+	"github.com/automoto/doomerang-mp/shared/playersim/playersimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPrediction_PredictStep_matches_playersim replays the shared movement
+// scripts through client prediction, feeding back the state each step
+// derives as the server does: the predicted trajectory must be exactly
+// what playersim produces on its own.
+func TestPrediction_PredictStep_matches_playersim(t *testing.T) {
+	for _, s := range playersimtest.Scripts {
+		t.Run(s.Name, func(t *testing.T) {
+			p := NewPrediction()
+			p.Space = playersimtest.NewSpace()
+			p.PlayerObj = playersim.NewPlayerObject(s.X, s.Y, "player")
+			p.Space.Add(p.PlayerObj)
+			pos := netcomponents.NetPositionData{X: s.X, Y: s.Y}
+			state := netconfig.Idle
+
+			got := make([]playersimtest.Frame, 0, len(s.Inputs))
+			for i, in := range s.Inputs {
+				input := messages.NewPlayerInput(uint32(i + 1))
+				input.Direction = in.Direction
+				input.Actions.Set(netconfig.ActionJump, in.Jump)
+				input.Actions.Set(netconfig.ActionCrouch, in.Crouch)
+				input.Actions.Set(netconfig.ActionAttack, in.Attack)
+				p.PredictStep(input, &pos, state)
+				state = playersim.MovementState(&p.Body, &p.Movement)
+
+				got = append(got, playersimtest.Frame{
+					X: pos.X, Y: pos.Y,
+					SpeedX: p.Body.SpeedX, SpeedY: p.Body.SpeedY,
+					State:  state,
+					Events: p.Events,
+				})
+			}
+
+			assert.Equal(t, playersimtest.Run(s), got)
+		})
+	}
+}
+
+// TestStepPlayer_matches_playersim replays the shared movement scripts as
+// held keys through the offline player's movement step.
+func TestStepPlayer_matches_playersim(t *testing.T) {
+	for _, s := range playersimtest.Scripts {
+		t.Run(s.Name, func(t *testing.T) {
+			space := playersimtest.NewSpace()
+			obj := playersim.NewPlayerObject(s.X, s.Y, "player")
+			space.Add(obj)
+			player := components.PlayerData{Movement: playersim.NewMovement()}
+			var physics components.PhysicsData
+			state := cfg.Idle
+
+			got := make([]playersimtest.Frame, 0, len(s.Inputs))
+			for _, in := range s.Inputs {
+				var input components.PlayerInputData
+				input.CurrentInput[cfg.ActionMoveLeft] = in.Direction < 0
+				input.CurrentInput[cfg.ActionMoveRight] = in.Direction > 0
+				input.CurrentInput[cfg.ActionJump] = in.Jump
+				input.CurrentInput[cfg.ActionCrouch] = in.Crouch
+				input.CurrentInput[cfg.ActionAttack] = in.Attack
+				events := StepPlayer(&input, &player, &physics, state, obj)
+				state = playersim.MovementState(&physics.Body, &player.Movement)
+
+				x, y := playersim.Position(obj)
+				got = append(got, playersimtest.Frame{
+					X: x, Y: y,
+					SpeedX: physics.SpeedX, SpeedY: physics.SpeedY,
+					State:  state,
+					Events: events,
+				})
+				assert.Equal(t, player.Movement.Facing, player.Direction.X)
+			}
+
+			assert.Equal(t, playersimtest.Run(s), got)
+		})
+	}
+}
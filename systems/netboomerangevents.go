@@ -3,6 +3,7 @@ package systems
 import (
 	cfg "github.com/automoto/doomerang-mp/config"
 	"github.com/automoto/doomerang-mp/network"
+	"github.com/automoto/doomerang-mp/shared/messages"
 	"github.com/automoto/doomerang-mp/shared/netcomponents"
 	"github.com/automoto/doomerang-mp/systems/factory"
 	"github.com/leap-fish/necs/esync"
@@ -10,65 +11,80 @@ import (
 	"github.com/yohamta/donburi/ecs"
 )
 
-// NewNetBoomerangEventSystem returns an ECS system that drains boomerang events
-// from the network client and triggers VFX/SFX each tick.
-func NewNetBoomerangEventSystem(client *network.Client) func(*ecs.ECS) {
+// NewNetBoomerangEventSystem returns an ECS system that handles this
+// frame's boomerang events and triggers VFX/SFX. The local
+// player's charge, throw and catch effects already played when prediction
+// is on, so only their confirmations are passed to prediction. Hits float
+// their damage in feed.
+func NewNetBoomerangEventSystem(client *network.Client, events *NetEvents, prediction *NetPrediction, feed *KillFeed) func(*ecs.ECS) {
 	// Track charge VFX per player (ownerNetworkID → VFX entry)
 	chargeVFX := make(map[uint]*donburi.Entry)
 
 	return func(e *ecs.ECS) {
-		// Charge events: spawn charge VFX at player feet
-		for _, evt := range client.DrainChargeEvents() {
-			PlaySFX(e, cfg.SoundBoomerangCharge)
-			vfx := factory.SpawnChargeVFX(e, evt.X, evt.Y)
-			if vfx != nil {
-				chargeVFX[evt.OwnerNetworkID] = vfx
-			}
+		predicted := func(owner uint) bool {
+			return prediction != nil && owner != 0 && owner == uint(client.NetworkID())
 		}
 
-		// Throw events: destroy charge VFX, play throw SFX, spawn muzzle flash
-		for _, evt := range client.DrainThrowEvents() {
-			if vfx, ok := chargeVFX[evt.OwnerNetworkID]; ok {
-				factory.DestroyChargeVFX(e, vfx)
-				delete(chargeVFX, evt.OwnerNetworkID)
-			}
-			PlaySFX(e, cfg.SoundBoomerangThrow)
-			factory.SpawnGunshot(e, evt.X, evt.Y, evt.DirectionX)
-		}
+		for _, evt := range events.Events() {
+			switch evt := evt.(type) {
+			case messages.BoomerangChargeEvent:
+				// Spawn charge VFX at player feet
+				if predicted(evt.OwnerNetworkID) {
+					continue
+				}
+				PlaySFX(e, cfg.SoundBoomerangCharge)
+				vfx := factory.SpawnChargeVFX(e, evt.X, evt.Y)
+				if vfx != nil {
+					chargeVFX[evt.OwnerNetworkID] = vfx
+				}
 
-		// Catch events: play SFX, immediately remove boomerang entity
-		for _, evt := range client.DrainCatchEvents() {
-			// Destroy any lingering charge VFX
-			if vfx, ok := chargeVFX[evt.OwnerNetworkID]; ok {
-				factory.DestroyChargeVFX(e, vfx)
-				delete(chargeVFX, evt.OwnerNetworkID)
-			}
+			case messages.BoomerangThrowEvent:
+				// Destroy charge VFX, play throw SFX, spawn muzzle flash
+				if predicted(evt.OwnerNetworkID) && prediction.ConfirmThrow(evt) {
+					continue
+				}
+				if vfx, ok := chargeVFX[evt.OwnerNetworkID]; ok {
+					factory.DestroyChargeVFX(e, vfx)
+					delete(chargeVFX, evt.OwnerNetworkID)
+				}
+				PlaySFX(e, cfg.SoundBoomerangThrow)
+				factory.SpawnGunshot(e, evt.X, evt.Y, evt.DirectionX)
 
-			PlaySFX(e, cfg.SoundBoomerangCatch)
+			case messages.BoomerangCatchEvent:
+				// Destroy any lingering charge VFX
+				if vfx, ok := chargeVFX[evt.OwnerNetworkID]; ok {
+					factory.DestroyChargeVFX(e, vfx)
+					delete(chargeVFX, evt.OwnerNetworkID)
+				}
 
-			// Immediately remove the boomerang entity from the client world
-			// so it disappears right away rather than waiting for the next snapshot.
-			var toRemove []donburi.Entity
-			esync.NetworkEntityQuery.Each(e.World, func(entry *donburi.Entry) {
-				if !entry.HasComponent(netcomponents.NetBoomerang) {
-					return
+				if !predicted(evt.OwnerNetworkID) || !prediction.ConfirmCatch(e) {
+					PlaySFX(e, cfg.SoundBoomerangCatch)
 				}
-				nb := netcomponents.NetBoomerang.Get(entry)
-				if nb.OwnerNetworkID == evt.OwnerNetworkID {
-					toRemove = append(toRemove, entry.Entity())
+
+				// Immediately remove the boomerang entity from the client world
+				// so it disappears right away rather than waiting for the next snapshot.
+				var toRemove []donburi.Entity
+				esync.NetworkEntityQuery.Each(e.World, func(entry *donburi.Entry) {
+					if !entry.HasComponent(netcomponents.NetBoomerang) {
+						return
+					}
+					nb := netcomponents.NetBoomerang.Get(entry)
+					if nb.OwnerNetworkID == evt.OwnerNetworkID {
+						toRemove = append(toRemove, entry.Entity())
+					}
+				})
+				for _, entity := range toRemove {
+					e.World.Remove(entity)
 				}
-			})
-			for _, entity := range toRemove {
-				e.World.Remove(entity)
-			}
-		}
 
-		// Hit events: play impact SFX, spawn explosion VFX, screen shake
-		for _, evt := range client.DrainHitEvents() {
-			PlaySFX(e, cfg.SoundBoomerangImpact)
-			explosionScale := 0.5 + evt.ChargeRatio*0.5
-			factory.SpawnExplosion(e, evt.HitX, evt.HitY, explosionScale)
-			TriggerScreenShake(e, cfg.ScreenShake.BoomerangIntensity, cfg.ScreenShake.BoomerangDuration)
+			case messages.BoomerangHitEvent:
+				// Play impact SFX, spawn explosion VFX, screen shake
+				PlaySFX(e, cfg.SoundBoomerangImpact)
+				explosionScale := 0.5 + evt.ChargeRatio*0.5
+				factory.SpawnExplosion(e, evt.HitX, evt.HitY, explosionScale)
+				TriggerScreenShake(e, cfg.ScreenShake.BoomerangIntensity, cfg.ScreenShake.BoomerangDuration)
+				feed.AddDamage(evt.HitX, evt.HitY, evt.Damage)
+			}
 		}
 
 		// Clean up stale charge VFX entries (owner disconnected, etc.)
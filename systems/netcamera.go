@@ -2,78 +2,174 @@ package systems
 
 import (
 	"math"
+	"slices"
 
 	"github.com/automoto/doomerang-mp/components"
 	"github.com/automoto/doomerang-mp/config"
 	"github.com/automoto/doomerang-mp/shared/netcomponents"
 	"github.com/leap-fish/necs/esync"
+	"github.com/yohamta/donburi"
 	"github.com/yohamta/donburi/ecs"
 )
 
+// spectatorPanSpeed is how far the free spectator camera moves per tick.
+const spectatorPanSpeed = 6.0
+
 // NewNetCameraSystem returns an update system that follows the local player
 // in networked mode. It reads NetPosition instead of components.Object.
 func NewNetCameraSystem(localNetID func() esync.NetworkId) func(*ecs.ECS) {
 	return func(e *ecs.ECS) {
-		cameraEntry, ok := components.Camera.First(e.World)
+		pos, ok := netPlayerPosition(e.World, localNetID())
 		if !ok {
 			return
 		}
-		camera := components.Camera.Get(cameraEntry)
+		moveNetCamera(e, pos.X, pos.Y)
+	}
+}
 
-		levelEntry, ok := components.Level.First(e.World)
-		if !ok {
+// SpectatorCamera is the view state of a client watching without a body.
+// It either follows one player or flies freely.
+type SpectatorCamera struct {
+	Free   bool
+	Target esync.NetworkId
+	X, Y   float64
+}
+
+// NewNetSpectatorCameraSystem returns an update system that drives the
+// camera while spectating() is true, on top of NewNetCameraSystem's
+// clamping and smoothing. Boomerang toggles free/follow; in follow mode
+// left/right cycle the followed player, in free mode the movement keys pan.
+func NewNetSpectatorCameraSystem(spectating func() bool, sc *SpectatorCamera) func(*ecs.ECS) {
+	bindings := config.ControlSchemeBindings[config.ControlSchemeB]
+
+	return func(e *ecs.ECS) {
+		if !spectating() {
 			return
 		}
-		levelData := components.Level.Get(levelEntry)
-		if levelData.CurrentLevel == nil {
+
+		if anyKeyJustPressed(bindings[config.ActionBoomerang]) {
+			sc.Free = !sc.Free
+			if cameraEntry, ok := components.Camera.First(e.World); ok {
+				camera := components.Camera.Get(cameraEntry)
+				sc.X, sc.Y = camera.Position.X, camera.Position.Y
+			}
+		}
+
+		if sc.Free {
+			if anyKeyPressed(bindings[config.ActionMoveLeft]) {
+				sc.X -= spectatorPanSpeed
+			}
+			if anyKeyPressed(bindings[config.ActionMoveRight]) {
+				sc.X += spectatorPanSpeed
+			}
+			if anyKeyPressed(bindings[config.ActionMoveUp]) {
+				sc.Y -= spectatorPanSpeed
+			}
+			if anyKeyPressed(bindings[config.ActionCrouch]) {
+				sc.Y += spectatorPanSpeed
+			}
+			sc.X, sc.Y = moveNetCamera(e, sc.X, sc.Y)
 			return
 		}
 
-		// Find local player entity
-		entity := esync.FindByNetworkId(e.World, localNetID())
-		if !e.World.Valid(entity) {
+		players := spectatablePlayers(e.World)
+		if len(players) == 0 {
 			return
 		}
-		entry := e.World.Entry(entity)
-		if !entry.HasComponent(netcomponents.NetPosition) {
+		idx := slices.Index(players, sc.Target)
+		switch {
+		case idx < 0:
+			idx = 0
+		case anyKeyJustPressed(bindings[config.ActionMoveRight]):
+			idx = (idx + 1) % len(players)
+		case anyKeyJustPressed(bindings[config.ActionMoveLeft]):
+			idx = (idx + len(players) - 1) % len(players)
+		}
+		sc.Target = players[idx]
+
+		if pos, ok := netPlayerPosition(e.World, sc.Target); ok {
+			moveNetCamera(e, pos.X, pos.Y)
+		}
+	}
+}
+
+// spectatablePlayers returns the network IDs of every player body, sorted
+// so cycling order is stable between snapshots.
+func spectatablePlayers(world donburi.World) []esync.NetworkId {
+	var ids []esync.NetworkId
+	esync.NetworkEntityQuery.Each(world, func(entry *donburi.Entry) {
+		if !entry.HasComponent(netcomponents.NetPlayerState) {
 			return
 		}
+		if id := esync.GetNetworkId(entry); id != nil {
+			ids = append(ids, *id)
+		}
+	})
+	slices.Sort(ids)
+	return ids
+}
 
-		pos := netcomponents.NetPosition.Get(entry)
-		targetX := pos.X
-		targetY := pos.Y
+func netPlayerPosition(world donburi.World, id esync.NetworkId) (*netcomponents.NetPositionData, bool) {
+	entity := esync.FindByNetworkId(world, id)
+	if !world.Valid(entity) {
+		return nil, false
+	}
+	entry := world.Entry(entity)
+	if !entry.HasComponent(netcomponents.NetPosition) {
+		return nil, false
+	}
+	return netcomponents.NetPosition.Get(entry), true
+}
 
-		// Clamp to level bounds
-		screenW := float64(config.C.Width)
-		screenH := float64(config.C.Height)
+// moveNetCamera eases the camera towards (targetX, targetY), clamped to
+// the level bounds, and returns the clamped target.
+func moveNetCamera(e *ecs.ECS, targetX, targetY float64) (float64, float64) {
+	cameraEntry, ok := components.Camera.First(e.World)
+	if !ok {
+		return targetX, targetY
+	}
+	camera := components.Camera.Get(cameraEntry)
 
-		zoom := camera.Zoom
-		if zoom == 0 {
-			zoom = 1.0
-		}
+	levelEntry, ok := components.Level.First(e.World)
+	if !ok {
+		return targetX, targetY
+	}
+	levelData := components.Level.Get(levelEntry)
+	if levelData.CurrentLevel == nil {
+		return targetX, targetY
+	}
 
-		visibleW := screenW / zoom
-		visibleH := screenH / zoom
+	// Clamp to level bounds
+	screenW := float64(config.C.Width)
+	screenH := float64(config.C.Height)
 
-		minCameraX := visibleW / 2
-		maxCameraX := float64(levelData.CurrentLevel.Width) - visibleW/2
-		minCameraY := visibleH / 2
-		maxCameraY := float64(levelData.CurrentLevel.Height) - visibleH/2
+	zoom := camera.Zoom
+	if zoom == 0 {
+		zoom = 1.0
+	}
 
-		if minCameraX > maxCameraX {
-			minCameraX = float64(levelData.CurrentLevel.Width) / 2
-			maxCameraX = minCameraX
-		}
-		if minCameraY > maxCameraY {
-			minCameraY = float64(levelData.CurrentLevel.Height) / 2
-			maxCameraY = minCameraY
-		}
+	visibleW := screenW / zoom
+	visibleH := screenH / zoom
 
-		targetX = math.Max(minCameraX, math.Min(maxCameraX, targetX))
-		targetY = math.Max(minCameraY, math.Min(maxCameraY, targetY))
+	minCameraX := visibleW / 2
+	maxCameraX := float64(levelData.CurrentLevel.Width) - visibleW/2
+	minCameraY := visibleH / 2
+	maxCameraY := float64(levelData.CurrentLevel.Height) - visibleH/2
 
-		// Smooth follow
-		camera.Position.X += (targetX - camera.Position.X) * config.Camera.FollowSmoothing
-		camera.Position.Y += (targetY - camera.Position.Y) * config.Camera.FollowSmoothing
+	if minCameraX > maxCameraX {
+		minCameraX = float64(levelData.CurrentLevel.Width) / 2
+		maxCameraX = minCameraX
+	}
+	if minCameraY > maxCameraY {
+		minCameraY = float64(levelData.CurrentLevel.Height) / 2
+		maxCameraY = minCameraY
 	}
+
+	targetX = math.Max(minCameraX, math.Min(maxCameraX, targetX))
+	targetY = math.Max(minCameraY, math.Min(maxCameraY, targetY))
+
+	// Smooth follow
+	camera.Position.X += (targetX - camera.Position.X) * config.Camera.FollowSmoothing
+	camera.Position.Y += (targetY - camera.Position.Y) * config.Camera.FollowSmoothing
+	return targetX, targetY
 }
@@ -4,49 +4,82 @@ import (
 	"github.com/automoto/doomerang-mp/components"
 	cfg "github.com/automoto/doomerang-mp/config"
 	"github.com/automoto/doomerang-mp/network"
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
 	"github.com/automoto/doomerang-mp/systems/factory"
 	"github.com/leap-fish/necs/esync"
 	"github.com/yohamta/donburi/ecs"
 )
 
-// NewNetCombatEventSystem returns an ECS system that drains melee combat,
-// death, and respawn events from the network client and triggers VFX/SFX.
-func NewNetCombatEventSystem(client *network.Client) func(*ecs.ECS) {
+// NewNetCombatEventSystem returns an ECS system that handles this frame's
+// melee combat, hazard, death, and respawn events and triggers VFX/SFX.
+// The local player's predicted attacks are confirmed instead of replayed.
+// KOs and damage go to feed, which it ages each tick.
+func NewNetCombatEventSystem(client *network.Client, events *NetEvents, prediction *NetPrediction, feed *KillFeed) func(*ecs.ECS) {
 	return func(e *ecs.ECS) {
-		// Attack initiation events: play punch/kick SFX
-		for _, evt := range client.DrainMeleeAttackEvents() {
-			if evt.IsPunch {
-				PlaySFX(e, cfg.SoundPunch)
-			} else {
-				PlaySFX(e, cfg.SoundKick)
-			}
-		}
+		for _, evt := range events.Events() {
+			switch evt := evt.(type) {
+			case messages.MeleeAttackEvent:
+				// Attack initiation: play punch/kick SFX
+				localID := client.NetworkID()
+				if prediction != nil && localID != 0 && evt.AttackerNetworkID == uint(localID) {
+					var state *netcomponents.NetPlayerStateData
+					if entity := esync.FindByNetworkId(e.World, localID); e.World.Valid(entity) {
+						if entry := e.World.Entry(entity); entry.HasComponent(netcomponents.NetPlayerState) {
+							state = netcomponents.NetPlayerState.Get(entry)
+						}
+					}
+					if prediction.ConfirmAttack(evt, state) {
+						continue
+					}
+				}
+				if evt.IsPunch {
+					PlaySFX(e, cfg.SoundPunch)
+				} else {
+					PlaySFX(e, cfg.SoundKick)
+				}
 
-		// Melee hit events
-		for _, evt := range client.DrainMeleeHitEvents() {
-			PlaySFX(e, cfg.SoundHit)
-			factory.SpawnHitExplosion(e, evt.HitX, evt.HitY, 0.6)
-			TriggerScreenShake(e, cfg.ScreenShake.MeleeIntensity, cfg.ScreenShake.MeleeDuration)
-
-			// Flash the target player
-			targetEntity := esync.FindByNetworkId(e.World, esync.NetworkId(evt.TargetNetworkID))
-			if e.World.Valid(targetEntity) {
-				targetEntry := e.World.Entry(targetEntity)
-				if targetEntry.HasComponent(components.Flash) {
-					TriggerDamageFlash(targetEntry)
+			case messages.MeleeHitEvent:
+				PlaySFX(e, cfg.SoundHit)
+				factory.SpawnHitExplosion(e, evt.HitX, evt.HitY, 0.6)
+				TriggerScreenShake(e, cfg.ScreenShake.MeleeIntensity, cfg.ScreenShake.MeleeDuration)
+				feed.AddDamage(evt.HitX, evt.HitY, evt.Damage)
+
+				// Flash the target player
+				targetEntity := esync.FindByNetworkId(e.World, esync.NetworkId(evt.TargetNetworkID))
+				if e.World.Valid(targetEntity) {
+					targetEntry := e.World.Entry(targetEntity)
+					if targetEntry.HasComponent(components.Flash) {
+						TriggerDamageFlash(targetEntry)
+					}
 				}
-			}
-		}
 
-		// Death events
-		for range client.DrainDeathEvents() {
-			PlaySFX(e, cfg.SoundDeath)
-			TriggerScreenShake(e, cfg.ScreenShake.PlayerDamageIntensity, cfg.ScreenShake.PlayerDamageDuration)
-		}
+			case messages.HazardHitEvent:
+				// Fire burns and enemy knives
+				PlaySFX(e, cfg.SoundHit)
+
+				targetEntity := esync.FindByNetworkId(e.World, esync.NetworkId(evt.TargetNetworkID))
+				if e.World.Valid(targetEntity) {
+					targetEntry := e.World.Entry(targetEntity)
+					if targetEntry.HasComponent(components.Flash) {
+						TriggerDamageFlash(targetEntry)
+					}
+					if targetEntry.HasComponent(netcomponents.NetPosition) {
+						pos := netcomponents.NetPosition.Get(targetEntry)
+						feed.AddDamage(pos.X+float64(cfg.Player.CollisionWidth)/2, pos.Y, evt.Damage)
+					}
+				}
 
-		// Respawn events
-		for _, evt := range client.DrainRespawnEvents() {
-			factory.SpawnExplosion(e, evt.X, evt.Y, 0.8)
+			case messages.DeathEvent:
+				PlaySFX(e, cfg.SoundDeath)
+				TriggerScreenShake(e, cfg.ScreenShake.PlayerDamageIntensity, cfg.ScreenShake.PlayerDamageDuration)
+				feed.AddKO(e, evt)
+
+			case messages.RespawnEvent:
+				factory.SpawnExplosion(e, evt.X, evt.Y, 0.8)
+			}
 		}
+
+		feed.Update()
 	}
 }
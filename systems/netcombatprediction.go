@@ -0,0 +1,368 @@
+package systems
+
+import (
+	"math"
+
+	"github.com/automoto/doomerang-mp/assets"
+	"github.com/automoto/doomerang-mp/components"
+	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/gamemath"
+	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
+	"github.com/automoto/doomerang-mp/shared/netconfig"
+	"github.com/automoto/doomerang-mp/shared/playersim"
+	"github.com/automoto/doomerang-mp/systems/factory"
+	"github.com/automoto/doomerang-mp/tags"
+	"github.com/leap-fish/necs/esync"
+	"github.com/solarlune/resolv"
+	"github.com/yohamta/donburi"
+	"github.com/yohamta/donburi/ecs"
+)
+
+// predictedAttack is a melee attack the local player started before the
+// server's MeleeAttackEvent for it arrived.
+type predictedAttack struct {
+	seq       uint32
+	state     netconfig.StateID
+	comboStep int // ComboStep before the attack, restored on rollback
+}
+
+// PredictedBoomerang is the local player's boomerang, thrown and flown on
+// the client until the server catches it or turns out never to have
+// thrown it. The server's own boomerang entity is hidden meanwhile.
+type PredictedBoomerang struct {
+	Seq       uint32 // input that released the throw
+	Confirmed bool   // the server's BoomerangThrowEvent has arrived
+	Caught    bool   // caught locally; waiting for the server's catch
+
+	entry    *donburi.Entry // NetBoomerang + Sprite, not network-synced
+	object   *resolv.Object
+	maxRange float64
+}
+
+// predictMelee mirrors the server's processMeleeAttack for one step: the
+// attack state is shown and its SFX played on the frame of the press.
+func (p *NetPrediction) predictMelee(e *ecs.ECS, input messages.PlayerInput, state *netcomponents.NetPlayerStateData) {
+	pressed := input.Actions.Has(netconfig.ActionAttack)
+	if pressed && !p.AttackWasPressed && p.AttackFrame == 0 {
+		attack := predictedAttack{seq: input.Sequence, comboStep: p.ComboStep}
+		p.AttackFrame = 1
+		p.AttackIsJumpKick = p.Body.OnGround == nil
+		switch {
+		case p.AttackIsJumpKick:
+			attack.state = netconfig.StateAttackingJump
+		case p.ComboStep == 0:
+			attack.state = netconfig.StateAttackingPunch
+			p.ComboStep = 1
+		default:
+			attack.state = netconfig.StateAttackingKick
+			p.ComboStep = 0
+		}
+		state.StateID = attack.state
+		p.pendingAttacks = append(p.pendingAttacks, attack)
+
+		if attack.state == netconfig.StateAttackingPunch {
+			PlaySFX(e, cfg.SoundPunch)
+		} else {
+			PlaySFX(e, cfg.SoundKick)
+		}
+	}
+	p.AttackWasPressed = pressed
+
+	if p.AttackFrame == 0 {
+		return
+	}
+	p.AttackFrame++
+	hitEnd := netconfig.MeleeHitboxEnd
+	if p.AttackIsJumpKick {
+		hitEnd = netconfig.JumpKickHitboxEnd
+	}
+	if p.AttackFrame > hitEnd+2 {
+		p.AttackFrame = 0
+		p.AttackIsJumpKick = false
+		p.ComboStep = 0
+	}
+}
+
+// predictBoomerang mirrors the server's processBoomerangCharge for one
+// step: charging, the charge VFX, and the throw on release.
+func (p *NetPrediction) predictBoomerang(e *ecs.ECS, input messages.PlayerInput, pos *netcomponents.NetPositionData, state *netcomponents.NetPlayerStateData) {
+	pressed := input.Actions.Has(netconfig.ActionBoomerang)
+	if p.Boomerang != nil {
+		p.BoomerangWasPressed = pressed
+		return
+	}
+
+	if pressed && !p.BoomerangWasPressed {
+		p.BoomerangCharging = true
+		p.BoomerangChargeTime = 0
+	}
+
+	if p.BoomerangCharging && pressed {
+		p.BoomerangChargeTime = min(p.BoomerangChargeTime+1, cfg.Boomerang.MaxChargeTime)
+		state.StateID = netconfig.StateChargingBoomerang
+
+		if p.BoomerangChargeTime == netconfig.BoomerangChargeVFXFrame {
+			PlaySFX(e, cfg.SoundBoomerangCharge)
+			w, h := float64(cfg.Player.CollisionWidth), float64(cfg.Player.CollisionHeight)
+			p.chargeVFX = factory.SpawnChargeVFX(e, pos.X+w/2, pos.Y+h)
+		}
+	}
+
+	if p.BoomerangCharging && !pressed {
+		p.throwBoomerang(e, input, pos, state)
+		p.BoomerangCharging = false
+		p.BoomerangChargeTime = 0
+	}
+
+	p.BoomerangWasPressed = pressed
+}
+
+// throwBoomerang spawns the predicted boomerang exactly as the server's
+// throwBoomerang does.
+func (p *NetPrediction) throwBoomerang(e *ecs.ECS, input messages.PlayerInput, pos *netcomponents.NetPositionData, state *netcomponents.NetPlayerStateData) {
+	chargeRatio := float64(p.BoomerangChargeTime) / float64(cfg.Boomerang.MaxChargeTime)
+
+	facingX := 1.0
+	if state.Direction < 0 {
+		facingX = -1.0
+	}
+	aimX, aimY := gamemath.CalculateAimDirection(facingX,
+		input.Actions.Has(netconfig.ActionMoveUp), input.Actions.Has(netconfig.ActionCrouch), input.Direction != 0)
+	if mag := math.Sqrt(aimX*aimX + aimY*aimY); mag > 0 {
+		aimX /= mag
+		aimY /= mag
+	}
+
+	w, h := float64(cfg.Player.CollisionWidth), float64(cfg.Player.CollisionHeight)
+	spawnX := pos.X + w/2 + facingX*10 - 6 // center the 12x12 boomerang
+	spawnY := pos.Y + h/2 - 6
+
+	speed := gamemath.CalculateThrowSpeed(cfg.Boomerang.ThrowSpeed, chargeRatio)
+	velX, velY := gamemath.CalculateThrowVelocity(aimX, aimY, speed, cfg.Boomerang.ThrowLift)
+
+	entry := e.World.Entry(e.World.Create(netcomponents.NetBoomerang, components.Sprite))
+	netcomponents.NetBoomerang.SetValue(entry, netcomponents.NetBoomerangData{
+		X:           spawnX,
+		Y:           spawnY,
+		VelX:        velX,
+		VelY:        velY,
+		State:       netconfig.BoomerangOutbound,
+		ChargeRatio: chargeRatio,
+	})
+	components.Sprite.SetValue(entry, components.SpriteData{Image: assets.GetObjectImage("boom_green.png")})
+
+	b := &PredictedBoomerang{
+		Seq:      input.Sequence,
+		entry:    entry,
+		maxRange: gamemath.CalculateMaxRange(cfg.Boomerang.BaseRange, cfg.Boomerang.MaxChargeRange, chargeRatio),
+	}
+	if p.Space != nil {
+		b.object = resolv.NewObject(spawnX, spawnY, 12, 12, tags.ResolvBoomerang)
+		b.object.SetShape(resolv.NewRectangle(0, 0, 12, 12))
+		p.Space.Add(b.object)
+	}
+	p.Boomerang = b
+
+	state.StateID = netconfig.Throw
+
+	factory.DestroyChargeVFX(e, p.chargeVFX)
+	p.chargeVFX = nil
+	PlaySFX(e, cfg.SoundBoomerangThrow)
+	factory.SpawnGunshot(e, spawnX, spawnY, aimX)
+}
+
+// stepBoomerang flies the predicted boomerang one step, mirroring the
+// server's stepBoomerangPhysics and checkBoomerangCollisions minus player
+// hits, which stay server-side.
+func (p *NetPrediction) stepBoomerang(e *ecs.ECS, pos *netcomponents.NetPositionData) {
+	b := p.Boomerang
+	if b == nil || b.entry == nil {
+		return
+	}
+	nb := netcomponents.NetBoomerang.Get(b.entry)
+
+	w, h := float64(cfg.Player.CollisionWidth), float64(cfg.Player.CollisionHeight)
+	ownerX, ownerY := pos.X+w/2, pos.Y+h/2
+
+	switch nb.State {
+	case netconfig.BoomerangOutbound:
+		nb.VelY += cfg.Boomerang.Gravity
+		nb.DistanceTraveled += math.Sqrt(nb.VelX*nb.VelX + nb.VelY*nb.VelY)
+		if nb.DistanceTraveled >= b.maxRange {
+			nb.State = netconfig.BoomerangInbound
+		}
+	case netconfig.BoomerangInbound:
+		nb.VelX, nb.VelY = gamemath.CalculateHomingVelocity(nb.X+6, nb.Y+6, ownerX, ownerY, cfg.Boomerang.ReturnSpeed)
+	}
+	nb.X += nb.VelX
+	nb.Y += nb.VelY
+
+	if nb.State == netconfig.BoomerangInbound {
+		dx, dy := nb.X+6-ownerX, nb.Y+6-ownerY
+		if math.Sqrt(dx*dx+dy*dy) < cfg.Boomerang.CatchRadius {
+			PlaySFX(e, cfg.SoundBoomerangCatch)
+			b.Caught = true
+			p.removeBoomerangEntity(e)
+			return
+		}
+	}
+
+	if b.object != nil {
+		b.object.X, b.object.Y = nb.X, nb.Y
+		b.object.Update()
+		if nb.State == netconfig.BoomerangOutbound {
+			if check := b.object.Check(0, 0, tags.ResolvSolid); check != nil && len(check.ObjectsByTags(tags.ResolvSolid)) > 0 {
+				nb.State = netconfig.BoomerangInbound
+			}
+		}
+	}
+}
+
+// removeBoomerangEntity removes the predicted boomerang from the world,
+// keeping the record until the server is done with its own.
+func (p *NetPrediction) removeBoomerangEntity(e *ecs.ECS) {
+	b := p.Boomerang
+	if b.entry != nil && b.entry.Valid() {
+		e.World.Remove(b.entry.Entity())
+	}
+	b.entry = nil
+	if b.object != nil && p.Space != nil {
+		p.Space.Remove(b.object)
+	}
+	b.object = nil
+}
+
+// endBoomerang forgets the predicted boomerang, letting the player throw
+// again.
+func (p *NetPrediction) endBoomerang(e *ecs.ECS) {
+	if p.Boomerang == nil {
+		return
+	}
+	p.removeBoomerangEntity(e)
+	p.Boomerang = nil
+}
+
+// ConfirmAttack matches a server MeleeAttackEvent for the local player to
+// the oldest predicted attack it covers, correcting the attack kind if the
+// prediction guessed wrong. It returns false for attacks that were never
+// predicted, whose SFX has not played yet.
+func (p *NetPrediction) ConfirmAttack(evt messages.MeleeAttackEvent, state *netcomponents.NetPlayerStateData) bool {
+	if len(p.pendingAttacks) == 0 || p.pendingAttacks[0].seq > evt.Sequence {
+		return false
+	}
+	attack := p.pendingAttacks[0]
+	p.pendingAttacks = p.pendingAttacks[1:]
+
+	want := netconfig.StateAttackingKick
+	switch {
+	case evt.IsJumpKick:
+		want = netconfig.StateAttackingJump
+	case evt.IsPunch:
+		want = netconfig.StateAttackingPunch
+	}
+	if want == attack.state {
+		return true
+	}
+	p.AttackIsJumpKick = evt.IsJumpKick
+	if !evt.IsJumpKick {
+		p.ComboStep = 0
+		if evt.IsPunch {
+			p.ComboStep = 1
+		}
+	}
+	if state != nil && state.StateID == attack.state {
+		state.StateID = want
+	}
+	return true
+}
+
+// ConfirmThrow marks the predicted boomerang as thrown by the server. It
+// returns false for throws that were never predicted, whose effects have
+// not played yet.
+func (p *NetPrediction) ConfirmThrow(evt messages.BoomerangThrowEvent) bool {
+	b := p.Boomerang
+	if b == nil || b.Confirmed || b.Seq > evt.Sequence {
+		return false
+	}
+	b.Confirmed = true
+	return true
+}
+
+// ConfirmCatch ends the predicted boomerang when the server catches the
+// local player's boomerang. It returns true if the catch was already
+// predicted, so its SFX has played.
+func (p *NetPrediction) ConfirmCatch(e *ecs.ECS) bool {
+	caught := p.Boomerang != nil && p.Boomerang.Caught
+	p.endBoomerang(e)
+	return caught
+}
+
+// movementState derives the local player's animation state from predicted
+// movement, as the server does.
+func (p *NetPrediction) movementState() netconfig.StateID {
+	return playersim.MovementState(&p.Body, &p.Movement)
+}
+
+// NewNetCombatPredictionSystem returns a system that rolls back predicted
+// attacks and throws the server has processed the input for without
+// confirming. The server sends an action's event before the snapshot
+// acknowledging its input, so it must run after the event systems.
+func NewNetCombatPredictionSystem(prediction *NetPrediction, localNetID func() esync.NetworkId) func(*ecs.ECS) {
+	return func(e *ecs.ECS) {
+		localID := localNetID()
+		if prediction == nil || localID == 0 {
+			return
+		}
+		var state *netcomponents.NetPlayerStateData
+		if entity := esync.FindByNetworkId(e.World, localID); e.World.Valid(entity) {
+			if entry := e.World.Entry(entity); entry.HasComponent(netcomponents.NetPlayerState) {
+				state = netcomponents.NetPlayerState.Get(entry)
+			}
+		}
+		acked := prediction.Buffer.Acked()
+
+		for len(prediction.pendingAttacks) > 0 && prediction.pendingAttacks[0].seq <= acked {
+			attack := prediction.pendingAttacks[0]
+			prediction.pendingAttacks = prediction.pendingAttacks[1:]
+			prediction.AttackFrame = 0
+			prediction.AttackIsJumpKick = false
+			prediction.ComboStep = attack.comboStep
+			if state != nil && state.StateID == attack.state {
+				state.StateID = prediction.movementState()
+			}
+		}
+
+		b := prediction.Boomerang
+		if b == nil {
+			return
+		}
+		var serverBoomerang *donburi.Entry
+		esync.NetworkEntityQuery.Each(e.World, func(entry *donburi.Entry) {
+			if entry.HasComponent(netcomponents.NetBoomerang) &&
+				netcomponents.NetBoomerang.Get(entry).OwnerNetworkID == uint(localID) {
+				serverBoomerang = entry
+			}
+		})
+
+		switch {
+		case b.Seq > acked:
+			// Server hasn't processed the throw yet
+		case !b.Confirmed:
+			prediction.endBoomerang(e)
+			if state != nil && state.StateID == netconfig.Throw {
+				state.StateID = prediction.movementState()
+			}
+			return
+		case serverBoomerang == nil:
+			// Destroyed without a catch, e.g. when the room was cleared
+			prediction.endBoomerang(e)
+			return
+		}
+
+		// The predicted boomerang is drawn instead of the server's
+		if serverBoomerang != nil && serverBoomerang.HasComponent(components.Sprite) {
+			components.Sprite.Get(serverBoomerang).Image = nil
+		}
+	}
+}
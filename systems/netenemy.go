@@ -0,0 +1,152 @@
+package systems
+
+import (
+	"image"
+	"math"
+
+	"github.com/automoto/doomerang-mp/components"
+	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/yohamta/donburi"
+	"github.com/yohamta/donburi/ecs"
+)
+
+// UpdateNetEnemies advances animation frames for networked co-op enemies
+// and switches animation state based on NetEnemy.State.
+func UpdateNetEnemies(e *ecs.ECS) {
+	netcomponents.NetEnemy.Each(e.World, func(entry *donburi.Entry) {
+		if !entry.HasComponent(components.Animation) {
+			return
+		}
+
+		ne := netcomponents.NetEnemy.Get(entry)
+		animData := components.Animation.Get(entry)
+
+		animData.SetAnimation(cfg.StateID(ne.State))
+
+		if animData.CurrentAnimation != nil {
+			animData.CurrentAnimation.Update()
+		}
+	})
+}
+
+// DrawNetworkedEnemies renders networked co-op enemies, tinted by type —
+// mirrors the enemy branch of DrawAnimated.
+func DrawNetworkedEnemies(e *ecs.ECS, screen *ebiten.Image) {
+	cameraEntry, ok := components.Camera.First(e.World)
+	if !ok {
+		return
+	}
+	camera := components.Camera.Get(cameraEntry)
+	screenW := float64(screen.Bounds().Dx())
+	screenH := float64(screen.Bounds().Dy())
+
+	zoom := camera.Zoom
+	if zoom == 0 {
+		zoom = 1.0
+	}
+
+	netcomponents.NetEnemy.Each(e.World, func(entry *donburi.Entry) {
+		if !entry.HasComponent(components.Animation) {
+			return
+		}
+		animData := components.Animation.Get(entry)
+		if animData.CurrentAnimation == nil {
+			return
+		}
+		frame := animData.CurrentAnimation.Frame()
+		img := animData.CachedFrames[animData.CurrentSheet][frame]
+
+		// Fallback to runtime slicing
+		if img == nil {
+			sheet := animData.SpriteSheets[animData.CurrentSheet]
+			if sheet == nil {
+				return
+			}
+			sx := frame * animData.FrameWidth
+			img = sheet.SubImage(image.Rect(sx, 0, sx+animData.FrameWidth, animData.FrameHeight)).(*ebiten.Image)
+			if animData.CachedFrames[animData.CurrentSheet] == nil {
+				animData.CachedFrames[animData.CurrentSheet] = make(map[int]*ebiten.Image)
+			}
+			animData.CachedFrames[animData.CurrentSheet][frame] = img
+		}
+
+		ne := netcomponents.NetEnemy.Get(entry)
+		typeCfg, ok := cfg.Enemy.Types[ne.TypeName]
+		if !ok {
+			typeCfg = cfg.Enemy.Types["Guard"]
+		}
+
+		drawOp.GeoM.Reset()
+		drawOp.ColorScale.Reset()
+
+		// Bottom-center anchor (feet at collision box bottom-center)
+		drawOp.GeoM.Translate(-float64(animData.FrameWidth)/2, -float64(animData.FrameHeight))
+		if ne.Direction < 0 {
+			drawOp.GeoM.Scale(-1, 1)
+		}
+		drawOp.GeoM.Translate(ne.X+float64(typeCfg.CollisionWidth)/2, ne.Y+float64(typeCfg.CollisionHeight))
+
+		// Camera transform
+		drawOp.GeoM.Translate(-camera.Position.X, -camera.Position.Y)
+		drawOp.GeoM.Scale(zoom, zoom)
+		drawOp.GeoM.Translate(screenW/2, screenH/2)
+
+		// Damage flash overrides the type tint
+		flash := components.Flash.Get(entry)
+		if flash.Duration > 0 {
+			drawOp.ColorScale.Scale(flash.R, flash.G, flash.B, 1)
+			flash.Duration--
+		} else {
+			tint := typeCfg.TintColor
+			drawOp.ColorScale.Scale(float32(tint.R)/255, float32(tint.G)/255, float32(tint.B)/255, float32(tint.A)/255)
+		}
+
+		screen.DrawImage(img, drawOp)
+	})
+}
+
+// DrawNetworkedKnives renders networked knives, pointing the way they fly.
+func DrawNetworkedKnives(e *ecs.ECS, screen *ebiten.Image) {
+	cameraEntry, ok := components.Camera.First(e.World)
+	if !ok {
+		return
+	}
+	camera := components.Camera.Get(cameraEntry)
+	screenW := float64(screen.Bounds().Dx())
+	screenH := float64(screen.Bounds().Dy())
+
+	zoom := camera.Zoom
+	if zoom == 0 {
+		zoom = 1.0
+	}
+
+	netcomponents.NetKnife.Each(e.World, func(entry *donburi.Entry) {
+		if !entry.HasComponent(components.Sprite) {
+			return
+		}
+		img := components.Sprite.Get(entry).Image
+		if img == nil {
+			return
+		}
+		nk := netcomponents.NetKnife.Get(entry)
+
+		drawOp.GeoM.Reset()
+		drawOp.ColorScale.Reset()
+
+		// Center pivot, rotated along the flight path
+		w := float64(img.Bounds().Dx())
+		h := float64(img.Bounds().Dy())
+		drawOp.GeoM.Translate(-w/2, -h/2)
+		drawOp.GeoM.Rotate(math.Atan2(nk.VelY, nk.VelX))
+		drawOp.GeoM.Translate(nk.X+cfg.Knife.Width/2, nk.Y+cfg.Knife.Height/2)
+
+		// Camera transform
+		drawOp.GeoM.Translate(-camera.Position.X, -camera.Position.Y)
+		drawOp.GeoM.Scale(zoom, zoom)
+		drawOp.GeoM.Translate(screenW/2, screenH/2)
+
+		screen.DrawImage(img, drawOp)
+	})
+}
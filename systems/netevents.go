@@ -0,0 +1,27 @@
+package systems
+
+import (
+	"github.com/automoto/doomerang-mp/network"
+	"github.com/yohamta/donburi/ecs"
+)
+
+// NetEvents holds the server events received this frame, in the order the
+// server sent them, for every networked system that reacts to events.
+type NetEvents struct {
+	events []any
+}
+
+// Events returns this frame's events. Each is a message value such as a
+// messages.DeathEvent; systems pick out the types they handle.
+func (n *NetEvents) Events() []any {
+	return n.events
+}
+
+// NewNetEventSystem returns an ECS system that drains the network client's
+// events into events once a frame. It must run before the systems that
+// read them.
+func NewNetEventSystem(client *network.Client, events *NetEvents) func(*ecs.ECS) {
+	return func(_ *ecs.ECS) {
+		events.events = client.DrainEvents()
+	}
+}
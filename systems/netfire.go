@@ -0,0 +1,29 @@
+package systems
+
+import (
+	"github.com/automoto/doomerang-mp/components"
+	"github.com/automoto/doomerang-mp/shared/netcomponents"
+	"github.com/automoto/doomerang-mp/tags"
+	"github.com/yohamta/donburi"
+	"github.com/yohamta/donburi/ecs"
+)
+
+// UpdateNetFires puts each locally drawn fire on the animation frame the
+// server last reported for it, matched by origin. Fires only animate
+// from snapshots online, so a burst is on screen exactly when it hurts.
+func UpdateNetFires(e *ecs.ECS) {
+	netcomponents.NetFire.Each(e.World, func(netEntry *donburi.Entry) {
+		nf := netcomponents.NetFire.Get(netEntry)
+		tags.Fire.Each(e.World, func(entry *donburi.Entry) {
+			fire := components.Fire.Get(entry)
+			if fire.OriginX != nf.X || fire.OriginY != nf.Y {
+				return
+			}
+			anim := components.Animation.Get(entry)
+			if anim.CurrentAnimation != nil {
+				anim.CurrentAnimation.SetFrame(nf.Frame)
+			}
+			fire.Hitbox.SetFrame(nf.Frame)
+		})
+	})
+}
@@ -0,0 +1,171 @@
+package systems
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	cfg "github.com/automoto/doomerang-mp/config"
+	"github.com/automoto/doomerang-mp/fonts"
+	"github.com/automoto/doomerang-mp/network"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text" //nolint:staticcheck // TODO: migrate to text/v2
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/yohamta/donburi/ecs"
+)
+
+// netGraphSamples is how many samples each network graph plots, one
+// pixel apiece.
+const netGraphSamples = 120
+
+const (
+	netGraphHeight = 24
+	netGraphTop    = 40
+	netGraphGap    = 14 // Room for each graph's label
+)
+
+// graphSeries is a ring of the most recent samples of one quantity.
+type graphSeries struct {
+	values [netGraphSamples]float64
+	next   int
+	count  int
+}
+
+func (s *graphSeries) push(v float64) {
+	s.values[s.next] = v
+	s.next = (s.next + 1) % netGraphSamples
+	s.count = min(s.count+1, netGraphSamples)
+}
+
+// at returns the i'th oldest sample.
+func (s *graphSeries) at(i int) float64 {
+	return s.values[(s.next-s.count+i+netGraphSamples)%netGraphSamples]
+}
+
+func (s *graphSeries) latest() float64 {
+	if s.count == 0 {
+		return 0
+	}
+	return s.at(s.count - 1)
+}
+
+func (s *graphSeries) max() float64 {
+	peak := 0.0
+	for i := range s.count {
+		peak = max(peak, s.at(i))
+	}
+	return peak
+}
+
+// NetGraph records the connection's quality for the network graph
+// overlay. NewNetGraphSystem samples round-trip time and bandwidth once a
+// second; the networked scene adds snapshot arrivals and prediction
+// errors as it applies snapshots.
+type NetGraph struct {
+	jitter       time.Duration
+	rttMs        graphSeries
+	snapshotMs   graphSeries // Time between snapshot arrivals
+	predictionPx graphSeries // Local player's error against the server, per snapshot
+	inBytes      graphSeries // Bytes received per second
+	outBytes     graphSeries // Bytes sent per second
+
+	lastSnapshot time.Time
+	lastSample   time.Time
+	lastIn       uint64
+	lastOut      uint64
+}
+
+func NewNetGraph() *NetGraph {
+	return &NetGraph{}
+}
+
+// AddSnapshot records a snapshot that arrived at received.
+func (g *NetGraph) AddSnapshot(received time.Time) {
+	if !g.lastSnapshot.IsZero() {
+		g.snapshotMs.push(float64(received.Sub(g.lastSnapshot).Milliseconds()))
+	}
+	g.lastSnapshot = received
+}
+
+// AddPredictionError records how far, in pixels, the server put the local
+// player from where this client predicted.
+func (g *NetGraph) AddPredictionError(px float64) {
+	g.predictionPx.push(px)
+}
+
+// NewNetGraphSystem returns an ECS system that samples client into graph
+// once a second, and toggles the overlay with F3.
+func NewNetGraphSystem(client *network.Client, graph *NetGraph) func(*ecs.ECS) {
+	return func(_ *ecs.ECS) {
+		if inpututil.IsKeyJustPressed(ebiten.KeyF3) {
+			cfg.Debug.ShowNetworkGraph = !cfg.Debug.ShowNetworkGraph
+		}
+
+		now := time.Now()
+		if now.Sub(graph.lastSample) < time.Second {
+			return
+		}
+		in, out := client.BytesTransferred()
+		if !graph.lastSample.IsZero() {
+			secs := now.Sub(graph.lastSample).Seconds()
+			graph.inBytes.push(float64(in-graph.lastIn) / secs)
+			graph.outBytes.push(float64(out-graph.lastOut) / secs)
+		}
+		rtt, jitter := client.RTT()
+		graph.rttMs.push(float64(rtt.Milliseconds()))
+		graph.jitter = jitter
+		graph.lastSample, graph.lastIn, graph.lastOut = now, in, out
+	}
+}
+
+// NewNetGraphRenderer returns a renderer that draws graph's history down
+// the left of the screen while cfg.Debug.ShowNetworkGraph is on.
+func NewNetGraphRenderer(graph *NetGraph) func(*ecs.ECS, *ebiten.Image) {
+	return func(_ *ecs.ECS, screen *ebiten.Image) {
+		if !cfg.Debug.ShowNetworkGraph {
+			return
+		}
+		y := float32(netGraphTop)
+		panel := func(label string, floor float64, series ...graphSeriesStyle) {
+			drawNetGraph(screen, 4, y, label, floor, series)
+			y += netGraphHeight + netGraphGap
+		}
+
+		panel(fmt.Sprintf("RTT %.0fms  jitter %dms", graph.rttMs.latest(), graph.jitter.Milliseconds()),
+			100, graphSeriesStyle{&graph.rttMs, cfg.LightGreen})
+		panel(fmt.Sprintf("Snapshot interval %.0fms", graph.snapshotMs.latest()),
+			50, graphSeriesStyle{&graph.snapshotMs, cfg.BrightYellow})
+		panel(fmt.Sprintf("Prediction error %.1fpx", graph.predictionPx.latest()),
+			cfg.Netcode.SmoothThreshold*2, graphSeriesStyle{&graph.predictionPx, cfg.BrightOrange})
+		panel(fmt.Sprintf("In %.1fKB/s  Out %.1fKB/s", graph.inBytes.latest()/1024, graph.outBytes.latest()/1024),
+			1024, graphSeriesStyle{&graph.inBytes, cfg.LightGreen}, graphSeriesStyle{&graph.outBytes, cfg.LightRed})
+	}
+}
+
+type graphSeriesStyle struct {
+	series *graphSeries
+	color  color.RGBA
+}
+
+// drawNetGraph draws one labelled graph at x, y, scaled to its highest
+// sample or floor, whichever is larger.
+func drawNetGraph(screen *ebiten.Image, x, y float32, label string, floor float64, series []graphSeriesStyle) {
+	text.Draw(screen, label, fonts.ExcelSmall.Get(), int(x), int(y)-2, cfg.White)
+	vector.FillRect(screen, x, y, netGraphSamples, netGraphHeight, color.RGBA{0, 0, 0, 140}, false)
+
+	top := floor
+	for _, s := range series {
+		top = max(top, s.series.max())
+	}
+	for _, s := range series {
+		offset := netGraphSamples - s.series.count // Newest sample on the right
+		for i := 1; i < s.series.count; i++ {
+			x0 := x + float32(offset+i-1)
+			x1 := x + float32(offset+i)
+			y0 := y + netGraphHeight - float32(s.series.at(i-1)/top*netGraphHeight)
+			y1 := y + netGraphHeight - float32(s.series.at(i)/top*netGraphHeight)
+			vector.StrokeLine(screen, x0, y0, x1, y1, 1, s.color, false)
+		}
+	}
+}
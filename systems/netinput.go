@@ -2,37 +2,31 @@ package systems
 
 import (
 	"log"
-	"math"
-	"time"
 
 	cfg "github.com/automoto/doomerang-mp/config"
 	"github.com/automoto/doomerang-mp/shared/messages"
 	"github.com/automoto/doomerang-mp/shared/netcomponents"
 	"github.com/automoto/doomerang-mp/shared/netconfig"
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/leap-fish/necs/esync"
-	"github.com/yohamta/donburi"
 	"github.com/yohamta/donburi/ecs"
 )
 
-const resendInterval = 50 * time.Millisecond
+// inputRedundancy is how many earlier unacknowledged inputs ride along
+// with each InputFrame.
+const inputRedundancy = 4
 
 type netInputState struct {
-	seq            uint32
-	lastDirection  int
-	lastActions    map[netconfig.ActionID]bool
-	lastSendTime   time.Time
-	currentActions map[netconfig.ActionID]bool // reused each tick to avoid allocation
+	seq uint32
 }
 
 // NewNetworkInputSystem returns an ECS system that polls keyboard input,
-// applies it locally for prediction, and sends PlayerInput messages to the
-// server when the input state changes.
+// applies it locally for prediction, and sends it to the server as an
+// InputFrame every frame. The server plays inputs back one per physics step,
+// so each frame also carries the last few inputs it has not acknowledged.
 func NewNetworkInputSystem(sendFn func(any) error, prediction *NetPrediction, localNetID func() esync.NetworkId) func(*ecs.ECS) {
-	state := &netInputState{
-		lastActions:    make(map[netconfig.ActionID]bool),
-		currentActions: make(map[netconfig.ActionID]bool),
-	}
+	state := &netInputState{}
 
 	bindings := cfg.ControlSchemeBindings[cfg.ControlSchemeB]
 
@@ -46,97 +40,70 @@ func NewNetworkInputSystem(sendFn func(any) error, prediction *NetPrediction, lo
 			dir = 1
 		}
 
-		actions := state.currentActions
-		actions[netconfig.ActionJump] = anyKeyPressed(bindings[cfg.ActionJump])
-		actions[netconfig.ActionAttack] = anyKeyPressed(bindings[cfg.ActionAttack])
-		actions[netconfig.ActionBoomerang] = anyKeyPressed(bindings[cfg.ActionBoomerang])
-		actions[netconfig.ActionCrouch] = anyKeyPressed(bindings[cfg.ActionCrouch])
-		actions[netconfig.ActionMoveUp] = anyKeyPressed(bindings[cfg.ActionMoveUp])
-
-		changed := dir != state.lastDirection
-		if !changed {
-			for action, pressed := range actions {
-				if pressed != state.lastActions[action] {
-					changed = true
-					break
-				}
-			}
-		}
-
-		// Build the input message (needed for both prediction and sending)
+		// Build the input (needed for both prediction and sending)
 		state.seq++
 		input := messages.NewPlayerInput(state.seq)
 		input.Direction = dir
-		for k, v := range actions {
-			input.Actions[k] = v
-		}
-		input.Timestamp = time.Now().UnixMilli()
+		input.Actions.Set(netconfig.ActionJump, anyKeyPressed(bindings[cfg.ActionJump]))
+		input.Actions.Set(netconfig.ActionAttack, anyKeyPressed(bindings[cfg.ActionAttack]))
+		input.Actions.Set(netconfig.ActionBoomerang, anyKeyPressed(bindings[cfg.ActionBoomerang]))
+		input.Actions.Set(netconfig.ActionCrouch, anyKeyPressed(bindings[cfg.ActionCrouch]))
+		input.Actions.Set(netconfig.ActionMoveUp, anyKeyPressed(bindings[cfg.ActionMoveUp]))
 
 		// Apply prediction locally every frame
-		applyPrediction(e.World, prediction, input, localNetID())
+		applyPrediction(e, prediction, input, localNetID())
 
-		// Only send to server when input changes or resend interval elapses
-		now := time.Now()
-		if !changed && now.Sub(state.lastSendTime) < resendInterval {
-			return
+		if prediction != nil {
+			input.Redundant = prediction.Buffer.Unacked(input.Sequence, inputRedundancy)
 		}
-
-		if err := sendFn(input); err != nil {
+		if err := sendFn(messages.EncodeInput(input)); err != nil {
 			log.Printf("[netinput] send error: %v", err)
 		}
-
-		state.lastDirection = dir
-		for k, v := range actions {
-			state.lastActions[k] = v
-		}
-		state.lastSendTime = now
 	}
 }
 
-// applyPrediction finds the local player entity and runs one prediction step.
-func applyPrediction(world donburi.World, pred *NetPrediction, input messages.PlayerInput, localID esync.NetworkId) {
+// applyPrediction finds the local player entity and runs one prediction
+// step: movement, then boomerang charge and throw, then melee, in the
+// server's order.
+func applyPrediction(e *ecs.ECS, pred *NetPrediction, input messages.PlayerInput, localID esync.NetworkId) {
 	if pred == nil || localID == 0 {
 		return
 	}
 
-	entity := esync.FindByNetworkId(world, localID)
-	if !world.Valid(entity) {
+	entity := esync.FindByNetworkId(e.World, localID)
+	if !e.World.Valid(entity) {
 		return
 	}
-	entry := world.Entry(entity)
+	entry := e.World.Entry(entity)
 	if !entry.HasComponent(netcomponents.NetPosition) {
 		return
 	}
 
 	pos := netcomponents.NetPosition.Get(entry)
-	pred.PredictStep(input, pos)
-
 	if !entry.HasComponent(netcomponents.NetPlayerState) {
+		pred.PredictStep(input, pos, netconfig.Idle)
+		pred.stepBoomerang(e, pos)
 		return
 	}
 	state := netcomponents.NetPlayerState.Get(entry)
-	if input.Direction != 0 {
-		state.Direction = input.Direction
-	}
-	// Don't overwrite server-locked states
+	pred.PredictStep(input, pos, state.StateID)
+	state.Direction = int(pred.Movement.Facing)
+	pred.predictBoomerang(e, input, pos, state)
+	pred.stepBoomerang(e, pos)
+	pred.predictMelee(e, input, state)
+
+	// Don't overwrite locked states
 	if state.StateID == netconfig.Throw || state.StateID == netconfig.Hit ||
 		state.StateID == netconfig.StateAttackingPunch || state.StateID == netconfig.StateAttackingKick ||
 		state.StateID == netconfig.StateAttackingJump || state.StateID == netconfig.Stunned ||
 		state.StateID == netconfig.Die {
 		return
 	}
-	if input.Actions[netconfig.ActionBoomerang] {
+	if pred.BoomerangCharging {
 		state.StateID = netconfig.StateChargingBoomerang
 		return
 	}
-	switch {
-	case !pred.OnGround:
-		state.StateID = netconfig.Jump
-	case math.Abs(pred.VelX) >= 0.1:
-		state.StateID = netconfig.Running
-	default:
-		state.StateID = netconfig.Idle
-	}
+	state.StateID = pred.movementState()
 }
 
 func anyKeyPressed(keys []ebiten.Key) bool {
@@ -147,3 +114,12 @@ func anyKeyPressed(keys []ebiten.Key) bool {
 	}
 	return false
 }
+
+func anyKeyJustPressed(keys []ebiten.Key) bool {
+	for _, k := range keys {
+		if inpututil.IsKeyJustPressed(k) {
+			return true
+		}
+	}
+	return false
+}
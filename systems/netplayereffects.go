@@ -4,6 +4,7 @@ import (
 	cfg "github.com/automoto/doomerang-mp/config"
 	"github.com/automoto/doomerang-mp/shared/netcomponents"
 	"github.com/automoto/doomerang-mp/shared/netconfig"
+	"github.com/automoto/doomerang-mp/shared/playersim"
 	"github.com/automoto/doomerang-mp/systems/factory"
 	"github.com/leap-fish/necs/esync"
 	"github.com/yohamta/donburi"
@@ -46,10 +47,9 @@ func NewNetPlayerEffectsSystem(prediction *NetPrediction, localNetID func() esyn
 				if prediction == nil || !prediction.Initialized {
 					return
 				}
-				// VelY < 0 filters false positives from reconciliation at jump apex
-				if prediction.WasOnGround && !prediction.OnGround && prediction.VelY < 0 {
+				if prediction.Events.Has(playersim.Jumped) {
 					triggerJumpEffects(e, entry, feetX, feetY)
-				} else if !prediction.WasOnGround && prediction.OnGround {
+				} else if prediction.Events.Has(playersim.Landed) {
 					triggerLandEffects(e, entry, feetX, feetY)
 				}
 				return
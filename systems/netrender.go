@@ -4,12 +4,16 @@ import (
 	"fmt"
 	"image"
 	"image/color"
+	"math"
 	"strconv"
+	"time"
 
 	"github.com/automoto/doomerang-mp/assets"
 	"github.com/automoto/doomerang-mp/components"
 	cfg "github.com/automoto/doomerang-mp/config"
 	"github.com/automoto/doomerang-mp/fonts"
+	"github.com/automoto/doomerang-mp/network"
+	"github.com/automoto/doomerang-mp/shared/messages"
 	"github.com/automoto/doomerang-mp/shared/netcomponents"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/text" //nolint:staticcheck // TODO: migrate to text/v2
@@ -29,18 +33,16 @@ const (
 var netHeartIcon *ebiten.Image
 var netHudDrawOp = &ebiten.DrawImageOptions{}
 
-// NewNetInterpSystem returns an interpolation system that uses the server's tick
-// rate to compute the interpolation step dynamically instead of hardcoding 1/3.
-func NewNetInterpSystem(tickRateFn func() int) func(*ecs.ECS) {
-	cachedRate := 0
-	cachedStep := 0.5 // default for 30 Hz
-
+// NewNetInterpSystem returns a system that draws remote players,
+// boomerangs, enemies and knives at the playout clock's render time: interpolated between the
+// buffered samples either side of it, or extrapolated from the newest for
+// up to Netcode.MaxExtrapFrames when the buffer runs dry.
+func NewNetInterpSystem(playout *network.PlayoutClock) func(*ecs.ECS) {
 	return func(e *ecs.ECS) {
-		if rate := tickRateFn(); rate != cachedRate && rate > 0 {
-			cachedRate = rate
-			cachedStep = float64(rate) / 60.0
+		renderTime, ok := playout.RenderTime(time.Now())
+		if !ok {
+			return
 		}
-		interpStep := cachedStep
 
 		esync.NetworkEntityQuery.Each(e.World, func(entry *donburi.Entry) {
 			if !entry.HasComponent(components.NetInterp) {
@@ -52,20 +54,24 @@ func NewNetInterpSystem(tickRateFn func() int) func(*ecs.ECS) {
 			// Boomerang interpolation
 			if entry.HasComponent(netcomponents.NetBoomerang) {
 				nb := netcomponents.NetBoomerang.Get(entry)
-				if interp.T < 1.0 {
-					interp.T += interpStep
-					if interp.T > 1.0 {
-						interp.T = 1.0
-					}
-					nb.X = interp.PrevX + (interp.TargetX-interp.PrevX)*interp.T
-					nb.Y = interp.PrevY + (interp.TargetY-interp.PrevY)*interp.T
-				} else if nb.VelX != 0 || nb.VelY != 0 {
-					overshoot := (interp.T - 1.0) / interpStep
-					if overshoot < cfg.Netcode.MaxExtrapFrames {
-						interp.T += interpStep
-						nb.X = interp.TargetX + nb.VelX*overshoot
-						nb.Y = interp.TargetY + nb.VelY*overshoot
-					}
+				if x, y, ok := sampleInterp(interp, renderTime, false); ok {
+					nb.X, nb.Y = x, y
+				}
+				return
+			}
+
+			// Enemy and knife interpolation
+			if entry.HasComponent(netcomponents.NetEnemy) {
+				ne := netcomponents.NetEnemy.Get(entry)
+				if x, y, ok := sampleInterp(interp, renderTime, true); ok {
+					ne.X, ne.Y = x, y
+				}
+				return
+			}
+			if entry.HasComponent(netcomponents.NetKnife) {
+				nk := netcomponents.NetKnife.Get(entry)
+				if x, y, ok := sampleInterp(interp, renderTime, false); ok {
+					nk.X, nk.Y = x, y
 				}
 				return
 			}
@@ -83,29 +89,42 @@ func NewNetInterpSystem(tickRateFn func() int) func(*ecs.ECS) {
 			}
 
 			pos := netcomponents.NetPosition.Get(entry)
-
-			if interp.T < 1.0 {
-				interp.T += interpStep
-				if interp.T > 1.0 {
-					interp.T = 1.0
-				}
-				pos.X = interp.PrevX + (interp.TargetX-interp.PrevX)*interp.T
-				pos.Y = interp.PrevY + (interp.TargetY-interp.PrevY)*interp.T
-			} else if interp.VelX != 0 || interp.VelY != 0 {
-				// Extrapolate when past T=1.0 using velocity
-				overshoot := (interp.T - 1.0) / interpStep
-				if overshoot < cfg.Netcode.MaxExtrapFrames {
-					interp.T += interpStep
-					pos.X = interp.TargetX + interp.VelX*overshoot
-					// Apply gravity to VelY during extrapolation for natural arcs
-					extrapVelY := interp.VelY + cfg.Physics.Gravity*overshoot
-					pos.Y = interp.TargetY + extrapVelY*overshoot
-				}
+			if x, y, ok := sampleInterp(interp, renderTime, true); ok {
+				pos.X, pos.Y = x, y
 			}
 		})
 	}
 }
 
+// sampleInterp returns the entity's position at server time t. Before the
+// oldest sample it holds there; past the newest it extrapolates.
+func sampleInterp(interp *components.NetInterpData, t time.Duration, gravity bool) (float64, float64, bool) {
+	if interp.Count == 0 {
+		return 0, 0, false
+	}
+
+	newest := interp.At(0)
+	if t >= newest.T {
+		frames := min((t-newest.T).Seconds()*60, cfg.Netcode.MaxExtrapFrames)
+		velY := newest.VelY
+		if gravity { // natural arcs for airborne players
+			velY += cfg.Physics.Gravity * frames
+		}
+		return newest.X + newest.VelX*frames, newest.Y + velY*frames, true
+	}
+
+	next := newest
+	for i := 1; i < interp.Count; i++ {
+		prev := interp.At(i)
+		if t >= prev.T {
+			f := float64(t-prev.T) / float64(next.T-prev.T)
+			return prev.X + (next.X-prev.X)*f, prev.Y + (next.Y-prev.Y)*f, true
+		}
+		next = prev
+	}
+	return next.X, next.Y, true
+}
+
 // UpdateNetAnimations advances animation frames for networked player entities
 // and switches animation state based on NetPlayerState.StateID.
 func UpdateNetAnimations(e *ecs.ECS) {
@@ -277,7 +296,8 @@ func DrawNetworkedPlayers(e *ecs.ECS, screen *ebiten.Image) {
 // boomerangRotation is a visual rotation counter for spinning boomerangs.
 var boomerangRotation float64
 
-// DrawNetworkedBoomerangs renders networked boomerang entities with a spinning sprite.
+// DrawNetworkedBoomerangs renders networked and predicted boomerang entities
+// with a spinning sprite.
 func DrawNetworkedBoomerangs(e *ecs.ECS, screen *ebiten.Image) {
 	cameraEntry, ok := components.Camera.First(e.World)
 	if !ok {
@@ -294,8 +314,8 @@ func DrawNetworkedBoomerangs(e *ecs.ECS, screen *ebiten.Image) {
 
 	boomerangRotation += 0.3
 
-	esync.NetworkEntityQuery.Each(e.World, func(entry *donburi.Entry) {
-		if !entry.HasComponent(netcomponents.NetBoomerang) || !entry.HasComponent(components.Sprite) {
+	netcomponents.NetBoomerang.Each(e.World, func(entry *donburi.Entry) {
+		if !entry.HasComponent(components.Sprite) {
 			return
 		}
 
@@ -329,7 +349,26 @@ func DrawNetworkedBoomerangs(e *ecs.ECS, screen *ebiten.Image) {
 	})
 }
 
-func DrawNetworkHUD(e *ecs.ECS, screen *ebiten.Image) {
+// NewNetworkHUDRenderer returns a renderer for the online match HUD. Its
+// timers count down to the server's deadlines on the client's estimate of
+// the server clock, and its results screen adds the match's stats once
+// the client has them.
+func NewNetworkHUDRenderer(client *network.Client) func(*ecs.ECS, *ebiten.Image) {
+	return func(e *ecs.ECS, screen *ebiten.Image) {
+		drawNetworkHUD(e, screen, client)
+	}
+}
+
+// timeUntil returns the seconds left until deadline, in server UnixNano,
+// or fallback if there is no deadline or the clock is not synced yet.
+func timeUntil(clock *network.ServerClock, deadline int64, fallback float64) float64 {
+	if deadline == 0 || !clock.Synced() {
+		return fallback
+	}
+	return clock.Until(deadline).Seconds()
+}
+
+func drawNetworkHUD(e *ecs.ECS, screen *ebiten.Image, client *network.Client) {
 	// 1. Get Game State
 	gameEntry, ok := netcomponents.NetGameState.First(e.World)
 	if !ok {
@@ -345,13 +384,19 @@ func DrawNetworkHUD(e *ecs.ECS, screen *ebiten.Image) {
 	case netcomponents.MatchStateWaiting:
 		drawWaitingMessage(screen, "WAITING FOR PLAYERS...", width, height)
 	case netcomponents.MatchStateCountdown:
-		drawNetworkCountdown(screen, gs.TimeRemaining, width, height)
+		drawNetworkCountdown(screen, timeUntil(client.Clock(), gs.PhaseEndsAt, gs.TimeRemaining), width, height)
 	case netcomponents.MatchStatePlaying:
-		drawNetworkTimer(screen, gs.TimeRemaining, width)
+		drawNetworkTimer(screen, timeUntil(client.Clock(), gs.PhaseEndsAt, gs.TimeRemaining), width)
+		if respawnAt := client.RespawnAt(); respawnAt != 0 {
+			drawRespawnCountdown(screen, timeUntil(client.Clock(), respawnAt, 0), width, height)
+		}
 	case netcomponents.MatchStateRoundEnd:
 		drawRoundEndOverlay(screen, e, gs, width, height)
 	case netcomponents.MatchStateFinished:
-		drawNetworkResults(screen, gs, width, height)
+		drawNetworkResults(screen, gs, client.MatchStats(), width, height)
+	}
+	if shutdownAt := client.ShutdownAt(); shutdownAt != 0 {
+		drawShutdownNotice(screen, timeUntil(client.Clock(), shutdownAt, 0), width)
 	}
 
 	// 3. Draw Player Corner HUD (Health + Lives) for all active players
@@ -374,6 +419,57 @@ func DrawNetworkHUD(e *ecs.ECS, screen *ebiten.Image) {
 	}
 }
 
+// NewNetInterpDebugRenderer returns a renderer that adds the remote
+// render delay, measured jitter and buffer underruns to the network debug
+// overlay.
+func NewNetInterpDebugRenderer(playout *network.PlayoutClock) func(*ecs.ECS, *ebiten.Image) {
+	return func(_ *ecs.ECS, screen *ebiten.Image) {
+		if !cfg.Debug.ShowNetworkDebug {
+			return
+		}
+		info := fmt.Sprintf("Interp delay: %dms  Jitter: %dms  Underruns: %d",
+			playout.Delay().Milliseconds(), playout.Jitter().Milliseconds(), playout.Underruns())
+		text.Draw(screen, info, fonts.ExcelSmall.Get(), 4, 24, cfg.LightGreen)
+	}
+}
+
+// NewSpectatorHUDRenderer returns a renderer that labels the view while
+// spectating() is true, naming the followed player when there is one.
+func NewSpectatorHUDRenderer(spectating func() bool, sc *SpectatorCamera) func(*ecs.ECS, *ebiten.Image) {
+	return func(e *ecs.ECS, screen *ebiten.Image) {
+		if !spectating() {
+			return
+		}
+
+		label := "SPECTATING - FREE CAMERA"
+		if !sc.Free {
+			label = "SPECTATING"
+			if gameEntry, ok := netcomponents.NetGameState.First(e.World); ok {
+				gs := netcomponents.NetGameState.Get(gameEntry)
+				for i, nid := range gs.SlotNetIDs {
+					if nid != 0 && esync.NetworkId(nid) == sc.Target && gs.SlotNames[i] != "" {
+						label = "SPECTATING " + gs.SlotNames[i]
+						break
+					}
+				}
+			}
+		}
+
+		height := screen.Bounds().Dy()
+		text.Draw(screen, label, fonts.ExcelSmall.Get(), 4, height-20, cfg.BrightOrange)
+		text.Draw(screen, "G: free/follow  A/D: switch player", fonts.ExcelSmall.Get(), 4, height-6, cfg.White)
+	}
+}
+
+// DrawReconnectingOverlay covers the frozen world while the client redials
+// after a dropped connection.
+func DrawReconnectingOverlay(screen *ebiten.Image) {
+	width := float64(screen.Bounds().Dx())
+	height := float64(screen.Bounds().Dy())
+	vector.FillRect(screen, 0, 0, float32(width), float32(height), color.RGBA{0, 0, 0, 160}, false)
+	drawWaitingMessage(screen, "RECONNECTING...", width, height)
+}
+
 func drawWaitingMessage(screen *ebiten.Image, msg string, width, height float64) {
 	fontFace := fonts.ExcelTitle.Get()
 	textWidth := len(msg) * 24
@@ -383,7 +479,7 @@ func drawWaitingMessage(screen *ebiten.Image, msg string, width, height float64)
 }
 
 func drawNetworkCountdown(screen *ebiten.Image, timeRemaining float64, width, height float64) {
-	seconds := int(timeRemaining) + 1
+	seconds := int(math.Ceil(timeRemaining))
 	var countStr string
 	if seconds > 0 {
 		countStr = fmt.Sprintf("%d", seconds)
@@ -407,7 +503,7 @@ func drawNetworkCountdown(screen *ebiten.Image, timeRemaining float64, width, he
 }
 
 func drawNetworkTimer(screen *ebiten.Image, timeRemaining float64, width float64) {
-	seconds := int(timeRemaining)
+	seconds := max(int(timeRemaining), 0)
 	minutes := seconds / 60
 	secs := seconds % 60
 	timeStr := fmt.Sprintf("%d:%02d", minutes, secs)
@@ -422,6 +518,29 @@ func drawNetworkTimer(screen *ebiten.Image, timeRemaining float64, width float64
 	text.Draw(screen, timeStr, fontFace, textX, 20, cfg.White)
 }
 
+// drawShutdownNotice warns that the server is shutting down, counting
+// down to the latest it will close.
+func drawShutdownNotice(screen *ebiten.Image, timeRemaining float64, width float64) {
+	msg := "SERVER SHUTTING DOWN"
+	if timeRemaining > 0 {
+		msg = fmt.Sprintf("SERVER SHUTTING DOWN - %ds LEFT", int(math.Ceil(timeRemaining)))
+	}
+	textWidth := len(msg) * 8
+	vector.FillRect(screen, float32(width/2)-float32(textWidth)/2-4, 28, float32(textWidth)+8, 18, color.RGBA{0, 0, 0, 180}, false)
+	text.Draw(screen, msg, fonts.ExcelBold.Get(), int(width/2)-textWidth/2, 42, cfg.LightRed)
+}
+
+// drawRespawnCountdown shows how long until the local player respawns, once
+// knocked out.
+func drawRespawnCountdown(screen *ebiten.Image, timeRemaining float64, width, height float64) {
+	if timeRemaining <= 0 {
+		return
+	}
+	msg := fmt.Sprintf("RESPAWN IN %d", int(math.Ceil(timeRemaining)))
+	textWidth := len(msg) * 8
+	text.Draw(screen, msg, fonts.ExcelBold.Get(), int(width/2)-textWidth/2, int(height/2)+40, cfg.BrightOrange)
+}
+
 func drawAllPlayersCornerHUD(e *ecs.ECS, screen *ebiten.Image, gs *netcomponents.NetGameStateData, screenWidth, screenHeight float64) {
 	netcomponents.NetPlayerState.Each(e.World, func(entry *donburi.Entry) {
 		state := netcomponents.NetPlayerState.Get(entry)
@@ -455,6 +574,11 @@ func drawAllPlayersCornerHUD(e *ecs.ECS, screen *ebiten.Image, gs *netcomponents
 		}
 		vector.FillRect(screen, x, y, netHudBarWidth*hpRatio, netHudBarHeight, playerColor, false)
 
+		// Ping beside the bar, on the side facing the screen's middle
+		if ping := gs.SlotPings[playerIndex]; ping > 0 {
+			drawNetworkPing(screen, ping, x, y, playerIndex == 1 || playerIndex == 3)
+		}
+
 		// Draw lives counter
 		drawNetworkPlayerLives(state.Lives, screen, x, y+netHudBarHeight+netLivesMargin, playerIndex)
 
@@ -463,6 +587,26 @@ func drawAllPlayersCornerHUD(e *ecs.ECS, screen *ebiten.Image, gs *netcomponents
 	})
 }
 
+// drawNetworkPing labels a corner HUD bar at x, y with its player's round
+// trip, colored by how playable it is.
+func drawNetworkPing(screen *ebiten.Image, ping int, x, y float32, rightSide bool) {
+	label := strconv.Itoa(ping) + "ms"
+	face := fonts.ExcelSmall.Get()
+	textX := int(x) + netHudBarWidth + 4
+	if rightSide {
+		textX = int(x) - text.BoundString(face, label).Dx() - 4
+	}
+
+	pingColor := cfg.LightGreen
+	switch {
+	case ping >= 150:
+		pingColor = cfg.LightRed
+	case ping >= 80:
+		pingColor = cfg.BrightYellow
+	}
+	text.Draw(screen, label, face, textX, int(y)+netHudBarHeight, pingColor)
+}
+
 func drawNetworkPlayerLives(lives int, screen *ebiten.Image, startX, startY float32, playerIndex int) {
 	if netHeartIcon == nil {
 		netHeartIcon = assets.GetIconImage("icon_heart.png")
@@ -529,7 +673,7 @@ func drawRoundEndOverlay(screen *ebiten.Image, e *ecs.ECS, gs *netcomponents.Net
 	}
 }
 
-func drawNetworkResults(screen *ebiten.Image, gs *netcomponents.NetGameStateData, width, height float64) {
+func drawNetworkResults(screen *ebiten.Image, gs *netcomponents.NetGameStateData, stats *messages.MatchStats, width, height float64) {
 	// Full dark overlay
 	vector.FillRect(screen, 0, 0, float32(width), float32(height), color.RGBA{0, 0, 0, 200}, false)
 
@@ -576,6 +720,55 @@ func drawNetworkResults(screen *ebiten.Image, gs *netcomponents.NetGameStateData
 		text.Draw(screen, line, fonts.ExcelBold.Get(), int(width/2)-len(line)*4, y, playerColor)
 		y += 22
 	}
+
+	if stats != nil {
+		drawNetworkStatsTable(screen, gs, stats, y+8)
+	}
+}
+
+// netStatsColumns are the x positions of the stats table's columns.
+var netStatsColumns = [...]int{40, 140, 175, 210, 250, 300, 350, 420, 500, 555}
+
+// drawNetworkStatsTable lists each slot's match stats under the results,
+// starting at y.
+func drawNetworkStatsTable(screen *ebiten.Image, gs *netcomponents.NetGameStateData, stats *messages.MatchStats, y int) {
+	face := fonts.ExcelSmall.Get()
+	header := [...]string{"PLAYER", "KO", "DTH", "SELF", "DMG", "TAKEN", "P/K/JK", "BOOMERANG", "STREAK", "ALIVE"}
+	for i, h := range header {
+		text.Draw(screen, h, face, netStatsColumns[i], y, cfg.White)
+	}
+
+	for slotIdx := 0; slotIdx < 4; slotIdx++ {
+		if gs.SlotTypes[slotIdx] == 0 {
+			continue
+		}
+		s, ok := stats.Players[gs.SlotNetIDs[slotIdx]]
+		if !ok {
+			continue
+		}
+		y += 14
+		name := gs.SlotNames[slotIdx]
+		if name == "" {
+			name = "P" + strconv.Itoa(slotIdx+1)
+		}
+		alive := int(s.TimeAlive)
+		row := [...]string{
+			name,
+			strconv.Itoa(s.KOs),
+			strconv.Itoa(s.Deaths),
+			strconv.Itoa(s.SelfKOs),
+			strconv.Itoa(s.DamageDealt),
+			strconv.Itoa(s.DamageTaken),
+			fmt.Sprintf("%d/%d/%d", s.PunchHits, s.KickHits, s.JumpKickHits),
+			fmt.Sprintf("%d/%d %d%%", s.BoomerangHits, s.BoomerangThrows, int(s.BoomerangAccuracy()*100)),
+			strconv.Itoa(s.LongestStreak),
+			fmt.Sprintf("%d:%02d", alive/60, alive%60),
+		}
+		playerColor := cfg.PlayerColors.Colors[slotIdx%len(cfg.PlayerColors.Colors)].RGBA
+		for i, cell := range row {
+			text.Draw(screen, cell, face, netStatsColumns[i], y, playerColor)
+		}
+	}
 }
 
 func getNetworkPlayerName(e *ecs.ECS, netID uint32) string {
@@ -17,14 +17,13 @@ func UpdatePhysics(ecs *ecs.ECS) {
 
 		physics := components.Physics.Get(e)
 
-		// Determine friction based on state
-		friction := physics.Friction
-		if e.HasComponent(components.State) {
-			state := components.State.Get(e)
-			if state.CurrentState == cfg.StateSliding {
-				friction = cfg.Player.SlideFriction
-			}
+		// Players are moved by their movement step in UpdatePlayer
+		if e.HasComponent(components.Player) {
+			trackSafeGround(e, physics)
+			return
 		}
+
+		friction := physics.Friction
 		if e.HasComponent(components.MeleeAttack) {
 			if melee := components.MeleeAttack.Get(e); melee.IsAttacking {
 				friction = physics.AttackFriction
@@ -50,15 +49,19 @@ func UpdatePhysics(ecs *ecs.ECS) {
 		if physics.WallSliding != nil && physics.SpeedY > cfg.Physics.WallSlideSpeed {
 			physics.SpeedY = cfg.Physics.WallSlideSpeed
 		}
-
-		// Track last safe ground position for player respawn
-		if e.HasComponent(components.Player) && physics.OnGround != nil {
-			obj := components.Object.Get(e)
-			if obj.Check(0, 0, tags.ResolvDeadZone) == nil {
-				player := components.Player.Get(e)
-				player.LastSafeX = obj.X
-				player.LastSafeY = obj.Y
-			}
-		}
 	})
 }
+
+// trackSafeGround records the last position where a player stood on safe
+// ground, for respawning.
+func trackSafeGround(e *donburi.Entry, physics *components.PhysicsData) {
+	if physics.OnGround == nil {
+		return
+	}
+	obj := components.Object.Get(e)
+	if obj.Check(0, 0, tags.ResolvDeadZone) == nil {
+		player := components.Player.Get(e)
+		player.LastSafeX = obj.X
+		player.LastSafeY = obj.Y
+	}
+}
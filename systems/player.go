@@ -5,7 +5,9 @@ import (
 
 	"github.com/automoto/doomerang-mp/components"
 	"github.com/automoto/doomerang-mp/shared/gamemath"
+	"github.com/automoto/doomerang-mp/shared/playersim"
 	"github.com/automoto/doomerang-mp/systems/factory"
+	"github.com/automoto/doomerang-mp/systems/movement"
 	"github.com/solarlune/resolv"
 	"github.com/yohamta/donburi"
 	"github.com/yohamta/donburi/ecs"
@@ -52,34 +54,17 @@ func updateSinglePlayer(ecs *ecs.ECS, playerEntry *donburi.Entry) {
 func handlePlayerInput(e *ecs.ECS, playerEntry *donburi.Entry, input *components.PlayerInputData, player *components.PlayerData, physics *components.PhysicsData, melee *components.MeleeAttackData, state *components.StateData, playerObject *resolv.Object) {
 	// Get action states from player input component
 	attackAction := GetPlayerAction(input, cfg.ActionAttack)
-	jumpAction := GetPlayerAction(input, cfg.ActionJump)
-	crouchAction := GetPlayerAction(input, cfg.ActionCrouch)
-	moveLeftAction := GetPlayerAction(input, cfg.ActionMoveLeft)
-	moveRightAction := GetPlayerAction(input, cfg.ActionMoveRight)
 
-	// Process combat and jump inputs only if not in a locked state
-	if !isInLockedState(state.CurrentState) {
-		handleMeleeInput(attackAction, physics, melee, state, player, playerObject)
-
-		if !isInAttackState(state.CurrentState) {
-			handleJumpInput(e, playerEntry, jumpAction, crouchAction, physics, playerObject)
-		}
+	// Attacking during a wall slide is a wall kick, handled by the movement step
+	if !isInLockedState(state.CurrentState) && physics.WallSliding == nil {
+		handleMeleeInput(attackAction, physics, melee, state)
 	}
 
-	// Horizontal movement (always processed)
-	handleMovementInput(moveLeftAction, moveRightAction, player, physics, state)
+	events := movement.StepPlayer(input, player, physics, state.CurrentState, playerObject)
+	playMovementEffects(e, playerEntry, events, melee, state, playerObject)
 }
 
-func handleMeleeInput(attackAction components.ActionState, physics *components.PhysicsData, melee *components.MeleeAttackData, state *components.StateData, player *components.PlayerData, playerObject *resolv.Object) {
-	// Wall kick: attack during wall slide = wall jump + kick away from wall
-	if physics.WallSliding != nil {
-		if !attackAction.JustPressed || isInAttackState(state.CurrentState) {
-			return
-		}
-		performWallKick(physics, player, playerObject, state, melee)
-		return
-	}
-
+func handleMeleeInput(attackAction components.ActionState, physics *components.PhysicsData, melee *components.MeleeAttackData, state *components.StateData) {
 	// Attack release
 	if melee.IsCharging && attackAction.JustReleased {
 		melee.IsCharging = false
@@ -105,63 +90,27 @@ func handleMeleeInput(attackAction components.ActionState, physics *components.P
 	}
 }
 
-func handleJumpInput(e *ecs.ECS, playerEntry *donburi.Entry, jumpAction, crouchAction components.ActionState, physics *components.PhysicsData, playerObject *resolv.Object) {
-	if !jumpAction.JustPressed {
-		return
-	}
-
-	// Drop-through platform
-	if crouchAction.Pressed && physics.OnGround != nil && physics.OnGround.HasTags("platform") {
-		physics.IgnorePlatform = physics.OnGround
-		return
-	}
+// playMovementEffects plays the sounds and particles for a movement step,
+// and starts the jump kick a wall kick performs.
+func playMovementEffects(e *ecs.ECS, playerEntry *donburi.Entry, events playersim.Events, melee *components.MeleeAttackData, state *components.StateData, playerObject *resolv.Object) {
+	feetX, feetY := playerObject.X+playerObject.W/2, playerObject.Y+playerObject.H
 
-	// Normal jump from ground
-	if physics.OnGround != nil {
-		physics.SpeedY = -cfg.Player.JumpSpeed
+	switch {
+	case events.Has(playersim.Jumped):
 		PlaySFX(e, cfg.SoundJump)
-		// Spawn jump dust and squash/stretch
-		factory.SpawnJumpDust(e, playerObject.X+playerObject.W/2, playerObject.Y+playerObject.H)
+		factory.SpawnJumpDust(e, feetX, feetY)
 		TriggerSquashStretch(playerEntry, cfg.SquashStretch.JumpScaleX, cfg.SquashStretch.JumpScaleY)
-		return
-	}
-
-	// Wall jump
-	if physics.WallSliding == nil {
-		return
-	}
-	physics.SpeedY = -cfg.Player.JumpSpeed
-	PlaySFX(e, cfg.SoundJump)
-	if physics.WallSliding.X > playerObject.X {
-		physics.SpeedX = -physics.MaxSpeed
-	} else {
-		physics.SpeedX = physics.MaxSpeed
-	}
-	physics.WallSliding = nil
-}
-
-func handleMovementInput(moveLeftAction, moveRightAction components.ActionState, player *components.PlayerData, physics *components.PhysicsData, state *components.StateData) {
-	if physics.WallSliding != nil {
-		return
-	}
-
-	// Block movement input during slide and crouch - handled separately in state machine
-	if state.CurrentState == cfg.StateSliding || state.CurrentState == cfg.Crouch {
-		return
-	}
-
-	accel := cfg.Player.Acceleration
-	if isInAttackState(state.CurrentState) {
-		accel = cfg.Player.AttackAccel
+	case events.Has(playersim.WallJumped):
+		PlaySFX(e, cfg.SoundJump)
+	case events.Has(playersim.WallKicked):
+		state.CurrentState = cfg.StateAttackingJump
+		state.StateTimer = 0
+		melee.IsAttacking = true
 	}
 
-	if moveRightAction.Pressed {
-		physics.SpeedX += accel
-		player.Direction.X = cfg.DirectionRight
-	}
-	if moveLeftAction.Pressed {
-		physics.SpeedX -= accel
-		player.Direction.X = cfg.DirectionLeft
+	if events.Has(playersim.SlideStarted) {
+		PlaySFX(e, cfg.SoundSlide)
+		factory.SpawnSlideDust(e, feetX, feetY)
 	}
 }
 
@@ -170,16 +119,18 @@ func updatePlayerState(ecs *ecs.ECS, input *components.PlayerInputData, playerEn
 
 	// Get action states from player input component
 	boomerangAction := GetPlayerAction(input, cfg.ActionBoomerang)
-	crouchAction := GetPlayerAction(input, cfg.ActionCrouch)
 
-	// Get player object for hitbox modifications
+	// Get player object for effect positions
 	playerObject := components.Object.Get(playerEntry).Object
 
 	// Main state machine logic
 	switch state.CurrentState {
 	case cfg.Idle, cfg.Running:
-		// Transition to charging
-		if melee.IsCharging {
+		// Crouching or sliding, entered by the movement step
+		if player.Movement.Mode != playersim.Standing {
+			transitionToMovementState(player, physics, state)
+		} else if melee.IsCharging {
+			// Transition to charging
 			state.CurrentState = cfg.StateChargingAttack
 			state.StateTimer = 0
 		} else if boomerangAction.Pressed && player.ActiveBoomerang == nil {
@@ -187,17 +138,6 @@ func updatePlayerState(ecs *ecs.ECS, input *components.PlayerInputData, playerEn
 			state.CurrentState = cfg.StateChargingBoomerang
 			player.BoomerangChargeTime = 0
 			state.StateTimer = 0
-		} else if crouchAction.JustPressed && physics.OnGround != nil {
-			// Slide if moving fast enough, otherwise crouch
-			if absFloat(physics.SpeedX) >= cfg.Player.SlideSpeedThreshold {
-				PlaySFX(ecs, cfg.SoundSlide)
-				enterSlideState(state, playerObject)
-				factory.SpawnSlideDust(ecs, playerObject.X+playerObject.W/2, playerObject.Y+playerObject.H)
-			} else {
-				enterCrouchState(state, playerObject)
-			}
-		} else if crouchAction.Pressed && physics.OnGround != nil && state.CurrentState != cfg.Running {
-			enterCrouchState(state, playerObject)
 		} else {
 			transitionToMovementState(player, physics, state)
 		}
@@ -238,8 +178,6 @@ func updatePlayerState(ecs *ecs.ECS, input *components.PlayerInputData, playerEn
 			if player.ChargeVFX != nil {
 				factory.UpdateChargeVFXPosition(player.ChargeVFX, playerObject.X+playerObject.W/2, playerObject.Y+playerObject.H)
 			}
-			// Apply friction instead of instant stop for smoother feel
-			applyThrowFriction(physics)
 			break
 		}
 		// Released - throw!
@@ -255,9 +193,6 @@ func updatePlayerState(ecs *ecs.ECS, input *components.PlayerInputData, playerEn
 		factory.CreateBoomerang(ecs, playerEntry, float64(player.BoomerangChargeTime), aimX, aimY)
 
 	case cfg.Throw:
-		// Apply friction instead of instant stop for smoother feel
-		applyThrowFriction(physics)
-
 		// Wait for animation to finish
 		if animationLooped(animData) {
 			transitionToMovementState(player, physics, state)
@@ -286,40 +221,9 @@ func updatePlayerState(ecs *ecs.ECS, input *components.PlayerInputData, playerEn
 			transitionToMovementState(player, physics, state)
 		}
 
-	case cfg.Crouch:
-		// Allow slow crouch-walking
-		applyCrouchMovement(input, player, physics)
-
-		// Transition back to movement when down key is released
-		if !crouchAction.Pressed {
-			// Try to stand up (may push player horizontally if partially blocked)
-			if !tryStandUp(playerObject, player.Direction.X) {
-				break // Completely blocked - stay crouched
-			}
-			transitionToMovementState(player, physics, state)
-		}
-
-	case cfg.StateSliding:
-		// Friction is handled by physics system with cfg.Player.SlideFriction
-		speed := absFloat(physics.SpeedX)
-		wantsToStandUp := !crouchAction.Pressed && state.StateTimer > cfg.Player.SlideRecoveryFrames
-		slideStopped := speed < cfg.Player.SlideMinSpeed
-
-		if !slideStopped && !wantsToStandUp {
-			break
-		}
-
-		// Try to stand up (may push player horizontally if partially blocked)
-		if !tryStandUp(playerObject, player.Direction.X) {
-			// Completely blocked - transition to crouch (keeps reduced hitbox)
-			physics.SpeedX = 0
-			enterCrouchState(state, playerObject)
-			break
-		}
-
-		if slideStopped && crouchAction.Pressed {
-			enterCrouchState(state, playerObject)
-		} else {
+	case cfg.Crouch, cfg.StateSliding:
+		// Stance is changed by the movement step
+		if playersim.MovementState(&physics.Body, &player.Movement) != state.CurrentState {
 			transitionToMovementState(player, physics, state)
 		}
 
@@ -406,124 +310,7 @@ func updatePlayerAnimation(state *components.StateData, animData *components.Ani
 }
 
 func transitionToMovementState(player *components.PlayerData, physics *components.PhysicsData, state *components.StateData) {
-	if physics.WallSliding != nil {
-		state.CurrentState = cfg.WallSlide
-	} else if physics.OnGround == nil {
-		state.CurrentState = cfg.Jump
-	} else if physics.SpeedX != 0 {
-		state.CurrentState = cfg.Running
-	} else {
-		state.CurrentState = cfg.Idle
-	}
+	state.CurrentState = playersim.MovementState(&physics.Body, &player.Movement)
 	state.StateTimer = 0
 	player.ComboCounter = 0
 }
-
-func applyThrowFriction(physics *components.PhysicsData) {
-	applyFriction(physics, cfg.Player.Friction)
-}
-
-// tryStandUp attempts to restore full height hitbox, pushing player horizontally if needed.
-func tryStandUp(playerObject *resolv.Object, facingX float64) bool {
-	normalHeight := float64(cfg.Player.CollisionHeight)
-	if playerObject.H >= normalHeight {
-		return true
-	}
-
-	heightDiff := normalHeight - playerObject.H
-
-	if playerObject.Check(0, -heightDiff, "solid") == nil {
-		playerObject.H = normalHeight
-		playerObject.Y -= heightDiff
-		return true
-	}
-
-	// Blocked above - try pushing horizontally
-	const pushDistance = 12.0
-	for _, dir := range []float64{facingX, -facingX} {
-		for offset := 1.0; offset <= pushDistance; offset++ {
-			if playerObject.Check(offset*dir, -heightDiff, "solid") == nil {
-				playerObject.X += offset * dir
-				playerObject.H = normalHeight
-				playerObject.Y -= heightDiff
-				return true
-			}
-		}
-	}
-
-	return false
-}
-
-func absFloat(x float64) float64 {
-	if x < 0 {
-		return -x
-	}
-	return x
-}
-
-func applyCrouchMovement(input *components.PlayerInputData, player *components.PlayerData, physics *components.PhysicsData) {
-	left := GetPlayerAction(input, cfg.ActionMoveLeft).Pressed
-	right := GetPlayerAction(input, cfg.ActionMoveRight).Pressed
-
-	switch {
-	case right:
-		physics.SpeedX = cfg.Player.CrouchWalkSpeed
-		player.Direction.X = cfg.DirectionRight
-	case left:
-		physics.SpeedX = -cfg.Player.CrouchWalkSpeed
-		player.Direction.X = cfg.DirectionLeft
-	default:
-		applyFriction(physics, cfg.Player.Friction)
-	}
-}
-
-func applyFriction(physics *components.PhysicsData, friction float64) {
-	switch {
-	case physics.SpeedX > friction:
-		physics.SpeedX -= friction
-	case physics.SpeedX < -friction:
-		physics.SpeedX += friction
-	default:
-		physics.SpeedX = 0
-	}
-}
-
-func performWallKick(physics *components.PhysicsData, player *components.PlayerData, playerObject *resolv.Object, state *components.StateData, melee *components.MeleeAttackData) {
-	wallCenterX := physics.WallSliding.X + physics.WallSliding.W/2
-	playerCenterX := playerObject.X + playerObject.W/2
-
-	physics.SpeedY = -cfg.Player.JumpSpeed
-	if wallCenterX > playerCenterX {
-		physics.SpeedX = -physics.MaxSpeed
-		player.Direction.X = cfg.DirectionLeft
-	} else {
-		physics.SpeedX = physics.MaxSpeed
-		player.Direction.X = cfg.DirectionRight
-	}
-	physics.WallSliding = nil
-	state.CurrentState = cfg.StateAttackingJump
-	state.StateTimer = 0
-	melee.IsAttacking = true
-}
-
-func enterSlideState(state *components.StateData, playerObject *resolv.Object) {
-	state.CurrentState = cfg.StateSliding
-	state.StateTimer = 0
-	reduceHitboxForCrouch(playerObject)
-}
-
-func enterCrouchState(state *components.StateData, playerObject *resolv.Object) {
-	state.CurrentState = cfg.Crouch
-	state.StateTimer = 0
-	reduceHitboxForCrouch(playerObject)
-}
-
-func reduceHitboxForCrouch(playerObject *resolv.Object) {
-	targetHeight := cfg.Player.SlideHitboxHeight
-	if playerObject.H <= targetHeight {
-		return
-	}
-	heightDiff := playerObject.H - targetHeight
-	playerObject.H = targetHeight
-	playerObject.Y += heightDiff
-}
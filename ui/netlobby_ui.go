@@ -6,6 +6,7 @@ import (
 
 	"github.com/automoto/doomerang-mp/assets"
 	"github.com/automoto/doomerang-mp/shared/messages"
+	"github.com/automoto/doomerang-mp/systems"
 	"github.com/ebitenui/ebitenui"
 	"github.com/ebitenui/ebitenui/image"
 	"github.com/ebitenui/ebitenui/widget"
@@ -21,11 +22,13 @@ type NetLobbyUI struct {
 	// State
 	Slots        [4]messages.LobbySlot
 	GameMode     string
+	FriendlyFire bool
 	MatchMinutes int
 	LevelIndex   int
 	HostID       uint32
 	LocalNetID   uint32
 	LevelNames   []string
+	RoomCode     string
 
 	// Callbacks
 	OnAction func(action messages.LobbyAction)
@@ -33,10 +36,12 @@ type NetLobbyUI struct {
 
 	// Widget references for updates
 	slotButtons    [4]*widget.Button // Clicking our own slot cycles ready
-	teamButtons    [4]*widget.Button // Team selection buttons (host only)
+	teamButtons    [4]*widget.Button // Own team, or anyone's for the host
 	gameModeLabel  *widget.Label
 	levelLabel     *widget.Label
 	gameModeButton *widget.Button
+	balanceButton  *widget.Button
+	friendlyButton *widget.Button
 	levelButton    *widget.Button
 	addBotButton   *widget.Button
 	startButton    *widget.Button
@@ -50,10 +55,11 @@ type NetLobbyUI struct {
 	initialized bool
 }
 
-func NewNetLobbyUI(localNetID uint32, levelNames []string, onAction func(messages.LobbyAction), onGoBack func()) *NetLobbyUI {
+func NewNetLobbyUI(localNetID uint32, levelNames []string, roomCode string, onAction func(messages.LobbyAction), onGoBack func()) *NetLobbyUI {
 	lui := &NetLobbyUI{
 		LocalNetID:   localNetID,
 		LevelNames:   levelNames,
+		RoomCode:     roomCode,
 		OnAction:     onAction,
 		OnGoBack:     onGoBack,
 		GameMode:     "ffa",
@@ -107,6 +113,14 @@ func (lui *NetLobbyUI) buildUI() {
 	)
 	contentContainer.AddChild(titleLabel)
 
+	if lui.RoomCode != "" {
+		contentContainer.AddChild(widget.NewLabel(
+			widget.LabelOpts.Text("Room code: "+lui.RoomCode, &lui.normalFace, &widget.LabelColor{
+				Idle: color.RGBA{255, 255, 100, 255},
+			}),
+		))
+	}
+
 	// Slots
 	slotsContainer := widget.NewContainer(
 		widget.ContainerOpts.Layout(widget.NewRowLayout(
@@ -213,9 +227,7 @@ func (lui *NetLobbyUI) buildSlotRow(slotIndex int) *widget.Container {
 			Idle: color.RGBA{255, 255, 255, 255},
 		}),
 		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
-			// Team-cycling click handler is a placeholder; LobbyAction
-			// for team change ships when host UI lands.
-			_ = lui
+			lui.OnAction(messages.LobbyAction{Action: "change_team", Value: idx})
 		}),
 	)
 	row.AddChild(lui.teamButtons[slotIndex])
@@ -260,6 +272,32 @@ func (lui *NetLobbyUI) buildSettingsContainer() *widget.Container {
 	modeRow.AddChild(lui.gameModeButton)
 	container.AddChild(modeRow)
 
+	// Teams
+	teamRow := widget.NewContainer(widget.ContainerOpts.Layout(widget.NewRowLayout(widget.RowLayoutOpts.Spacing(6))))
+	lui.balanceButton = widget.NewButton(
+		widget.ButtonOpts.WidgetOpts(widget.WidgetOpts.MinSize(70, 18)),
+		widget.ButtonOpts.Image(lui.buttonImage(color.RGBA{60, 60, 80, 255})),
+		widget.ButtonOpts.Text("Balance", &lui.smallFace, &widget.ButtonTextColor{Idle: color.White}),
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			if lui.LocalNetID == lui.HostID {
+				lui.OnAction(messages.LobbyAction{Action: "auto_balance"})
+			}
+		}),
+	)
+	teamRow.AddChild(lui.balanceButton)
+	lui.friendlyButton = widget.NewButton(
+		widget.ButtonOpts.WidgetOpts(widget.WidgetOpts.MinSize(120, 18)),
+		widget.ButtonOpts.Image(lui.buttonImage(color.RGBA{60, 60, 80, 255})),
+		widget.ButtonOpts.Text("Friendly Fire: Off", &lui.smallFace, &widget.ButtonTextColor{Idle: color.White}),
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			if lui.LocalNetID == lui.HostID {
+				lui.OnAction(messages.LobbyAction{Action: "toggle_friendly_fire"})
+			}
+		}),
+	)
+	teamRow.AddChild(lui.friendlyButton)
+	container.AddChild(teamRow)
+
 	// Add Bot
 	lui.addBotButton = widget.NewButton(
 		widget.ButtonOpts.WidgetOpts(widget.WidgetOpts.MinSize(100, 20)),
@@ -298,6 +336,7 @@ func (lui *NetLobbyUI) buildSettingsContainer() *widget.Container {
 func (lui *NetLobbyUI) UpdateState(update messages.LobbyUpdate) {
 	lui.Slots = update.Slots
 	lui.GameMode = update.GameMode
+	lui.FriendlyFire = update.FriendlyFire
 	lui.MatchMinutes = update.MatchMinutes
 	lui.LevelIndex = update.LevelIndex
 	lui.HostID = update.HostID
@@ -310,6 +349,7 @@ func (lui *NetLobbyUI) UpdateUI() {
 	}
 
 	isHost := lui.LocalNetID == lui.HostID
+	teamMode := lui.GameMode == "2v2" || lui.GameMode == "coop"
 
 	for i := 0; i < 4; i++ {
 		slot := lui.Slots[i]
@@ -340,8 +380,15 @@ func (lui *NetLobbyUI) UpdateUI() {
 			txt.Label = text
 		}
 
-		if lui.teamButtons[i] != nil {
-			lui.teamButtons[i].GetWidget().Disabled = !isHost || slot.Type == 0
+		if teamBtn := lui.teamButtons[i]; teamBtn != nil {
+			if txt := teamBtn.Text(); txt != nil {
+				txt.Label = "-"
+				if teamMode && slot.Type != 0 {
+					txt.Label = systems.GetTeamName(slot.Team)
+				}
+			}
+			ownSlot := slot.Type == 1 && slot.PlayerID == lui.LocalNetID
+			teamBtn.GetWidget().Disabled = !teamMode || slot.Type == 0 || !(isHost || ownSlot)
 		}
 	}
 
@@ -354,6 +401,18 @@ func (lui *NetLobbyUI) UpdateUI() {
 	if lui.addBotButton != nil {
 		lui.addBotButton.GetWidget().Disabled = !isHost
 	}
+	if lui.balanceButton != nil {
+		lui.balanceButton.GetWidget().Disabled = !isHost || !teamMode
+	}
+	if lui.friendlyButton != nil {
+		if txt := lui.friendlyButton.Text(); txt != nil {
+			txt.Label = "Friendly Fire: Off"
+			if lui.FriendlyFire {
+				txt.Label = "Friendly Fire: On"
+			}
+		}
+		lui.friendlyButton.GetWidget().Disabled = !isHost || !teamMode
+	}
 
 	if lui.levelLabel != nil && len(lui.LevelNames) > 0 {
 		lui.levelLabel.Label = lui.LevelNames[lui.LevelIndex]
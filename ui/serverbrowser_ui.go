@@ -26,14 +26,20 @@ type ServerEntry struct {
 type ServerBrowserUI struct {
 	UI *ebitenui.UI
 
-	OnConnect func(address, level string)
-	OnGoBack  func()
-	OnRefresh func()
-
-	ipInput     *widget.TextInput
-	portInput   *widget.TextInput
-	statusLabel *widget.Label
-	connectBtn  *widget.Button
+	OnConnect    func(address, level string)
+	OnCreateRoom func(address, level string)
+	OnSpectate   func(address, level string)
+	OnGoBack     func()
+	OnRefresh    func()
+
+	ipInput       *widget.TextInput
+	portInput     *widget.TextInput
+	roomInput     *widget.TextInput
+	passwordInput *widget.TextInput
+	statusLabel   *widget.Label
+	connectBtn    *widget.Button
+	createRoomBtn *widget.Button
+	spectateBtn   *widget.Button
 
 	levelNames       []string
 	selectedLevelIdx int
@@ -55,12 +61,14 @@ type ServerBrowserUI struct {
 	smallFace  text.Face
 }
 
-func NewServerBrowserUI(onConnect func(address, level string), onGoBack func(), onRefresh func(), levelNames []string) *ServerBrowserUI {
+func NewServerBrowserUI(onConnect, onCreateRoom, onSpectate func(address, level string), onGoBack func(), onRefresh func(), levelNames []string) *ServerBrowserUI {
 	ui := &ServerBrowserUI{
-		OnConnect:  onConnect,
-		OnGoBack:   onGoBack,
-		OnRefresh:  onRefresh,
-		levelNames: levelNames,
+		OnConnect:    onConnect,
+		OnCreateRoom: onCreateRoom,
+		OnSpectate:   onSpectate,
+		OnGoBack:     onGoBack,
+		OnRefresh:    onRefresh,
+		levelNames:   levelNames,
 	}
 	ui.loadFonts()
 	ui.buildUI()
@@ -463,6 +471,14 @@ func (ui *ServerBrowserUI) buildDirectConnectPanel() *widget.Container {
 
 	panel.AddChild(portRow)
 
+	roomRow, roomInput := ui.buildTextInputRow("Room:     ", "public")
+	ui.roomInput = roomInput
+	panel.AddChild(roomRow)
+
+	passwordRow, passwordInput := ui.buildTextInputRow("Password:", "none")
+	ui.passwordInput = passwordInput
+	panel.AddChild(passwordRow)
+
 	// Level row
 	if len(ui.levelNames) > 0 {
 		levelRow := widget.NewContainer(
@@ -529,11 +545,99 @@ func (ui *ServerBrowserUI) buildDirectConnectPanel() *widget.Container {
 			}
 		}),
 	)
-	panel.AddChild(ui.connectBtn)
+
+	ui.createRoomBtn = widget.NewButton(
+		widget.ButtonOpts.WidgetOpts(widget.WidgetOpts.MinSize(120, 26)),
+		widget.ButtonOpts.Image(&widget.ButtonImage{
+			Idle:     image.NewNineSliceColor(color.RGBA{40, 60, 100, 255}),
+			Hover:    image.NewNineSliceColor(color.RGBA{60, 80, 140, 255}),
+			Pressed:  image.NewNineSliceColor(color.RGBA{30, 40, 80, 255}),
+			Disabled: image.NewNineSliceColor(color.RGBA{40, 40, 50, 255}),
+		}),
+		widget.ButtonOpts.Text("Create Room", &ui.normalFace, &widget.ButtonTextColor{
+			Idle:     color.RGBA{255, 255, 255, 255},
+			Hover:    color.RGBA{200, 200, 255, 255},
+			Pressed:  color.RGBA{150, 150, 200, 255},
+			Disabled: color.RGBA{100, 100, 100, 255},
+		}),
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			if ui.OnCreateRoom != nil {
+				ui.OnCreateRoom(ui.getAddress(), ui.SelectedLevel())
+			}
+		}),
+	)
+
+	buttonRow := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Direction(widget.DirectionHorizontal),
+			widget.RowLayoutOpts.Spacing(6),
+		)),
+	)
+	buttonRow.AddChild(ui.connectBtn)
+	buttonRow.AddChild(ui.createRoomBtn)
+
+	ui.spectateBtn = widget.NewButton(
+		widget.ButtonOpts.WidgetOpts(widget.WidgetOpts.MinSize(120, 26)),
+		widget.ButtonOpts.Image(&widget.ButtonImage{
+			Idle:     image.NewNineSliceColor(color.RGBA{80, 70, 40, 255}),
+			Hover:    image.NewNineSliceColor(color.RGBA{120, 100, 60, 255}),
+			Pressed:  image.NewNineSliceColor(color.RGBA{60, 50, 30, 255}),
+			Disabled: image.NewNineSliceColor(color.RGBA{50, 45, 40, 255}),
+		}),
+		widget.ButtonOpts.Text("Spectate", &ui.normalFace, &widget.ButtonTextColor{
+			Idle:     color.RGBA{255, 255, 255, 255},
+			Hover:    color.RGBA{255, 240, 200, 255},
+			Pressed:  color.RGBA{200, 190, 150, 255},
+			Disabled: color.RGBA{100, 100, 100, 255},
+		}),
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			if ui.OnSpectate != nil {
+				ui.OnSpectate(ui.getAddress(), ui.SelectedLevel())
+			}
+		}),
+	)
+	buttonRow.AddChild(ui.spectateBtn)
+	panel.AddChild(buttonRow)
 
 	return panel
 }
 
+// buildTextInputRow returns a labelled single-line text input row.
+func (ui *ServerBrowserUI) buildTextInputRow(label, placeholder string) (*widget.Container, *widget.TextInput) {
+	row := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Direction(widget.DirectionHorizontal),
+			widget.RowLayoutOpts.Spacing(6),
+		)),
+	)
+
+	row.AddChild(widget.NewLabel(
+		widget.LabelOpts.Text(label, &ui.normalFace, &widget.LabelColor{
+			Idle: color.RGBA{200, 200, 200, 255},
+		}),
+	))
+
+	input := widget.NewTextInput(
+		widget.TextInputOpts.WidgetOpts(widget.WidgetOpts.MinSize(120, 22)),
+		widget.TextInputOpts.Image(&widget.TextInputImage{
+			Idle:     image.NewNineSliceColor(color.RGBA{50, 50, 70, 255}),
+			Disabled: image.NewNineSliceColor(color.RGBA{40, 40, 50, 255}),
+		}),
+		widget.TextInputOpts.Face(&ui.normalFace),
+		widget.TextInputOpts.Color(&widget.TextInputColor{
+			Idle:          color.RGBA{255, 255, 255, 255},
+			Disabled:      color.RGBA{128, 128, 128, 255},
+			Caret:         color.RGBA{255, 255, 255, 255},
+			DisabledCaret: color.RGBA{128, 128, 128, 255},
+		}),
+		widget.TextInputOpts.Placeholder(placeholder),
+		widget.TextInputOpts.Padding(widget.NewInsetsSimple(4)),
+	)
+	row.AddChild(input)
+
+	return row, input
+}
+
 func (ui *ServerBrowserUI) buildButtons() *widget.Container {
 	container := widget.NewContainer(
 		widget.ContainerOpts.Layout(widget.NewRowLayout(
@@ -587,6 +691,29 @@ func (ui *ServerBrowserUI) SetConnecting(connecting bool) {
 	if ui.connectBtn != nil {
 		ui.connectBtn.GetWidget().Disabled = connecting
 	}
+	if ui.createRoomBtn != nil {
+		ui.createRoomBtn.GetWidget().Disabled = connecting
+	}
+	if ui.spectateBtn != nil {
+		ui.spectateBtn.GetWidget().Disabled = connecting
+	}
+}
+
+// RoomCode returns the room code typed into the direct-connect panel.
+// Empty means join any public room.
+func (ui *ServerBrowserUI) RoomCode() string {
+	if ui.roomInput == nil {
+		return ""
+	}
+	return ui.roomInput.GetText()
+}
+
+// RoomPassword returns the room password typed into the direct-connect panel.
+func (ui *ServerBrowserUI) RoomPassword() string {
+	if ui.passwordInput == nil {
+		return ""
+	}
+	return ui.passwordInput.GetText()
 }
 
 func (ui *ServerBrowserUI) cycleLevel() {